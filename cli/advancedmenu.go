@@ -37,8 +37,12 @@ func NewAdvancedMenuModel(publicID, sshHost, sshConfigPath string) AdvancedMenuM
 		advancedAction{title: "Audit Ignore UI", description: "Configure ignore_tables via web UI"},
 		advancedAction{title: "Run Flow", description: "Execute a test flow against the VM"},
 		advancedAction{title: "Get State", description: "Print the current simulator state"},
+		advancedAction{title: "Reset Env State", description: "Clear the cached env state without touching the database"},
 		advancedAction{title: "Create Checkpoint", description: "Create a checkpoint of current VM state"},
 		advancedAction{title: "Set up root SSH", description: "Configure root SSH password access"},
+		advancedAction{title: "Rotate SSH Key", description: "Generate and install a fresh SSH key for this VM"},
+		advancedAction{title: "Extend Timeout", description: "Add an hour to the VM's lifetime"},
+		advancedAction{title: "Export Launch Spec", description: "Save this launch to launch-spec.yaml for plato launch --spec"},
 		advancedAction{title: "Back", description: "Return to main menu"},
 	}
 
@@ -162,7 +162,12 @@ func (m ArtifactIDModel) Update(msg tea.Msg) (ArtifactIDModel, tea.Cmd) {
 				m.err = nil
 				// Launch environment with the selected artifact ID, version, and dataset
 				return m, func() tea.Msg {
-					return launchEnvironmentMsg{simulator: m.simulator, artifactID: &artifactID, version: &version, dataset: &dataset}
+					return launchEnvironmentMsg{options: LaunchOptions{
+						Simulator:  m.simulator,
+						ArtifactID: &artifactID,
+						Version:    &version,
+						Dataset:    &dataset, // Dataset from artifact
+					}}
 				}
 			}
 			return m, nil
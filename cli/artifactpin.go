@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	plato "plato-sdk"
+	"plato-sdk/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// artifactIDPattern matches the UUIDs the API assigns to snapshot artifacts
+var artifactIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// launchArtifact is the snapshot a launch starts from, and where it was chosen
+type launchArtifact struct {
+	artifactID string
+	version    string
+	// source is "flag" or "plato-config.yml"
+	source string
+}
+
+// resolveLaunchArtifact picks the snapshot a launch of dataset starts from.
+// The --artifact-id and --version flags win; without them the artifact_id or
+// version the dataset pins in plato-config.yml is used. A version alone is
+// looked up among the published versions of the dataset. It returns nil when
+// nothing is pinned, for a VM built from the dataset's config.
+func resolveLaunchArtifact(ctx context.Context, client *plato.PlatoClient, service, dataset string, config models.SimConfigDataset, flagArtifactID, flagVersion string) (*launchArtifact, error) {
+	pin := launchArtifact{artifactID: flagArtifactID, version: flagVersion, source: "flag"}
+	if pin.artifactID == "" && pin.version == "" {
+		pin = launchArtifact{artifactID: config.ArtifactID, version: config.Version, source: platoConfigFilename}
+	}
+	if pin.artifactID == "" && pin.version == "" {
+		return nil, nil
+	}
+
+	if pin.artifactID != "" {
+		if !artifactIDPattern.MatchString(pin.artifactID) {
+			if pin.source == platoConfigFilename {
+				return nil, newUsageError("dataset '%s' in %s pins artifact_id '%s', which is not an artifact ID (expected a UUID such as 2ea954c0-7e5a-4a12-82fd-12cb46538827)", dataset, platoConfigFilename, pin.artifactID)
+			}
+			return nil, newUsageError("--artifact-id '%s' is not an artifact ID (expected a UUID such as 2ea954c0-7e5a-4a12-82fd-12cb46538827)", pin.artifactID)
+		}
+		return &pin, nil
+	}
+
+	versions, err := client.Simulator.GetVersions(ctx, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up version %s of dataset '%s': %w", pin.version, dataset, err)
+	}
+	for _, v := range versions {
+		if v.Dataset == dataset && v.Version == pin.version {
+			pin.artifactID = v.ArtifactID
+			return &pin, nil
+		}
+	}
+	return nil, newNotFoundError("dataset '%s' has no published version %s; list them with `plato versions %s --dataset %s`", dataset, pin.version, service, dataset)
+}
+
+// pinnedArtifactResolvedMsg carries the launch of a plato-config.yml dataset
+// once its pinned artifact is known
+type pinnedArtifactResolvedMsg struct {
+	launch launchFromConfigMsg
+	err    error
+}
+
+// resolvePinnedArtifact looks up the artifact a dataset picked in the TUI
+// pins, before the launch starts
+func resolvePinnedArtifact(client *plato.PlatoClient, launch launchFromConfigMsg) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		pin, err := resolveLaunchArtifact(ctx, client, launch.service, launch.datasetName, launch.datasetConfig, "", "")
+		if err != nil {
+			return pinnedArtifactResolvedMsg{err: err}
+		}
+		if pin != nil {
+			launch.artifactID = &pin.artifactID
+			if pin.version != "" {
+				launch.version = &pin.version
+			}
+		}
+		return pinnedArtifactResolvedMsg{launch: launch}
+	}
+}
+
+// pinnedArtifactLabel marks the artifact in the VM view when it is the one
+// the dataset pins in plato-config.yml
+func pinnedArtifactLabel(config *models.PlatoConfig, dataset string, artifactID, version *string) string {
+	if config == nil || artifactID == nil {
+		return ""
+	}
+	pinned, ok := config.Datasets[dataset]
+	switch {
+	case !ok:
+		return ""
+	case pinned.ArtifactID != "":
+		if pinned.ArtifactID != *artifactID {
+			return ""
+		}
+	case pinned.Version == "" || version == nil || pinned.Version != *version:
+		return ""
+	}
+	return " (pinned in " + platoConfigFilename + ")"
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	cfgpkg "plato-cli/internal/config"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stateCleanedMsg reports the result of the Clean State action
+type stateCleanedMsg struct {
+	report utils.CleanupReport
+	err    error
+}
+
+// runCleanup implements `plato cleanup <publicID>`, running the pre-snapshot
+// cleanup on its own so state can be reset without taking a snapshot
+func runCleanup(args []string) error {
+	fs := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	service := fs.String("service", "", "Service name (defaults to the service in plato-config.yml)")
+	dataset := fs.String("dataset", defaultDataset(), "Dataset whose database config to use")
+
+	// Allow the public ID to come before or after the flags
+	var publicID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		publicID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if publicID == "" && fs.NArg() > 0 {
+		publicID = fs.Arg(0)
+	}
+	if publicID == "" {
+		return newUsageError("public ID is required")
+	}
+	client := NewConfigModel().client
+	publicID, err := utils.ResolvePublicID(client, publicID)
+	if err != nil {
+		return err
+	}
+
+	if *service == "" {
+		config, err := LoadPlatoConfig()
+		var cfgErr *cfgpkg.ConfigError
+		if errors.As(err, &cfgErr) && !cfgErr.Missing {
+			return err
+		}
+		if err != nil || config.Service == "" {
+			return newUsageError("--service is required when plato-config.yml does not name a service")
+		}
+		*service = config.Service
+	}
+
+	dbConfig, ok := utils.GetDBConfigForDataset(*service, *dataset)
+	if !ok {
+		return newNotFoundError("no database config saved for %s/%s; enter it once via Snapshot VM in the TUI", *service, *dataset)
+	}
+
+	jobGroupID, err := lookupJobGroupID(client, publicID)
+	if err != nil {
+		return err
+	}
+	if jobGroupID == "" {
+		return newNotFoundError("sandbox %s not found", publicID)
+	}
+
+	fmt.Printf("🧹 Cleaning state of %s...\n", publicID)
+	report, err := utils.CleanState(client, publicID, jobGroupID, dbConfig)
+	for _, line := range report.Lines() {
+		fmt.Println(line)
+	}
+	if err != nil {
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+	fmt.Println("✅ State cleaned")
+	return nil
+}
+
+// lookupJobGroupID finds the job group of a running sandbox by public ID,
+// returning "" if it is not in the sandbox list
+func lookupJobGroupID(client *plato.PlatoClient, publicID string) (string, error) {
+	sandboxes, err := client.Sandbox.List(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to list sandboxes: %w", err)
+	}
+	for _, sb := range sandboxes {
+		if sb.PublicId == publicID {
+			return sb.JobGroupId, nil
+		}
+	}
+	return "", nil
+}
+
+// cleanState runs the pre-snapshot cleanup for the Clean State action
+func cleanState(client *plato.PlatoClient, publicID, jobGroupID string, dbConfig utils.DBConfig) tea.Cmd {
+	return func() tea.Msg {
+		report, err := utils.CleanState(client, publicID, jobGroupID, dbConfig)
+		return stateCleanedMsg{report: report, err: err}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"plato-cli/internal/session"
+	"plato-cli/internal/utils"
+)
+
+// cleanupLocalSSH removes what the CLI set up locally to reach a VM over SSH:
+// its ~/.ssh/config entry, pinned host key, temporary SSH config file and key
+// pair. Empty arguments are skipped. It returns one result line per step.
+func cleanupLocalSSH(sshHost, sshConfigPath, sshPrivateKeyPath string) []string {
+	var lines []string
+	if sshHost != "" {
+		if err := utils.CleanupSSHConfig(sshHost); err != nil {
+			lines = append(lines, fmt.Sprintf("❌ SSH config entry %s: %v", sshHost, err))
+		} else {
+			lines = append(lines, fmt.Sprintf("✓ Removed SSH config entry %s", sshHost))
+		}
+		if err := os.Remove(utils.KnownHostsPath(sshHost)); err != nil && !os.IsNotExist(err) {
+			lines = append(lines, fmt.Sprintf("❌ Pinned host key for %s: %v", sshHost, err))
+		}
+	}
+	if sshConfigPath != "" {
+		if err := os.Remove(sshConfigPath); err != nil && !os.IsNotExist(err) {
+			lines = append(lines, fmt.Sprintf("❌ SSH config file %s: %v", sshConfigPath, err))
+		} else {
+			lines = append(lines, fmt.Sprintf("✓ Removed SSH config file %s", sshConfigPath))
+		}
+	}
+	if sshPrivateKeyPath != "" {
+		if err := utils.CleanupSSHKeyPair(sshPrivateKeyPath); err != nil {
+			lines = append(lines, fmt.Sprintf("❌ SSH key pair %s: %v", sshPrivateKeyPath, err))
+		} else {
+			lines = append(lines, fmt.Sprintf("✓ Removed SSH key pair %s", sshPrivateKeyPath))
+		}
+	}
+	return lines
+}
+
+// runClose implements `plato close [public-id]`, deleting a VM without the
+// TUI and cleaning up what the CLI created for it. The VM defaults to the one
+// in .sandbox.yaml, whose SSH host and key paths are also cleaned up unless
+// --keep-ssh is given. Tunnels tracked for the VM in the session are stopped.
+// Nothing local is touched when the VM could not be deleted, so the command
+// can be retried.
+// Heartbeats only run inside the TUI that launched a VM, and stop being
+// accepted once the VM is deleted.
+func runClose(args []string) error {
+	fs := flag.NewFlagSet("close", flag.ExitOnError)
+	publicIDFlag := fs.String("public-id", "", "VM to close (default: the one in .sandbox.yaml)")
+	keepSSH := fs.Bool("keep-ssh", false, "leave the SSH config entry, key pair and temp config file in place")
+
+	// Allow the public ID to come before or after the flags
+	var argID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		argID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 || (argID != "" && fs.NArg() > 0) {
+		return newUsageError("expected at most one public ID, e.g. plato close abc123")
+	}
+	if argID == "" {
+		argID = fs.Arg(0)
+	}
+
+	sandboxFilePath := sandboxFileName
+	sandboxFile, err := ReadSandboxFile()
+	if err != nil {
+		sandboxFile = nil
+	}
+
+	publicID := *publicIDFlag
+	if publicID == "" {
+		publicID = argID
+	}
+	if publicID == "" && sandboxFile != nil {
+		publicID = sandboxFile.PublicID
+	}
+	if publicID == "" {
+		return newUsageError("no public ID given and no .sandbox.yaml in the current directory")
+	}
+	client := NewConfigModel().client
+	if publicID, err = utils.ResolvePublicID(client, publicID); err != nil {
+		return err
+	}
+	// .sandbox.yaml only describes this VM if it was written for it;
+	// otherwise it may have been launched with --no-sandbox-file
+	if sandboxFile == nil || sandboxFile.PublicID != publicID {
+		sandboxFilePath = savedSandboxFilePath(publicID)
+		sandboxFile, err = readSandboxFile(sandboxFilePath)
+		if err != nil {
+			sandboxFile = nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	switch err := client.Sandbox.DeleteVM(ctx, publicID); {
+	case vmExpired(err):
+		// Nothing left to delete, but the local setup still needs cleaning up
+		fmt.Printf("✓ VM %s had already expired\n", publicID)
+	case err != nil:
+		// The VM may still be running: keep what a retry needs to reach it
+		fmt.Printf("⏭  Kept the local setup of %s so plato close can be retried\n", publicID)
+		return fmt.Errorf("failed to delete VM %s: %w", publicID, err)
+	default:
+		fmt.Printf("✓ Deleted VM %s\n", publicID)
+	}
+
+	// The session knows about tunnels, keys and files even without .sandbox.yaml
+	resources, err := session.List()
+	if err != nil {
+		utils.LogDebug("Failed to read session resources: %v", err)
+	}
+	var sshHost, sshConfigPath, sshPrivateKeyPath string
+	if sandboxFile != nil {
+		sshHost, sshConfigPath, sshPrivateKeyPath = sandboxFile.SSHHost, sandboxFile.SSHConfigPath, sandboxFile.SSHPrivateKeyPath
+	}
+	for _, r := range resources {
+		if r.PublicID != publicID {
+			continue
+		}
+		switch r.Kind {
+		case session.KindTunnel:
+			pid, _ := strconv.Atoi(r.ID)
+			if !isProxytunnelProcess(pid) {
+				continue
+			}
+			if err := terminateProcess(pid); err != nil {
+				fmt.Printf("❌ Tunnel %s (PID %d): %v\n", r.Detail, pid, err)
+			} else {
+				fmt.Printf("✓ Stopped tunnel %s (PID %d)\n", r.Detail, pid)
+			}
+		case session.KindSSHKey:
+			if sshPrivateKeyPath == "" {
+				sshPrivateKeyPath = r.ID
+			}
+			if sshHost == "" {
+				sshHost = r.Detail
+			}
+		case session.KindTempFile:
+			if sshConfigPath == "" {
+				sshConfigPath = r.ID
+			}
+		}
+	}
+
+	if *keepSSH {
+		fmt.Println("⏭  Kept SSH config and keys (--keep-ssh)")
+	} else {
+		for _, line := range cleanupLocalSSH(sshHost, sshConfigPath, sshPrivateKeyPath) {
+			fmt.Println(line)
+		}
+	}
+
+	if sandboxFile != nil {
+		if err := removeSandboxFile(sandboxFilePath); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		} else {
+			fmt.Printf("✓ Removed %s\n", sandboxFilePath)
+		}
+	}
+
+	forgetVMSession(publicID)
+	if *keepSSH {
+		// The kept keys and files stay visible in `plato session`
+		forgetResource(session.KindVM, publicID)
+		return nil
+	}
+	if err := session.ForgetVM(publicID); err != nil {
+		utils.LogDebug("Failed to forget session resources: %v", err)
+	}
+	return nil
+}
@@ -2,18 +2,18 @@
 //
 // This file implements the ConfigModel which handles loading and displaying
 // API configuration from environment variables and .env files. It shows the
-// current API key and base URL settings to the user.
+// current API key, credential provider and base URL settings to the user.
 package main
 
 import (
 
+"plato-cli/internal/config"
 "plato-cli/internal/ui/components"
 	"os"
 	"strings"
 	plato "plato-sdk"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/joho/godotenv"
 )
 
 type ConfigModel struct {
@@ -21,10 +21,10 @@ type ConfigModel struct {
 }
 
 func NewConfigModel() ConfigModel {
-	// Load .env file
-	godotenv.Load()
+	// Load .env files (project, then ~/.plato/.env)
+	config.LoadEnv()
 
-	apiKey := os.Getenv("PLATO_API_KEY")
+	apiKey := config.ResolveAPIKey()
 	baseURL := os.Getenv("PLATO_BASE_URL")
 	hubBaseURL := os.Getenv("PLATO_HUB_API_URL")
 
@@ -37,7 +37,7 @@ func NewConfigModel() ConfigModel {
 	if hubBaseURL == "" {
 		hubBaseURL = "https://plato.so/api"
 	}
-	opts = append(opts, plato.WithHubBaseURL(hubBaseURL))
+	opts = append(opts, plato.WithHubBaseURL(hubBaseURL), plato.WithHubGitTransport(config.HubGitTransport()), plato.WithHubCloneOptions(config.HubCloneOptions()), plato.WithSSEIdleTimeout(config.StreamIdleTimeout()))
 
 	client := plato.NewClient(apiKey, opts...)
 
@@ -92,6 +92,16 @@ func (m ConfigModel) View() string {
 	}
 	content.WriteString("\n")
 
+	// Credential provider
+	provider := config.SelectedProviderName()
+	if provider == "" {
+		provider = "env, file"
+	}
+	content.WriteString(containerStyle.Render(labelStyle.Render("Key Source:")))
+	content.WriteString(" ")
+	content.WriteString(valueStyle.Render(provider))
+	content.WriteString("\n")
+
 	// Base URL
 	content.WriteString(containerStyle.Render(labelStyle.Render("Base URL:")))
 	content.WriteString(" ")
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"plato-cli/internal/config"
 	"plato-sdk/models"
 
 	"gopkg.in/yaml.v3"
@@ -17,19 +18,20 @@ func ConfigExists() bool {
 	return err == nil
 }
 
-// LoadPlatoConfig loads and parses plato-config.yml from the current directory
+// LoadPlatoConfig loads and parses plato-config.yml from the current directory.
+// Errors are *config.ConfigError so callers can tell a missing file from a malformed one.
 func LoadPlatoConfig() (*models.PlatoConfig, error) {
-	data, err := os.ReadFile(platoConfigFilename)
-	if err != nil {
-		return nil, err
-	}
+	return config.LoadPlatoConfig()
+}
 
-	var config models.PlatoConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
-	}
+// defaultDataset returns the dataset to use when none is given explicitly
+func defaultDataset() string {
+	return config.DefaultDataset()
+}
 
-	return &config, nil
+// datasetOrDefault returns dataset, or the default dataset when it is empty
+func datasetOrDefault(dataset string) string {
+	return config.DatasetOrDefault(dataset)
 }
 
 // GetPlatoConfigDir returns the absolute directory path where plato-config.yml is located
@@ -58,6 +60,44 @@ func SavePlatoConfig(config *models.PlatoConfig) error {
 	return os.WriteFile(platoConfigFilename, data, 0644)
 }
 
+// DefaultPlatoConfig returns a starter PlatoConfig for the given service with a
+// single models.DefaultDatasetName dataset running the service's docker-compose.yml.
+func DefaultPlatoConfig(service string) *models.PlatoConfig {
+	return &models.PlatoConfig{
+		Version: models.PlatoConfigVersion,
+		Service: service,
+		Datasets: map[string]models.SimConfigDataset{
+			models.DefaultDatasetName: {
+				Compute: models.SimConfigCompute{
+					Cpus:               1,
+					Memory:             512,
+					Disk:               10240,
+					AppPort:            8080,
+					PlatoMessagingPort: 7000,
+				},
+				Metadata: models.SimConfigMetadata{
+					Favicon:       "https://plato.so/favicon.ico",
+					Name:          service,
+					Description:   "A Plato simulator environment",
+					SourceCodeUrl: "https://github.com/useplato/plato",
+					StartUrl:      "http://localhost:8080",
+					License:       "MIT",
+					Variables:     []models.Variable{{Name: "PLATO_API_KEY", Value: "your-api-key"}},
+				},
+				Services: map[string]models.SimConfigService{
+					"main_app": {
+						Type:                      "docker-compose",
+						File:                      "docker-compose.yml",
+						RequiredHealthyContainers: []string{"all"},
+						HealthyWaitTimeout:        300,
+					},
+				},
+				Listeners: map[string]models.SimConfigListener{},
+			},
+		},
+	}
+}
+
 // GetCurrentDir returns the current working directory
 func GetCurrentDir() string {
 	dir, err := os.Getwd()
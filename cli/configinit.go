@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"plato-sdk/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDBPorts maps supported listener db types to their default ports
+var defaultDBPorts = map[string]int32{
+	"postgresql": 5432,
+	"mysql":      3306,
+}
+
+// configInitComments are attached to keys of the generated plato-config.yml
+var configInitComments = map[string]string{
+	"version":     "Schema version of this file; `plato config migrate` upgrades older files",
+	"service":     "Service name as registered on Plato Hub",
+	"datasets":    "Each dataset is a separately snapshottable variant of the simulator",
+	"compute":     "VM resources (memory and disk are in MB) and the ports the app and worker listen on",
+	"metadata":    "Metadata shown for the simulator on Plato",
+	"services":    "Services started on the VM (docker-compose files are relative to this directory)",
+	"listeners":   "Listeners track state changes; the db listener must match your app's database",
+	"db_host":     "Hostname of the database as seen from the VM",
+	"db_database": "Database that holds the application's data",
+}
+
+// runConfigInit implements `plato config init`
+func runConfigInit(args []string) error {
+	fs := flag.NewFlagSet("config init", flag.ExitOnError)
+	service := fs.String("service", GetCurrentDir(), "service name")
+	dbType := fs.String("db-type", "postgresql", "database type for the sample db listener (postgresql or mysql)")
+	force := fs.Bool("force", false, "overwrite an existing plato-config.yml")
+	fs.Parse(args)
+
+	dbPort, ok := defaultDBPorts[*dbType]
+	if !ok {
+		return newUsageError("unsupported db type '%s' (expected postgresql or mysql)", *dbType)
+	}
+
+	if ConfigExists() && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", platoConfigFilename)
+	}
+
+	config := DefaultPlatoConfig(*service)
+	base := config.Datasets[models.DefaultDatasetName]
+	base.Listeners["db"] = models.SimConfigListener{
+		Type:       "db",
+		DbType:     *dbType,
+		DbHost:     "127.0.0.1",
+		DbPort:     dbPort,
+		DbUser:     *service,
+		DbPassword: *service,
+		DbDatabase: *service,
+	}
+	config.Datasets[models.DefaultDatasetName] = base
+
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	commentConfigNode(&doc)
+	doc.HeadComment = "Plato simulator configuration\nGenerated by `plato config init` - adjust the db listener to match your app"
+
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(platoConfigFilename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", platoConfigFilename, err)
+	}
+
+	fmt.Printf("✅ Wrote %s for service '%s'\n", platoConfigFilename, *service)
+	fmt.Printf("\n💡 Next steps:\n")
+	fmt.Printf("   Review the db listener credentials in %s\n", platoConfigFilename)
+	fmt.Printf("   Run 'plato' and choose your dataset to launch a VM\n")
+	if !gitignoresSandboxFile() {
+		fmt.Printf("   Add %s to .gitignore, or launch with --no-sandbox-file to keep it out of this directory\n", sandboxFileName)
+	}
+	return nil
+}
+
+// gitignoresSandboxFile reports whether the .gitignore in the current
+// directory already lists .sandbox.yaml
+func gitignoresSandboxFile() bool {
+	data, err := os.ReadFile(".gitignore")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		switch strings.TrimPrefix(strings.TrimSpace(line), "/") {
+		case sandboxFileName, ".sandbox.*", "*.yaml":
+			return true
+		}
+	}
+	return false
+}
+
+// commentConfigNode walks a yaml node tree and attaches configInitComments to matching keys
+func commentConfigNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if comment, ok := configInitComments[key.Value]; ok && key.HeadComment == "" {
+				key.HeadComment = comment
+			}
+		}
+	}
+	for _, child := range node.Content {
+		commentConfigNode(child)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"plato-cli/internal/config"
+)
+
+// runConfigMigrate implements `plato config migrate`, upgrading
+// plato-config.yml in place to the current schema version
+func runConfigMigrate(args []string) error {
+	fs := flag.NewFlagSet("config migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "list the upgrades without rewriting the file")
+	fs.Parse(args)
+
+	m, err := config.MigratePlatoConfigFile(*dryRun)
+	if err != nil {
+		return err
+	}
+	if !m.Changed() {
+		fmt.Printf("✅ %s is already at schema version %d\n", platoConfigFilename, m.To)
+		return nil
+	}
+
+	if *dryRun {
+		fmt.Printf("%s would be upgraded from schema version %d to %d:\n", platoConfigFilename, m.From, m.To)
+	} else {
+		fmt.Printf("✅ Upgraded %s from schema version %d to %d:\n", platoConfigFilename, m.From, m.To)
+	}
+	for _, step := range m.Steps {
+		fmt.Printf("   - %s\n", step)
+	}
+	if *dryRun {
+		fmt.Printf("\nRun without --dry-run to rewrite the file (comments are kept, indentation is normalized)\n")
+	}
+	return nil
+}
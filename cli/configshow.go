@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"text/tabwriter"
+
+	"plato-cli/internal/config"
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+	"plato-sdk/services"
+)
+
+// Where a `plato config show` value came from
+const (
+	originDefault = "default"
+	originFile    = "file"
+	originEnv     = "env"
+	originFlag    = "flag"
+)
+
+// configValue is one resolved setting in `plato config show`
+type configValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// settingFrom resolves a setting with the usual precedence: the environment
+// variable (if any), then plato-config.yml, then the built-in default
+func settingFrom(key, envVar, fileValue, defaultValue string) configValue {
+	if envVar != "" {
+		if v := os.Getenv(envVar); v != "" {
+			return configValue{Key: key, Value: v, Origin: originEnv + " " + envVar}
+		}
+	}
+	if fileValue != "" {
+		return configValue{Key: key, Value: fileValue, Origin: originFile}
+	}
+	return configValue{Key: key, Value: defaultValue, Origin: originDefault}
+}
+
+// intSetting formats a numeric plato-config.yml value, treating 0 as unset
+func intSetting(key string, fileValue, defaultValue int) configValue {
+	file := ""
+	if fileValue > 0 {
+		file = strconv.Itoa(fileValue)
+	}
+	return settingFrom(key, "", file, strconv.Itoa(defaultValue))
+}
+
+// effectiveConfig lists every setting the CLI resolves from plato-config.yml,
+// the environment and flags, in the order `plato config show` prints them.
+// platoConfig may be nil when there is no plato-config.yml. Secrets are masked.
+func effectiveConfig(platoConfig *models.PlatoConfig, datasetFlag string) []configValue {
+	if platoConfig == nil {
+		platoConfig = &models.PlatoConfig{}
+	}
+	var values []configValue
+
+	// API access
+	baseURL := settingFrom("base_url", "PLATO_BASE_URL", "", "https://plato.so/api")
+	values = append(values,
+		configValue{Key: "environment", Value: config.DetectEnvironment(baseURL.Value), Origin: "derived from base_url"},
+		baseURL,
+		settingFrom("hub_api_url", "PLATO_HUB_API_URL", "", "https://plato.so/api"),
+		settingFrom("hub_git_transport", "PLATO_HUB_GIT_TRANSPORT", "", "https"),
+		settingFrom("credential_provider", "PLATO_CREDENTIAL_PROVIDER", "", "env, then file"),
+	)
+	apiKey, provider := config.ResolveAPIKeyWithProvider()
+	if apiKey == "" {
+		values = append(values, configValue{Key: "api_key", Value: "(not configured)", Origin: originDefault})
+	} else {
+		values = append(values, configValue{Key: "api_key", Value: maskAPIKey(apiKey), Origin: provider})
+	}
+
+	// Service and dataset
+	values = append(values,
+		settingFrom("service", "", platoConfig.Service, ""),
+		settingFrom("default_dataset", "", platoConfig.DefaultDataset, models.DefaultDatasetName),
+	)
+	dataset := configValue{Key: "dataset", Value: datasetFlag, Origin: originFlag + " --dataset"}
+	if datasetFlag == "" {
+		dataset = settingFrom("dataset", "", platoConfig.DefaultDataset, models.DefaultDatasetName)
+	}
+	values = append(values, dataset)
+	if datasetConfig, ok := platoConfig.Datasets[dataset.Value]; ok {
+		compute := datasetConfig.Compute
+		values = append(values,
+			intSetting("compute.cpus", int(compute.Cpus), 0),
+			intSetting("compute.memory", int(compute.Memory), 0),
+			intSetting("compute.disk", int(compute.Disk), 0),
+			intSetting("compute.app_port", int(compute.AppPort), defaultAppPort),
+			intSetting("compute.plato_messaging_port", int(compute.PlatoMessagingPort), defaultMessagingPort),
+		)
+	}
+
+	// ECR
+	aws := platoConfig.AWS
+	if aws == nil {
+		aws = &models.AWSConfig{}
+	}
+	ecr := resolveECRSettings(platoConfig)
+	values = append(values,
+		settingFrom("aws.region", "PLATO_AWS_REGION", aws.Region, defaultECRRegion),
+		settingFrom("aws.profile", "PLATO_AWS_PROFILE", aws.Profile, "(aws CLI default)"),
+		settingFrom("aws.registry", "PLATO_AWS_REGISTRY", aws.Registry, ecr.registry),
+	)
+	ecrEnabled := configValue{Key: "ecr.enabled", Origin: originDefault}
+	if platoConfig.ECR != nil && platoConfig.ECR.Enabled != nil {
+		ecrEnabled.Value, ecrEnabled.Origin = strconv.FormatBool(*platoConfig.ECR.Enabled), originFile
+	} else if _, err := exec.LookPath("aws"); err == nil {
+		ecrEnabled.Value = "true (aws CLI found)"
+	} else {
+		ecrEnabled.Value = "false (aws CLI not found)"
+	}
+	values = append(values, ecrEnabled)
+
+	// VM layout, tunnels, SSH and hub git
+	remote := platoConfig.Remote
+	if remote == nil {
+		remote = &models.RemoteConfig{}
+	}
+	tunnel := platoConfig.Tunnel
+	if tunnel == nil {
+		tunnel = &models.TunnelConfig{}
+	}
+	strictPorts := ""
+	if tunnel.StrictPorts {
+		strictPorts = "true"
+	}
+	ssh := platoConfig.SSH
+	if ssh == nil {
+		ssh = &models.SSHConfig{}
+	}
+	strictHostKey := ""
+	if ssh.StrictHostKey {
+		strictHostKey = "true"
+	}
+	hub := platoConfig.Hub
+	if hub == nil {
+		hub = &models.HubConfig{}
+	}
+	cloneDepth := ""
+	if hub.CloneDepth != nil {
+		cloneDepth = strconv.Itoa(*hub.CloneDepth)
+	}
+	partialClone := ""
+	if hub.PartialClone {
+		partialClone = "true"
+	}
+	timeouts := platoConfig.Timeouts
+	if timeouts == nil {
+		timeouts = &models.TimeoutsConfig{}
+	}
+	defaults := utils.DefaultSSHTimings()
+	values = append(values,
+		settingFrom("remote.worktree_path", "", remote.WorktreePath, defaultRemoteWorktreePath),
+		settingFrom("remote.docker_host", "", remote.DockerHost, defaultRemoteDockerHost),
+		settingFrom("tunnel.bind_address", "", tunnel.BindAddress, utils.DefaultBindAddress),
+		settingFrom("tunnel.strict_ports", "", strictPorts, "false"),
+		intSetting("ssh.connect_timeout", ssh.ConnectTimeout, defaults.ConnectTimeout),
+		intSetting("ssh.server_alive_interval", ssh.ServerAliveInterval, defaults.ServerAliveInterval),
+		intSetting("ssh.server_alive_count_max", ssh.ServerAliveCountMax, defaults.ServerAliveCountMax),
+		settingFrom("ssh.strict_host_key", "", strictHostKey, "false"),
+		settingFrom("hub.clone_depth", "", cloneDepth, "1"),
+		settingFrom("hub.partial_clone", "", partialClone, "false"),
+		settingFrom("hub.snapshot_branch", "", hub.SnapshotBranch, "(repository default branch)"),
+		settingFrom("timeouts.provision", "", timeouts.Provision, defaultProvisionTimeout.String()),
+		settingFrom("timeouts.setup", "", timeouts.Setup, defaultSetupTimeout.String()),
+		settingFrom("timeouts.stream_idle", "", timeouts.StreamIdle, services.DefaultSSEIdleTimeout.String()),
+		settingFrom("keep_temp", "PLATO_KEEP_TEMP", "", "false"),
+		// Only the environment variable and --no-sandbox-file set this, so it is
+		// listed under the variable's name rather than as a config key
+		settingFrom(noSandboxFileEnv, noSandboxFileEnv, "", "false"),
+	)
+	return values
+}
+
+// hasDataset reports whether plato-config.yml defines the dataset name
+func hasDataset(platoConfig *models.PlatoConfig, name string) bool {
+	_, ok := platoConfig.Datasets[name]
+	return ok
+}
+
+// runConfigShow implements `plato config show`, printing the effective
+// configuration with the origin of each value
+func runConfigShow(args []string) error {
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	dataset := fs.String("dataset", "", "Show the settings for this dataset instead of default_dataset")
+	asJSON := fs.Bool("json", false, "Print the configuration as JSON")
+	fs.Parse(args)
+
+	config.LoadEnv()
+	platoConfig, err := LoadPlatoConfig()
+	if err != nil {
+		var configErr *config.ConfigError
+		if !errors.As(err, &configErr) || !configErr.Missing {
+			return err
+		}
+		platoConfig = nil
+	}
+	if *dataset != "" && (platoConfig == nil || !hasDataset(platoConfig, *dataset)) {
+		return newNotFoundError("dataset '%s' not found in plato-config.yml", *dataset)
+	}
+	values := effectiveConfig(platoConfig, *dataset)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal configuration: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, v := range values {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, v.Origin)
+	}
+	return w.Flush()
+}
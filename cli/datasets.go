@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cfgpkg "plato-cli/internal/config"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+	"plato-sdk/services"
+)
+
+// datasetEntry is one dataset in the `plato datasets` output
+type datasetEntry struct {
+	Dataset       string `json:"dataset"`
+	Versions      int    `json:"versions"`
+	LatestVersion string `json:"latest_version"`
+	ArtifactID    string `json:"artifact_id"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+// runDatasets implements `plato datasets [service]`, listing the datasets that
+// have published versions of a service on the server
+func runDatasets(args []string) error {
+	fs := flag.NewFlagSet("datasets", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the datasets as JSON")
+
+	// Allow the service to come before or after the flags
+	var service string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		service = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if service == "" && fs.NArg() > 0 {
+		service = fs.Arg(0)
+	}
+	if service == "" {
+		config, err := LoadPlatoConfig()
+		var cfgErr *cfgpkg.ConfigError
+		if errors.As(err, &cfgErr) && !cfgErr.Missing {
+			return err
+		}
+		if err != nil || config.Service == "" {
+			return newUsageError("a service is required when plato-config.yml does not name one")
+		}
+		service = config.Service
+	}
+
+	client := NewConfigModel().client
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	versions, err := client.Simulator.GetVersions(ctx, service)
+	if err != nil {
+		return fmt.Errorf("failed to list versions of %s: %w", service, err)
+	}
+
+	// Group versions by dataset, keeping the most recent one
+	byDataset := make(map[string]*datasetEntry)
+	for _, v := range versions {
+		if v.Dataset == "" {
+			continue
+		}
+		entry, ok := byDataset[v.Dataset]
+		if !ok {
+			entry = &datasetEntry{Dataset: v.Dataset}
+			byDataset[v.Dataset] = entry
+		}
+		entry.Versions++
+		if v.CreatedAt >= entry.CreatedAt {
+			entry.LatestVersion, entry.ArtifactID, entry.CreatedAt = v.Version, v.ArtifactID, v.CreatedAt
+		}
+	}
+	entries := make([]datasetEntry, 0, len(byDataset))
+	for _, entry := range byDataset {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Dataset < entries[j].Dataset })
+
+	if *asJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal datasets: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("%s has no published datasets yet\n", service)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATASET\tVERSIONS\tLATEST\tARTIFACT\tCREATED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", e.Dataset, e.Versions, e.LatestVersion, e.ArtifactID, e.CreatedAt)
+	}
+	return w.Flush()
+}
+
+// didYouMean formats close matches of name among candidates as a hint, or ""
+func didYouMean(name string, candidates []string) string {
+	suggestions := utils.SuggestNames(name, candidates)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean '%s'?", strings.Join(suggestions, "' or '"))
+}
+
+// checkDatasetOnServer is the launch pre-flight for the dataset name. A name
+// that looks like a typo of a published dataset fails before a VM is
+// provisioned, unless plato-config.yml defines it, in which case it is only a
+// warning; a name with no published versions at all is allowed, since a
+// dataset only appears on the server after its first snapshot. Failing to
+// reach the server is reported but does not block the launch.
+func checkDatasetOnServer(client *plato.PlatoClient, service, dataset string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	err := client.Simulator.ValidateDataset(ctx, service, dataset)
+	var unknown *services.UnknownDatasetError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &unknown) && len(unknown.Suggestions) > 0:
+		if datasetInPlatoConfig(dataset) {
+			fmt.Printf("⚠️  %v (launching it anyway, %s defines it)\n", err, platoConfigFilename)
+			return nil
+		}
+		return newNotFoundError("%v (pass --no-dataset-check to launch it anyway)", err)
+	case errors.As(err, &unknown):
+		if len(unknown.Known) > 0 {
+			fmt.Printf("ℹ️  Dataset '%s' has no published versions of %s yet; its first snapshot will create it\n", dataset, service)
+		}
+		return nil
+	default:
+		utils.LogDebug("Skipping dataset check for %s/%s: %v", service, dataset, err)
+		return nil
+	}
+}
+
+// datasetInPlatoConfig reports whether plato-config.yml defines dataset
+func datasetInPlatoConfig(dataset string) bool {
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		utils.LogDebug("Failed to load %s: %v", platoConfigFilename, err)
+		return false
+	}
+	_, ok := config.Datasets[dataset]
+	return ok
+}
@@ -19,6 +19,8 @@ type DatasetSelectorModel struct {
 	lg             *lipgloss.Renderer
 	err            string
 	snapshotParams snapshotParams
+	// selected holds datasets marked with space for a batch snapshot
+	selected map[string]bool
 }
 
 type snapshotParams struct {
@@ -32,9 +34,15 @@ type datasetOption struct {
 	name        string
 	description string
 	dataset     models.SimConfigDataset
+	selected    bool
 }
 
-func (d datasetOption) Title() string       { return d.name }
+func (d datasetOption) Title() string {
+	if d.selected {
+		return "[x] " + d.name
+	}
+	return d.name
+}
 func (d datasetOption) Description() string { return d.description }
 func (d datasetOption) FilterValue() string { return d.name }
 
@@ -44,6 +52,12 @@ type datasetSelectedMsg struct {
 	params        snapshotParams
 }
 
+// datasetsSelectedMsg requests one snapshot per dataset, in list order
+type datasetsSelectedMsg struct {
+	datasetNames []string
+	params       snapshotParams
+}
+
 type refreshDatasetsMsg struct{}
 
 func NewDatasetSelectorModel(service string, params snapshotParams) DatasetSelectorModel {
@@ -54,7 +68,7 @@ func NewDatasetSelectorModel(service string, params snapshotParams) DatasetSelec
 	var errMsg string
 
 	if err != nil {
-		errMsg = fmt.Sprintf("Failed to load plato-config.yml: %v", err)
+		errMsg = err.Error()
 	} else {
 		// Build dataset options
 		for name, dataset := range config.Datasets {
@@ -101,6 +115,7 @@ func NewDatasetSelectorModel(service string, params snapshotParams) DatasetSelec
 		lg:             lipgloss.DefaultRenderer(),
 		err:            errMsg,
 		snapshotParams: params,
+		selected:       make(map[string]bool),
 	}
 }
 
@@ -129,7 +144,35 @@ func (m DatasetSelectorModel) Update(msg tea.Msg) (DatasetSelectorModel, tea.Cmd
 			return m, func() tea.Msg {
 				return NavigateMsg{view: ViewVMInfo}
 			}
+		case " ":
+			// Toggle the highlighted dataset for a batch snapshot
+			if m.list.FilterState() == list.Filtering {
+				break
+			}
+			option, ok := m.list.SelectedItem().(datasetOption)
+			if !ok || option.name == "🔄 Refresh Datasets" {
+				return m, nil
+			}
+			option.selected = !option.selected
+			if option.selected {
+				m.selected[option.name] = true
+			} else {
+				delete(m.selected, option.name)
+			}
+			return m, m.list.SetItem(m.list.GlobalIndex(), option)
 		case "enter":
+			if len(m.selected) > 0 && m.list.FilterState() != list.Filtering {
+				var names []string
+				for _, item := range m.list.Items() {
+					if option, ok := item.(datasetOption); ok && m.selected[option.name] {
+						names = append(names, option.name)
+					}
+				}
+				params := m.snapshotParams
+				return m, func() tea.Msg {
+					return datasetsSelectedMsg{datasetNames: names, params: params}
+				}
+			}
 			selectedItem := m.list.SelectedItem()
 			if selectedItem != nil {
 				option := selectedItem.(datasetOption)
@@ -187,7 +230,11 @@ func (m DatasetSelectorModel) View() string {
 	} else {
 		body.WriteString(m.list.View())
 		body.WriteString("\n")
-		body.WriteString(helpStyle.Render("↑/↓: navigate • enter: select • /: filter • esc: back"))
+		help := "↑/↓: navigate • enter: select • space: mark for batch • /: filter • esc: back"
+		if len(m.selected) > 0 {
+			help = fmt.Sprintf("↑/↓: navigate • space: toggle • enter: snapshot %d datasets • esc: back", len(m.selected))
+		}
+		body.WriteString(helpStyle.Render(help))
 	}
 
 	return components.RenderHeader() + "\n" + header + "\n" + body.String()
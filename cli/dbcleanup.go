@@ -23,6 +23,9 @@ type DBConfig struct {
 	Password  string   `json:"password"`
 	DestPort  int      `json:"dest_port"`
 	Databases []string `json:"databases"`
+	// PasswordEnv names an environment variable that holds the password and
+	// wins over Password
+	PasswordEnv string `json:"password_env,omitempty"`
 }
 
 var simDBConfigs = map[string]DBConfig{
@@ -87,33 +90,17 @@ func loadCustomDBConfigs() map[string]DBConfig {
 	return customConfigs
 }
 
-// saveCustomDBConfig saves a new custom DB config to file
+// saveCustomDBConfig saves a new custom DB config to file, through
+// utils.SaveCustomDBConfig so saves are locked and atomic
 func saveCustomDBConfig(service string, config DBConfig) error {
-	// Load existing configs
-	customConfigs := loadCustomDBConfigs()
-
-	// Add/update the new config
-	customConfigs[service] = config
-
-	// Ensure directory exists
-	configDir := filepath.Dir(getCustomDBConfigPath())
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	// Marshal to JSON
-	data, err := json.MarshalIndent(customConfigs, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal configs: %w", err)
-	}
-
-	// Write to file
-	if err := os.WriteFile(getCustomDBConfigPath(), data, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
-
-	logDebug("Saved custom DB config for service: %s", service)
-	return nil
+	return utils.SaveCustomDBConfig(service, utils.DBConfig{
+		DBType:      config.DBType,
+		User:        config.User,
+		Password:    config.Password,
+		DestPort:    config.DestPort,
+		Databases:   config.Databases,
+		PasswordEnv: config.PasswordEnv,
+	})
 }
 
 // getDBConfig gets DB config for a service, checking custom configs first, then presets
@@ -231,15 +218,19 @@ func closeTemporaryProxytunnel(cmd *exec.Cmd) {
 func clearAuditLog(dbConfig DBConfig, localPort int) error {
 	logDebug("Clearing audit_log from %s database on localhost:%d", dbConfig.DBType, localPort)
 
+	password, err := utils.DBConfig{Password: dbConfig.Password, PasswordEnv: dbConfig.PasswordEnv}.ResolvePassword()
+	if err != nil {
+		return err
+	}
+
 	var db *sql.DB
-	var err error
 	clearedCount := 0
 
 	if dbConfig.DBType == "postgresql" {
 		// Try each database and clear audit_log if it exists
 		for _, dbName := range dbConfig.Databases {
 			connStr := fmt.Sprintf("host=127.0.0.1 port=%d user=%s password=%s dbname=%s sslmode=disable",
-				localPort, dbConfig.User, dbConfig.Password, dbName)
+				localPort, dbConfig.User, password, dbName)
 
 			db, err = sql.Open("postgres", connStr)
 			if err != nil {
@@ -272,7 +263,7 @@ func clearAuditLog(dbConfig DBConfig, localPort int) error {
 		// Try each database and clear audit_log if it exists
 		for _, dbName := range dbConfig.Databases {
 			dsn := fmt.Sprintf("%s:%s@tcp(127.0.0.1:%d)/%s",
-				dbConfig.User, dbConfig.Password, localPort, dbName)
+				dbConfig.User, password, localPort, dbName)
 
 			db, err = sql.Open("mysql", dsn)
 			if err != nil {
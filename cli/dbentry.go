@@ -14,6 +14,8 @@ import (
 
 type DBEntryModel struct {
 	service    string
+	params     snapshotParams
+	datasets   []string
 	inputs     []textinput.Model
 	focusIndex int
 	width      int
@@ -24,14 +26,17 @@ type DBEntryModel struct {
 type dbConfigEnteredMsg struct {
 	service string
 	config  utils.DBConfig
+	// params and datasets are the snapshot that waited for the config
+	params   snapshotParams
+	datasets []string
 }
 
-func NewDBEntryModel(service string) DBEntryModel {
+func NewDBEntryModel(service string, params snapshotParams, datasets []string) DBEntryModel {
 	inputs := make([]textinput.Model, 5)
 
 	// DB Type
 	inputs[0] = textinput.New()
-	inputs[0].Placeholder = "postgresql or mysql"
+	inputs[0].Placeholder = "postgresql, mysql, mssql or sqlite"
 	inputs[0].Focus()
 	inputs[0].CharLimit = 20
 	inputs[0].Width = 40
@@ -44,7 +49,7 @@ func NewDBEntryModel(service string) DBEntryModel {
 
 	// Password
 	inputs[2] = textinput.New()
-	inputs[2].Placeholder = "database password"
+	inputs[2].Placeholder = "database password, or $VAR to read it from the environment"
 	inputs[2].CharLimit = 100
 	inputs[2].Width = 40
 	inputs[2].EchoMode = textinput.EchoPassword
@@ -52,18 +57,20 @@ func NewDBEntryModel(service string) DBEntryModel {
 
 	// Port
 	inputs[3] = textinput.New()
-	inputs[3].Placeholder = "5432 or 3306"
+	inputs[3].Placeholder = "5432, 3306 or 1433 (0 for sqlite)"
 	inputs[3].CharLimit = 5
 	inputs[3].Width = 40
 
 	// Database names (comma-separated)
 	inputs[4] = textinput.New()
-	inputs[4].Placeholder = "postgres,mydb (comma-separated)"
+	inputs[4].Placeholder = "postgres,mydb (comma-separated; file paths for sqlite)"
 	inputs[4].CharLimit = 200
 	inputs[4].Width = 40
 
 	return DBEntryModel{
 		service:    service,
+		params:     params,
+		datasets:   datasets,
 		inputs:     inputs,
 		focusIndex: 0,
 		width:      100,
@@ -104,16 +111,23 @@ func (m DBEntryModel) Update(msg tea.Msg) (DBEntryModel, tea.Cmd) {
 				databasesStr := strings.TrimSpace(m.inputs[4].Value())
 
 				// Validate
-				if dbType != "postgresql" && dbType != "mysql" {
-					m.err = "DB type must be 'postgresql' or 'mysql'"
+				switch dbType {
+				case "postgresql", "mysql", "mssql", "sqlite":
+				default:
+					m.err = "DB type must be 'postgresql', 'mysql', 'mssql' or 'sqlite'"
 					return m, nil
 				}
-				if user == "" {
+				// SQLite files are opened directly, without a user or tunnel port
+				fileDB := !utils.NeedsProxytunnel(dbType)
+				if user == "" && !fileDB {
 					m.err = "Username is required"
 					return m, nil
 				}
 				port, err := strconv.Atoi(portStr)
-				if err != nil || port < 1 || port > 65535 {
+				if fileDB && portStr == "" {
+					port, err = 0, nil
+				}
+				if err != nil || (!fileDB && (port < 1 || port > 65535)) {
 					m.err = "Invalid port number"
 					return m, nil
 				}
@@ -136,7 +150,8 @@ func (m DBEntryModel) Update(msg tea.Msg) (DBEntryModel, tea.Cmd) {
 					return m, nil
 				}
 
-				// Create config
+				// Create config. A $VAR password is saved as a reference that
+				// is read at cleanup time, keeping the secret out of the file.
 				config := utils.DBConfig{
 					DBType:    dbType,
 					User:      user,
@@ -144,6 +159,9 @@ func (m DBEntryModel) Update(msg tea.Msg) (DBEntryModel, tea.Cmd) {
 					DestPort:  port,
 					Databases: databases,
 				}
+				if name, ok := strings.CutPrefix(password, "$"); ok && name != "" {
+					config.Password, config.PasswordEnv = "", name
+				}
 
 				// Save and return
 				if err := utils.SaveCustomDBConfig(m.service, config); err != nil {
@@ -153,8 +171,10 @@ func (m DBEntryModel) Update(msg tea.Msg) (DBEntryModel, tea.Cmd) {
 
 				return m, func() tea.Msg {
 					return dbConfigEnteredMsg{
-						service: m.service,
-						config:  config,
+						service:  m.service,
+						config:   config,
+						params:   m.params,
+						datasets: m.datasets,
 					}
 				}
 			}
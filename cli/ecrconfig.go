@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+)
+
+const (
+	defaultECRRegion    = "us-west-1"
+	defaultECRAccountID = "383806609161"
+)
+
+// ecrSettings holds the resolved AWS settings used for ECR authentication
+type ecrSettings struct {
+	region   string
+	profile  string
+	registry string
+}
+
+// resolveECRSettings determines the AWS region, profile and registry for ECR auth.
+//
+// Precedence (highest first):
+//  1. PLATO_AWS_REGION, PLATO_AWS_PROFILE and PLATO_AWS_REGISTRY environment variables
+//  2. The aws section of plato-config.yml
+//  3. Built-in defaults (us-west-1 and the Plato ECR registry for that region)
+//
+// When no profile is configured the AWS CLI falls back to its own resolution (AWS_PROFILE, default profile).
+func resolveECRSettings(config *models.PlatoConfig) ecrSettings {
+	var settings ecrSettings
+	if config != nil && config.AWS != nil {
+		settings.region = config.AWS.Region
+		settings.profile = config.AWS.Profile
+		settings.registry = config.AWS.Registry
+	}
+
+	if v := os.Getenv("PLATO_AWS_REGION"); v != "" {
+		settings.region = v
+	}
+	if v := os.Getenv("PLATO_AWS_PROFILE"); v != "" {
+		settings.profile = v
+	}
+	if v := os.Getenv("PLATO_AWS_REGISTRY"); v != "" {
+		settings.registry = v
+	}
+
+	if settings.region == "" {
+		settings.region = defaultECRRegion
+	}
+	if settings.registry == "" {
+		settings.registry = fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", defaultECRAccountID, settings.region)
+	}
+
+	return settings
+}
+
+// getLoginPasswordArgs returns the aws CLI arguments for fetching an ECR login token
+func (s ecrSettings) getLoginPasswordArgs() []string {
+	args := []string{"ecr", "get-login-password", "--region", s.region}
+	if s.profile != "" {
+		args = append(args, "--profile", s.profile)
+	}
+	return args
+}
+
+// ecrAutoAuthEnabled reports whether ECR authentication should run automatically once a VM is ready.
+// ecr.enabled in plato-config.yml decides when set; otherwise it runs only if the AWS CLI is installed.
+// The manual "Authenticate ECR" action is available either way.
+func ecrAutoAuthEnabled(config *models.PlatoConfig) bool {
+	if config != nil && config.ECR != nil && config.ECR.Enabled != nil {
+		return *config.ECR.Enabled
+	}
+	if _, err := exec.LookPath("aws"); err != nil {
+		utils.LogDebug("Skipping automatic ECR auth: aws CLI not found")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runEnv dispatches `plato env <subcommand>`
+func runEnv(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand (expected: ls, reset-state)")
+	}
+
+	switch args[0] {
+	case "ls", "list":
+		return listEnvironments()
+	case "reset-state":
+		return resetEnvState(args[1:])
+	default:
+		return newUsageError("unknown env subcommand '%s' (expected: ls, reset-state)", args[0])
+	}
+}
+
+// listEnvironments implements `plato env ls`, printing active environments and their URLs
+func listEnvironments() error {
+	config := NewConfigModel()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	envs, err := config.client.Environment.List(ctx)
+	if endpointUnsupported(err) {
+		return fmt.Errorf("this Plato API cannot list environments yet")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	if len(envs) == 0 {
+		fmt.Println("No active environments")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENV\tJOB ID\tALIAS\tSTATUS\tURL")
+	for _, env := range envs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", env.EnvID, env.JobID, env.Alias, env.Status, env.PublicURL)
+	}
+	return w.Flush()
+}
+
+// resetEnvState implements `plato env reset-state <jobGroupID>`, clearing the
+// cached env state of a job group without touching its databases
+func resetEnvState(args []string) error {
+	if len(args) != 1 || args[0] == "" {
+		return newUsageError("usage: plato env reset-state <jobGroupID>")
+	}
+	jobGroupID := args[0]
+
+	client := NewConfigModel().client
+	if err := utils.ClearEnvState(client, jobGroupID); err != nil {
+		return fmt.Errorf("failed to reset env state: %w", err)
+	}
+	fmt.Printf("✅ Env state of %s reset\n", jobGroupID)
+	return nil
+}
+
+// envStateResetMsg reports the result of the Reset Env State action
+type envStateResetMsg struct {
+	err error
+}
+
+// resetEnvStateCmd clears the env state cache for the Reset Env State action
+func resetEnvStateCmd(client *plato.PlatoClient, jobGroupID string) tea.Cmd {
+	return func() tea.Msg {
+		return envStateResetMsg{err: utils.ClearEnvState(client, jobGroupID)}
+	}
+}
@@ -73,6 +73,9 @@ func launchEnvironment(client *plato.PlatoClient, simulator *models.SimulatorLis
 		}
 
 		statusChan <- fmt.Sprintf("Environment created (ID: %s)", env.JobID)
+		if url := getPublicURL(client, env); url != "" {
+			statusChan <- fmt.Sprintf("Public URL: %s", url)
+		}
 		return envCreatedMsg{env: env, err: nil}
 	}
 }
@@ -147,6 +150,11 @@ func setupSSHForEnvironment(client *plato.PlatoClient, jobID string, statusChan
 			return envSSHConfiguredMsg{sshHost: "", err: err}
 		}
 
+		if err := utils.PinHostKey(context.Background(), configPath, sshHost); err != nil {
+			close(statusChan)
+			return envSSHConfiguredMsg{sshHost: "", err: err}
+		}
+
 		statusChan <- fmt.Sprintf("SSH configured: ssh -F %s %s", configPath, sshHost)
 		close(statusChan)
 		return envSSHConfiguredMsg{sshHost: sshHost, err: nil}
@@ -180,7 +188,7 @@ func NewEnvLauncherModel(client *plato.PlatoClient, simulator *models.SimulatorL
 		stopwatch:      components.NewStopwatch(),
 		statusMessages: []string{},
 		statusChan:     make(chan string, 10),
-		dataset:        "base", // Default dataset
+		dataset:        defaultDataset(),
 	}
 }
 
@@ -247,6 +255,9 @@ func (m EnvLauncherModel) Update(msg tea.Msg) (EnvLauncherModel, tea.Cmd) {
 		}
 		m.sshHost = msg.sshHost
 		m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Environment ready! (took %s)", m.stopwatch.View()))
+		if url := getPublicURL(m.client, m.environment); url != "" {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("🌐 %s", url))
+		}
 
 		// Navigate to VM info
 		return m, tea.Batch(
@@ -335,30 +346,10 @@ func (m EnvLauncherModel) View() string {
 	return content
 }
 
-// getPublicURL computes the public URL for an environment based on the base URL
+// getPublicURL returns the public URL for an environment, computing it if Make didn't
 func getPublicURL(client *plato.PlatoClient, env *models.Environment) string {
-	baseURL := client.GetBaseURL()
-	identifier := env.Alias
-	if identifier == "" {
-		identifier = env.JobID
+	if env.PublicURL != "" {
+		return env.PublicURL
 	}
-
-	// Determine environment based on base_url
-	if strings.Contains(baseURL, "localhost:8080") {
-		return fmt.Sprintf("http://%s.sims.localhost:8080", identifier)
-	} else if strings.Contains(baseURL, "plato.so") {
-		// Parse subdomain from base URL
-		parts := strings.Split(baseURL, ".")
-		if len(parts) >= 3 {
-			// Extract subdomain (e.g., "dev", "staging")
-			subdomain := strings.TrimPrefix(parts[0], "https://")
-			subdomain = strings.TrimPrefix(subdomain, "http://")
-			if subdomain != "plato" {
-				return fmt.Sprintf("https://%s.%s.sims.plato.so", identifier, subdomain)
-			}
-		}
-		return fmt.Sprintf("https://%s.sims.plato.so", identifier)
-	}
-
-	return ""
+	return models.ComputePublicURL(client.GetBaseURL(), env.URLIdentifier())
 }
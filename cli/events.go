@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// runEvents implements `plato events <correlationID>`.
+// It prints the raw SSE stream for an operation until it finishes, returning
+// the operation's error so the exit code reflects its outcome.
+func runEvents(args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 20*time.Minute, "How long to follow the stream before giving up")
+
+	// Allow the correlation ID to come before or after the flags
+	var correlationID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		correlationID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if correlationID == "" && fs.NArg() > 0 {
+		correlationID = fs.Arg(0)
+	}
+	correlationID = strings.TrimSpace(correlationID)
+	if correlationID == "" {
+		return newUsageError("correlation ID is required")
+	}
+
+	client := NewConfigModel().client
+	fmt.Printf("[DEBUG] GET %s/public-build/events/%s (timeout %s)\n", client.GetBaseURL(), correlationID, *timeout)
+
+	start := time.Now()
+	err := client.Sandbox.StreamRawEvents(context.Background(), correlationID, *timeout, func(line string) {
+		fmt.Println(line)
+	})
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Printf("[DEBUG] stopped after %s\n", elapsed)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return operationError("following events", "increase --timeout", err)
+		}
+		return err
+	}
+	fmt.Printf("[DEBUG] operation succeeded after %s\n", elapsed)
+	return nil
+}
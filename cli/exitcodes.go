@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+
+	plato "plato-sdk"
+	"plato-sdk/services"
+)
+
+// Exit codes shared by every subcommand and the interactive TUI, so scripts
+// and CI can tell failure modes apart
+const (
+	exitOK       = 0 // success
+	exitError    = 1 // any failure not covered below
+	exitUsage    = 2 // bad arguments or flags (the flag package also exits 2)
+	exitAuth     = 3 // missing, invalid or unauthorized API key
+	exitNotFound = 4 // the sandbox, simulator, file or other resource does not exist
+)
+
+// usageError reports invalid command-line arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// newUsageError returns a usageError; commands exit with exitUsage for it
+func newUsageError(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// notFoundError reports a resource the CLI looked up locally that does not exist
+type notFoundError struct {
+	msg string
+}
+
+func (e *notFoundError) Error() string {
+	return e.msg
+}
+
+// newNotFoundError returns a notFoundError; commands exit with exitNotFound for it
+func newNotFoundError(format string, args ...any) error {
+	return &notFoundError{msg: fmt.Sprintf(format, args...)}
+}
+
+// vmExpired reports whether err says the VM no longer exists on the server
+func vmExpired(err error) bool {
+	return errors.Is(err, services.ErrSandboxNotFound) && !endpointUnsupported(err)
+}
+
+// endpointUnsupported reports whether err says the API does not offer the
+// endpoint at all, for features the API may not have yet
+func endpointUnsupported(err error) bool {
+	return errors.Is(err, services.ErrEndpointUnsupported)
+}
+
+// vmExpiredError replaces the API's 404 for a VM that has gone away
+func vmExpiredError(publicID string) error {
+	return newNotFoundError("VM %s has expired or was deleted", publicID)
+}
+
+// statusCodeRe finds the HTTP status in an *APIError that reached us only as
+// text, as errors passed through the C bindings do: "API error (404): ..." or
+// "API error (404, request_id: ...): ...". Nothing else is matched, so other
+// numbers in parentheses are never read as a status.
+var statusCodeRe = regexp.MustCompile(`\bAPI error \((\d{3})[,)]`)
+
+// exitCode maps err onto the exit code contract
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+	var notFound *notFoundError
+	if errors.As(err, &notFound) {
+		return exitNotFound
+	}
+
+	if errors.Is(err, services.ErrHubAuth) {
+		return exitAuth
+	}
+
+	switch errorStatus(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return exitAuth
+	case http.StatusNotFound:
+		return exitNotFound
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return exitNotFound
+	}
+	return exitError
+}
+
+// errorStatus returns the HTTP status an SDK error reports, or 0 if it has
+// none. A wrapped *APIError is used as is; the text is only searched for the
+// APIError format the C bindings pass through.
+func errorStatus(err error) int {
+	var apiErr *plato.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	if m := statusCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+	"plato-sdk/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiExtendStep is how much lifetime the Extend Timeout action adds
+const tuiExtendStep = time.Hour
+
+// timeoutExtendedMsg reports the result of the Extend Timeout action
+type timeoutExtendedMsg struct {
+	extension time.Duration
+	response  *models.ExtendTimeoutResponse
+	err       error
+}
+
+// runExtend implements `plato extend <publicID> <duration>`, adding to the
+// lifetime of a running VM and printing its new expiry
+func runExtend(args []string) error {
+	fs := flag.NewFlagSet("extend", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return newUsageError("expected a public ID and a duration, e.g. plato extend abc123 30m")
+	}
+	client := NewConfigModel().client
+	publicID, err := utils.ResolvePublicID(client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	extension, err := time.ParseDuration(fs.Arg(1))
+	if err != nil || extension < time.Second {
+		return newUsageError("invalid duration %q, use e.g. 30m or 2h", fs.Arg(1))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := client.Sandbox.ExtendTimeout(ctx, publicID, int(extension/time.Second))
+	if endpointUnsupported(err) {
+		return fmt.Errorf("failed to extend %s: this Plato API cannot extend VM timeouts yet", publicID)
+	}
+	if vmExpired(err) {
+		return vmExpiredError(publicID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend %s: %w", publicID, err)
+	}
+
+	fmt.Printf("✅ Extended %s by %s\n", publicID, extension)
+	fmt.Printf("   %s\n", describeExpiry(resp, time.Now()))
+	return nil
+}
+
+// extendTimeout runs ExtendTimeout for the Extend Timeout action
+func extendTimeout(ctx context.Context, client *plato.PlatoClient, publicID string, extension time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := client.Sandbox.ExtendTimeout(ctx, publicID, int(extension/time.Second))
+		return timeoutExtendedMsg{extension: extension, response: resp, err: err}
+	}
+}
+
+// describeExpiry formats the expiry reported after an extension, with the
+// time left from now when the API returns a timestamp
+func describeExpiry(resp *models.ExtendTimeoutResponse, now time.Time) string {
+	if expiresAt, err := time.Parse(time.RFC3339, resp.ExpiresAt); err == nil {
+		left := expiresAt.Sub(now).Round(time.Minute)
+		return fmt.Sprintf("New expiry: %s (in %s)", expiresAt.Local().Format("Jan 2 15:04 MST"), left)
+	}
+	if resp.ExpiresAt != "" {
+		return "New expiry: " + resp.ExpiresAt
+	}
+	if resp.Timeout > 0 {
+		return fmt.Sprintf("Total lifetime: %s", time.Duration(resp.Timeout)*time.Second)
+	}
+	return "The API did not report the new expiry"
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"plato-cli/internal/utils"
+)
+
+// progressFunc reports a status line from a running operation. A transient
+// line, such as a git transfer percentage, replaces the previous transient
+// line instead of adding another.
+type progressFunc func(message string, transient bool)
+
+// report calls p if it is set, so operations can run without a status panel
+func (p progressFunc) report(message string, transient bool) {
+	if p != nil {
+		p(message, transient)
+	}
+}
+
+// gitProgressInterval limits how often git transfer percentages are reported
+const gitProgressInterval = 250 * time.Millisecond
+
+// runGitWithProgress runs a git subcommand with --progress, forwarding the
+// progress output as transient lines. The command is killed when ctx ends;
+// the error then wraps ctx.Err() so timeouts can be told apart from git
+// failures.
+func runGitWithProgress(ctx context.Context, dir string, env []string, progress progressFunc, args ...string) error {
+	args = append([]string{args[0], "--progress"}, args[1:]...)
+	cmd := utils.GitCommand(ctx, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// git rewrites its progress line with \r, so split on both line endings
+	var output strings.Builder
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	var lastReport time.Time
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.Contains(line, "%") {
+			output.WriteString(line + "\n")
+		}
+		if time.Since(lastReport) >= gitProgressInterval || strings.Contains(line, "done") {
+			progress.report("  "+line, true)
+			lastReport = time.Now()
+		}
+	}
+	io.Copy(io.Discard, stderr)
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		return fmt.Errorf("%w\nOutput: %s%s", err, stdout.String(), output.String())
+	}
+	return nil
+}
+
+// scanProgressLines is a bufio.SplitFunc that ends lines at \n or \r
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// partialCloneFilter is the git clone flag added for hub.partial_clone
+const partialCloneFilter = "--filter=blob:none"
+
+// withoutPartialClone returns clone args without the partial clone filter and
+// whether the filter was present, for retrying with a git that rejects it
+func withoutPartialClone(args []string) ([]string, bool) {
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != partialCloneFilter {
+			filtered = append(filtered, arg)
+		}
+	}
+	return filtered, len(filtered) != len(args)
+}
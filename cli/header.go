@@ -7,6 +7,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"sync"
+
+	"plato-cli/internal/config"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -18,6 +21,36 @@ var (
 	buildTime = "unknown"
 )
 
+// headerEnvironment caches the environment for the lifetime of the process;
+// the header is re-rendered on every frame.
+var (
+	headerEnvironmentOnce sync.Once
+	headerEnvironment     string
+)
+
+// environmentBadgeColors maps each environment to its badge background.
+// Production is red so it stands out.
+var environmentBadgeColors = map[string]string{
+	config.EnvironmentLocal:   "#5A5A5A",
+	config.EnvironmentDev:     "#2E7D32",
+	config.EnvironmentStaging: "#E65100",
+	config.EnvironmentProd:    "#C62828",
+}
+
+// RenderEnvironmentBadge renders a color-coded "ENV: <name>" label
+func RenderEnvironmentBadge(environment string) string {
+	color, ok := environmentBadgeColors[environment]
+	if !ok {
+		color = environmentBadgeColors[config.EnvironmentProd]
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFFFFF")).
+		Background(lipgloss.Color(color)).
+		Bold(true).
+		Padding(0, 1).
+		Render("ENV: " + environment)
+}
+
 func RenderHeader() string {
 	titleStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#7D56F4")).
@@ -43,7 +76,11 @@ func RenderHeader() string {
 		versionInfo += fmt.Sprintf(" (%s)", gitCommit[:7])
 	}
 
-	title := titleStyle.Render("Plato Sandbox CLI")
+	headerEnvironmentOnce.Do(func() {
+		headerEnvironment = config.DetectEnvironment(config.GetBaseURL())
+	})
+
+	title := titleStyle.Render("Plato Sandbox CLI") + "  " + RenderEnvironmentBadge(headerEnvironment)
 	subtitle := subtitleStyle.Render(fmt.Sprintf("%s · %s", versionInfo, cwd))
 
 	return fmt.Sprintf("%s\n%s\n", title, subtitle)
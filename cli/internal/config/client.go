@@ -6,18 +6,37 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	plato "plato-sdk"
+	"plato-sdk/services"
 
 	"github.com/joho/godotenv"
 )
 
+// GetUserEnvPath returns the path of the per-user env file written by `plato login`
+func GetUserEnvPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", ".env")
+}
+
+// LoadEnv loads .env from the current directory, then ~/.plato/.env.
+// Existing environment variables are never overridden, so a project .env
+// takes precedence over settings saved by `plato login`.
+func LoadEnv() {
+	godotenv.Load()
+	if _, err := os.Stat(GetUserEnvPath()); err == nil {
+		godotenv.Load(GetUserEnvPath())
+	}
+}
+
 // LoadClient loads configuration from environment and creates a Plato client
 func LoadClient() *plato.PlatoClient {
-	// Load .env file
-	godotenv.Load()
+	// Load .env files
+	LoadEnv()
 
-	apiKey := os.Getenv("PLATO_API_KEY")
+	apiKey := ResolveAPIKey()
 	baseURL := os.Getenv("PLATO_BASE_URL")
 	hubBaseURL := os.Getenv("PLATO_HUB_API_URL")
 
@@ -30,20 +49,57 @@ func LoadClient() *plato.PlatoClient {
 	if hubBaseURL == "" {
 		hubBaseURL = "https://plato.so/api"
 	}
-	opts = append(opts, plato.WithHubBaseURL(hubBaseURL))
+	opts = append(opts, plato.WithHubBaseURL(hubBaseURL), plato.WithHubGitTransport(HubGitTransport()), plato.WithHubCloneOptions(HubCloneOptions()), plato.WithSSEIdleTimeout(StreamIdleTimeout()))
 
 	return plato.NewClient(apiKey, opts...)
 }
 
-// GetAPIKey returns the API key from environment
+// HubGitTransport returns the git transport for hub repositories from
+// PLATO_HUB_GIT_TRANSPORT ("https" or "ssh"), defaulting to HTTPS
+func HubGitTransport() services.GitTransport {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("PLATO_HUB_GIT_TRANSPORT")), string(services.GitTransportSSH)) {
+		return services.GitTransportSSH
+	}
+	return services.GitTransportHTTPS
+}
+
+// HubCloneOptions returns how hub repositories are cloned, from the hub
+// section of plato-config.yml when there is one
+func HubCloneOptions() services.CloneOptions {
+	clone := services.DefaultCloneOptions()
+	platoConfig, err := LoadPlatoConfig()
+	if err != nil || platoConfig.Hub == nil {
+		return clone
+	}
+	if platoConfig.Hub.CloneDepth != nil {
+		clone.Depth = *platoConfig.Hub.CloneDepth
+	}
+	clone.PartialClone = platoConfig.Hub.PartialClone
+	return clone
+}
+
+// StreamIdleTimeout returns timeouts.stream_idle from plato-config.yml, or 0
+// (the SDK default) when it is unset or not a positive duration
+func StreamIdleTimeout() time.Duration {
+	platoConfig, err := LoadPlatoConfig()
+	if err != nil || platoConfig.Timeouts == nil || platoConfig.Timeouts.StreamIdle == "" {
+		return 0
+	}
+	idle, err := time.ParseDuration(platoConfig.Timeouts.StreamIdle)
+	if err != nil || idle <= 0 {
+		return 0
+	}
+	return idle
+}
+
+// GetAPIKey returns the API key from the configured credential provider
 func GetAPIKey() string {
-	godotenv.Load()
-	return os.Getenv("PLATO_API_KEY")
+	return ResolveAPIKey()
 }
 
 // GetBaseURL returns the base URL from environment or default
 func GetBaseURL() string {
-	godotenv.Load()
+	LoadEnv()
 	baseURL := os.Getenv("PLATO_BASE_URL")
 	if baseURL == "" {
 		return "https://plato.so/api"
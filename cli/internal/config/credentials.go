@@ -0,0 +1,213 @@
+// Package config provides configuration management for the Plato CLI.
+//
+// This file defines pluggable credential providers used to look up the API key
+// from the environment, a local credentials file, or the OS keychain.
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// ProviderEnv reads PLATO_API_KEY from the environment (and .env)
+	ProviderEnv = "env"
+	// ProviderFile reads the key from ~/.plato/credentials
+	ProviderFile = "file"
+	// ProviderKeychain reads the key from the macOS Keychain or libsecret
+	ProviderKeychain = "keychain"
+
+	keychainService = "plato-cli"
+	keychainAccount = "api-key"
+)
+
+// CredentialProvider stores and retrieves the Plato API key
+type CredentialProvider interface {
+	// Name returns the provider identifier used in PLATO_CREDENTIAL_PROVIDER
+	Name() string
+	// GetAPIKey returns the stored key, or an empty string if none is stored
+	GetAPIKey() (string, error)
+	// SetAPIKey stores the key, replacing any existing value
+	SetAPIKey(apiKey string) error
+	// DeleteAPIKey removes the stored key
+	DeleteAPIKey() error
+}
+
+// NewCredentialProvider returns the provider registered under name
+func NewCredentialProvider(name string) (CredentialProvider, error) {
+	switch name {
+	case ProviderEnv:
+		return EnvProvider{}, nil
+	case ProviderFile:
+		return FileProvider{Path: GetCredentialsFilePath()}, nil
+	case ProviderKeychain:
+		return KeychainProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown credential provider '%s' (expected env, file or keychain)", name)
+	}
+}
+
+// SelectedProviderName returns the provider configured via PLATO_CREDENTIAL_PROVIDER, or "" if unset
+func SelectedProviderName() string {
+	LoadEnv()
+	return strings.TrimSpace(os.Getenv("PLATO_CREDENTIAL_PROVIDER"))
+}
+
+// ResolveAPIKey looks up the API key.
+//
+// When PLATO_CREDENTIAL_PROVIDER is set, that provider is consulted first. The
+// environment and the credentials file are always used as fallbacks, in that order.
+func ResolveAPIKey() string {
+	key, _ := ResolveAPIKeyWithProvider()
+	return key
+}
+
+// ResolveAPIKeyWithProvider is ResolveAPIKey but also reports which provider
+// supplied the key. Both values are empty when no key is configured.
+func ResolveAPIKeyWithProvider() (string, string) {
+	for _, name := range CredentialProviderOrder(SelectedProviderName()) {
+		provider, err := NewCredentialProvider(name)
+		if err != nil {
+			continue
+		}
+		if key, err := provider.GetAPIKey(); err == nil && key != "" {
+			return key, name
+		}
+	}
+	return "", ""
+}
+
+// CredentialProviderOrder returns the providers ResolveAPIKey consults, in
+// order, when selected is the PLATO_CREDENTIAL_PROVIDER value
+func CredentialProviderOrder(selected string) []string {
+	order := []string{ProviderEnv, ProviderFile}
+	if selected != "" {
+		order = append([]string{selected}, order...)
+	}
+	return order
+}
+
+// GetCredentialsFilePath returns the path of the file used by FileProvider
+func GetCredentialsFilePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "credentials")
+}
+
+// EnvProvider reads the key from PLATO_API_KEY. It cannot persist keys.
+type EnvProvider struct{}
+
+func (EnvProvider) Name() string { return ProviderEnv }
+
+func (EnvProvider) GetAPIKey() (string, error) {
+	LoadEnv()
+	return os.Getenv("PLATO_API_KEY"), nil
+}
+
+func (EnvProvider) SetAPIKey(apiKey string) error {
+	return fmt.Errorf("the env provider is read-only; set PLATO_API_KEY instead")
+}
+
+func (EnvProvider) DeleteAPIKey() error {
+	return fmt.Errorf("the env provider is read-only; unset PLATO_API_KEY instead")
+}
+
+// FileProvider stores the key in a user-only readable file
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) Name() string { return ProviderFile }
+
+func (p FileProvider) GetAPIKey() (string, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (p FileProvider) SetAPIKey(apiKey string) error {
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := os.WriteFile(p.Path, []byte(apiKey+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+func (p FileProvider) DeleteAPIKey() error {
+	if err := os.Remove(p.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove credentials file: %w", err)
+	}
+	return nil
+}
+
+// KeychainProvider stores the key in the macOS Keychain (via `security`)
+// or a libsecret keyring on Linux (via `secret-tool`)
+type KeychainProvider struct{}
+
+func (KeychainProvider) Name() string { return ProviderKeychain }
+
+func (KeychainProvider) GetAPIKey() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", keychainAccount)
+	default:
+		return "", fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		// Both tools exit non-zero when no matching item exists
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read from keychain: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (KeychainProvider) SetAPIKey(apiKey string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", keychainAccount, "-w", apiKey)
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=Plato API key", "service", keychainService, "account", keychainAccount)
+		cmd.Stdin = strings.NewReader(apiKey)
+	default:
+		return fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write to keychain: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+func (KeychainProvider) DeleteAPIKey() error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", keychainAccount)
+	case "linux":
+		cmd = exec.Command("secret-tool", "clear", "service", keychainService, "account", keychainAccount)
+	default:
+		return fmt.Errorf("keychain is not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove from keychain: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Environments reported by DetectEnvironment
+const (
+	EnvironmentLocal   = "local"
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
+)
+
+// DetectEnvironment classifies a Plato API base URL by its host.
+// Anything that is not recognisably local, dev or staging is treated as
+// production so the CLI errs on the side of caution.
+func DetectEnvironment(baseURL string) string {
+	host := strings.ToLower(strings.TrimSpace(baseURL))
+	if u, err := url.Parse(host); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		host == "127.0.0.1" || host == "0.0.0.0" || host == "::1" ||
+		host == "host.docker.internal" {
+		return EnvironmentLocal
+	}
+
+	labels := strings.FieldsFunc(host, func(r rune) bool { return r == '.' || r == '-' })
+	for _, label := range labels {
+		switch label {
+		case "staging", "stage", "stg":
+			return EnvironmentStaging
+		case "dev", "development":
+			return EnvironmentDev
+		}
+	}
+	return EnvironmentProd
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ConfigError explains why plato-config.yml could not be loaded.
+// Missing is set when the file does not exist; otherwise Line and Column
+// locate the YAML syntax error when the parser reported them.
+type ConfigError struct {
+	Path    string
+	Missing bool
+	Line    int
+	Column  int
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("%s not found in the current directory (run `plato config init` to create one)", e.Path)
+	}
+	detail := yamlPrefixRe.ReplaceAllString(e.Err.Error(), "")
+	if e.Line > 0 && e.Column > 0 {
+		return fmt.Sprintf("%s is malformed at line %d, column %d: %s", e.Path, e.Line, e.Column, detail)
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s is malformed at line %d: %s", e.Path, e.Line, detail)
+	}
+	return fmt.Sprintf("failed to load %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	yamlLineRe   = regexp.MustCompile(`line (\d+)`)
+	yamlColumnRe = regexp.MustCompile(`column (\d+)`)
+	// yamlPrefixRe strips the position prefix the parser puts on its messages
+	yamlPrefixRe = regexp.MustCompile(`^yaml: (line \d+: )?`)
+)
+
+// newReadError wraps a failure to read path
+func newReadError(path string, err error) error {
+	return &ConfigError{Path: path, Missing: errors.Is(err, os.ErrNotExist), Err: err}
+}
+
+// newParseError wraps a YAML decode failure, pulling the position out of the
+// parser message since yaml.v3 does not expose it as fields
+func newParseError(path string, err error) error {
+	e := &ConfigError{Path: path, Err: err}
+	if m := yamlLineRe.FindStringSubmatch(err.Error()); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+	}
+	if m := yamlColumnRe.FindStringSubmatch(err.Error()); m != nil {
+		e.Column, _ = strconv.Atoi(m[1])
+	}
+	return e
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// extendsKey names the dataset a dataset inherits from; baseDatasetKey is
+// accepted as an alias
+const (
+	extendsKey     = "extends"
+	baseDatasetKey = "base_dataset"
+)
+
+// pinnedArtifactKeys name the snapshot a dataset launches from. A snapshot
+// belongs to one dataset, so they are not inherited.
+var pinnedArtifactKeys = []string{"artifact_id", "version"}
+
+// resolveDatasetExtends expands datasets that extend another dataset in the
+// raw plato-config.yml document, in place. It reports whether anything was
+// expanded so callers can skip re-encoding configs that use no inheritance.
+//
+// Merge semantics:
+//   - the child starts from the fully resolved base, so chains (c extends b
+//     extends a) work
+//   - mappings (compute, metadata, services, a single service, ...) are merged
+//     key by key, with the child's value winning
+//   - scalars and lists (ports, required_healthy_containers, variables, ...)
+//     replace the base value as a whole
+//   - a key set to null in the child clears the inherited value
+//   - artifact_id and version are never inherited
+//
+// The base must exist and inheritance must not form a cycle.
+func resolveDatasetExtends(raw map[string]any) (bool, error) {
+	datasets, ok := raw["datasets"].(map[string]any)
+	if !ok {
+		return false, nil
+	}
+
+	bases := make(map[string]string)
+	for name, value := range datasets {
+		dataset, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		base, err := datasetBase(name, dataset)
+		if err != nil {
+			return false, err
+		}
+		if base == "" {
+			continue
+		}
+		if _, ok := datasets[base].(map[string]any); !ok {
+			return false, fmt.Errorf("dataset '%s' extends unknown dataset '%s'", name, base)
+		}
+		bases[name] = base
+	}
+	if len(bases) == 0 {
+		return false, nil
+	}
+
+	// Resolve in a fixed order so errors are reproducible
+	names := make([]string, 0, len(bases))
+	for name := range bases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]map[string]any)
+	var resolve func(name string, chain []string) (map[string]any, error)
+	resolve = func(name string, chain []string) (map[string]any, error) {
+		if dataset, ok := resolved[name]; ok {
+			return dataset, nil
+		}
+		for i, seen := range chain {
+			if seen == name {
+				return nil, fmt.Errorf("datasets extend each other in a cycle: %s", strings.Join(append(chain[i:], name), " -> "))
+			}
+		}
+
+		dataset := datasets[name].(map[string]any)
+		base, ok := bases[name]
+		if !ok {
+			resolved[name] = dataset
+			return dataset, nil
+		}
+		parent, err := resolve(base, append(chain, name))
+		if err != nil {
+			return nil, err
+		}
+
+		own := make(map[string]any, len(dataset))
+		for key, value := range dataset {
+			if key != extendsKey && key != baseDatasetKey {
+				own[key] = value
+			}
+		}
+		inherited := make(map[string]any, len(parent))
+		for key, value := range parent {
+			inherited[key] = value
+		}
+		for _, key := range pinnedArtifactKeys {
+			delete(inherited, key)
+		}
+		merged := mergeYAMLMaps(inherited, own)
+		resolved[name] = merged
+		return merged, nil
+	}
+
+	for _, name := range names {
+		dataset, err := resolve(name, nil)
+		if err != nil {
+			return false, err
+		}
+		datasets[name] = dataset
+	}
+	return true, nil
+}
+
+// datasetBase returns the dataset named by extends (or base_dataset), or ""
+func datasetBase(name string, dataset map[string]any) (string, error) {
+	var base string
+	for _, key := range []string{extendsKey, baseDatasetKey} {
+		value, ok := dataset[key]
+		if !ok {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok || s == "" {
+			return "", fmt.Errorf("dataset '%s': %s must be the name of another dataset", name, key)
+		}
+		if base != "" && base != s {
+			return "", fmt.Errorf("dataset '%s' sets both %s and %s", name, extendsKey, baseDatasetKey)
+		}
+		base = s
+	}
+	if base == name {
+		return "", fmt.Errorf("dataset '%s' extends itself", name)
+	}
+	return base, nil
+}
+
+// mergeYAMLMaps returns a new map with override applied on top of base.
+// Nested mappings are merged recursively; any other value replaces the base.
+func mergeYAMLMaps(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseMap, baseIsMap := merged[key].(map[string]any)
+		overrideMap, overrideIsMap := value.(map[string]any)
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeYAMLMaps(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
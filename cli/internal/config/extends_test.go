@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.WriteFile(platoConfigFilename, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestLoadPlatoConfigResolvesExtends(t *testing.T) {
+	writeConfig(t, `
+service: espocrm
+datasets:
+  base:
+    compute:
+      cpus: 2
+      memory: 2048
+      disk: 10240
+      app_port: 8080
+    metadata:
+      name: EspoCRM
+      start_url: http://localhost:8080
+    services:
+      main_app:
+        type: docker-compose
+        file: docker-compose.yml
+        required_healthy_containers: [app, db]
+  large:
+    extends: base
+    compute:
+      memory: 8192
+  demo:
+    base_dataset: large
+    metadata:
+      name: EspoCRM demo
+    services:
+      main_app:
+        required_healthy_containers: [app]
+`)
+
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	large := config.Datasets["large"]
+	if large.Compute.Memory != 8192 || large.Compute.Cpus != 2 || large.Compute.AppPort != 8080 {
+		t.Errorf("expected compute to merge field by field, got %+v", large.Compute)
+	}
+	if large.Metadata.Name != "EspoCRM" {
+		t.Errorf("expected metadata to be inherited, got %q", large.Metadata.Name)
+	}
+
+	demo := config.Datasets["demo"]
+	if demo.Compute.Memory != 8192 || demo.Metadata.StartUrl != "http://localhost:8080" {
+		t.Errorf("expected demo to inherit through large, got %+v", demo)
+	}
+	if demo.Metadata.Name != "EspoCRM demo" {
+		t.Errorf("expected name override, got %q", demo.Metadata.Name)
+	}
+	service := demo.Services["main_app"]
+	if service.File != "docker-compose.yml" {
+		t.Errorf("expected service fields to be inherited, got %+v", service)
+	}
+	if strings.Join(service.RequiredHealthyContainers, ",") != "app" {
+		t.Errorf("expected lists to be replaced, got %v", service.RequiredHealthyContainers)
+	}
+
+	if base := config.Datasets["base"]; base.Compute.Memory != 2048 || len(base.Services["main_app"].RequiredHealthyContainers) != 2 {
+		t.Errorf("base dataset was modified: %+v", base)
+	}
+}
+
+func TestLoadPlatoConfigExtendsDoesNotInheritPinnedArtifact(t *testing.T) {
+	writeConfig(t, `
+service: espocrm
+datasets:
+  base:
+    artifact_id: 2ea954c0-7e5a-4a12-82fd-12cb46538827
+    version: "3"
+    compute:
+      cpus: 2
+  large:
+    extends: base
+    compute:
+      memory: 8192
+`)
+
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if base := config.Datasets["base"]; base.ArtifactID != "2ea954c0-7e5a-4a12-82fd-12cb46538827" || base.Version != "3" {
+		t.Errorf("expected base to keep its pinned artifact, got %q version %q", base.ArtifactID, base.Version)
+	}
+	if large := config.Datasets["large"]; large.ArtifactID != "" || large.Version != "" {
+		t.Errorf("expected the pinned artifact not to be inherited, got %q version %q", large.ArtifactID, large.Version)
+	}
+}
+
+func TestLoadPlatoConfigExtendsErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown dataset 'missing'": `
+datasets:
+  a:
+    extends: missing
+`,
+		"cycle: a -> b -> a": `
+datasets:
+  a:
+    extends: b
+  b:
+    extends: a
+`,
+		"extends itself": `
+datasets:
+  a:
+    extends: a
+`,
+	}
+	for want, content := range cases {
+		writeConfig(t, content)
+		_, err := LoadPlatoConfig()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"plato-sdk/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// versionKey is the top-level plato-config.yml key holding the schema version
+const versionKey = "version"
+
+// configMigration upgrades a plato-config.yml document by one schema version
+type configMigration struct {
+	// description says what the upgrade changes, for `plato config migrate`
+	description string
+	// apply rewrites the root mapping of the document in place; nil when only
+	// the version changes
+	apply func(root *yaml.Node) error
+}
+
+// configMigrations[i] upgrades a document from schema version i to i+1, so
+// its length is always models.PlatoConfigVersion. When a key moves or changes
+// meaning, append a migration and bump models.PlatoConfigVersion.
+//
+// Files written before versioning (version 0) need no changes: every key they
+// can hold still means the same, and keys added since, such as ecr or
+// timeouts, fall back to their defaults when missing.
+var configMigrations = []configMigration{
+	{description: "record the schema version"},
+}
+
+// ConfigMigration is the result of upgrading a plato-config.yml document
+type ConfigMigration struct {
+	From  int
+	To    int
+	Steps []string // what each applied upgrade changed, oldest first
+	Data  []byte   // the upgraded document
+}
+
+// Changed reports whether the document was written for an older schema
+func (m *ConfigMigration) Changed() bool {
+	return m.From != m.To
+}
+
+// MigrateConfig upgrades a plato-config.yml document to
+// models.PlatoConfigVersion and records the version in it. Comments are kept,
+// but the document is re-indented. A document written for a newer schema than
+// this CLI knows is an error.
+func MigrateConfig(data []byte) (*ConfigMigration, error) {
+	return migrateConfig(data, false)
+}
+
+// migrateConfig is MigrateConfig. With forLoad set, a document only needing
+// its version recorded is returned unchanged, so that reading it does not
+// shift the positions in error messages.
+func migrateConfig(data []byte, forLoad bool) (*ConfigMigration, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		// An empty file, or one holding only comments
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	from, err := configVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	m := &ConfigMigration{From: from, To: models.PlatoConfigVersion, Data: data}
+	if from > m.To {
+		return nil, fmt.Errorf("schema version %d is newer than this CLI supports (%d); upgrade the Plato CLI", from, m.To)
+	}
+	if from == m.To {
+		return m, nil
+	}
+
+	restructured := false
+	for _, migration := range configMigrations[from:] {
+		if migration.apply != nil {
+			if err := migration.apply(root); err != nil {
+				return nil, fmt.Errorf("failed to %s: %w", migration.description, err)
+			}
+			restructured = true
+		}
+		m.Steps = append(m.Steps, migration.description)
+	}
+	if forLoad && !restructured {
+		return m, nil
+	}
+
+	setConfigVersion(root, m.To)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	m.Data = buf.Bytes()
+	return m, nil
+}
+
+// configVersion returns the version recorded in the root mapping, or 0
+func configVersion(root *yaml.Node) (int, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != versionKey {
+			continue
+		}
+		value := root.Content[i+1]
+		version, err := strconv.Atoi(value.Value)
+		if value.Kind != yaml.ScalarNode || err != nil || version < 0 {
+			return 0, fmt.Errorf("version must be a whole number, got %q", value.Value)
+		}
+		return version, nil
+	}
+	return 0, nil
+}
+
+// setConfigVersion records version in the root mapping, adding the key at the
+// top when it is missing
+func setConfigVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == versionKey {
+			root.Content[i+1] = value
+			return
+		}
+	}
+	// A comment above the first key usually describes the whole file, so it
+	// stays at the top
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: versionKey}
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// MigratePlatoConfigFile upgrades plato-config.yml in the current directory
+// to the current schema, rewriting it unless dryRun is set or it is already
+// current. Errors reading or parsing the file are *ConfigError.
+func MigratePlatoConfigFile(dryRun bool) (*ConfigMigration, error) {
+	data, err := os.ReadFile(platoConfigFilename)
+	if err != nil {
+		return nil, newReadError(platoConfigFilename, err)
+	}
+	m, err := MigrateConfig(data)
+	if err != nil {
+		return nil, newParseError(platoConfigFilename, err)
+	}
+	if dryRun || !m.Changed() {
+		return m, nil
+	}
+
+	info, err := os.Stat(platoConfigFilename)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(platoConfigFilename, m.Data, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", platoConfigFilename, err)
+	}
+	return m, nil
+}
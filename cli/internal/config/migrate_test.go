@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"plato-sdk/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigMigrationsCoverEveryVersion(t *testing.T) {
+	if len(configMigrations) != models.PlatoConfigVersion {
+		t.Fatalf("%d migrations for schema version %d", len(configMigrations), models.PlatoConfigVersion)
+	}
+}
+
+func TestMigrateConfig_Unversioned(t *testing.T) {
+	data := []byte("# my simulator\nservice: espocrm\ndatasets:\n    base:\n        compute:\n            cpus: 1\n")
+	m, err := MigrateConfig(data)
+	if err != nil {
+		t.Fatalf("MigrateConfig: %v", err)
+	}
+	if m.From != 0 || m.To != models.PlatoConfigVersion || !m.Changed() || len(m.Steps) != models.PlatoConfigVersion {
+		t.Errorf("unexpected migration %+v", m)
+	}
+	out := string(m.Data)
+	if !strings.HasPrefix(out, "# my simulator\nversion: 1\nservice: espocrm\n") {
+		t.Errorf("expected the version at the top with the comment kept, got:\n%s", out)
+	}
+	if !strings.Contains(out, "\n  base:\n    compute:\n") {
+		t.Errorf("expected two-space indentation, got:\n%s", out)
+	}
+
+	// Migrating again is a no-op
+	again, err := MigrateConfig(m.Data)
+	if err != nil || again.Changed() || string(again.Data) != out {
+		t.Errorf("expected current config to be left alone, got %+v, %v", again, err)
+	}
+}
+
+func TestMigrateConfig_Errors(t *testing.T) {
+	for data, want := range map[string]string{
+		"version: 99\nservice: x\n":     "newer than this CLI supports",
+		"version: latest\nservice: x\n": "version must be a whole number",
+		"- a\n- b\n":                    "mapping at the top level",
+	} {
+		if _, err := MigrateConfig([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("MigrateConfig(%q) = %v, want error containing %q", data, err, want)
+		}
+	}
+}
+
+func TestMigrateConfig_AppliesMigrations(t *testing.T) {
+	saved := configMigrations
+	t.Cleanup(func() { configMigrations = saved })
+	// Pretend the first schema version renamed a key
+	configMigrations = append([]configMigration{{
+		description: "rename service to simulator",
+		apply: func(root *yaml.Node) error {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i].Value == "service" {
+					root.Content[i].Value = "simulator"
+				}
+			}
+			return nil
+		},
+	}}, saved[1:]...)
+
+	// Loading rewrites the document in memory when keys moved
+	m, err := migrateConfig([]byte("service: espocrm\n"), true)
+	if err != nil {
+		t.Fatalf("migrateConfig: %v", err)
+	}
+	if string(m.Data) != "version: 1\nsimulator: espocrm\n" {
+		t.Errorf("unexpected migrated document:\n%s", m.Data)
+	}
+	if len(m.Steps) != 1 || m.Steps[0] != "rename service to simulator" {
+		t.Errorf("unexpected steps %v", m.Steps)
+	}
+}
+
+func TestLoadPlatoConfig_RecordsVersion(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(platoConfigFilename, []byte("service: espocrm\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		t.Fatalf("LoadPlatoConfig: %v", err)
+	}
+	if config.Version != models.PlatoConfigVersion || config.Service != "espocrm" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	if err := os.WriteFile(platoConfigFilename, []byte("version: 7\nservice: espocrm\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlatoConfig(); err == nil || !strings.Contains(err.Error(), "newer than this CLI supports") {
+		t.Errorf("expected a newer schema to be rejected, got %v", err)
+	}
+
+	m, err := MigratePlatoConfigFile(true)
+	if err == nil {
+		t.Errorf("expected dry run of a newer schema to fail, got %+v", m)
+	}
+}
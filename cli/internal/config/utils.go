@@ -4,8 +4,10 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"plato-sdk/models"
 
@@ -20,22 +22,74 @@ func ConfigExists() bool {
 	return err == nil
 }
 
-// LoadPlatoConfig loads and parses plato-config.yml from the current directory
+// LoadPlatoConfig loads and parses plato-config.yml from the current directory,
+// upgrading files written for an older schema (see MigrateConfig) and
+// resolving datasets that extend another (see resolveDatasetExtends). The
+// returned config is always at models.PlatoConfigVersion.
+// Errors are *ConfigError so callers can tell a missing file from a malformed one.
 func LoadPlatoConfig() (*models.PlatoConfig, error) {
 	data, err := os.ReadFile(platoConfigFilename)
 	if err != nil {
-		return nil, err
+		return nil, newReadError(platoConfigFilename, err)
+	}
+
+	// Expand datasets that extend another one before decoding. Configs without
+	// inheritance are decoded as written so error positions match the file.
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, newParseError(platoConfigFilename, err)
+	}
+	migration, err := migrateConfig(data, true)
+	if err != nil {
+		return nil, &ConfigError{Path: platoConfigFilename, Err: err}
+	}
+	if !bytes.Equal(migration.Data, data) {
+		data = migration.Data
+		raw = nil
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, &ConfigError{Path: platoConfigFilename, Err: err}
+		}
+	}
+	expanded, err := resolveDatasetExtends(raw)
+	if err != nil {
+		return nil, &ConfigError{Path: platoConfigFilename, Err: err}
+	}
+	if expanded {
+		if data, err = yaml.Marshal(raw); err != nil {
+			return nil, &ConfigError{Path: platoConfigFilename, Err: err}
+		}
 	}
 
 	var config models.PlatoConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
+		return nil, newParseError(platoConfigFilename, err)
 	}
+	config.Version = models.PlatoConfigVersion
 
 	return &config, nil
 }
 
+// DefaultDataset returns the default_dataset from plato-config.yml, or
+// models.DefaultDatasetName when there is no config or it does not set one
+func DefaultDataset() string {
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		return models.DefaultDatasetName
+	}
+	return config.GetDefaultDataset()
+}
+
+// DatasetOrDefault returns dataset, or DefaultDataset when it is empty
+func DatasetOrDefault(dataset string) string {
+	if dataset = strings.TrimSpace(dataset); dataset != "" {
+		return dataset
+	}
+	return DefaultDataset()
+}
+
 // SavePlatoConfig saves a PlatoConfig to plato-config.yml in the current directory
+// Inheritance is already resolved in a loaded config, so datasets that used
+// extends are written out in full.
 func SavePlatoConfig(config *models.PlatoConfig) error {
 	data, err := yaml.Marshal(config)
 	if err != nil {
@@ -0,0 +1,156 @@
+// Package session records resources the CLI creates so they can be reviewed
+// and cleaned up later.
+//
+// Resources are stored in ~/.plato/session.json and survive restarts, so a VM
+// or tunnel left behind by a crashed run still shows up in `plato session`.
+// Live VMs are also saved with their SSH setup in ~/.plato/sessions.json, so
+// the TUI can reconnect to them.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"plato-cli/internal/utils"
+)
+
+// Kind identifies the type of a tracked resource
+type Kind string
+
+const (
+	// KindVM is a sandbox VM, identified by its public ID
+	KindVM Kind = "vm"
+	// KindTunnel is a proxytunnel process, identified by its PID
+	KindTunnel Kind = "tunnel"
+	// KindSSHKey is a generated private key, identified by its path
+	KindSSHKey Kind = "ssh_key"
+	// KindTempFile is a temporary file such as a per-VM SSH config, identified by its path
+	KindTempFile Kind = "temp_file"
+	// KindBranch is a hub branch pushed but not yet merged by a snapshot, identified by its name
+	KindBranch Kind = "branch"
+)
+
+// Resource is a single tracked resource
+type Resource struct {
+	Kind Kind   `json:"kind"`
+	ID   string `json:"id"`
+	// PublicID is the VM the resource belongs to, if any
+	PublicID string `json:"public_id,omitempty"`
+	// Detail is a human readable description (alias, port mapping, service name)
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Path returns the file resources are stored in
+func Path() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "session.json")
+}
+
+func lockPath() string {
+	return Path() + ".lock"
+}
+
+// Record adds a resource, replacing any existing entry with the same kind and ID
+func Record(r Resource) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return update(func(resources []Resource) []Resource {
+		resources = without(resources, func(e Resource) bool { return e.Kind == r.Kind && e.ID == r.ID })
+		return append(resources, r)
+	})
+}
+
+// Forget removes the resource with the given kind and ID
+func Forget(kind Kind, id string) error {
+	return update(func(resources []Resource) []Resource {
+		return without(resources, func(e Resource) bool { return e.Kind == kind && e.ID == id })
+	})
+}
+
+// ForgetVM removes a VM and the tunnels, keys and files that belong to it.
+// Branches are kept because they outlive the VM in the hub.
+func ForgetVM(publicID string) error {
+	return update(func(resources []Resource) []Resource {
+		return without(resources, func(e Resource) bool {
+			return e.PublicID == publicID && e.Kind != KindBranch
+		})
+	})
+}
+
+// List returns all tracked resources in the order they were recorded
+func List() ([]Resource, error) {
+	unlock, err := utils.LockFile(lockPath())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return load()
+}
+
+func update(fn func([]Resource) []Resource) error {
+	unlock, err := utils.LockFile(lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	resources, err := load()
+	if err != nil {
+		return err
+	}
+	return save(fn(resources))
+}
+
+func load() ([]Resource, error) {
+	return readList[Resource](Path())
+}
+
+func save(resources []Resource) error {
+	return writeList(Path(), resources)
+}
+
+// readList reads a JSON list stored by writeList, returning nil if the file
+// does not exist yet
+func readList[T any](path string) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return items, nil
+}
+
+func writeList[T any](path string, items []T) error {
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// Write to a temp file and rename so a crash never leaves a truncated file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func without[T any](items []T, drop func(T) bool) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if !drop(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"plato-cli/internal/utils"
+)
+
+// VM is everything the TUI needs to pick a live VM back up after it exits or
+// crashes. Unlike a Resource, it is stored in ~/.plato/sessions.json.
+type VM struct {
+	PublicID   string `json:"public_id"`
+	JobID      string `json:"job_id,omitempty"`
+	JobGroupID string `json:"job_group_id"`
+	Alias      string `json:"alias,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Dataset    string `json:"dataset,omitempty"`
+	Service    string `json:"service,omitempty"`
+	ArtifactID string `json:"artifact_id,omitempty"`
+	Version    string `json:"version,omitempty"`
+	// Dir is the working directory the VM was launched from, where its
+	// plato-config.yml lives
+	Dir               string    `json:"dir,omitempty"`
+	SSHURL            string    `json:"ssh_url,omitempty"`
+	SSHHost           string    `json:"ssh_host,omitempty"`
+	SSHConfigPath     string    `json:"ssh_config_path,omitempty"`
+	SSHPrivateKeyPath string    `json:"ssh_private_key_path,omitempty"`
+	Tunnels           []Tunnel  `json:"tunnels,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Tunnel is a proxytunnel process forwarding a local port to the VM
+type Tunnel struct {
+	PID         int    `json:"pid"`
+	BindAddress string `json:"bind_address,omitempty"`
+	LocalPort   int    `json:"local_port"`
+	RemotePort  int    `json:"remote_port"`
+}
+
+// VMsPath returns the file live VMs are stored in
+func VMsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "sessions.json")
+}
+
+func vmsLockPath() string {
+	return VMsPath() + ".lock"
+}
+
+// SaveVM stores vm, replacing the entry with the same public ID. The original
+// CreatedAt is kept when vm does not set one.
+func SaveVM(vm VM) error {
+	return updateVMs(func(vms []VM) []VM {
+		for _, existing := range vms {
+			if existing.PublicID == vm.PublicID && vm.CreatedAt.IsZero() {
+				vm.CreatedAt = existing.CreatedAt
+			}
+		}
+		if vm.CreatedAt.IsZero() {
+			vm.CreatedAt = time.Now()
+		}
+		vms = without(vms, func(e VM) bool { return e.PublicID == vm.PublicID })
+		return append(vms, vm)
+	})
+}
+
+// RemoveVM removes the VM with the given public ID
+func RemoveVM(publicID string) error {
+	return updateVMs(func(vms []VM) []VM {
+		return without(vms, func(e VM) bool { return e.PublicID == publicID })
+	})
+}
+
+// ListVMs returns the stored VMs, most recently launched first
+func ListVMs() ([]VM, error) {
+	unlock, err := utils.LockFile(vmsLockPath())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	vms, err := readList[VM](VMsPath())
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(vms, func(i, j int) bool { return vms[i].CreatedAt.After(vms[j].CreatedAt) })
+	return vms, nil
+}
+
+func updateVMs(fn func([]VM) []VM) error {
+	unlock, err := utils.LockFile(vmsLockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	vms, err := readList[VM](VMsPath())
+	if err != nil {
+		return err
+	}
+	return writeList(VMsPath(), fn(vms))
+}
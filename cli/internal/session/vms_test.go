@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaveVM_ReplacesAndKeepsCreatedAt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	launched := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	if err := SaveVM(VM{PublicID: "vm-1", JobGroupID: "jg-1", CreatedAt: launched}); err != nil {
+		t.Fatalf("SaveVM: %v", err)
+	}
+	if err := SaveVM(VM{PublicID: "vm-1", JobGroupID: "jg-1", Tunnels: []Tunnel{{PID: 42, LocalPort: 8080, RemotePort: 80}}}); err != nil {
+		t.Fatalf("SaveVM: %v", err)
+	}
+
+	vms, err := ListVMs()
+	if err != nil {
+		t.Fatalf("ListVMs: %v", err)
+	}
+	if len(vms) != 1 {
+		t.Fatalf("got %d VMs, want 1", len(vms))
+	}
+	if !vms[0].CreatedAt.Equal(launched) {
+		t.Errorf("CreatedAt = %v, want %v", vms[0].CreatedAt, launched)
+	}
+	if len(vms[0].Tunnels) != 1 || vms[0].Tunnels[0].PID != 42 {
+		t.Errorf("Tunnels = %+v, want the saved tunnel", vms[0].Tunnels)
+	}
+}
+
+func TestListVMs_NewestFirstAndRemoveVM(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Now()
+	for _, vm := range []VM{
+		{PublicID: "old", CreatedAt: now.Add(-time.Hour)},
+		{PublicID: "new", CreatedAt: now},
+		{PublicID: "middle", CreatedAt: now.Add(-time.Minute)},
+	} {
+		if err := SaveVM(vm); err != nil {
+			t.Fatalf("SaveVM(%s): %v", vm.PublicID, err)
+		}
+	}
+	if err := RemoveVM("middle"); err != nil {
+		t.Fatalf("RemoveVM: %v", err)
+	}
+
+	vms, err := ListVMs()
+	if err != nil {
+		t.Fatalf("ListVMs: %v", err)
+	}
+	var ids []string
+	for _, vm := range vms {
+		ids = append(ids, vm.PublicID)
+	}
+	if len(ids) != 2 || ids[0] != "new" || ids[1] != "old" {
+		t.Errorf("ListVMs = %v, want [new old]", ids)
+	}
+}
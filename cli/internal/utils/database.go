@@ -7,10 +7,13 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	plato "plato-sdk"
@@ -25,6 +28,19 @@ type DBConfig struct {
 	Password  string   `json:"password"`
 	DestPort  int      `json:"dest_port"`
 	Databases []string `json:"databases"`
+	// CleanupTables lists tables emptied along with audit_log before a snapshot
+	CleanupTables []string `json:"cleanup_tables,omitempty"`
+	// CleanupSQL holds optional statements run against each database after audit_log is cleared
+	CleanupSQL []string `json:"cleanup_sql,omitempty"`
+	// PasswordEnv names an environment variable holding the password; it is
+	// read at cleanup time and wins over Password
+	PasswordEnv string `json:"password_env,omitempty"`
+}
+
+// ResolvePassword returns the value of PasswordEnv when it is set in the
+// environment, otherwise Password
+func (c DBConfig) ResolvePassword() (string, error) {
+	return toSDKDBConfig(c).ResolvePassword()
 }
 
 // SimDBConfigs contains preset database configurations for known simulators
@@ -61,22 +77,69 @@ func LoadCustomDBConfigs() map[string]DBConfig {
 	return customConfigs
 }
 
-// SaveCustomDBConfig saves a new custom DB config to file
-func SaveCustomDBConfig(service string, config DBConfig) error {
-	customConfigs := LoadCustomDBConfigs()
-	customConfigs[service] = config
+// customDBConfigLockPath guards the read-modify-write of the custom DB configs
+func customDBConfigLockPath() string {
+	return GetCustomDBConfigPath() + ".lock"
+}
 
-	configDir := filepath.Dir(GetCustomDBConfigPath())
+// SaveCustomDBConfig saves a new custom DB config to file. Saves from
+// concurrent plato processes are serialized by a lock, and the file is
+// replaced by a rename, so a reader never sees it half written.
+func SaveCustomDBConfig(service string, config DBConfig) error {
+	path := GetCustomDBConfigPath()
+	configDir := filepath.Dir(path)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(customConfigs, "", "  ")
+	unlock, err := LockFile(customDBConfigLockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Read again under the lock so an entry saved meanwhile is kept. A file
+	// that no longer parses is left for the user to fix rather than replaced.
+	customConfigs := make(map[string]DBConfig)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &customConfigs); err != nil {
+			return fmt.Errorf("%s is not valid JSON, fix or remove it before saving: %w", path, err)
+		}
+	}
+	customConfigs[service] = config
+
+	data, err = json.MarshalIndent(customConfigs, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal configs: %w", err)
 	}
 
-	if err := os.WriteFile(GetCustomDBConfigPath(), data, 0644); err != nil {
+	tmp, err := os.CreateTemp(configDir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := validateCustomDBConfigs(tmp.Name(), service); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -84,6 +147,23 @@ func SaveCustomDBConfig(service string, config DBConfig) error {
 	return nil
 }
 
+// validateCustomDBConfigs checks that the configs written to path read back
+// as JSON and include service
+func validateCustomDBConfigs(path, service string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read back config file: %w", err)
+	}
+	var written map[string]DBConfig
+	if err := json.Unmarshal(data, &written); err != nil {
+		return fmt.Errorf("written config file is not valid JSON: %w", err)
+	}
+	if _, ok := written[service]; !ok {
+		return fmt.Errorf("written config file is missing the config for %s", service)
+	}
+	return nil
+}
+
 // GetDBConfigFromPlatoConfig extracts DB config from plato-config.yml for a specific dataset
 func GetDBConfigFromPlatoConfig(dataset string) (DBConfig, bool) {
 	platoConfig, err := config.LoadPlatoConfig()
@@ -111,8 +191,10 @@ func GetDBConfigFromPlatoConfig(dataset string) (DBConfig, bool) {
 		dbConfig.DBType = listener.DbType
 		dbConfig.User = listener.DbUser
 		dbConfig.Password = listener.DbPassword
+		dbConfig.PasswordEnv = listener.DbPasswordEnv
 		dbConfig.DestPort = int(listener.DbPort)
 		dbConfig.Databases = []string{listener.DbDatabase}
+		dbConfig.CleanupTables = listener.CleanupTables
 
 		LogDebug("Found DB config in plato-config.yml for dataset '%s': type=%s, port=%d", dataset, dbConfig.DBType, dbConfig.DestPort)
 		return dbConfig, true
@@ -123,12 +205,13 @@ func GetDBConfigFromPlatoConfig(dataset string) (DBConfig, bool) {
 }
 
 // GetDBConfig gets DB config for a service, checking in this order:
-// 1. plato-config.yml for the current dataset
+// 1. plato-config.yml for the default dataset (default_dataset, else "base")
 // 2. Custom configs from ~/.plato/custom_db_configs.json
 // 3. Preset configs from SimDBConfigs
 func GetDBConfig(service string) (DBConfig, bool) {
-	// Try to get from plato-config.yml first (check for "base" dataset by default)
-	if config, ok := GetDBConfigFromPlatoConfig("base"); ok {
+	// Try to get from plato-config.yml first, using the default dataset
+	dataset := config.DefaultDataset()
+	if config, ok := GetDBConfigFromPlatoConfig(dataset); ok {
 		LogDebug("Using DB config from plato-config.yml for service: %s", service)
 		return config, true
 	}
@@ -176,6 +259,20 @@ func OpenTemporaryProxytunnel(baseURL, publicID string, remotePort int) (*exec.C
 	return cmd, localPort, nil
 }
 
+// NormalizePublicID trims and validates a user-supplied public ID, extracting it from a pasted sandbox URL
+func NormalizePublicID(input string) (string, error) {
+	return sdkutils.NormalizePublicID(input)
+}
+
+// ResolvePublicID is NormalizePublicID for callers with a client: a pasted
+// sandbox URL, which names an aliased sandbox by its alias, is resolved to
+// the sandbox's public ID
+func ResolvePublicID(client *plato.PlatoClient, input string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return client.Sandbox.ResolvePublicID(ctx, input)
+}
+
 // CloseTemporaryProxytunnel closes a temporary proxytunnel
 func CloseTemporaryProxytunnel(cmd *exec.Cmd) {
 	if cmd != nil && cmd.Process != nil {
@@ -184,29 +281,70 @@ func CloseTemporaryProxytunnel(cmd *exec.Cmd) {
 	}
 }
 
-// ClearAuditLog connects to the database and clears the audit_log table
-func ClearAuditLog(dbConfig DBConfig, localPort int) error {
+// AuditLogResult reports whether audit_log was cleared in one database
+type AuditLogResult = sdkutils.AuditLogResult
+
+// NeedsProxytunnel reports whether databases of dbType are reached through a
+// proxytunnel; SQLite files are opened directly
+func NeedsProxytunnel(dbType string) bool {
+	return sdkutils.NeedsProxytunnel(dbType)
+}
+
+// ClearAuditLog connects to the database and clears the audit_log table and
+// any CleanupTables, returning what was cleared in each database
+func ClearAuditLog(dbConfig DBConfig, localPort int) ([]AuditLogResult, error) {
 	LogDebug("Clearing audit_log from %s database on localhost:%d", dbConfig.DBType, localPort)
 
 	// Convert CLI DBConfig to SDK DBConfig
-	sdkDBConfig := sdkutils.DBConfig{
-		DBType:    dbConfig.DBType,
-		User:      dbConfig.User,
-		Password:  dbConfig.Password,
-		DestPort:  dbConfig.DestPort,
-		Databases: dbConfig.Databases,
-	}
+	sdkDBConfig := toSDKDBConfig(dbConfig)
 
-	err := sdkutils.ClearAuditLog(sdkDBConfig, localPort)
+	results, err := sdkutils.ClearAuditLogResults(sdkDBConfig, localPort)
+	for _, r := range results {
+		if !r.Cleared {
+			LogDebug("audit_log not cleared in %s: %v", r.Database, r.Err)
+		}
+	}
 	if err != nil {
 		LogDebug("Warning: failed to clear audit_log: %v", err)
-		return err
+		return results, err
 	}
 
 	LogDebug("Successfully cleared audit_log from database(s)")
+	return results, nil
+}
+
+// RunCleanupSQL runs the config's custom cleanup statements, logging any that
+// fail. The failures are also returned for the cleanup report.
+func RunCleanupSQL(dbConfig DBConfig, localPort int) error {
+	if len(dbConfig.CleanupSQL) == 0 {
+		return nil
+	}
+
+	LogDebug("Running %d cleanup SQL statement(s)", len(dbConfig.CleanupSQL))
+	if err := sdkutils.RunCleanupSQL(toSDKDBConfig(dbConfig), localPort); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			LogDebug("Warning: cleanup SQL failed: %s", line)
+		}
+		return err
+	}
+	LogDebug("Cleanup SQL completed successfully")
 	return nil
 }
 
+// toSDKDBConfig converts a CLI DBConfig to the SDK equivalent
+func toSDKDBConfig(dbConfig DBConfig) sdkutils.DBConfig {
+	return sdkutils.DBConfig{
+		DBType:        dbConfig.DBType,
+		User:          dbConfig.User,
+		Password:      dbConfig.Password,
+		DestPort:      dbConfig.DestPort,
+		Databases:     dbConfig.Databases,
+		CleanupTables: dbConfig.CleanupTables,
+		CleanupSQL:    dbConfig.CleanupSQL,
+		PasswordEnv:   dbConfig.PasswordEnv,
+	}
+}
+
 // ClearEnvState calls the /env/{job_group_id}/state endpoint to clear cache
 func ClearEnvState(client *plato.PlatoClient, jobGroupID string) error {
 	LogDebug("Clearing env state for job group: %s", jobGroupID)
@@ -233,6 +371,177 @@ func ClearEnvState(client *plato.PlatoClient, jobGroupID string) error {
 	return nil
 }
 
+// DBUnreachableError reports that cleanup could not connect to any database
+type DBUnreachableError = sdkutils.DBUnreachableError
+
+// commonDBPorts are probed when a database does not answer on its DestPort,
+// to catch VM images that run it on a non-default port
+var commonDBPorts = map[string][]int{
+	"postgresql": {5432, 5433},
+	"mysql":      {3306, 3307},
+	"mssql":      {1433},
+}
+
+// dbProbeTimeout bounds each probe of an alternative port
+const dbProbeTimeout = 3 * time.Second
+
+// CleanupReport describes what CleanState cleared. Audit log and cleanup SQL
+// failures are recorded rather than returned, since the rest of the cleanup
+// still runs.
+type CleanupReport struct {
+	DBType        string
+	Databases     []string
+	AuditLogErr   error            // set if clearing audit_log failed
+	AuditLog      []AuditLogResult // per database, whether audit_log was cleared
+	CleanupSQL    int              // number of custom cleanup statements run per database
+	CleanupSQLErr error            // set if any cleanup statement failed
+	DatabaseDone  bool             // the proxytunnel opened and the database steps ran
+	EnvStateDone  bool             // the env state cache was cleared
+	// Unreachable is set when no database answered on DestPort or any of
+	// the probed ports, so nothing in the database was cleaned
+	Unreachable *DBUnreachableError
+	ProbedPorts []int // ports tried after DestPort did not answer
+	FoundPort   int   // the probed port the database answered on, if any
+}
+
+// Lines renders the report for the CLI and the TUI status panel
+func (r CleanupReport) Lines() []string {
+	var lines []string
+	databases := strings.Join(r.Databases, ", ")
+	switch {
+	case !r.DatabaseDone:
+	case r.Unreachable != nil:
+		msg := fmt.Sprintf("⚠️  Database cleanup skipped: could not connect to %s on port %d", r.DBType, r.Unreachable.Port)
+		if len(r.ProbedPorts) > 0 {
+			msg += fmt.Sprintf(" (also tried %s)", joinPorts(r.ProbedPorts))
+		}
+		lines = append(lines, msg+", audit_log was not cleared")
+	default:
+		if r.FoundPort != 0 {
+			lines = append(lines, fmt.Sprintf("⚠️  %s answered on port %d, not dest_port; update dest_port in the DB config", r.DBType, r.FoundPort))
+		}
+		switch {
+		case len(r.AuditLog) > 0 && r.AuditLogErr == nil:
+			// Name the databases that were cleared and why the others were not
+			var cleared []string
+			for _, result := range r.AuditLog {
+				if result.Cleared {
+					cleared = append(cleared, result.Database)
+				}
+			}
+			lines = append(lines, fmt.Sprintf("✓ Cleared audit_log in %s (%s)", strings.Join(cleared, ", "), r.DBType))
+			for _, result := range r.AuditLog {
+				if !result.Cleared {
+					lines = append(lines, fmt.Sprintf("⚠️  audit_log not cleared in %s: %v", result.Database, result.Err))
+				}
+			}
+		case r.AuditLogErr != nil:
+			lines = append(lines, fmt.Sprintf("⚠️  audit_log not cleared in %s (%s): %v", databases, r.DBType, r.AuditLogErr))
+		default:
+			lines = append(lines, fmt.Sprintf("✓ Cleared audit_log in %s (%s)", databases, r.DBType))
+		}
+		for _, result := range r.AuditLog {
+			if len(result.Tables) > 0 {
+				lines = append(lines, fmt.Sprintf("✓ Cleared %s in %s", strings.Join(result.Tables, ", "), result.Database))
+			}
+		}
+		if r.CleanupSQL > 0 {
+			if r.CleanupSQLErr != nil {
+				lines = append(lines, fmt.Sprintf("⚠️  Cleanup SQL failed: %v", r.CleanupSQLErr))
+			} else {
+				lines = append(lines, fmt.Sprintf("✓ Ran %d cleanup SQL statement(s)", r.CleanupSQL))
+			}
+		}
+	}
+	if r.EnvStateDone {
+		lines = append(lines, "✓ Cleared env state cache")
+	}
+	return lines
+}
+
+// joinPorts formats ports as "5433, 3306"
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CleanState clears the audit log, runs the cleanup SQL and clears the env
+// state cache of a VM. It is the cleanup that runs before every snapshot and
+// can also be run on its own to reset state during development.
+//
+// If no database answers on DestPort, the usual ports for the DB type are
+// probed and the cleanup runs against the first one that answers.
+func CleanState(client *plato.PlatoClient, publicID, jobGroupID string, dbConfig DBConfig) (CleanupReport, error) {
+	report := CleanupReport{DBType: dbConfig.DBType, Databases: dbConfig.Databases, CleanupSQL: len(dbConfig.CleanupSQL)}
+
+	// SQLite files are opened directly, so they need no tunnel
+	var tunnelCmd *exec.Cmd
+	localPort := 0
+	if NeedsProxytunnel(dbConfig.DBType) {
+		var err error
+		tunnelCmd, localPort, err = OpenTemporaryProxytunnel(client.GetBaseURL(), publicID, dbConfig.DestPort)
+		if err != nil {
+			return report, fmt.Errorf("failed to open proxytunnel: %w", err)
+		}
+	}
+	defer func() { CloseTemporaryProxytunnel(tunnelCmd) }()
+
+	auditLog, auditErr := ClearAuditLog(dbConfig, localPort)
+	var unreachable *DBUnreachableError
+	if errors.As(auditErr, &unreachable) {
+		for _, port := range commonDBPorts[dbConfig.DBType] {
+			if port == dbConfig.DestPort {
+				continue
+			}
+			report.ProbedPorts = append(report.ProbedPorts, port)
+			probeCmd, probePort, err := OpenTemporaryProxytunnel(client.GetBaseURL(), publicID, port)
+			if err != nil {
+				continue
+			}
+			if sdkutils.ProbeDatabase(toSDKDBConfig(dbConfig), probePort, dbProbeTimeout) != nil {
+				CloseTemporaryProxytunnel(probeCmd)
+				continue
+			}
+			LogDebug("Warning: %s answered on port %d instead of dest_port %d", dbConfig.DBType, port, dbConfig.DestPort)
+			CloseTemporaryProxytunnel(tunnelCmd)
+			tunnelCmd, localPort = probeCmd, probePort
+			dbConfig.DestPort = port
+			report.FoundPort = port
+			auditLog, auditErr = ClearAuditLog(dbConfig, localPort)
+			break
+		}
+	}
+
+	if errors.As(auditErr, &unreachable) {
+		LogDebug("Warning: database cleanup skipped: %v", auditErr)
+		report.Unreachable = unreachable
+	} else {
+		report.AuditLogErr = auditErr
+		report.AuditLog = auditLog
+		report.CleanupSQLErr = RunCleanupSQL(dbConfig, localPort)
+	}
+	report.DatabaseDone = true
+
+	if err := ClearEnvState(client, jobGroupID); err != nil {
+		return report, fmt.Errorf("failed to clear env state: %w", err)
+	}
+	report.EnvStateDone = true
+	return report, nil
+}
+
+// unreachableCleanupError turns a cleanup that could not reach the database
+// into an error for the snapshot flows, which would otherwise snapshot stale
+// data without saying so. It wraps the *DBUnreachableError.
+func unreachableCleanupError(report CleanupReport) error {
+	if len(report.ProbedPorts) == 0 {
+		return fmt.Errorf("database cleanup skipped: %w", report.Unreachable)
+	}
+	return fmt.Errorf("database cleanup skipped (also tried port %s): %w", joinPorts(report.ProbedPorts), report.Unreachable)
+}
+
 // PreSnapshotCleanup performs database cleanup and cache clearing before snapshot
 // Returns (needsDBConfig, error) - needsDBConfig=true means manual entry is required
 func PreSnapshotCleanup(client *plato.PlatoClient, publicID, jobGroupID, service, dataset string) (bool, error) {
@@ -245,18 +554,12 @@ func PreSnapshotCleanup(client *plato.PlatoClient, publicID, jobGroupID, service
 		return true, nil
 	}
 
-	tunnelCmd, localPort, err := OpenTemporaryProxytunnel(client.GetBaseURL(), publicID, dbConfig.DestPort)
+	report, err := CleanState(client, publicID, jobGroupID, dbConfig)
 	if err != nil {
-		return false, fmt.Errorf("failed to open proxytunnel: %w", err)
+		return false, err
 	}
-	defer CloseTemporaryProxytunnel(tunnelCmd)
-
-	if err := ClearAuditLog(dbConfig, localPort); err != nil {
-		LogDebug("Warning: failed to clear audit_log: %v", err)
-	}
-
-	if err := ClearEnvState(client, jobGroupID); err != nil {
-		return false, fmt.Errorf("failed to clear env state: %w", err)
+	if report.Unreachable != nil {
+		return false, unreachableCleanupError(report)
 	}
 
 	LogDebug("Pre-snapshot cleanup completed successfully")
@@ -267,18 +570,12 @@ func PreSnapshotCleanup(client *plato.PlatoClient, publicID, jobGroupID, service
 func PreSnapshotCleanupWithConfig(client *plato.PlatoClient, publicID, jobGroupID string, dbConfig DBConfig) error {
 	LogDebug("Starting pre-snapshot cleanup with provided config")
 
-	tunnelCmd, localPort, err := OpenTemporaryProxytunnel(client.GetBaseURL(), publicID, dbConfig.DestPort)
+	report, err := CleanState(client, publicID, jobGroupID, dbConfig)
 	if err != nil {
-		return fmt.Errorf("failed to open proxytunnel: %w", err)
-	}
-	defer CloseTemporaryProxytunnel(tunnelCmd)
-
-	if err := ClearAuditLog(dbConfig, localPort); err != nil {
-		LogDebug("Warning: failed to clear audit_log: %v", err)
+		return err
 	}
-
-	if err := ClearEnvState(client, jobGroupID); err != nil {
-		return fmt.Errorf("failed to clear env state: %w", err)
+	if report.Unreachable != nil {
+		return unreachableCleanupError(report)
 	}
 
 	LogDebug("Pre-snapshot cleanup completed successfully")
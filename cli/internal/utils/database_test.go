@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"plato-cli/internal/config"
+)
+
+// inConfigDir runs the test from a temp directory containing plato-config.yml
+// (skipped when contents is empty) with HOME pointed at an empty directory
+func inConfigDir(t *testing.T, contents string) {
+	t.Helper()
+	dir := t.TempDir()
+	if contents != "" {
+		if err := os.WriteFile(filepath.Join(dir, "plato-config.yml"), []byte(contents), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	t.Setenv("HOME", t.TempDir())
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get cwd: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+const twoDatasetConfig = `service: app
+%s
+datasets:
+  base:
+    listeners:
+      db:
+        type: db
+        db_type: postgresql
+        db_port: 5432
+  seeded:
+    listeners:
+      db:
+        type: db
+        db_type: mysql
+        db_port: 3306
+  empty:
+    compute:
+      cpus: 1
+`
+
+// TestDefaultDatasetHonoredByDBConfig covers each place a dataset defaults:
+// the --dataset flags and the env launcher (DefaultDataset), the snapshot
+// dataset, the VM config model and its form (DatasetOrDefault), and the DB
+// config lookups used for pre-snapshot cleanup
+func TestDefaultDatasetHonoredByDBConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantDataset string
+		wantPort    int
+	}{
+		{"no config", "", "base", 0},
+		{"unset falls back to base", fmt.Sprintf(twoDatasetConfig, ""), "base", 5432},
+		{"configured default", fmt.Sprintf(twoDatasetConfig, "default_dataset: seeded"), "seeded", 3306},
+		{"default without a DB listener", fmt.Sprintf(twoDatasetConfig, "default_dataset: empty"), "empty", 0},
+		{"unparseable config", "datasets: [", "base", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inConfigDir(t, tt.config)
+
+			if got := config.DefaultDataset(); got != tt.wantDataset {
+				t.Errorf("DefaultDataset() = %q, want %q", got, tt.wantDataset)
+			}
+			for _, given := range []string{"", "  "} {
+				if got := config.DatasetOrDefault(given); got != tt.wantDataset {
+					t.Errorf("DatasetOrDefault(%q) = %q, want %q", given, got, tt.wantDataset)
+				}
+			}
+			if got := config.DatasetOrDefault("other"); got != "other" {
+				t.Errorf("DatasetOrDefault(\"other\") = %q, want the given dataset", got)
+			}
+
+			if dbConfig, ok := GetDBConfigFromPlatoConfig(config.DefaultDataset()); ok != (tt.wantPort != 0) || dbConfig.DestPort != tt.wantPort {
+				t.Errorf("GetDBConfigFromPlatoConfig(default) = %+v, %t; want port %d", dbConfig, ok, tt.wantPort)
+			}
+
+			dbConfig, ok := GetDBConfig("unknown-service")
+			if tt.wantPort == 0 {
+				if ok {
+					t.Errorf("expected no DB config, got %+v", dbConfig)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected DB config for dataset %q", tt.wantDataset)
+			}
+			if dbConfig.DestPort != tt.wantPort {
+				t.Errorf("DestPort = %d, want %d (from dataset %q)", dbConfig.DestPort, tt.wantPort, tt.wantDataset)
+			}
+		})
+	}
+}
+
+func TestDBConfigFromPlatoConfigPasswordEnv(t *testing.T) {
+	inConfigDir(t, `service: app
+datasets:
+  base:
+    listeners:
+      db:
+        type: db
+        db_type: postgresql
+        db_port: 5432
+        db_password: fallback
+        db_password_env: APP_DB_PASSWORD
+`)
+
+	dbConfig, ok := GetDBConfigFromPlatoConfig("base")
+	if !ok {
+		t.Fatal("expected DB config for dataset base")
+	}
+	if dbConfig.PasswordEnv != "APP_DB_PASSWORD" {
+		t.Errorf("PasswordEnv = %q, want APP_DB_PASSWORD", dbConfig.PasswordEnv)
+	}
+	t.Setenv("APP_DB_PASSWORD", "from-env")
+	if password, err := dbConfig.ResolvePassword(); err != nil || password != "from-env" {
+		t.Errorf("ResolvePassword() = %q, %v; want the value of APP_DB_PASSWORD", password, err)
+	}
+}
+
+func TestCleanupReportLinesUnreachableDatabase(t *testing.T) {
+	report := CleanupReport{
+		DBType:       "postgresql",
+		Databases:    []string{"postgres", "app"},
+		DatabaseDone: true,
+		EnvStateDone: true,
+		Unreachable:  &DBUnreachableError{DBType: "postgresql", Port: 5432, Err: fmt.Errorf("connection refused")},
+		ProbedPorts:  []int{5433},
+	}
+	lines := report.Lines()
+	want := []string{
+		"⚠️  Database cleanup skipped: could not connect to postgresql on port 5432 (also tried 5433), audit_log was not cleared",
+		"✓ Cleared env state cache",
+	}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("Lines() = %q, want %q", lines, want)
+	}
+
+	// A missing audit_log table is reported as such, not as unreachable
+	report = CleanupReport{DBType: "mysql", Databases: []string{"app"}, DatabaseDone: true, AuditLogErr: fmt.Errorf("could not find or clear audit_log table in any database")}
+	if lines := report.Lines(); len(lines) != 1 || lines[0] != "⚠️  audit_log not cleared in app (mysql): could not find or clear audit_log table in any database" {
+		t.Errorf("Lines() = %q", lines)
+	}
+}
+
+func TestCleanupReportLinesPerDatabase(t *testing.T) {
+	report := CleanupReport{
+		DBType:       "sqlite",
+		Databases:    []string{"/data/app.db", "/data/cache.db"},
+		DatabaseDone: true,
+		AuditLog: []AuditLogResult{
+			{Database: "/data/app.db", Cleared: true, Tables: []string{"sessions", "jobs"}},
+			{Database: "/data/cache.db", Err: fmt.Errorf("no such table: audit_log")},
+		},
+	}
+	want := []string{
+		"✓ Cleared audit_log in /data/app.db (sqlite)",
+		"⚠️  audit_log not cleared in /data/cache.db: no such table: audit_log",
+		"✓ Cleared sessions, jobs in /data/app.db",
+	}
+	if lines := report.Lines(); fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("Lines() = %q, want %q", lines, want)
+	}
+}
+
+func TestSaveCustomDBConfigConcurrentSaves(t *testing.T) {
+	inConfigDir(t, "")
+
+	const saves = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, saves)
+	for i := 0; i < saves; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- SaveCustomDBConfig(fmt.Sprintf("service-%d", i), DBConfig{DBType: "postgresql", DestPort: 5432, Databases: []string{"app"}})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SaveCustomDBConfig failed: %v", err)
+		}
+	}
+
+	configs := LoadCustomDBConfigs()
+	if len(configs) != saves {
+		t.Fatalf("expected %d saved configs, got %d", saves, len(configs))
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(GetCustomDBConfigPath()), "*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("expected no temp files to be left behind, got %v", leftovers)
+	}
+}
+
+func TestSaveCustomDBConfigKeepsInvalidFile(t *testing.T) {
+	inConfigDir(t, "")
+	path := GetCustomDBConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("failed to write configs: %v", err)
+	}
+
+	if err := SaveCustomDBConfig("app", DBConfig{DBType: "mysql"}); err == nil {
+		t.Fatal("expected saving over an invalid file to fail")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "{not json" {
+		t.Errorf("expected the invalid file to be left untouched, got %q", data)
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"os/exec"
+
+	sdkutils "plato-sdk/utils"
+)
+
+// DefaultGitTimeout bounds each hub clone or push whose caller set no deadline
+const DefaultGitTimeout = sdkutils.DefaultGitTimeout
+
+// WithGitTimeout returns ctx with DefaultGitTimeout applied, unless ctx
+// already has a deadline of its own
+func WithGitTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return sdkutils.WithGitTimeout(ctx)
+}
+
+// GitCommand returns a command running git with args that is killed when ctx
+// is done
+func GitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return sdkutils.GitCommand(ctx, args...)
+}
+
+// RunRemoteGit runs a git command that talks to a remote in dir with env,
+// giving it DefaultGitTimeout of its own unless ctx has a deadline, and
+// returns its combined output
+func RunRemoteGit(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	return sdkutils.RunRemoteGit(ctx, dir, env, args...)
+}
+
+// ErrBranchMoved reports that a lease push was refused because someone else
+// updated the target branch
+var ErrBranchMoved = sdkutils.ErrBranchMoved
+
+// RemoteBranchTip returns the commit branch points to on remote, or "" when
+// remote has no such branch
+func RemoteBranchTip(ctx context.Context, remote string, env []string, branch string) (string, error) {
+	return sdkutils.RemoteBranchTip(ctx, remote, env, branch)
+}
+
+// LeasePushArgs returns the git arguments that reset branch on origin to HEAD
+// only if it still points to tip; an empty tip means it must not exist yet
+func LeasePushArgs(branch, tip string) []string {
+	return sdkutils.LeasePushArgs(branch, tip)
+}
+
+// LeaseError wraps err with ErrBranchMoved when output shows the lease
+// refused the push
+func LeaseError(err error, output string) error {
+	return sdkutils.LeaseError(err, output)
+}
+
+// GitInterrupted adds ctx's error to err when ctx ended while git ran
+func GitInterrupted(ctx context.Context, err error) error {
+	return sdkutils.GitInterrupted(ctx, err)
+}
+
+// CopyProgress reports how far CopyFilesRespectingGitignore has got
+type CopyProgress = sdkutils.CopyProgress
+
+// CopyFilesRespectingGitignore copies the files of src that git does not
+// ignore to dst, calling progress, if not nil, after each file
+func CopyFilesRespectingGitignore(src, dst string, progress func(CopyProgress)) error {
+	return sdkutils.CopyFilesRespectingGitignore(src, dst, progress)
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	sdkutils "plato-sdk/utils"
+)
+
+// LockFile takes an exclusive lock on path, creating it if needed. It blocks
+// until the lock is available and returns a function that releases it.
+func LockFile(path string) (func(), error) {
+	return sdkutils.LockFile(path)
+}
@@ -9,6 +9,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+
+	sdkutils "plato-sdk/utils"
 )
 
 var debugLogger *log.Logger
@@ -28,6 +30,7 @@ func InitLogger() error {
 
 	debugLogger = log.New(file, "", log.LstdFlags|log.Lshortfile)
 	debugLogger.Printf("=== Plato CLI Started ===")
+	sdkutils.DebugLogf = LogDebug
 	return nil
 }
 
@@ -51,6 +51,41 @@ func IsPortAvailable(port int) bool {
 	return true
 }
 
+// DefaultBindAddress is the address local tunnel ports listen on unless configured otherwise
+const DefaultBindAddress = "127.0.0.1"
+
+// FindFreePortOn tries the preferred port on bindAddress and falls back to any free port there.
+// bindAddress may be an IPv4 or IPv6 literal (e.g. "0.0.0.0", "::1").
+func FindFreePortOn(bindAddress string, preferred int) (int, error) {
+	if l, err := net.Listen("tcp", net.JoinHostPort(bindAddress, fmt.Sprintf("%d", preferred))); err == nil {
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+		return port, nil
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort(bindAddress, "0"))
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// IsPortAvailableOn checks if a port is free on bindAddress
+func IsPortAvailableOn(bindAddress string, port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort(bindAddress, fmt.Sprintf("%d", port)))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// ListenAddress formats bindAddress and port for proxytunnel's -a flag
+func ListenAddress(bindAddress string, port int) string {
+	return net.JoinHostPort(bindAddress, fmt.Sprintf("%d", port))
+}
+
 // ProxyConfig holds the proxy server configuration
 type ProxyConfig struct {
 	Server string // e.g., "proxy.plato.so:9000", "staging.proxy.plato.so:9000", or "proxy.localhost:9000"
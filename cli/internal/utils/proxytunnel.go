@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 // FindProxytunnelPath finds the proxytunnel binary, preferring bundled binary over system installation
@@ -103,3 +105,150 @@ func isExecutable(path string) error {
 	}
 	return nil
 }
+
+// ResolveTunnelLocalPort picks the local port for a tunnel on bindAddress.
+// With strict set, the preferred port must be free or an error is returned;
+// otherwise any free port is used when the preferred one is taken.
+func ResolveTunnelLocalPort(bindAddress string, preferred int, strict bool) (int, error) {
+	if !strict {
+		return FindFreePortOn(bindAddress, preferred)
+	}
+	if !IsPortAvailableOn(bindAddress, preferred) {
+		return 0, fmt.Errorf("local port %d is already in use on %s", preferred, bindAddress)
+	}
+	return preferred, nil
+}
+
+// BuildProxytunnelArgs returns proxytunnel arguments that forward localPort on
+// bindAddress to remotePort on the VM identified by publicID
+func BuildProxytunnelArgs(baseURL, publicID string, remotePort int, bindAddress string, localPort int) []string {
+	proxyConfig := GetProxyConfig(baseURL)
+
+	args := []string{}
+	if proxyConfig.Secure {
+		args = append(args, "-E")
+	}
+	return append(args,
+		"-p", proxyConfig.Server,
+		"-P", fmt.Sprintf("%s@%d:newpass", publicID, remotePort),
+		"-d", fmt.Sprintf("127.0.0.1:%d", remotePort),
+		"-a", ListenAddress(bindAddress, localPort),
+		"-v",
+		"--no-check-certificate",
+	)
+}
+
+// platoProxyServers are the proxies GetProxyConfig points tunnels at
+var platoProxyServers = []string{"proxy.plato.so:9000", "staging.proxy.plato.so:9000", "proxy.localhost:9000"}
+
+// processReapers adopt processes whose parent exited, so a proxytunnel whose
+// parent is one of them has outlived the CLI that started it
+var processReapers = map[string]bool{"init": true, "systemd": true, "launchd": true}
+
+// ProxytunnelProcess is a running proxytunnel that forwards to a Plato sandbox
+type ProxytunnelProcess struct {
+	PID        int
+	ParentPID  int
+	PublicID   string
+	RemotePort int
+	// Listen is the local address it forwards, empty for an SSH ProxyCommand
+	Listen string
+	// Orphaned is set when the process that started it has exited
+	Orphaned bool
+}
+
+// FindPlatoProxytunnels lists the running proxytunnel processes that go
+// through a Plato proxy, including the one baseURL uses. Other proxytunnel
+// usage on the machine is left out.
+func FindPlatoProxytunnels(baseURL string) ([]ProxytunnelProcess, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("listing proxytunnel processes is not supported on windows")
+	}
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,ppid=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	servers := append([]string{GetProxyConfig(baseURL).Server}, platoProxyServers...)
+	return parsePlatoProxytunnels(string(out), servers), nil
+}
+
+// parsePlatoProxytunnels picks the Plato proxytunnels out of `ps -o
+// pid=,ppid=,args=` output
+func parsePlatoProxytunnels(psOutput string, servers []string) []ProxytunnelProcess {
+	type process struct {
+		pid, ppid int
+		args      []string
+	}
+	var processes []process
+	byPID := make(map[int]process)
+	for _, line := range strings.Split(psOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		p := process{pid: pid, ppid: ppid, args: fields[2:]}
+		processes = append(processes, p)
+		byPID[pid] = p
+	}
+
+	var tunnels []ProxytunnelProcess
+	for _, p := range processes {
+		tunnel, ok := parseProxytunnelArgs(p.args, servers)
+		if !ok {
+			continue
+		}
+		tunnel.PID, tunnel.ParentPID = p.pid, p.ppid
+		parent, alive := byPID[p.ppid]
+		tunnel.Orphaned = p.ppid <= 1 || !alive || processReapers[filepath.Base(parent.args[0])]
+		tunnels = append(tunnels, tunnel)
+	}
+	return tunnels
+}
+
+// parseProxytunnelArgs recognises the command line BuildProxytunnelArgs and
+// the SSH ProxyCommand produce: proxytunnel through one of servers,
+// authenticating as <publicID>@<port>:newpass
+func parseProxytunnelArgs(args []string, servers []string) (ProxytunnelProcess, bool) {
+	var tunnel ProxytunnelProcess
+	if len(args) == 0 || !strings.HasPrefix(filepath.Base(args[0]), "proxytunnel") {
+		return tunnel, false
+	}
+	var server, auth string
+	for i := 1; i+1 < len(args); i++ {
+		switch args[i] {
+		case "-p", "--proxy":
+			server = args[i+1]
+		case "-P", "--proxyauth":
+			auth = args[i+1]
+		case "-a", "--standalone":
+			tunnel.Listen = args[i+1]
+		default:
+			continue
+		}
+		i++
+	}
+
+	known := false
+	for _, s := range servers {
+		known = known || (s != "" && server == s)
+	}
+	auth, ok := strings.CutSuffix(strings.Trim(auth, "'"), ":newpass")
+	if !known || !ok {
+		return tunnel, false
+	}
+	publicID, port, ok := strings.Cut(auth, "@")
+	if !ok || publicID == "" {
+		return tunnel, false
+	}
+	remotePort, err := strconv.Atoi(port)
+	if err != nil {
+		return tunnel, false
+	}
+	tunnel.PublicID, tunnel.RemotePort = publicID, remotePort
+	return tunnel, true
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "testing"
+
+func TestParsePlatoProxytunnels(t *testing.T) {
+	ps := `    1     0 /sbin/init
+  100     1 /usr/lib/systemd/systemd --user
+  200   100 -zsh
+  300   200 plato
+  301   300 /opt/plato/bin/proxytunnel-linux-amd64 -E -p proxy.plato.so:9000 -P abc123@8080:newpass -d 127.0.0.1:8080 -a 127.0.0.1:8080 -v --no-check-certificate
+  302     1 /usr/bin/proxytunnel -E -p proxy.plato.so:9000 -P abc123@5432:newpass -d 127.0.0.1:5432 -a 5432 -v --no-check-certificate
+  303   100 proxytunnel -E -p staging.proxy.plato.so:9000 -P def456@22:newpass -d 10.0.0.1:22 --no-check-certificate
+  304   999 proxytunnel -p proxy.localhost:9000 -P ghi789@3000:newpass -d 127.0.0.1:3000 -a 3000
+  305     1 proxytunnel -E -p corp-proxy.example.com:8080 -P user@22:secret -d host:22
+  306     1 proxytunnel -E -p proxy.plato.so:9000 -P abc123@22:secret -d host:22
+  307     1 vim proxytunnel.go
+`
+	got := parsePlatoProxytunnels(ps, platoProxyServers)
+	want := []ProxytunnelProcess{
+		{PID: 301, ParentPID: 300, PublicID: "abc123", RemotePort: 8080, Listen: "127.0.0.1:8080"},
+		{PID: 302, ParentPID: 1, PublicID: "abc123", RemotePort: 5432, Listen: "5432", Orphaned: true},
+		{PID: 303, ParentPID: 100, PublicID: "def456", RemotePort: 22, Orphaned: true},
+		{PID: 304, ParentPID: 999, PublicID: "ghi789", RemotePort: 3000, Listen: "3000", Orphaned: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tunnels, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tunnel %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
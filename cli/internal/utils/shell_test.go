@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"main", "main"},
+		{"feature/plato-123", "feature/plato-123"},
+		{"https://user:pw@hub.plato.so/org/repo.git", "https://user:pw@hub.plato.so/org/repo.git"},
+		{"has space", "'has space'"},
+		{"it's", `'it'\''s'`},
+		{`"double"`, `'"double"'`},
+		{"$(rm -rf /)", "'$(rm -rf /)'"},
+		{"a;b|c&d", "'a;b|c&d'"},
+	}
+
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestShellJoinRoundTrip(t *testing.T) {
+	args := []string{"plain", "with space", "it's", `"quoted"`, "$HOME", "`id`", "new\nline", ""}
+
+	// printf prints each argument followed by NUL so the shell's view of the words can be compared exactly
+	out, err := exec.Command("sh", "-c", "printf '%s\\0' "+ShellJoin(args...)).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+
+	var got []string
+	start := 0
+	for i, b := range out {
+		if b == 0 {
+			got = append(got, string(out[start:i]))
+			start = i + 1
+		}
+	}
+
+	if len(got) != len(args) {
+		t.Fatalf("expected %d words, got %d: %q", len(args), len(got), got)
+	}
+	for i := range args {
+		if got[i] != args[i] {
+			t.Errorf("word %d: expected %q, got %q", i, args[i], got[i])
+		}
+	}
+}
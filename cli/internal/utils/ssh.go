@@ -5,16 +5,14 @@
 package utils
 
 import (
-	"crypto/ed25519"
-	"crypto/rand"
-	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
-	"golang.org/x/crypto/ssh"
+	"plato-cli/internal/config"
+	sdkutils "plato-sdk/utils"
 )
 
 // ReadSSHPublicKey reads the user's SSH public key from ~/.ssh directory
@@ -47,6 +45,13 @@ func ReadSSHPublicKey() (string, error) {
 // GenerateSSHKeyPair generates a new ed25519 SSH key pair for a specific sandbox
 // Returns (publicKey, privateKeyPath, error)
 func GenerateSSHKeyPair(sandboxNum int) (string, string, error) {
+	return GenerateSSHKeyPairOfType(sandboxNum, sdkutils.DefaultSSHKeyType)
+}
+
+// GenerateSSHKeyPairOfType generates a new SSH key pair of keyType ("ed25519",
+// "rsa" or "ecdsa") for a specific sandbox
+// Returns (publicKey, privateKeyPath, error)
+func GenerateSSHKeyPairOfType(sandboxNum int, keyType string) (string, string, error) {
 	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
 	if err := os.MkdirAll(platoDir, 0700); err != nil {
 		return "", "", fmt.Errorf("failed to create .plato directory: %w", err)
@@ -54,53 +59,18 @@ func GenerateSSHKeyPair(sandboxNum int) (string, string, error) {
 
 	// Generate key pair in ~/.plato/ssh_{num}_key (private) and ssh_{num}_key.pub (public)
 	privateKeyPath := filepath.Join(platoDir, fmt.Sprintf("ssh_%d_key", sandboxNum))
-	publicKeyPath := privateKeyPath + ".pub"
-
-	// Remove existing keys if they exist
-	os.Remove(privateKeyPath)
-	os.Remove(publicKeyPath)
-
-	// Generate ed25519 key pair using native Go crypto
-	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
-	}
-
-	// Convert to SSH format
-	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	publicKey, err := sdkutils.GenerateSSHKeyPairOfTypeAt(privateKeyPath, fmt.Sprintf("plato-sandbox-%d", sandboxNum), keyType)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to convert public key: %w", err)
-	}
-
-	// Format public key in OpenSSH authorized_keys format
-	comment := fmt.Sprintf("plato-sandbox-%d", sandboxNum)
-	pubKeyBytes := ssh.MarshalAuthorizedKey(sshPublicKey)
-	// Add comment to public key (MarshalAuthorizedKey includes a newline)
-	pubKeyStr := strings.TrimSpace(string(pubKeyBytes)) + " " + comment + "\n"
-
-	// Write public key with 0644 permissions (standard for .pub files)
-	if err := os.WriteFile(publicKeyPath, []byte(pubKeyStr), 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write public key: %w", err)
-	}
-
-	// Marshal private key in OpenSSH format
-	privKeyPEM, err := ssh.MarshalPrivateKey(privateKey, comment)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to marshal private key: %w", err)
-	}
-
-	// Encode PEM block to bytes
-	privKeyBytes := pem.EncodeToMemory(privKeyPEM)
-	if privKeyBytes == nil {
-		return "", "", fmt.Errorf("failed to encode private key to PEM")
+		return "", "", err
 	}
 
-	// Write private key with 0600 permissions (required for SSH to accept it)
-	if err := os.WriteFile(privateKeyPath, privKeyBytes, 0600); err != nil {
-		return "", "", fmt.Errorf("failed to write private key: %w", err)
-	}
+	return publicKey, privateKeyPath, nil
+}
 
-	return strings.TrimSpace(pubKeyStr), privateKeyPath, nil
+// GenerateSSHKeyPairAt generates an ed25519 key pair at privateKeyPath (and privateKeyPath.pub)
+// Returns the public key in authorized_keys format
+func GenerateSSHKeyPairAt(privateKeyPath string, comment string) (string, error) {
+	return sdkutils.GenerateSSHKeyPairOfTypeAt(privateKeyPath, comment, sdkutils.DefaultSSHKeyType)
 }
 
 // GetSSHPrivateKeyPath returns the path to the SSH private key
@@ -193,9 +163,97 @@ func WriteSSHConfig(configContent string) error {
 	return os.WriteFile(sshConfigPath, []byte(content), 0600)
 }
 
+// SSHTimings are the connection settings written into generated SSH configs
+type SSHTimings struct {
+	ConnectTimeout      int
+	ServerAliveInterval int
+	ServerAliveCountMax int
+}
+
+// DefaultSSHTimings returns the settings used when plato-config.yml has no ssh section
+func DefaultSSHTimings() SSHTimings {
+	return SSHTimings{
+		ConnectTimeout:      sdkutils.DefaultSSHConnectTimeout,
+		ServerAliveInterval: sdkutils.DefaultSSHServerAliveInterval,
+		ServerAliveCountMax: sdkutils.DefaultSSHServerAliveCountMax,
+	}
+}
+
+// LoadSSHTimings reads the ssh section of plato-config.yml, keeping the
+// defaults for anything unset or when there is no config file
+func LoadSSHTimings() SSHTimings {
+	timings := DefaultSSHTimings()
+
+	platoConfig, err := config.LoadPlatoConfig()
+	if err != nil || platoConfig.SSH == nil {
+		return timings
+	}
+	if platoConfig.SSH.ConnectTimeout > 0 {
+		timings.ConnectTimeout = platoConfig.SSH.ConnectTimeout
+	}
+	if platoConfig.SSH.ServerAliveInterval > 0 {
+		timings.ServerAliveInterval = platoConfig.SSH.ServerAliveInterval
+	}
+	if platoConfig.SSH.ServerAliveCountMax > 0 {
+		timings.ServerAliveCountMax = platoConfig.SSH.ServerAliveCountMax
+	}
+	return timings
+}
+
 // CreateTempSSHConfig creates a temporary SSH config file for a specific host
 // Returns the path to the temporary config file
 func CreateTempSSHConfig(baseURL, hostname string, port int, jobGroupID string, username string, privateKeyPath string) (string, error) {
+	configContent, err := BuildSSHConfigBlock(baseURL, hostname, port, jobGroupID, username, privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+
+	// Create temp file in ~/.plato directory
+	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
+	if err := os.MkdirAll(platoDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create .plato directory: %w", err)
+	}
+
+	tempConfigPath := sandboxFilePath(hostname, "conf")
+	if err := os.WriteFile(tempConfigPath, []byte(configContent), 0600); err != nil {
+		return "", fmt.Errorf("failed to write temp SSH config: %w", err)
+	}
+
+	return tempConfigPath, nil
+}
+
+// sandboxFilePath returns ~/.plato/ssh_N.<ext> for hostname "sandbox-N"
+func sandboxFilePath(hostname, ext string) string {
+	numStr := strings.TrimPrefix(hostname, "sandbox-")
+	return filepath.Join(os.Getenv("HOME"), ".plato", fmt.Sprintf("ssh_%s.%s", numStr, ext))
+}
+
+// KnownHostsPath returns the per-VM known_hosts file that holds the pinned
+// host key when ssh.strict_host_key is enabled
+func KnownHostsPath(hostname string) string {
+	return sandboxFilePath(hostname, "known_hosts")
+}
+
+// StrictHostKeyEnabled reports whether plato-config.yml sets ssh.strict_host_key
+func StrictHostKeyEnabled() bool {
+	platoConfig, err := config.LoadPlatoConfig()
+	return err == nil && platoConfig.SSH != nil && platoConfig.SSH.StrictHostKey
+}
+
+// hostKeyOptions returns the host key settings for a sandbox Host block.
+// By default checks are skipped, since every VM presents a new key behind
+// the same proxy address; with ssh.strict_host_key the key is verified
+// against the VM's own known_hosts file, filled in by PinHostKey.
+func hostKeyOptions(hostname string) string {
+	if !StrictHostKeyEnabled() {
+		return "    StrictHostKeyChecking no\n    UserKnownHostsFile /dev/null\n"
+	}
+	return fmt.Sprintf("    StrictHostKeyChecking yes\n    UserKnownHostsFile %s\n    HostKeyAlias %s\n", KnownHostsPath(hostname), hostname)
+}
+
+// BuildSSHConfigBlock returns the SSH config Host block used to reach a sandbox through proxytunnel.
+// It is shared by CreateTempSSHConfig, AppendSSHHostEntry and `plato ssh-config` so the printed block matches what is written.
+func BuildSSHConfigBlock(baseURL, hostname string, port int, jobGroupID string, username string, privateKeyPath string) (string, error) {
 	// Find proxytunnel path (checks bundled binary first, then PATH)
 	proxytunnelPath, err := FindProxytunnelPath()
 	if err != nil {
@@ -212,6 +270,8 @@ func CreateTempSSHConfig(baseURL, hostname string, port int, jobGroupID string,
 	}
 	proxyCmd += fmt.Sprintf(" -p %s -P '%s@22:newpass' -d %%h:%%p --no-check-certificate", proxyConfig.Server, jobGroupID)
 
+	timings := LoadSSHTimings()
+
 	// Create temp config content
 	configContent := fmt.Sprintf(`Host %s
     HostName localhost
@@ -219,85 +279,49 @@ func CreateTempSSHConfig(baseURL, hostname string, port int, jobGroupID string,
     User %s
     IdentityFile %s
     IdentitiesOnly yes
-    StrictHostKeyChecking no
-    UserKnownHostsFile /dev/null
-    ConnectTimeout 10
+%s    ConnectTimeout %d
     ProxyCommand %s
-    ServerAliveInterval 30
-    ServerAliveCountMax 3
+    ServerAliveInterval %d
+    ServerAliveCountMax %d
     TCPKeepAlive yes
-`, hostname, port, username, privateKeyPath, proxyCmd)
-
-	// Create temp file in ~/.plato directory
-	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
-	if err := os.MkdirAll(platoDir, 0700); err != nil {
-		return "", fmt.Errorf("failed to create .plato directory: %w", err)
-	}
+`, hostname, port, username, privateKeyPath, hostKeyOptions(hostname), timings.ConnectTimeout, proxyCmd, timings.ServerAliveInterval, timings.ServerAliveCountMax)
 
-	// Extract number from hostname (e.g., "sandbox-1" -> "1")
-	// Use simple naming: ssh_N.conf
-	numStr := strings.TrimPrefix(hostname, "sandbox-")
-	tempConfigPath := filepath.Join(platoDir, fmt.Sprintf("ssh_%s.conf", numStr))
-	if err := os.WriteFile(tempConfigPath, []byte(configContent), 0600); err != nil {
-		return "", fmt.Errorf("failed to write temp SSH config: %w", err)
-	}
-
-	return tempConfigPath, nil
+	return configContent, nil
 }
 
-// AppendSSHHostEntry appends a new SSH host entry to config
+// AppendSSHHostEntry appends a new SSH host entry to config, built by
+// BuildSSHConfigBlock like the temporary configs
 func AppendSSHHostEntry(baseURL, hostname string, port int, jobGroupID string, username string) error {
 	configContent, err := ReadSSHConfig()
 	if err != nil {
 		return err
 	}
 
-	// Find proxytunnel path (checks bundled binary first, then PATH)
-	proxytunnelPath, err := FindProxytunnelPath()
-	if err != nil {
-		return fmt.Errorf("SSH config setup failed: %w", err)
-	}
-
 	// Get the private key path to include in the SSH config
 	privateKeyPath, err := GetSSHPrivateKeyPath()
 	if err != nil {
 		return fmt.Errorf("failed to find SSH private key: %w", err)
 	}
 
-	// Get proxy configuration based on base URL
-	proxyConfig := GetProxyConfig(baseURL)
-
-	// Build ProxyCommand
-	proxyCmd := proxytunnelPath
-	if proxyConfig.Secure {
-		proxyCmd += " -E"
+	block, err := BuildSSHConfigBlock(baseURL, hostname, port, jobGroupID, username, privateKeyPath)
+	if err != nil {
+		return err
 	}
-	proxyCmd += fmt.Sprintf(" -p %s -P '%s@22:newpass' -d %%h:%%p --no-check-certificate", proxyConfig.Server, jobGroupID)
-
-	configWithProxy := fmt.Sprintf(`Host %s
-    HostName localhost
-    Port %d
-    User %s
-    IdentityFile %s
-    IdentitiesOnly yes
-    StrictHostKeyChecking no
-    UserKnownHostsFile /dev/null
-    ConnectTimeout 10
-    ProxyCommand %s
-    ServerAliveInterval 30
-    ServerAliveCountMax 3
-    TCPKeepAlive yes
-    `, hostname, port, username, privateKeyPath, proxyCmd)
 
 	if configContent != "" {
-		configContent = strings.TrimRight(configContent, "\n") + "\n\n" + configWithProxy
+		configContent = strings.TrimRight(configContent, "\n") + "\n\n" + block
 	} else {
-		configContent = configWithProxy
+		configContent = block
 	}
 
 	return WriteSSHConfig(configContent)
 }
 
+// getSandboxLockPath returns the lockfile guarding sandbox number allocation
+func getSandboxLockPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "sandbox.lock")
+}
+
 // getNextSandboxNumber finds the next available sandbox number by checking existing config files
 func getNextSandboxNumber() int {
 	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
@@ -308,12 +332,18 @@ func getNextSandboxNumber() int {
 
 	maxNum := 0
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), "ssh_") && strings.HasSuffix(file.Name(), ".conf") {
-			// Extract number from ssh_N.conf
-			name := strings.TrimPrefix(file.Name(), "ssh_")
-			name = strings.TrimSuffix(name, ".conf")
-			if num, err := strconv.Atoi(name); err == nil && num > maxNum {
-				maxNum = num
+		if !strings.HasPrefix(file.Name(), "ssh_") {
+			continue
+		}
+		// Extract number from ssh_N.conf, ssh_N_key or ssh_N_key.pub so that a
+		// half-finished allocation still reserves its number
+		name := strings.TrimPrefix(file.Name(), "ssh_")
+		for _, suffix := range []string{".conf", "_key.pub", "_key"} {
+			if strings.HasSuffix(name, suffix) {
+				if num, err := strconv.Atoi(strings.TrimSuffix(name, suffix)); err == nil && num > maxNum {
+					maxNum = num
+				}
+				break
 			}
 		}
 	}
@@ -323,6 +353,14 @@ func getNextSandboxNumber() int {
 // SetupSSHConfig creates a temporary SSH config file and generates a new SSH key pair
 // Returns (hostname, configPath, publicKey, privateKeyPath, error)
 func SetupSSHConfig(baseURL string, localPort int, jobPublicID string, username string) (string, string, string, string, error) {
+	// Hold the ~/.plato lock until the key and config files exist so that
+	// concurrent launches never compute the same sandbox number
+	unlock, err := LockFile(getSandboxLockPath())
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to lock sandbox allocation: %w", err)
+	}
+	defer unlock()
+
 	// Get next available sandbox number for a simple hostname
 	sandboxNum := getNextSandboxNumber()
 	sshHost := fmt.Sprintf("sandbox-%d", sandboxNum)
@@ -342,7 +380,8 @@ func SetupSSHConfig(baseURL string, localPort int, jobPublicID string, username
 	return sshHost, configPath, publicKey, privateKeyPath, nil
 }
 
-// CleanupSSHConfig removes a SSH host entry from config
+// CleanupSSHConfig removes a SSH host entry from config, along with any
+// stale duplicates of other Plato hosts. Entries the user wrote are kept.
 func CleanupSSHConfig(hostname string) error {
 	existingConfig, err := ReadSSHConfig()
 	if err != nil {
@@ -353,10 +392,56 @@ func CleanupSSHConfig(hostname string) error {
 		return nil
 	}
 
-	updatedConfig := RemoveSSHHostFromConfig(hostname, existingConfig)
+	updatedConfig := RemoveManagedSSHHost(hostname, existingConfig)
+	updatedConfig, removed := DedupeSSHHosts(updatedConfig)
+	if len(removed) > 0 {
+		LogDebug("Removed duplicate SSH config entries for: %s", strings.Join(removed, ", "))
+	}
 	return WriteSSHConfig(updatedConfig)
 }
 
+// UpdateSSHConfigFileIdentity updates the IdentityFile for a host in a specific SSH config file
+func UpdateSSHConfigFileIdentity(configPath, hostname, privateKeyPath string) error {
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	existingConfig := string(configContent)
+	if !HostExistsInConfig(hostname, existingConfig) {
+		return fmt.Errorf("host %s not found in SSH config", hostname)
+	}
+
+	lines := strings.Split(existingConfig, "\n")
+	inTargetHost := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "Host ") {
+			inTargetHost = trimmed == fmt.Sprintf("Host %s", hostname)
+			continue
+		}
+		if inTargetHost && strings.HasPrefix(trimmed, "IdentityFile ") {
+			lines[i] = fmt.Sprintf("    IdentityFile %s", privateKeyPath)
+		}
+	}
+
+	return os.WriteFile(configPath, []byte(strings.Join(lines, "\n")), 0600)
+}
+
+// UpdateSSHConfigIdentity points the IdentityFile of a host in ~/.ssh/config,
+// added there when the VM was opened in Cursor/VSCode, at privateKeyPath. A
+// host that isn't in ~/.ssh/config is left alone.
+func UpdateSSHConfigIdentity(hostname, privateKeyPath string) error {
+	existingConfig, err := ReadSSHConfig()
+	if err != nil {
+		return err
+	}
+	if !HostExistsInConfig(hostname, existingConfig) {
+		return nil
+	}
+	return UpdateSSHConfigFileIdentity(filepath.Join(os.Getenv("HOME"), ".ssh", "config"), hostname, privateKeyPath)
+}
+
 // CleanupSSHKeyPair removes the SSH key pair files for a sandbox
 func CleanupSSHKeyPair(privateKeyPath string) error {
 	if privateKeyPath == "" {
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultSSHCommandTimeout bounds RunSSHCommand when the context has no deadline
+const DefaultSSHCommandTimeout = 10 * time.Minute
+
+// sshBinary is the ssh executable used by RunSSHCommand; tests point it at a fake
+var sshBinary = "ssh"
+
+// sshMultiplexOptions reuse one connection per host for back-to-back commands
+var sshMultiplexOptions = []string{
+	"-o", "ControlMaster=auto",
+	"-o", "ControlPath=/tmp/plato-ssh-%C",
+	"-o", "ControlPersist=60s",
+}
+
+// SSHCommandError describes a remote command that failed or could not be run
+type SSHCommandError struct {
+	Host     string
+	Command  string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *SSHCommandError) Error() string {
+	msg := fmt.Sprintf("ssh %s: command failed", e.Host)
+	if e.ExitCode >= 0 {
+		msg = fmt.Sprintf("ssh %s: command exited with status %d", e.Host, e.ExitCode)
+	}
+	if e.Err != nil && e.ExitCode < 0 {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Stderr != "" {
+		msg += "\nOutput: " + e.Stderr
+	}
+	return msg
+}
+
+func (e *SSHCommandError) Unwrap() error {
+	return e.Err
+}
+
+// RunSSHCommand runs cmd on sshHost using the given ssh config file and returns
+// its stdout and stderr. Connections are multiplexed and the command is killed
+// after DefaultSSHCommandTimeout unless ctx carries its own deadline.
+func RunSSHCommand(ctx context.Context, sshConfigPath, sshHost, cmd string) (string, string, error) {
+	return RunSSHCommandWithOptions(ctx, sshConfigPath, sshHost, nil, cmd)
+}
+
+// RunSSHCommandWithOptions is RunSSHCommand with extra ssh arguments (e.g. "-o", "User=root").
+// ssh keeps the first value it sees for an option, so these override the defaults.
+func RunSSHCommandWithOptions(ctx context.Context, sshConfigPath, sshHost string, options []string, cmd string) (string, string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultSSHCommandTimeout)
+		defer cancel()
+	}
+
+	args := []string{}
+	if sshConfigPath != "" {
+		args = append(args, "-F", sshConfigPath)
+	}
+	args = append(args, options...)
+	args = append(args, sshMultiplexOptions...)
+	args = append(args, sshHost, cmd)
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, sshBinary, args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	// A persisted control master can hold our pipes open after ssh exits
+	c.WaitDelay = 5 * time.Second
+
+	// The command itself is not logged since it may carry tokens or credentials
+	LogDebug("Running SSH command on %s", sshHost)
+	err := c.Run()
+	if err == nil {
+		return stdout.String(), stderr.String(), nil
+	}
+
+	cmdErr := &SSHCommandError{
+		Host:     sshHost,
+		Command:  cmd,
+		ExitCode: -1,
+		Stderr:   strings.TrimSpace(stderr.String()),
+		Err:      err,
+	}
+	var exitErr *exec.ExitError
+	if ctx.Err() != nil {
+		cmdErr.Err = ctx.Err()
+	} else if errors.As(err, &exitErr) {
+		cmdErr.ExitCode = exitErr.ExitCode()
+	}
+	LogDebug("SSH command on %s failed: exit=%d stderr=%s", sshHost, cmdErr.ExitCode, cmdErr.Stderr)
+	return stdout.String(), stderr.String(), cmdErr
+}
+
+// StreamSSHCommand runs a long-lived cmd on sshHost, such as a log tail, and
+// sends each line of its stdout and stderr on the returned channel. ssh is
+// killed when ctx is cancelled; the channel is closed once it has exited.
+func StreamSSHCommand(ctx context.Context, sshConfigPath, sshHost, cmd string) (<-chan string, error) {
+	args := []string{}
+	if sshConfigPath != "" {
+		args = append(args, "-F", sshConfigPath)
+	}
+	args = append(args, sshMultiplexOptions...)
+	args = append(args, sshHost, cmd)
+
+	pr, pw := io.Pipe()
+	c := exec.CommandContext(ctx, sshBinary, args...)
+	c.Stdout = pw
+	c.Stderr = pw
+	c.WaitDelay = 5 * time.Second
+
+	LogDebug("Streaming SSH command on %s", sshHost)
+	if err := c.Start(); err != nil {
+		pw.Close()
+		return nil, &SSHCommandError{Host: sshHost, Command: cmd, ExitCode: -1, Err: err}
+	}
+	go func() {
+		err := c.Wait()
+		if err != nil && ctx.Err() == nil {
+			LogDebug("Streamed SSH command on %s exited: %v", sshHost, err)
+		}
+		pw.Close()
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		// Keep draining after ctx ends so ssh never blocks on a full pipe
+		defer io.Copy(io.Discard, pr)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
+
+// hostKeyPinAttempts and hostKeyPinInterval bound how long PinHostKey waits
+// for the VM's sshd to answer through the proxy
+const (
+	hostKeyPinAttempts = 20
+	hostKeyPinInterval = 3 * time.Second
+)
+
+// PinHostKey records the VM's host key in KnownHostsPath(sshHost) when
+// ssh.strict_host_key is enabled, so later connections verify it. ssh stores
+// the key before authenticating, so this works even before our key is
+// installed. A file left over from an earlier VM with the same hostname is
+// replaced. It does nothing when strict host key checking is off.
+func PinHostKey(ctx context.Context, sshConfigPath, sshHost string) error {
+	if !StrictHostKeyEnabled() {
+		return nil
+	}
+	knownHosts := KnownHostsPath(sshHost)
+	if err := os.Remove(knownHosts); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reset %s: %w", knownHosts, err)
+	}
+
+	options := []string{"-o", "StrictHostKeyChecking=accept-new", "-o", "BatchMode=yes", "-o", "ControlPath=none"}
+	var lastErr error
+	for attempt := 0; attempt < hostKeyPinAttempts; attempt++ {
+		_, _, err := RunSSHCommandWithOptions(ctx, sshConfigPath, sshHost, options, "true")
+		if info, statErr := os.Stat(knownHosts); statErr == nil && info.Size() > 0 {
+			LogDebug("Pinned host key for %s in %s", sshHost, knownHosts)
+			return nil
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(hostKeyPinInterval):
+		}
+	}
+	return fmt.Errorf("could not fetch the host key for %s: %w", sshHost, lastErr)
+}
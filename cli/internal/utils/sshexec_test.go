@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSH installs a shell script as the ssh binary for the duration of the test
+func fakeSSH(t *testing.T, script string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake ssh: %v", err)
+	}
+	original := sshBinary
+	sshBinary = path
+	t.Cleanup(func() { sshBinary = original })
+}
+
+func TestRunSSHCommandCapturesOutput(t *testing.T) {
+	fakeSSH(t, `echo "$@"; echo "warning" >&2`)
+
+	stdout, stderr, err := RunSSHCommand(context.Background(), "/tmp/ssh_config", "sandbox-1", "ls -la")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"-F /tmp/ssh_config", "ControlMaster=auto", "ControlPersist=60s", "sandbox-1 ls -la"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected args to contain %q, got %q", want, stdout)
+		}
+	}
+	if strings.TrimSpace(stderr) != "warning" {
+		t.Errorf("expected stderr 'warning', got %q", stderr)
+	}
+}
+
+func TestRunSSHCommandOptionsPrecedeDefaults(t *testing.T) {
+	fakeSSH(t, `echo "$@"`)
+
+	stdout, _, err := RunSSHCommandWithOptions(context.Background(), "", "sandbox-1", []string{"-o", "ControlPath=none"}, "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(stdout, "-F") {
+		t.Errorf("expected no -F without a config path, got %q", stdout)
+	}
+	override := strings.Index(stdout, "ControlPath=none")
+	def := strings.Index(stdout, "ControlPath=/tmp/plato-ssh-")
+	if override < 0 || def < 0 || override > def {
+		t.Errorf("expected caller options before defaults, got %q", stdout)
+	}
+}
+
+func TestRunSSHCommandExitError(t *testing.T) {
+	fakeSSH(t, `echo "partial"; echo "permission denied" >&2; exit 3`)
+
+	stdout, _, err := RunSSHCommand(context.Background(), "cfg", "sandbox-1", "cat /root/secret")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var cmdErr *SSHCommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("expected *SSHCommandError, got %T", err)
+	}
+	if cmdErr.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", cmdErr.ExitCode)
+	}
+	if cmdErr.Stderr != "permission denied" {
+		t.Errorf("expected stderr 'permission denied', got %q", cmdErr.Stderr)
+	}
+	if strings.TrimSpace(stdout) != "partial" {
+		t.Errorf("expected stdout to be returned on failure, got %q", stdout)
+	}
+	if !strings.Contains(err.Error(), "status 3") || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected exit status and stderr in message, got %q", err.Error())
+	}
+}
+
+func TestRunSSHCommandTimeout(t *testing.T) {
+	fakeSSH(t, `exec sleep 5`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := RunSSHCommand(ctx, "cfg", "sandbox-1", "true")
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("command was not killed promptly")
+	}
+}
+
+func TestPinHostKeyRecordsKeyEvenIfAuthFails(t *testing.T) {
+	inConfigDir(t, "ssh:\n  strict_host_key: true\n")
+	argsFile := filepath.Join(t.TempDir(), "args")
+	// Like real ssh with accept-new: the key is stored, then authentication fails
+	fakeSSH(t, `echo "$@" > `+argsFile+`
+mkdir -p "$HOME/.plato"
+echo "sandbox-1 ssh-ed25519 AAAA" > "$HOME/.plato/ssh_1.known_hosts"
+exit 255`)
+
+	if err := PinHostKey(context.Background(), "/tmp/ssh_config", "sandbox-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "StrictHostKeyChecking=accept-new") {
+		t.Errorf("expected accept-new for the pinning connection, got %q", args)
+	}
+}
+
+func TestPinHostKeyDisabledByDefault(t *testing.T) {
+	inConfigDir(t, "")
+	fakeSSH(t, `exit 1`)
+	if err := PinHostKey(context.Background(), "/tmp/ssh_config", "sandbox-1"); err != nil {
+		t.Fatalf("expected no-op without ssh.strict_host_key, got %v", err)
+	}
+}
+
+func TestStreamSSHCommandSendsLines(t *testing.T) {
+	fakeSSH(t, `echo "web-1  | started"; echo "db-1  | ready" >&2`)
+
+	lines, err := StreamSSHCommand(context.Background(), "/tmp/ssh_config", "sandbox-1", "docker compose logs -f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	if strings.Join(got, "|") != "web-1  | started|db-1  | ready" {
+		t.Errorf("unexpected lines %q", got)
+	}
+}
+
+func TestStreamSSHCommandStopsOnCancel(t *testing.T) {
+	fakeSSH(t, `echo first; exec sleep 30`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, err := StreamSSHCommand(ctx, "", "sandbox-1", "docker compose logs -f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line := <-lines; line != "first" {
+		t.Fatalf("first line = %q", line)
+	}
+	cancel()
+	select {
+	case line, ok := <-lines:
+		if ok {
+			t.Fatalf("expected channel to close after cancel, got %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestAppendSSHHostEntryHonorsStrictHostKey(t *testing.T) {
+	inConfigDir(t, "ssh:\n  strict_host_key: true\n")
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "proxytunnel"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake proxytunnel: %v", err)
+	}
+	t.Setenv("PATH", bin)
+	sshDir := filepath.Join(os.Getenv("HOME"), ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sshDir, "id_ed25519.pub"), []byte("ssh-ed25519 AAAA test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendSSHHostEntry("https://plato.so", "sandbox-7", 2222, "jg-1", "root"); err != nil {
+		t.Fatalf("AppendSSHHostEntry: %v", err)
+	}
+	config, err := ReadSSHConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(config, "StrictHostKeyChecking yes") || !strings.Contains(config, "HostKeyAlias sandbox-7") {
+		t.Errorf("expected the entry to verify the pinned host key, got:\n%s", config)
+	}
+	if strings.Contains(config, "/dev/null") {
+		t.Errorf("expected no UserKnownHostsFile /dev/null with ssh.strict_host_key, got:\n%s", config)
+	}
+}
@@ -0,0 +1,232 @@
+// Package utils provides SSH config de-duplication for the Plato CLI.
+//
+// Host entries are appended to ~/.ssh/config, so a crash before cleanup can
+// leave several blocks for the same sandbox host. SSH uses the first match,
+// which is usually the stale one. This file finds those duplicates and keeps
+// only the most recent Plato-managed block for each host.
+package utils
+
+import (
+	"strings"
+)
+
+// sshHostBlock is one Host block of an SSH config, spanning lines[start:end]
+type sshHostBlock struct {
+	names   []string
+	start   int
+	end     int
+	managed bool
+}
+
+// isSSHBlockStart reports whether line starts a new Host or Match block
+func isSSHBlockStart(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	keyword := strings.ToLower(fields[0])
+	return keyword == "host" || keyword == "match"
+}
+
+// parseSSHHostBlocks splits config lines into Host blocks. Lines before the
+// first block and Match blocks are not returned. A block is Plato-managed when
+// its ProxyCommand goes through proxytunnel, as every block the CLI writes does.
+func parseSSHHostBlocks(lines []string) []sshHostBlock {
+	var blocks []sshHostBlock
+	var current *sshHostBlock
+	flush := func(end int) {
+		if current != nil {
+			current.end = end
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+
+	for i, line := range lines {
+		if isSSHBlockStart(line) {
+			flush(i)
+			fields := strings.Fields(line)
+			if strings.EqualFold(fields[0], "host") {
+				current = &sshHostBlock{names: fields[1:], start: i}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 1 && strings.EqualFold(fields[0], "ProxyCommand") && strings.Contains(line, "proxytunnel") {
+			current.managed = true
+		}
+	}
+	flush(len(lines))
+	return blocks
+}
+
+// SSHHostDefined reports whether a Host block in configContent names hostname.
+// Unlike HostExistsInConfig it does not match hosts that merely share a
+// prefix, such as sandbox-10 for sandbox-1.
+func SSHHostDefined(hostname, configContent string) bool {
+	for _, block := range parseSSHHostBlocks(strings.Split(configContent, "\n")) {
+		for _, name := range block.names {
+			if name == hostname {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DuplicateSSHHosts returns the host names defined by more than one Host
+// block, in the order they first appear
+func DuplicateSSHHosts(configContent string) []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, block := range parseSSHHostBlocks(strings.Split(configContent, "\n")) {
+		for _, name := range block.names {
+			if counts[name] == 0 {
+				order = append(order, name)
+			}
+			counts[name]++
+		}
+	}
+
+	var dups []string
+	for _, name := range order {
+		if counts[name] > 1 {
+			dups = append(dups, name)
+		}
+	}
+	return dups
+}
+
+// SSHHostCollisions returns the Plato-managed host names that are also
+// defined by a block the user wrote. SSH picks whichever comes first, so such
+// a host may not reach the sandbox.
+func SSHHostCollisions(configContent string) []string {
+	managed := make(map[string]bool)
+	user := make(map[string]bool)
+	for _, block := range parseSSHHostBlocks(strings.Split(configContent, "\n")) {
+		for _, name := range block.names {
+			if block.managed {
+				managed[name] = true
+			} else {
+				user[name] = true
+			}
+		}
+	}
+
+	var collisions []string
+	for _, name := range DuplicateSSHHosts(configContent) {
+		if managed[name] && user[name] {
+			collisions = append(collisions, name)
+		}
+	}
+	return collisions
+}
+
+// DedupeSSHHosts removes all but the last Plato-managed block for each host
+// defined more than once, since appends put the most recent entry last. Blocks
+// the user wrote are never removed. It returns the updated content and the
+// hosts that had blocks removed.
+func DedupeSSHHosts(configContent string) (string, []string) {
+	lines := strings.Split(configContent, "\n")
+	blocks := parseSSHHostBlocks(lines)
+
+	// Index of the last managed block for each host
+	last := make(map[string]int)
+	for i, block := range blocks {
+		if !block.managed {
+			continue
+		}
+		for _, name := range block.names {
+			last[name] = i
+		}
+	}
+
+	drop := make(map[int]bool)
+	var removed []string
+	seen := make(map[string]bool)
+	for i, block := range blocks {
+		if !block.managed {
+			continue
+		}
+		stale := true
+		for _, name := range block.names {
+			if last[name] == i {
+				stale = false
+			}
+		}
+		if !stale {
+			continue
+		}
+		for line := block.start; line < block.end; line++ {
+			drop[line] = true
+		}
+		for _, name := range block.names {
+			if !seen[name] {
+				seen[name] = true
+				removed = append(removed, name)
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return configContent, nil
+	}
+
+	return dropSSHLines(lines, drop), removed
+}
+
+// dropSSHLines joins lines back into config content, leaving out those in drop
+func dropSSHLines(lines []string, drop map[int]bool) string {
+	var kept []string
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n")
+}
+
+// RemoveManagedSSHHost removes every Plato-managed block for hostname,
+// leaving a block the user wrote for the same name in place
+func RemoveManagedSSHHost(hostname, configContent string) string {
+	lines := strings.Split(configContent, "\n")
+	drop := make(map[int]bool)
+	for _, block := range parseSSHHostBlocks(lines) {
+		if !block.managed {
+			continue
+		}
+		for _, name := range block.names {
+			if name == hostname {
+				for line := block.start; line < block.end; line++ {
+					drop[line] = true
+				}
+				break
+			}
+		}
+	}
+	if len(drop) == 0 {
+		return configContent
+	}
+
+	return dropSSHLines(lines, drop)
+}
+
+// ReconcileSSHConfig de-duplicates the Plato-managed hosts in ~/.ssh/config,
+// rewriting it only if something was removed. It returns the hosts that had
+// stale blocks removed and the hosts that collide with the user's own entries.
+func ReconcileSSHConfig() (removed []string, collisions []string, err error) {
+	existingConfig, err := ReadSSHConfig()
+	if err != nil || existingConfig == "" {
+		return nil, nil, err
+	}
+
+	updatedConfig, removed := DedupeSSHHosts(existingConfig)
+	if len(removed) > 0 {
+		if err := WriteSSHConfig(updatedConfig); err != nil {
+			return nil, nil, err
+		}
+	}
+	return removed, SSHHostCollisions(updatedConfig), nil
+}
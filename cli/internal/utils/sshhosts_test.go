@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func platoHostBlock(host string, port int) string {
+	return fmt.Sprintf("Host %s\n    HostName localhost\n    Port %d\n    ProxyCommand /usr/bin/proxytunnel -p proxy:9000 -d %%h:%%p\n", host, port)
+}
+
+func TestDuplicateSSHHosts(t *testing.T) {
+	config := platoHostBlock("sandbox-1", 2201) + "\n" + platoHostBlock("sandbox-10", 2202) + "\n" + platoHostBlock("sandbox-1", 2203)
+
+	if got := DuplicateSSHHosts(config); !reflect.DeepEqual(got, []string{"sandbox-1"}) {
+		t.Errorf("DuplicateSSHHosts = %v, want [sandbox-1]", got)
+	}
+	if !SSHHostDefined("sandbox-10", config) || SSHHostDefined("sandbox-2", config) {
+		t.Error("SSHHostDefined matched the wrong hosts")
+	}
+}
+
+func TestDedupeSSHHostsKeepsMostRecentPlatoBlock(t *testing.T) {
+	user := "Host github.com\n    User git\n"
+	config := user + "\n" + platoHostBlock("sandbox-1", 2201) + "\n" + platoHostBlock("sandbox-2", 2202) + "\n" + platoHostBlock("sandbox-1", 2203)
+
+	got, removed := DedupeSSHHosts(config)
+	if !reflect.DeepEqual(removed, []string{"sandbox-1"}) {
+		t.Fatalf("removed = %v, want [sandbox-1]", removed)
+	}
+	if strings.Count(got, "Host sandbox-1\n") != 1 || !strings.Contains(got, "Port 2203") || strings.Contains(got, "Port 2201") {
+		t.Errorf("expected only the last sandbox-1 block to remain:\n%s", got)
+	}
+	if !strings.Contains(got, "Host github.com") || !strings.Contains(got, "Host sandbox-2") {
+		t.Errorf("unrelated hosts were removed:\n%s", got)
+	}
+
+	if again, removed := DedupeSSHHosts(got); again != got || removed != nil {
+		t.Errorf("dedupe is not idempotent: removed %v", removed)
+	}
+}
+
+func TestDedupeSSHHostsKeepsUserBlocks(t *testing.T) {
+	user := "Host sandbox-1\n    HostName my-server.example.com\n"
+	config := user + "\n" + platoHostBlock("sandbox-1", 2201)
+
+	got, removed := DedupeSSHHosts(config)
+	if removed != nil || got != config {
+		t.Errorf("user block should not be treated as a stale duplicate, removed %v", removed)
+	}
+	if collisions := SSHHostCollisions(config); !reflect.DeepEqual(collisions, []string{"sandbox-1"}) {
+		t.Errorf("SSHHostCollisions = %v, want [sandbox-1]", collisions)
+	}
+
+	cleaned := RemoveManagedSSHHost("sandbox-1", config)
+	if !strings.Contains(cleaned, "my-server.example.com") || strings.Contains(cleaned, "proxytunnel") {
+		t.Errorf("RemoveManagedSSHHost should keep only the user block:\n%s", cleaned)
+	}
+}
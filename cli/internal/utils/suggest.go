@@ -0,0 +1,10 @@
+package utils
+
+import (
+	sdkutils "plato-sdk/utils"
+)
+
+// SuggestNames returns the candidates that look like a typo of name, closest first
+func SuggestNames(name string, candidates []string) []string {
+	return sdkutils.SuggestNames(name, candidates)
+}
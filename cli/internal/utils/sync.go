@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rsyncBinary is the rsync executable used by SyncDirToVM; tests point it at a fake
+var rsyncBinary = "rsync"
+
+// SyncSpec maps a local directory to a path on the VM
+type SyncSpec struct {
+	LocalDir  string
+	RemoteDir string
+}
+
+// ParseSyncSpec parses "<local>:<remote>", e.g. "./src:/home/plato/app".
+// The local directory must exist; the remote path must be absolute.
+func ParseSyncSpec(spec string) (SyncSpec, error) {
+	i := strings.LastIndex(spec, ":")
+	if i <= 0 || i == len(spec)-1 {
+		return SyncSpec{}, fmt.Errorf("invalid sync spec '%s' (expected <local-dir>:<remote-dir>)", spec)
+	}
+	local, remote := spec[:i], spec[i+1:]
+	if !strings.HasPrefix(remote, "/") {
+		return SyncSpec{}, fmt.Errorf("remote sync path '%s' must be absolute", remote)
+	}
+
+	abs, err := filepath.Abs(local)
+	if err != nil {
+		return SyncSpec{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return SyncSpec{}, fmt.Errorf("local sync directory: %w", err)
+	}
+	if !info.IsDir() {
+		return SyncSpec{}, fmt.Errorf("local sync path '%s' is not a directory", local)
+	}
+	return SyncSpec{LocalDir: abs, RemoteDir: remote}, nil
+}
+
+// rsyncArgs builds the rsync invocation for spec. .gitignore files are honoured
+// at every level via a dir-merge filter, and .git itself is never copied.
+func rsyncArgs(sshConfigPath, sshHost string, spec SyncSpec) []string {
+	sshCmd := "ssh"
+	if sshConfigPath != "" {
+		sshCmd = ShellJoin("ssh", "-F", sshConfigPath)
+	}
+	return []string{
+		"-az",
+		"--exclude=.git/",
+		"--filter=:- .gitignore",
+		"-e", sshCmd,
+		strings.TrimRight(spec.LocalDir, "/") + "/",
+		fmt.Sprintf("%s:%s/", sshHost, strings.TrimRight(spec.RemoteDir, "/")),
+	}
+}
+
+// SyncDirToVM copies spec.LocalDir into spec.RemoteDir on the VM with rsync,
+// creating the remote directory first. Files are never deleted on the VM.
+func SyncDirToVM(ctx context.Context, sshConfigPath, sshHost string, spec SyncSpec) error {
+	if _, err := exec.LookPath(rsyncBinary); err != nil {
+		return fmt.Errorf("rsync is required for --sync: %w", err)
+	}
+	if _, _, err := RunSSHCommand(ctx, sshConfigPath, sshHost, "mkdir -p "+ShellQuote(spec.RemoteDir)); err != nil {
+		return fmt.Errorf("failed to create %s on VM: %w", spec.RemoteDir, err)
+	}
+
+	cmd := exec.CommandContext(ctx, rsyncBinary, rsyncArgs(sshConfigPath, sshHost, spec)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rsync failed: %w\nOutput: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// dirFingerprint summarises modification times and sizes under dir, skipping
+// .git, so a change anywhere produces a different value
+func dirFingerprint(dir string) (string, error) {
+	var latest time.Time
+	var files, size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		if !info.IsDir() {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%d/%d", latest.UnixNano(), files, size), nil
+}
+
+// WatchAndSync polls spec.LocalDir every interval and re-runs SyncDirToVM
+// whenever it changes, until ctx is cancelled. onSync is called after each
+// sync attempt with its error, if any.
+func WatchAndSync(ctx context.Context, sshConfigPath, sshHost string, spec SyncSpec, interval time.Duration, onSync func(error)) error {
+	last, err := dirFingerprint(spec.LocalDir)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			current, err := dirFingerprint(spec.LocalDir)
+			if err != nil {
+				LogDebug("Failed to scan %s: %v", spec.LocalDir, err)
+				continue
+			}
+			if current == last {
+				continue
+			}
+			last = current
+			err = SyncDirToVM(ctx, sshConfigPath, sshHost, spec)
+			if ctx.Err() != nil {
+				return nil
+			}
+			onSync(err)
+		}
+	}
+}
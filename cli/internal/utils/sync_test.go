@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSyncSpec(t *testing.T) {
+	dir := t.TempDir()
+
+	spec, err := ParseSyncSpec(dir + ":/home/plato/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.LocalDir != dir || spec.RemoteDir != "/home/plato/app" {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+
+	for _, bad := range []string{dir, dir + ":", ":/app", dir + ":relative/path", filepath.Join(dir, "missing") + ":/app"} {
+		if _, err := ParseSyncSpec(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSyncDirToVMRunsRsync(t *testing.T) {
+	fakeSSH(t, `exit 0`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+	rsync := filepath.Join(t.TempDir(), "rsync")
+	if err := os.WriteFile(rsync, []byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake rsync: %v", err)
+	}
+	original := rsyncBinary
+	rsyncBinary = rsync
+	t.Cleanup(func() { rsyncBinary = original })
+
+	spec := SyncSpec{LocalDir: "/src/app", RemoteDir: "/home/plato/app/"}
+	if err := SyncDirToVM(context.Background(), "/tmp/ssh_config", "sandbox-1", spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("rsync was not run: %v", err)
+	}
+	for _, want := range []string{"--filter=:- .gitignore", "--exclude=.git/", "ssh -F /tmp/ssh_config", "/src/app/ sandbox-1:/home/plato/app/"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("expected rsync args to contain %q, got %q", want, args)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	sdkutils "plato-sdk/utils"
+)
+
+// CleanupTempDir removes dir, or keeps it and notes its path in err when the
+// operation failed and PLATO_KEEP_TEMP is set
+func CleanupTempDir(dir string, err error) error {
+	return sdkutils.CleanupTempDir(dir, err)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+	"plato-sdk/services"
+)
+
+// runLaunch implements `plato launch`.
+// It creates a VM from a dataset in plato-config.yml, or from a launch spec
+// with --spec, sets it up for SSH and, with --with-worker, starts the Plato
+// worker and waits until it is ready.
+func runLaunch(args []string) error {
+	fs := flag.NewFlagSet("launch", flag.ExitOnError)
+	dataset := fs.String("dataset", defaultDataset(), "Dataset from plato-config.yml to launch")
+	withWorker := fs.Bool("with-worker", false, "Start the Plato worker once setup completes and wait for it")
+	workerTimeout := fs.Duration("worker-timeout", 10*time.Minute, "How long to wait for the worker to become ready")
+	appPort := fs.Int("app-port", 0, "Port the service listens on (overrides compute.app_port)")
+	messagingPort := fs.Int("messaging-port", 0, "Port for Plato worker messaging (overrides compute.plato_messaging_port)")
+	syncSpec := fs.String("sync", "", "Copy a local directory into the VM once SSH is ready, as <local>:<remote> (e.g. ./src:/home/plato/app)")
+	watch := fs.Bool("watch", false, "With --sync, keep running and re-sync whenever the local directory changes")
+	noDatasetCheck := fs.Bool("no-dataset-check", false, "Launch even if the dataset looks like a typo of a published dataset")
+	specPath := fs.String("spec", "", "Launch exactly what a launch spec file describes instead of reading plato-config.yml")
+	saveSpec := fs.String("save-spec", "", "Write a launch spec of this launch to the file, to replay it with --spec")
+	artifactID := fs.String("artifact-id", "", "Launch from this snapshot artifact (overrides the dataset's artifact_id in plato-config.yml)")
+	version := fs.String("version", "", "Launch from this published version of the dataset (overrides the dataset's version in plato-config.yml)")
+	timeoutOverrides := addLaunchTimeoutFlags(fs)
+	fs.BoolVar(&noSandboxFile, "no-sandbox-file", false, "Write .sandbox.yaml to ~/.plato/sandboxes instead of the current directory")
+	fs.Parse(args)
+
+	overrides, err := timeoutOverrides()
+	if err != nil {
+		return err
+	}
+
+	var syncDir *utils.SyncSpec
+	if *syncSpec != "" {
+		spec, err := utils.ParseSyncSpec(*syncSpec)
+		if err != nil {
+			return err
+		}
+		syncDir = &spec
+	} else if *watch {
+		return newUsageError("--watch requires --sync")
+	}
+
+	client := NewConfigModel().client
+	ctx := context.Background()
+
+	var spec LaunchSpec
+	if *specPath != "" {
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		for _, name := range []string{"dataset", "app-port", "messaging-port", "artifact-id", "version"} {
+			if set[name] {
+				return newUsageError("--%s cannot be combined with --spec, the spec pins it", name)
+			}
+		}
+		spec, err = readLaunchSpec(*specPath)
+		if err != nil {
+			return err
+		}
+		// Timeouts stay tunable per environment when replaying a spec
+		if overrides.provision > 0 {
+			spec.ProvisionTimeout = overrides.provision.String()
+		}
+		if overrides.setup > 0 {
+			spec.SetupTimeout = overrides.setup.String()
+		}
+	} else {
+		spec, err = launchSpecFromConfig(*dataset, *appPort, *messagingPort, overrides)
+		if err != nil {
+			return err
+		}
+		pin, err := resolveLaunchArtifact(ctx, client, spec.Service, spec.Dataset, spec.Config, *artifactID, *version)
+		if err != nil {
+			return err
+		}
+		if pin != nil {
+			spec.ArtifactID, spec.ArtifactVersion = pin.artifactID, pin.version
+			if pin.source == platoConfigFilename {
+				fmt.Printf("📌 Dataset '%s' pins artifact %s in %s\n", spec.Dataset, pin.artifactID, platoConfigFilename)
+			}
+		}
+	}
+	timeouts, err := spec.timeouts()
+	if err != nil {
+		return err
+	}
+	opts := spec.LaunchOptions()
+	datasetConfig := spec.Config
+
+	if *saveSpec != "" {
+		if err := writeLaunchSpec(*saveSpec, spec); err != nil {
+			return err
+		}
+		fmt.Printf("📝 Wrote launch spec to %s\n", *saveSpec)
+	}
+
+	if !*noDatasetCheck && opts.ArtifactID == nil {
+		if err := checkDatasetOnServer(client, spec.Service, spec.Dataset); err != nil {
+			return err
+		}
+	}
+
+	if opts.ArtifactID != nil {
+		fmt.Printf("🚀 Creating VM for %s from artifact %s (dataset: %s)...\n", spec.Service, *opts.ArtifactID, spec.Dataset)
+	} else {
+		fmt.Printf("🚀 Creating VM for %s (dataset: %s)...\n", spec.Service, spec.Dataset)
+	}
+	sandbox, err := client.Sandbox.CreateWithOptions(ctx, &models.CreateSandboxOptions{
+		Config:     &datasetConfig,
+		Dataset:    spec.Dataset,
+		Alias:      sandboxAlias(datasetConfig),
+		Service:    spec.Service,
+		ArtifactID: opts.ArtifactID,
+		Timeout:    &spec.Timeout,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create VM: %w", err)
+	}
+	fmt.Printf("   VM %s created, waiting for provisioning...\n", sandbox.PublicId)
+	if err := client.Sandbox.MonitorOperation(ctx, sandbox.CorrelationId, timeouts.provision); err != nil {
+		return operationError("VM provisioning", provisioningTimeoutHint(sandbox.CorrelationId), err)
+	}
+
+	// Artifact VMs already contain the service, so they only need SSH as root
+	sshUser := "plato"
+	if opts.ArtifactID != nil {
+		sshUser = "root"
+	}
+	fmt.Println("🔑 Setting up SSH access...")
+	localPort := rand.Intn(100) + 2200
+	sshHost, sshConfigPath, sshPublicKey, sshPrivateKeyPath, err := utils.SetupSSHConfig(client.GetBaseURL(), localPort, sandbox.PublicId, sshUser)
+	if err != nil {
+		return fmt.Errorf("failed to setup SSH: %w", err)
+	}
+	recordLaunchedVM(sandbox, sshHost, sshConfigPath, sshPrivateKeyPath)
+
+	if opts.ArtifactID != nil {
+		correlationID, err := client.Sandbox.SetupRootAccess(ctx, sandbox.PublicId, sshPublicKey)
+		if err == nil {
+			err = confirmRootAccess(ctx, client, correlationID, sshConfigPath, sshHost)
+		}
+		if err != nil {
+			return fmt.Errorf("root SSH setup failed: %w", err)
+		}
+	} else {
+		correlationID, err := client.Sandbox.SetupSandbox(ctx, sandbox.PublicId, &datasetConfig, spec.Dataset, sshPublicKey)
+		if err != nil {
+			return fmt.Errorf("sandbox setup failed: %w", err)
+		}
+		if correlationID != "" {
+			events := make(chan string, 50)
+			go func() {
+				var lastKeepalive time.Time
+				for event := range events {
+					switch {
+					case event == services.KeepaliveEvent:
+						// Quiet steps still print a sign of life, once a minute
+						if time.Since(lastKeepalive) >= time.Minute {
+							fmt.Printf("   %s\n", keepalivePrefix)
+							lastKeepalive = time.Now()
+						}
+					case !strings.HasPrefix(event, "[DEBUG]"):
+						fmt.Printf("   %s\n", event)
+					}
+				}
+			}()
+			err := monitorSetup(client, correlationID, timeouts.setup, events)
+			close(events)
+			if err != nil {
+				return operationError("sandbox setup", setupTimeoutHint(correlationID), err)
+			}
+		}
+	}
+	if err := utils.PinHostKey(ctx, sshConfigPath, sshHost); err != nil {
+		return err
+	}
+
+	platoConfigPath := ""
+	if configDir, err := GetPlatoConfigDir(); err == nil {
+		platoConfigPath = filepath.Join(configDir, platoConfigFilename)
+	}
+	if err := WriteSandboxFile(sandbox, spec.Dataset, platoConfigPath, opts.ArtifactID, opts.Version, sshHost, sshConfigPath, sshPrivateKeyPath); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	vm := newVMSession(sandbox, spec.Dataset, spec.Service, opts.ArtifactID, opts.Version)
+	vm.SSHHost, vm.SSHConfigPath, vm.SSHPrivateKeyPath = sshHost, sshConfigPath, sshPrivateKeyPath
+	saveVMSession(vm)
+
+	if syncDir != nil {
+		fmt.Printf("📂 Syncing %s to %s...\n", syncDir.LocalDir, syncDir.RemoteDir)
+		if err := utils.SyncDirToVM(ctx, sshConfigPath, sshHost, *syncDir); err != nil {
+			return fmt.Errorf("VM %s is up but %w", sandbox.PublicId, err)
+		}
+		fmt.Println("✓ Sync complete")
+	}
+
+	if *withWorker {
+		fmt.Println("⚙️  Starting Plato worker...")
+		workerTimeoutSecs := int32(workerTimeout.Seconds())
+		req := models.StartWorkerRequest{
+			Service:            spec.Service,
+			Dataset:            spec.Dataset,
+			PlatoDatasetConfig: &datasetConfig,
+			Timeout:            &workerTimeoutSecs,
+		}
+		if _, err := client.Sandbox.StartWorkerAndWait(ctx, sandbox.PublicId, &req, *workerTimeout); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = operationError("worker setup", "increase --worker-timeout", err)
+			}
+			if lines := fetchWorkerLogTail(client, sandbox.PublicId); len(lines) > 0 {
+				fmt.Println("Recent worker logs:")
+				for _, line := range lines {
+					fmt.Printf("  %s\n", line)
+				}
+			}
+			return fmt.Errorf("VM %s is up but %w", sandbox.PublicId, err)
+		}
+		fmt.Println("✓ Worker ready")
+	}
+
+	fmt.Printf("✅ VM ready: %s\n", sandbox.PublicId)
+	fmt.Printf("   SSH: ssh -F %s %s\n", sshConfigPath, sshHost)
+	if sandbox.Url != "" {
+		fmt.Printf("   URL: %s (app port %d)\n", sandbox.Url, datasetConfig.Compute.AppPort)
+	}
+	fmt.Println("   Use `plato session --cleanup` to shut it down when you are done")
+
+	if syncDir != nil && *watch {
+		watchCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Printf("👀 Watching %s for changes (Ctrl+C to stop, the VM keeps running)\n", syncDir.LocalDir)
+		return utils.WatchAndSync(watchCtx, sshConfigPath, sshHost, *syncDir, syncWatchInterval, func(err error) {
+			if err != nil {
+				fmt.Printf("⚠️  Sync failed: %v\n", err)
+				return
+			}
+			fmt.Printf("✓ Synced at %s\n", time.Now().Format("15:04:05"))
+		})
+	}
+	return nil
+}
+
+// launchSpecFromConfig resolves a launch of dataset from plato-config.yml,
+// with the port flags and timeout overrides applied
+func launchSpecFromConfig(dataset string, appPort, messagingPort int, overrides launchTimeouts) (LaunchSpec, error) {
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		return LaunchSpec{}, err
+	}
+	timeouts, err := resolveLaunchTimeouts(config, overrides)
+	if err != nil {
+		return LaunchSpec{}, err
+	}
+	if config.Service == "" {
+		return LaunchSpec{}, fmt.Errorf("service not specified in plato-config.yml")
+	}
+	datasetConfig, ok := config.Datasets[dataset]
+	if !ok {
+		names := make([]string, 0, len(config.Datasets))
+		for name := range config.Datasets {
+			names = append(names, name)
+		}
+		return LaunchSpec{}, newNotFoundError("dataset '%s' not found in plato-config.yml%s", dataset, didYouMean(dataset, names))
+	}
+	for name, port := range map[string]int{"--app-port": appPort, "--messaging-port": messagingPort} {
+		if port < 0 || port > 65535 {
+			return LaunchSpec{}, newUsageError("%s must be between 1-65535", name)
+		}
+	}
+	if appPort > 0 {
+		datasetConfig.Compute.AppPort = int32(appPort)
+	}
+	if messagingPort > 0 {
+		datasetConfig.Compute.PlatoMessagingPort = int32(messagingPort)
+	}
+	if datasetConfig.Compute.AppPort == 0 {
+		datasetConfig.Compute.AppPort = defaultAppPort
+	}
+	if datasetConfig.Compute.PlatoMessagingPort == 0 {
+		datasetConfig.Compute.PlatoMessagingPort = defaultMessagingPort
+	}
+	return newLaunchSpec(config.Service, LaunchOptions{Dataset: &dataset}, datasetConfig, timeouts), nil
+}
+
+// syncWatchInterval is how often `launch --sync --watch` checks for local changes
+const syncWatchInterval = 2 * time.Second
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"plato-sdk/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// launchSpecVersion is the format version written to launch specs
+const launchSpecVersion = 1
+
+// defaultLaunchSpecPath is where the TUI exports the spec of the current VM
+const defaultLaunchSpecPath = "launch-spec.yaml"
+
+// LaunchSpec is one launch captured as a file, so a teammate can launch the
+// same VM with `plato launch --spec`. Unlike plato-config.yml, which describes
+// a service, it pins a single dataset's resolved config (compute, services and
+// their environment), the artifact and the timeouts of that launch.
+type LaunchSpec struct {
+	SpecVersion     int    `yaml:"spec_version"`
+	Service         string `yaml:"service"`
+	Dataset         string `yaml:"dataset"`
+	ArtifactID      string `yaml:"artifact_id,omitempty"`
+	ArtifactVersion string `yaml:"artifact_version,omitempty"`
+	// Timeout is the VM lifetime in seconds
+	Timeout          int                     `yaml:"timeout"`
+	ProvisionTimeout string                  `yaml:"provision_timeout,omitempty"`
+	SetupTimeout     string                  `yaml:"setup_timeout,omitempty"`
+	Config           models.SimConfigDataset `yaml:"config"`
+}
+
+// newLaunchSpec captures a launch of service described by opts, whose
+// dataset resolved to config
+func newLaunchSpec(service string, opts LaunchOptions, config models.SimConfigDataset, timeouts launchTimeouts) LaunchSpec {
+	spec := LaunchSpec{
+		SpecVersion:      launchSpecVersion,
+		Service:          service,
+		Timeout:          defaultVMLifetime,
+		ProvisionTimeout: timeouts.provision.String(),
+		SetupTimeout:     timeouts.setup.String(),
+		Config:           config,
+	}
+	if opts.Dataset != nil {
+		spec.Dataset = *opts.Dataset
+	}
+	if opts.ArtifactID != nil {
+		spec.ArtifactID = *opts.ArtifactID
+	}
+	if opts.Version != nil {
+		spec.ArtifactVersion = *opts.Version
+	}
+	return spec
+}
+
+// LaunchOptions returns the options the spec was captured from. The ports
+// are already part of Config.
+func (s LaunchSpec) LaunchOptions() LaunchOptions {
+	opts := LaunchOptions{Dataset: &s.Dataset}
+	if s.ArtifactID != "" {
+		opts.ArtifactID = &s.ArtifactID
+	}
+	if s.ArtifactVersion != "" {
+		opts.Version = &s.ArtifactVersion
+	}
+	return opts
+}
+
+// timeouts returns the spec's provisioning and setup waits, with the
+// defaults for any it does not set
+func (s LaunchSpec) timeouts() (launchTimeouts, error) {
+	var timeouts launchTimeouts
+	var err error
+	if timeouts.provision, err = parseSpecDuration("provision_timeout", s.ProvisionTimeout, defaultProvisionTimeout); err != nil {
+		return timeouts, err
+	}
+	if timeouts.setup, err = parseSpecDuration("setup_timeout", s.SetupTimeout, defaultSetupTimeout); err != nil {
+		return timeouts, err
+	}
+	return timeouts, nil
+}
+
+// parseSpecDuration parses a positive duration from a launch spec, returning
+// fallback when it is empty
+func parseSpecDuration(key, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("launch spec %s must be a positive duration such as 20m, got %q", key, value)
+	}
+	return d, nil
+}
+
+// writeLaunchSpec saves spec as YAML to path
+func writeLaunchSpec(path string, spec LaunchSpec) error {
+	data, err := yaml.Marshal(&spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal launch spec: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write launch spec: %w", err)
+	}
+	return nil
+}
+
+// readLaunchSpec loads and validates a launch spec written by writeLaunchSpec
+func readLaunchSpec(path string) (LaunchSpec, error) {
+	var spec LaunchSpec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return spec, fmt.Errorf("failed to read launch spec: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return spec, fmt.Errorf("failed to parse launch spec %s: %w", path, err)
+	}
+
+	switch {
+	case spec.SpecVersion > launchSpecVersion:
+		return spec, fmt.Errorf("launch spec %s has spec_version %d, this CLI supports up to %d; upgrade plato", path, spec.SpecVersion, launchSpecVersion)
+	case spec.Service == "":
+		return spec, fmt.Errorf("launch spec %s does not name a service", path)
+	case spec.Dataset == "":
+		return spec, fmt.Errorf("launch spec %s does not name a dataset", path)
+	case spec.Timeout < 0:
+		return spec, fmt.Errorf("launch spec %s has a negative timeout", path)
+	}
+	if spec.Timeout == 0 {
+		spec.Timeout = defaultVMLifetime
+	}
+	if _, err := spec.timeouts(); err != nil {
+		return spec, err
+	}
+	return spec, nil
+}
+
+// launchSpec captures how the VM shown in the TUI was launched, for the
+// Export Launch Spec action
+func (m VMInfoModel) launchSpec() (LaunchSpec, error) {
+	if m.config == nil || m.config.Service == "" {
+		return LaunchSpec{}, fmt.Errorf("exporting a launch spec needs a plato-config.yml that names the service")
+	}
+	datasetConfig, ok := m.config.Datasets[m.dataset]
+	if !ok {
+		return LaunchSpec{}, fmt.Errorf("dataset '%s' not found in plato-config.yml", m.dataset)
+	}
+	if datasetConfig.Compute.AppPort == 0 {
+		datasetConfig.Compute.AppPort = defaultAppPort
+	}
+	if datasetConfig.Compute.PlatoMessagingPort == 0 {
+		datasetConfig.Compute.PlatoMessagingPort = defaultMessagingPort
+	}
+	dataset := m.dataset
+	opts := LaunchOptions{Dataset: &dataset, ArtifactID: m.artifactID, Version: m.version}
+	return newLaunchSpec(m.config.Service, opts, datasetConfig, tuiLaunchTimeouts), nil
+}
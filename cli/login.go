@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"plato-cli/internal/config"
+	plato "plato-sdk"
+
+	"github.com/charmbracelet/huh"
+	"github.com/joho/godotenv"
+)
+
+// runLogin implements `plato login`. It prompts for the API key (masked) and an
+// optional base URL, validates them against the API and persists them.
+func runLogin(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	useKeychain := fs.Bool("keychain", false, "store the API key in the OS keychain instead of ~/.plato/credentials")
+	fs.Parse(args)
+
+	// Read before any .env file is loaded: a provider chosen in the shell
+	// outlasts the one login saves to ~/.plato/.env
+	shellProvider := strings.TrimSpace(os.Getenv("PLATO_CREDENTIAL_PROVIDER"))
+
+	apiKey := ""
+	baseURL := config.GetBaseURL()
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Plato API key").
+				EchoMode(huh.EchoModePassword).
+				Value(&apiKey).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("API key is required")
+					}
+					return nil
+				}),
+			huh.NewInput().
+				Title("Base URL").
+				Value(&baseURL),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return fmt.Errorf("login cancelled: %w", err)
+	}
+
+	apiKey = strings.TrimSpace(apiKey)
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = "https://plato.so/api"
+	}
+
+	fmt.Println("🔑 Validating API key...")
+	client := plato.NewClient(apiKey, plato.WithBaseURL(baseURL), plato.WithRetryConfig(&plato.RetryConfig{MaxRetries: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if _, err := client.Simulator.List(ctx); err != nil {
+		return fmt.Errorf("could not validate API key against %s: %w", baseURL, err)
+	}
+
+	providerName := config.ProviderFile
+	if *useKeychain {
+		providerName = config.ProviderKeychain
+	}
+	provider, err := config.NewCredentialProvider(providerName)
+	if err != nil {
+		return err
+	}
+	if err := provider.SetAPIKey(apiKey); err != nil {
+		return err
+	}
+
+	settings := map[string]string{
+		"PLATO_BASE_URL":            baseURL,
+		"PLATO_CREDENTIAL_PROVIDER": providerName,
+	}
+	if err := saveUserEnv(settings); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✅ Logged in to %s\n", baseURL)
+	fmt.Printf("🔐 API key stored using the %s provider\n", providerName)
+	if used := shadowingProvider(providerName, shellProvider); used != "" {
+		fmt.Printf("⚠️  The API key from the %s provider will be used instead (PLATO_CREDENTIAL_PROVIDER is set in your environment or .env)\n", used)
+	}
+	return nil
+}
+
+// shadowingProvider returns the provider whose key ResolveAPIKey will use
+// instead of the one just stored with saved, or "" if the stored key wins.
+// The provider selected in the shell or ./.env takes precedence over the
+// PLATO_CREDENTIAL_PROVIDER that login writes to ~/.plato/.env.
+func shadowingProvider(saved, shellProvider string) string {
+	selected := shellProvider
+	if selected == "" {
+		if projectEnv, err := godotenv.Read(".env"); err == nil {
+			selected = strings.TrimSpace(projectEnv["PLATO_CREDENTIAL_PROVIDER"])
+		}
+	}
+	if selected == "" {
+		selected = saved
+	}
+
+	for _, name := range config.CredentialProviderOrder(selected) {
+		if name == saved {
+			return ""
+		}
+		provider, err := config.NewCredentialProvider(name)
+		if err != nil {
+			continue
+		}
+		if key, err := provider.GetAPIKey(); err == nil && key != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// runLogout implements `plato logout`, removing credentials stored by `plato login`
+func runLogout() error {
+	fileProvider, _ := config.NewCredentialProvider(config.ProviderFile)
+	if err := fileProvider.DeleteAPIKey(); err != nil {
+		return err
+	}
+
+	if config.SelectedProviderName() == config.ProviderKeychain {
+		keychainProvider, _ := config.NewCredentialProvider(config.ProviderKeychain)
+		if err := keychainProvider.DeleteAPIKey(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(config.GetUserEnvPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", config.GetUserEnvPath(), err)
+	}
+
+	fmt.Println("✅ Logged out - stored credentials removed")
+	if os.Getenv("PLATO_API_KEY") != "" {
+		fmt.Println("⚠️  PLATO_API_KEY is still set in your environment or .env")
+	}
+	return nil
+}
+
+// saveUserEnv merges settings into ~/.plato/.env
+func saveUserEnv(settings map[string]string) error {
+	path := config.GetUserEnvPath()
+	existing, err := godotenv.Read(path)
+	if err != nil {
+		existing = make(map[string]string)
+	}
+	for k, v := range settings {
+		existing[k] = v
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create .plato directory: %w", err)
+	}
+	if err := godotenv.Write(existing, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return os.Chmod(path, 0600)
+}
@@ -11,11 +11,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"plato-cli/internal/ui/components"
@@ -43,14 +45,19 @@ type navigateToVMInfoMsg struct {
 	fromExistingSim   bool
 	artifactID        *string
 	version           *string
+	appPort           int // 0 when the launch did not choose one
 }
 
 type navigateToProxytunnelPortMsg struct {
-	publicID string
+	publicID    string
+	defaultPort int
 }
 
 type navigateToDBEntryMsg struct {
 	service string
+	// params and datasets describe the snapshot waiting for the DB config
+	params   snapshotParams
+	datasets []string
 }
 
 type navigateToDatasetSelectorMsg struct {
@@ -75,6 +82,8 @@ const (
 	ViewDatasetSelector
 	ViewAdvanced
 	ViewFlowEntry
+	ViewSession
+	ViewReconnect
 )
 
 type Model struct {
@@ -93,6 +102,8 @@ type Model struct {
 	datasetSelector  DatasetSelectorModel
 	advancedMenu     AdvancedMenuModel
 	flowEntry        FlowEntryModel
+	session          SessionModel
+	reconnect        ReconnectModel
 	quitting         bool
 }
 
@@ -103,7 +114,7 @@ func newModel() Model {
 		mainMenu:         NewMainMenuModel(),
 		config:           config,
 		launch:           NewLaunchModel(config.client),
-		vmConfig:         NewVMConfigModel(config.client, nil, nil, nil, nil), // Blank VM - no simulator, no artifact, no version, no dataset
+		vmConfig:         NewVMConfigModel(config.client, LaunchOptions{}), // Blank VM - no simulator, no artifact, no version, no dataset
 		platoConfig:      NewPlatoConfigModel(config.client),
 		simSelector:      NewSimSelectorModel(config.client),
 		simLaunchOptions: SimLaunchOptionsModel{}, // Will be initialized when simulator is selected
@@ -126,10 +137,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		vmInfo.sshHost = navMsg.sshHost
 		vmInfo.sshConfigPath = navMsg.sshConfigPath
 		vmInfo.sshPrivateKeyPath = navMsg.sshPrivateKeyPath
+		vmInfo.refreshActionAvailability()
+		if navMsg.appPort > 0 {
+			vmInfo.appPort = navMsg.appPort
+		}
 		m.vmInfo = vmInfo
 		m.currentView = ViewVMInfo
 
-		// Write .sandbox.yaml file to current working directory
+		// Write .sandbox.yaml to the working directory, or ~/.plato/sandboxes
+		// with --no-sandbox-file
 		// Get path to plato-config.yml
 		platoConfigPath := ""
 		if configDir, err := GetPlatoConfigDir(); err == nil {
@@ -140,22 +156,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			utils.LogDebug("Failed to write .sandbox.yaml: %v", err)
 			// Non-fatal error, just log it
 		} else {
-			utils.LogDebug("Successfully wrote .sandbox.yaml for VM: %s", navMsg.sandbox.PublicId)
+			utils.LogDebug("Successfully wrote %s for VM: %s", sandboxFilePath(navMsg.sandbox.PublicId), navMsg.sandbox.PublicId)
 		}
 
+		recordLaunchedVM(navMsg.sandbox, navMsg.sshHost, navMsg.sshConfigPath, navMsg.sshPrivateKeyPath)
+		m.vmInfo.saveSession()
+
+		return m, m.vmInfo.Init()
+	}
+
+	// Handle reconnecting to a VM saved by an earlier run
+	if navMsg, ok := msg.(reconnectVMMsg); ok {
+		m.vmInfo = newReconnectedVMInfoModel(m.config.client, navMsg.vm)
+		m.currentView = ViewVMInfo
+		// Drop tunnels that did not survive
+		m.vmInfo.saveSession()
 		return m, m.vmInfo.Init()
 	}
 
 	// Handle navigation to proxytunnel port selector
 	if navMsg, ok := msg.(navigateToProxytunnelPortMsg); ok {
-		m.proxytunnelPort = NewProxytunnelPortModel(navMsg.publicID)
+		m.proxytunnelPort = NewProxytunnelPortModel(navMsg.publicID, navMsg.defaultPort)
 		m.currentView = ViewProxytunnelPort
 		return m, m.proxytunnelPort.Init()
 	}
 
 	// Handle navigation to DB entry
 	if navMsg, ok := msg.(navigateToDBEntryMsg); ok {
-		m.dbEntry = NewDBEntryModel(navMsg.service)
+		m.dbEntry = NewDBEntryModel(navMsg.service, navMsg.params, navMsg.datasets)
 		m.currentView = ViewDBEntry
 		return m, m.dbEntry.Init()
 	}
@@ -165,8 +193,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		logDebug("openTunnelMsg received in main, publicID=%s, remotePort=%d", openMsg.publicID, openMsg.remotePort)
 		// Open the tunnel and go back to VM info
 		m.currentView = ViewVMInfo
+
+		bindAddress := tunnelBindAddress(m.vmInfo.config)
+		if existing, ok := m.vmInfo.findOpenTunnel(openMsg.publicID, bindAddress, openMsg.remotePort); ok {
+			logDebug("Reusing proxytunnel on local port %d for remote port %d", existing.localPort, existing.remotePort)
+			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("✓ Proxytunnel already open: %s → remote:%d", utils.ListenAddress(existing.bindAddress, existing.localPort), existing.remotePort))
+			m.vmInfo.refreshViewport()
+			return m, nil
+		}
+
 		logDebug("Switched to ViewVMInfo and calling openProxytunnelWithPort")
-		return m, openProxytunnelWithPort(m.vmInfo.client, openMsg.publicID, openMsg.remotePort)
+		return m, openProxytunnelWithPort(m.vmInfo.client, openMsg.publicID, openMsg.remotePort, bindAddress, tunnelStrictPorts(m.vmInfo.config))
 	}
 
 	// Handle navigation to sim launch options with simulator data
@@ -185,14 +222,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handle environment launch with simulator and optional artifact ID
 	if navMsg, ok := msg.(launchEnvironmentMsg); ok {
-		m.vmConfig = NewVMConfigModel(m.config.client, navMsg.simulator, navMsg.artifactID, navMsg.version, navMsg.dataset)
+		m.vmConfig = NewVMConfigModel(m.config.client, navMsg.options)
 		m.currentView = ViewVMConfig
 		return m, m.vmConfig.Init()
 	}
 
 	// Handle launch from plato config
 	if navMsg, ok := msg.(launchFromConfigMsg); ok {
-		m.vmConfig = NewVMConfigModelFromConfig(m.config.client, navMsg.datasetName, navMsg.datasetConfig, navMsg.service)
+		m.vmConfig = NewVMConfigModelFromConfig(m.config.client, navMsg.datasetName, navMsg.datasetConfig, navMsg.service, navMsg.artifactID, navMsg.version)
 		m.currentView = ViewVMConfig
 		return m, m.vmConfig.Init()
 	}
@@ -228,6 +265,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.advancedMenu.Init()
 		case ViewFlowEntry:
 			return m, m.flowEntry.Init()
+		case ViewSession:
+			m.session = NewSessionModel(m.config.client)
+			return m, m.session.Init()
+		case ViewReconnect:
+			m.reconnect = NewReconnectModel(m.config.client)
+			return m, m.reconnect.Init()
 		}
 		return m, nil
 	}
@@ -253,14 +296,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch actionMsg.action {
 		case "Authenticate ECR":
 			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "Authenticating Docker with AWS ECR...")
-			m.vmInfo.runningCommand = true
-			return m, tea.Batch(m.vmInfo.spinner.Tick, authenticateECR(m.vmInfo.sshHost, m.vmInfo.sshConfigPath))
+			return m, m.vmInfo.startECRAuth()
 		case "Open Proxytunnel":
 			// Navigate to proxytunnel port selector
 			return m, func() tea.Msg {
-				return navigateToProxytunnelPortMsg{publicID: m.vmInfo.sandbox.PublicId}
+				return navigateToProxytunnelPortMsg{publicID: m.vmInfo.sandbox.PublicId, defaultPort: m.vmInfo.appPort}
 			}
 		case "Audit Ignore UI":
+			if m.vmInfo.auditUIProcess != nil {
+				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "⚠️  Audit Ignore UI is already running at http://localhost:8501 (press x to stop it)")
+				return m, nil
+			}
 			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "Launching Audit Ignore UI in browser...")
 			m.vmInfo.runningCommand = true
 			return m, tea.Batch(m.vmInfo.spinner.Tick, launchAuditIgnoreUI())
@@ -298,9 +344,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.currentView = ViewFlowEntry
 			return m, m.flowEntry.Init()
 		case "Get State":
-			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "Fetching simulator state...")
-			m.vmInfo.runningCommand = true
-			return m, tea.Batch(m.vmInfo.spinner.Tick, getEnvironmentState(m.config.client, m.vmInfo.sandbox.JobGroupId))
+			return m, m.vmInfo.startStateFetch()
+		case "Reset Env State":
+			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "🧹 Resetting env state...")
+			client, jobGroupID := m.vmInfo.client, m.vmInfo.sandbox.JobGroupId
+			return m, m.vmInfo.runOperation("env state reset", func(ctx context.Context) tea.Cmd {
+				return resetEnvStateCmd(client, jobGroupID)
+			})
 		case "Set up root SSH":
 			if m.vmInfo.rootPasswordSetup {
 				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "⚠️  Root SSH password is already configured")
@@ -311,13 +361,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "Setting up root SSH password...")
-			m.vmInfo.runningCommand = true
-			return m, tea.Batch(m.vmInfo.spinner.Tick, setupRootPassword(m.config.client, m.vmInfo.sandbox.PublicId, m.vmInfo.sshPrivateKeyPath, m.vmInfo.sshHost))
+			return m, m.vmInfo.startRootSetup()
+		case "Rotate SSH Key":
+			if m.vmInfo.sshHost == "" || m.vmInfo.sshPrivateKeyPath == "" {
+				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "❌ SSH is not configured for this VM. Cannot rotate key.")
+				return m, nil
+			}
+			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, "🔐 Rotating SSH key...")
+			vm := m.vmInfo
+			return m, m.vmInfo.runOperation("SSH key rotation", func(ctx context.Context) tea.Cmd {
+				return rotateSSHKey(ctx, vm.sandbox, vm.sshHost, vm.sshConfigPath, vm.sshPrivateKeyPath)
+			})
+		case "Extend Timeout":
+			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("⏱️  Extending VM lifetime by %s...", tuiExtendStep))
+			client, publicID := m.vmInfo.client, m.vmInfo.sandbox.PublicId
+			return m, m.vmInfo.runOperation("timeout extension", func(ctx context.Context) tea.Cmd {
+				return extendTimeout(ctx, client, publicID, tuiExtendStep)
+			})
+		case "Export Launch Spec":
+			spec, err := m.vmInfo.launchSpec()
+			if err == nil {
+				err = writeLaunchSpec(defaultLaunchSpecPath, spec)
+			}
+			if err != nil {
+				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("❌ %v", err))
+			} else {
+				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("✓ Wrote %s, replay it with: plato launch --spec %s", defaultLaunchSpecPath, defaultLaunchSpecPath))
+			}
+			m.vmInfo.refreshViewport()
+			return m, nil
 		case "Create Checkpoint":
 			// Load the config to get service
 			config, err := LoadPlatoConfig()
 			if err != nil {
-				errMsg := fmt.Sprintf("❌ Failed to load plato-config.yml: %v", err)
+				errMsg := fmt.Sprintf("❌ %v", err)
 				m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, errMsg)
 				logErrorToFile("plato_error.log", errMsg)
 				return m, nil
@@ -339,8 +416,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("Creating checkpoint for service: %s...", service))
-			m.vmInfo.runningCommand = true
-			return m, tea.Batch(m.vmInfo.spinner.Tick, createCheckpoint(m.config.client, m.vmInfo.sandbox.PublicId, service, dataset))
+			client, publicID := m.config.client, m.vmInfo.sandbox.PublicId
+			return m, m.vmInfo.runOperation("checkpoint", func(ctx context.Context) tea.Cmd {
+				return createCheckpoint(ctx, client, publicID, service, dataset)
+			})
 		}
 		return m, nil
 	}
@@ -356,7 +435,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Navigate to DB entry view
 			logDebug("No DB config for service %s, navigating to DB entry", datasetMsg.params.service)
 			return m, func() tea.Msg {
-				return navigateToDBEntryMsg{service: datasetMsg.params.service}
+				return navigateToDBEntryMsg{service: datasetMsg.params.service, params: datasetMsg.params, datasets: []string{datasetMsg.datasetName}}
 			}
 		}
 
@@ -365,20 +444,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Add status message
 		m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("Creating snapshot for service: %s, dataset: %s", datasetMsg.params.service, datasetMsg.datasetName))
-		m.vmInfo.runningCommand = true
 
 		// Trigger snapshot
-		return m, tea.Batch(
-			m.vmInfo.spinner.Tick,
-			createSnapshotWithCleanup(
-				m.config.client,
-				datasetMsg.params.publicID,
-				datasetMsg.params.jobGroupID,
-				datasetMsg.params.service,
+		client, params, snapshotBranch := m.config.client, datasetMsg.params, hubSnapshotBranch(m.vmInfo.config)
+		return m, m.vmInfo.runOperationWithProgress("snapshot", func(ctx context.Context, progress progressFunc) tea.Cmd {
+			return createSnapshotWithCleanup(
+				ctx,
+				client,
+				params.publicID,
+				params.jobGroupID,
+				params.service,
 				datasetPtr,
-				datasetMsg.params.lastPushedBranch,
-			),
-		)
+				params.lastPushedBranch,
+				snapshotBranch,
+				progress,
+			)
+		})
+	}
+
+	// Handle batch selection - snapshot the VM as each selected dataset
+	if datasetsMsg, ok := msg.(datasetsSelectedMsg); ok {
+		logDebug("Datasets selected: %v for service: %s", datasetsMsg.datasetNames, datasetsMsg.params.service)
+		m.currentView = ViewVMInfo
+
+		if _, hasConfig := utils.GetDBConfig(datasetsMsg.params.service); !hasConfig {
+			logDebug("No DB config for service %s, navigating to DB entry", datasetsMsg.params.service)
+			return m, func() tea.Msg {
+				return navigateToDBEntryMsg{service: datasetsMsg.params.service, params: datasetsMsg.params, datasets: datasetsMsg.datasetNames}
+			}
+		}
+		return m, m.startDatasetSnapshots(datasetsMsg.params, datasetsMsg.datasetNames)
 	}
 
 	// Handle DB config entered message - trigger snapshot with the entered config
@@ -386,18 +481,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		logDebug("DB config entered for service: %s", dbMsg.service)
 		m.currentView = ViewVMInfo
 
+		// The config is saved by now, so a batch goes through the same path
+		// as when it already existed
+		if len(dbMsg.datasets) > 1 {
+			return m, m.startDatasetSnapshots(dbMsg.params, dbMsg.datasets)
+		}
+
 		// Get dataset pointer
 		datasetPtr := &m.vmInfo.dataset
+		if len(dbMsg.datasets) == 1 {
+			datasetPtr = &dbMsg.datasets[0]
+		}
 
-		// Trigger snapshot with the user-provided DB config
-		return m, createSnapshotWithConfig(
-			m.config.client,
-			m.vmInfo.sandbox.PublicId,
-			m.vmInfo.sandbox.JobGroupId,
-			dbMsg.service,
-			datasetPtr,
-			dbMsg.config,
-		)
+		m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("Creating snapshot for service: %s, dataset: %s", dbMsg.service, *datasetPtr))
+
+		// The cleanup reads the config that was just saved
+		client, params, snapshotBranch := m.config.client, dbMsg.params, hubSnapshotBranch(m.vmInfo.config)
+		return m, m.vmInfo.runOperationWithProgress("snapshot", func(ctx context.Context, progress progressFunc) tea.Cmd {
+			return createSnapshotWithCleanup(
+				ctx,
+				client,
+				params.publicID,
+				params.jobGroupID,
+				dbMsg.service,
+				datasetPtr,
+				params.lastPushedBranch,
+				snapshotBranch,
+				progress,
+			)
+		})
 	}
 
 	// Handle flow config entered message - launch flow with user-provided config
@@ -419,6 +531,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.currentView = ViewMainMenu
 			return m, nil
 		}
+		if m.currentView == ViewSession && (k == "q" || k == "esc") {
+			m.currentView = ViewMainMenu
+			return m, nil
+		}
+		if m.currentView == ViewReconnect && (k == "q" || k == "esc") {
+			m.currentView = ViewMainMenu
+			return m, nil
+		}
 		if m.currentView == ViewLaunchEnvironment && (k == "q" || k == "esc") {
 			m.currentView = ViewMainMenu
 			return m, nil
@@ -447,6 +567,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Heartbeat warnings belong to the VM whichever view is showing, so the
+	// command waiting for the next one is not lost
+	if warning, ok := msg.(heartbeatWarningMsg); ok {
+		var cmd tea.Cmd
+		m.vmInfo, cmd = m.vmInfo.Update(warning)
+		return m, cmd
+	}
+	// So do the proxytunnel health checks
+	switch msg.(type) {
+	case tunnelHealthTickMsg, tunnelsProbedMsg, proxytunnelRestartedMsg:
+		var cmd tea.Cmd
+		m.vmInfo, cmd = m.vmInfo.Update(msg)
+		return m, cmd
+	// The log tail only runs while the VM view shows it
+	case logStreamOpenedMsg, logLinesMsg, logStreamEndedMsg:
+		if m.currentView != ViewVMInfo {
+			m.vmInfo.closeLogs()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.vmInfo, cmd = m.vmInfo.Update(msg)
+		return m, cmd
+	}
+
 	// Route updates to current view
 	var cmd tea.Cmd
 	switch m.currentView {
@@ -478,11 +622,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.advancedMenu, cmd = m.advancedMenu.Update(msg)
 	case ViewFlowEntry:
 		m.flowEntry, cmd = m.flowEntry.Update(msg)
+	case ViewSession:
+		m.session, cmd = m.session.Update(msg)
+	case ViewReconnect:
+		m.reconnect, cmd = m.reconnect.Update(msg)
 	}
 
 	return m, cmd
 }
 
+// startDatasetSnapshots snapshots the VM once as each of names
+func (m *Model) startDatasetSnapshots(params snapshotParams, names []string) tea.Cmd {
+	m.vmInfo.statusMessages = append(m.vmInfo.statusMessages, fmt.Sprintf("Creating %d snapshots for service: %s, datasets: %s", len(names), params.service, strings.Join(names, ", ")))
+
+	client, snapshotBranch := m.config.client, hubSnapshotBranch(m.vmInfo.config)
+	return m.vmInfo.runOperationWithProgress("snapshots", func(ctx context.Context, progress progressFunc) tea.Cmd {
+		return createSnapshotsForDatasets(
+			ctx,
+			client,
+			params.publicID,
+			params.jobGroupID,
+			params.service,
+			names,
+			params.lastPushedBranch,
+			snapshotBranch,
+			progress,
+		)
+	})
+}
+
+// failure returns the error of a VM launch or setup that failed in the TUI,
+// or nil if the last launch succeeded or none was attempted
+func (m Model) failure() error {
+	if m.vmConfig.launchErr != nil {
+		return m.vmConfig.launchErr
+	}
+	return m.vmInfo.setupErr
+}
+
 func (m Model) View() string {
 	if m.quitting {
 		return "bye!\n"
@@ -518,6 +695,10 @@ func (m Model) View() string {
 		return m.advancedMenu.View()
 	case ViewFlowEntry:
 		return m.flowEntry.View()
+	case ViewSession:
+		return m.session.View()
+	case ViewReconnect:
+		return m.reconnect.View()
 	default:
 		return "Unknown view\n"
 	}
@@ -532,7 +713,7 @@ func showCredentials() error {
 	ctx := context.Background()
 
 	// Get Gitea service
-	giteaService := services.NewGiteaService(config.client)
+	giteaService := config.client.Gitea
 
 	// Get credentials
 	creds, err := giteaService.GetCredentials(ctx)
@@ -564,7 +745,7 @@ func cloneService(serviceName string) error {
 	ctx := context.Background()
 
 	// Get Gitea service
-	giteaService := services.NewGiteaService(config.client)
+	giteaService := config.client.Gitea
 
 	// Get credentials
 	fmt.Println("🔑 Fetching credentials...")
@@ -573,25 +754,16 @@ func cloneService(serviceName string) error {
 		return fmt.Errorf("failed to get credentials: %w", err)
 	}
 
-	// List simulators to find the service
-	fmt.Println("📋 Listing available simulators...")
-	simulators, err := giteaService.ListSimulators(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list simulators: %w", err)
-	}
-
 	// Find the simulator by service name
-	var simulator *models.GiteaSimulator
-	for i := range simulators {
-		if strings.EqualFold(simulators[i].Name, serviceName) {
-			simulator = &simulators[i]
-			break
-		}
-	}
-
-	if simulator == nil {
+	fmt.Println("📋 Looking up the service...")
+	simulator, err := giteaService.GetSimulatorByName(ctx, serviceName)
+	var notFound *services.SimulatorNotFoundError
+	if errors.As(err, &notFound) {
 		return fmt.Errorf("service '%s' not found in hub", serviceName)
 	}
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("✓ Found service: %s\n", simulator.Name)
 
@@ -607,10 +779,10 @@ func cloneService(serviceName string) error {
 		return fmt.Errorf("failed to get repository: %w", err)
 	}
 
-	// Build authenticated clone URL
-	cloneURL := repo.CloneURL
-	if strings.HasPrefix(cloneURL, "https://") {
-		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	// Build the clone URL for the configured transport
+	cloneURL, err := giteaService.RemoteURL(repo, creds)
+	if err != nil {
+		return err
 	}
 
 	// Determine target directory (use service name)
@@ -622,6 +794,7 @@ func cloneService(serviceName string) error {
 	// Clone the repository
 	fmt.Printf("📥 Cloning repository to '%s'...\n", targetDir)
 	cmd := exec.Command("git", "clone", cloneURL, targetDir)
+	cmd.Env = giteaService.GitEnv()
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to clone repository: %w\nOutput: %s", err, string(output))
@@ -646,15 +819,71 @@ func main() {
 		fmt.Printf("Usage:\n")
 		fmt.Printf("  plato [command] [options]\n\n")
 		fmt.Printf("Commands:\n")
-		fmt.Printf("  clone <service>    Clone a service from Plato Hub to local machine\n")
+		fmt.Printf("  clone <service>    Clone a service from Plato Hub (PLATO_HUB_GIT_TRANSPORT=ssh to use your SSH key)\n")
 		fmt.Printf("  credentials        Display your Plato Hub credentials\n")
+		fmt.Printf("  ps                 List your sandboxes\n")
+		fmt.Printf("  env ls             List your active environments and their URLs\n")
+		fmt.Printf("  env reset-state    Clear the cached env state of a job group, leaving its database alone\n")
+		fmt.Printf("  session            Show resources the CLI created (--cleanup to remove them and stale SSH hosts)\n")
+		fmt.Printf("  login              Save and validate your API key (--keychain to use the OS keychain)\n")
+		fmt.Printf("  logout             Remove credentials saved by login\n")
+		fmt.Printf("  whoami             Show the environment, base URL and API key source in use\n")
+		fmt.Printf("  presets            List services with built-in database presets (--json, --show-secret)\n")
+		fmt.Printf("  config init        Write a starter plato-config.yml in the current directory\n")
+		fmt.Printf("  config show        Print the effective configuration and where each value came from (--json)\n")
+		fmt.Printf("  config migrate     Upgrade plato-config.yml to the current schema version (--dry-run)\n")
+		fmt.Printf("  ssh-config <id>    Print the SSH config block for a sandbox without connecting\n")
+		fmt.Printf("  tunnel <id>        Forward a local port to a sandbox port, reconnecting if it drops (--remote, --local, --strict, --reconnect=false)\n")
+		fmt.Printf("  tunnels prune      Stop proxytunnels left running by a CLI that exited (--dry-run to only list them)\n")
+		fmt.Printf("  launch             Launch a VM from plato-config.yml or a launch spec (--with-worker, --spec, --save-spec, --artifact-id, --version, --no-sandbox-file)\n")
+		fmt.Printf("  snapshot <id>      Snapshot a sandbox (--wait to block until the artifact is available, --tags to label it)\n")
+		fmt.Printf("  cleanup <id>       Clear the audit log and env state without snapshotting (--service, --dataset)\n")
+		fmt.Printf("  extend <id> <dur>  Add to a running VM's lifetime and print its new expiry\n")
+		fmt.Printf("  close [id]         Delete a VM and clean up its SSH setup without the TUI (default: .sandbox.yaml; --keep-ssh)\n")
+		fmt.Printf("  datasets [service] List the datasets with published versions of a service (--json)\n")
+		fmt.Printf("  versions [service] List the published versions of a service, newest first (--dataset, --tag, --json)\n")
+		fmt.Printf("  events <id>        Print the raw event stream for a correlation ID (exits non-zero if the operation fails)\n")
 		fmt.Printf("  --version, -v      Show version information\n")
 		fmt.Printf("  --help, -h         Show this help message\n\n")
 		fmt.Printf("Interactive Mode:\n")
-		fmt.Printf("  Run 'plato' without any commands to start the interactive TUI\n\n")
+		fmt.Printf("  Run 'plato' without any commands to start the interactive TUI\n")
+		fmt.Printf("  --provision-timeout and --setup-timeout change how long its launches wait (default 20m)\n")
+		fmt.Printf("  --no-sandbox-file writes .sandbox.yaml to ~/.plato/sandboxes/<id>.yaml instead of the current directory\n\n")
+		fmt.Printf("Environment:\n")
+		fmt.Printf("  PLATO_KEEP_TEMP=1  Keep the temporary hub checkout when a push or merge fails\n")
+		fmt.Printf("  PLATO_NO_SANDBOX_FILE=1  Same as --no-sandbox-file for the TUI and launch\n\n")
+		fmt.Printf("Exit Codes:\n")
+		fmt.Printf("  0  Success\n")
+		fmt.Printf("  1  Error (anything not listed below)\n")
+		fmt.Printf("  2  Usage error: unknown command, bad flag or missing argument\n")
+		fmt.Printf("  3  Authentication error: missing, invalid or unauthorized API key\n")
+		fmt.Printf("  4  Not found: sandbox, simulator, dataset or file does not exist\n")
+		fmt.Printf("  The interactive TUI exits non-zero if the last VM launch failed\n\n")
 		fmt.Printf("Examples:\n")
 		fmt.Printf("  plato clone espocrm          # Clone the espocrm service\n")
 		fmt.Printf("  plato credentials            # Show your Hub credentials\n")
+		fmt.Printf("  plato presets --json         # Dump the built-in DB presets for tooling\n")
+		fmt.Printf("  plato config init --db-type mysql  # Scaffold a config with a MySQL listener\n")
+		fmt.Printf("  plato config show --dataset large  # See which base URL, ports and ECR settings apply\n")
+		fmt.Printf("  plato ssh-config abc123 --user root  # Show the SSH block for a sandbox\n")
+		fmt.Printf("  plato tunnel abc123 --remote 8080 --local 8080 --strict  # Pin a tunnel to local port 8080\n")
+		fmt.Printf("  plato tunnels prune --dry-run  # See which leaked proxytunnels would be stopped\n")
+		fmt.Printf("  plato launch --dataset base --with-worker  # Launch a VM with the worker already running\n")
+		fmt.Printf("  plato launch --app-port 3000 # Launch a service that listens on port 3000\n")
+		fmt.Printf("  plato launch --save-spec spec.yaml  # Record the launch so a teammate can reproduce it\n")
+		fmt.Printf("  plato launch --spec spec.yaml  # Launch exactly what a shared spec describes\n")
+		fmt.Printf("  plato launch --dataset base --version 3  # Launch a published version (artifact_id or version in a dataset pins one)\n")
+		fmt.Printf("  plato launch --provision-timeout 45m --setup-timeout 30m  # Give a large dataset more time\n")
+		fmt.Printf("  plato launch --sync ./src:/home/plato/app --watch  # Keep local code synced into the VM\n")
+		fmt.Printf("  plato snapshot abc123 --dataset base --wait  # Snapshot and wait until it can be launched\n")
+		fmt.Printf("  plato snapshot abc123 --wait --output-dir out/  # Also write snapshot-<artifactID>.json for CI\n")
+		fmt.Printf("  plato snapshot abc123 --dataset base,seeded,demo  # Snapshot one VM state as several datasets\n")
+		fmt.Printf("  plato cleanup abc123 --service espocrm  # Reset database state mid-session\n")
+		fmt.Printf("  plato env reset-state 1a2b3c  # Bust the env state cache while debugging\n")
+		fmt.Printf("  plato extend abc123 2h       # Keep a VM alive for two more hours\n")
+		fmt.Printf("  plato datasets espocrm       # See which datasets can be launched\n")
+		fmt.Printf("  plato snapshot abc123 --tags stable,ci  # Tag the artifact so it can be found later\n")
+		fmt.Printf("  plato versions espocrm --tag stable  # List only the versions tagged stable\n")
 		fmt.Printf("  plato                        # Start interactive mode\n")
 		os.Exit(0)
 	}
@@ -672,12 +901,12 @@ func main() {
 		if len(os.Args) < 3 {
 			fmt.Println("Usage: plato clone <service>")
 			fmt.Println("Example: plato clone espocrm")
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 		serviceName := os.Args[2]
 		if err := cloneService(serviceName); err != nil {
 			fmt.Printf("Error cloning service: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 		os.Exit(0)
 	}
@@ -686,11 +915,229 @@ func main() {
 	if len(os.Args) > 1 && os.Args[1] == "credentials" {
 		if err := showCredentials(); err != nil {
 			fmt.Printf("Error fetching credentials: %v\n", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle ps command
+	if len(os.Args) > 1 && os.Args[1] == "ps" {
+		if err := listSandboxes(); err != nil {
+			fmt.Printf("Error listing sandboxes: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle env command
+	if len(os.Args) > 1 && os.Args[1] == "env" {
+		if err := runEnv(os.Args[2:]); err != nil {
+			fmt.Printf("Error running env command: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle session command
+	if len(os.Args) > 1 && os.Args[1] == "session" {
+		if err := runSession(os.Args[2:]); err != nil {
+			fmt.Printf("Error inspecting session: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle login command
+	if len(os.Args) > 1 && os.Args[1] == "login" {
+		if err := runLogin(os.Args[2:]); err != nil {
+			fmt.Printf("Error logging in: %v\n", err)
+			os.Exit(exitCode(err))
 		}
 		os.Exit(0)
 	}
 
+	// Handle logout command
+	if len(os.Args) > 1 && os.Args[1] == "logout" {
+		if err := runLogout(); err != nil {
+			fmt.Printf("Error logging out: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle whoami command
+	if len(os.Args) > 1 && os.Args[1] == "whoami" {
+		if err := runWhoami(); err != nil {
+			fmt.Printf("Error reading configuration: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle presets command
+	if len(os.Args) > 1 && os.Args[1] == "presets" {
+		if err := runPresets(os.Args[2:]); err != nil {
+			fmt.Printf("Error listing presets: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle config command
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if len(os.Args) >= 3 && os.Args[2] == "show" {
+			if err := runConfigShow(os.Args[3:]); err != nil {
+				fmt.Printf("Error showing config: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			os.Exit(0)
+		}
+		if len(os.Args) >= 3 && os.Args[2] == "migrate" {
+			if err := runConfigMigrate(os.Args[3:]); err != nil {
+				fmt.Printf("Error migrating config: %v\n", err)
+				os.Exit(exitCode(err))
+			}
+			os.Exit(0)
+		}
+		if len(os.Args) < 3 || os.Args[2] != "init" {
+			fmt.Println("Usage: plato config init [--service name] [--db-type postgresql|mysql] [--force]")
+			fmt.Println("       plato config show [--dataset name] [--json]")
+			fmt.Println("       plato config migrate [--dry-run]")
+			os.Exit(exitUsage)
+		}
+		if err := runConfigInit(os.Args[3:]); err != nil {
+			fmt.Printf("Error initializing config: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle ssh-config command
+	if len(os.Args) > 1 && os.Args[1] == "ssh-config" {
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: plato ssh-config <publicID> [--user plato] [--host name] [--port 2200] [--identity path]")
+			os.Exit(exitUsage)
+		}
+		if err := printSSHConfig(os.Args[2:]); err != nil {
+			fmt.Printf("Error generating SSH config: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle tunnel command
+	if len(os.Args) > 1 && os.Args[1] == "tunnel" {
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: plato tunnel <publicID> --remote <port> [--local <port>] [--strict] [--bind address]")
+			os.Exit(exitUsage)
+		}
+		if err := runTunnel(os.Args[2:]); err != nil {
+			fmt.Printf("Error opening tunnel: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle tunnels command
+	if len(os.Args) > 1 && os.Args[1] == "tunnels" {
+		if len(os.Args) < 3 || os.Args[2] != "prune" {
+			fmt.Println("Usage: plato tunnels prune [--dry-run]")
+			os.Exit(exitUsage)
+		}
+		if err := runTunnelsPrune(os.Args[3:]); err != nil {
+			fmt.Printf("Error pruning tunnels: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle events command
+	if len(os.Args) > 1 && os.Args[1] == "events" {
+		if err := runEvents(os.Args[2:]); err != nil {
+			fmt.Printf("Error following events: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle launch command
+	if len(os.Args) > 1 && os.Args[1] == "launch" {
+		if err := runLaunch(os.Args[2:]); err != nil {
+			fmt.Printf("Error launching VM: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle snapshot command
+	if len(os.Args) > 1 && os.Args[1] == "snapshot" {
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: plato snapshot <publicID> [--service name] [--dataset base] [--skip-cleanup] [--wait] [--timeout 30m]")
+			os.Exit(exitUsage)
+		}
+		if err := runSnapshot(os.Args[2:]); err != nil {
+			fmt.Printf("Error creating snapshot: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle cleanup command
+	if len(os.Args) > 1 && os.Args[1] == "cleanup" {
+		if err := runCleanup(os.Args[2:]); err != nil {
+			fmt.Printf("Error cleaning state: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle close command
+	if len(os.Args) > 1 && os.Args[1] == "close" {
+		if err := runClose(os.Args[2:]); err != nil {
+			fmt.Printf("Error closing VM: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle extend command
+	if len(os.Args) > 1 && os.Args[1] == "extend" {
+		if err := runExtend(os.Args[2:]); err != nil {
+			fmt.Printf("Error extending VM: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle datasets command
+	if len(os.Args) > 1 && os.Args[1] == "datasets" {
+		if err := runDatasets(os.Args[2:]); err != nil {
+			fmt.Printf("Error listing datasets: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	// Handle versions command
+	if len(os.Args) > 1 && os.Args[1] == "versions" {
+		if err := runVersions(os.Args[2:]); err != nil {
+			fmt.Printf("Error listing versions: %v\n", err)
+			os.Exit(exitCode(err))
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		fmt.Printf("Unknown command '%s'. Run 'plato --help' for usage.\n", os.Args[1])
+		os.Exit(exitUsage)
+	}
+
+	// Flags of the interactive TUI itself
+	if err := parseTUIFlags(os.Args[1:]); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
 	// Initialize debug logger
 	if err := utils.InitLogger(); err != nil {
 		fmt.Printf("Warning: failed to initialize logger: %v\n", err)
@@ -699,11 +1146,20 @@ func main() {
 	initialModel := newModel()
 	p := tea.NewProgram(initialModel)
 
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		fmt.Println("could not run program:", err)
+		os.Exit(exitError)
+	}
+	// Leaving the TUI after a failed launch is a failure too
+	if m, ok := finalModel.(Model); ok {
+		if err := m.failure(); err != nil {
+			os.Exit(exitCode(err))
+		}
 	}
 }
 type auditUILaunchedMsg struct {
+	cmd *exec.Cmd
 	err error
 }
 
@@ -727,8 +1183,10 @@ func launchAuditIgnoreUI() tea.Cmd {
 			return auditUILaunchedMsg{err: fmt.Errorf("uv not found - install from https://docs.astral.sh/uv/")}
 		}
 
-		// Launch streamlit (uv will auto-install dependencies: streamlit, psycopg2-binary, pymysql)
-		cmd := exec.Command("sh", "-c", fmt.Sprintf("uv run --with streamlit --with psycopg2-binary --with pymysql streamlit run %s &", scriptPath))
+		// Launch streamlit (uv will auto-install dependencies: streamlit, psycopg2-binary, pymysql).
+		// Run it in its own process group so it can be stopped along with its children.
+		cmd := exec.Command("uv", "run", "--with", "streamlit", "--with", "psycopg2-binary", "--with", "pymysql", "streamlit", "run", scriptPath)
+		startInProcessGroup(cmd)
 		err = cmd.Start()
 
 		if err != nil {
@@ -739,7 +1197,7 @@ func launchAuditIgnoreUI() tea.Cmd {
 		time.Sleep(2 * time.Second)
 		exec.Command("open", "http://localhost:8501").Start()
 
-		return auditUILaunchedMsg{err: nil}
+		return auditUILaunchedMsg{cmd: cmd, err: nil}
 	}
 }
 
@@ -753,10 +1211,9 @@ type stateRetrievedMsg struct {
 	err   error
 }
 
-func getEnvironmentState(client *plato.PlatoClient, jobGroupID string) tea.Cmd {
+func getEnvironmentState(ctx context.Context, client *plato.PlatoClient, jobGroupID string, received *atomic.Int64) tea.Cmd {
 	return func() tea.Msg {
-		ctx := context.Background()
-		state, err := client.Environment.GetState(ctx, jobGroupID, false)
+		state, err := client.Environment.GetStateWithProgress(ctx, jobGroupID, false, received.Store)
 		return stateRetrievedMsg{state: state, err: err}
 	}
 }
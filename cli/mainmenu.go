@@ -32,6 +32,8 @@ func (i menuItem) FilterValue() string { return i.title }
 func NewMainMenuModel() MainMenuModel {
 	items := []list.Item{
 		menuItem{title: "Launch Environment", description: "Start from an existing environment or a blank slate."},
+		menuItem{title: "Reconnect to VM", description: "Pick up a VM left running by an earlier session"},
+		menuItem{title: "Session", description: "Review and clean up VMs, tunnels and files created by the CLI"},
 		menuItem{title: "Configuration", description: "View API key and settings"},
 		menuItem{title: "Quit", description: "Exit the CLI"},
 	}
@@ -80,6 +82,14 @@ func (m MainMenuModel) Update(msg tea.Msg) (MainMenuModel, tea.Cmd) {
 					return m, func() tea.Msg {
 						return NavigateMsg{view: ViewLaunchEnvironment}
 					}
+				case "Reconnect to VM":
+					return m, func() tea.Msg {
+						return NavigateMsg{view: ViewReconnect}
+					}
+				case "Session":
+					return m, func() tea.Msg {
+						return NavigateMsg{view: ViewSession}
+					}
 				case "Configuration":
 					return m, func() tea.Msg {
 						return NavigateMsg{view: ViewConfig}
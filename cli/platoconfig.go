@@ -30,6 +30,9 @@ type launchFromConfigMsg struct {
 	datasetName   string
 	datasetConfig models.SimConfigDataset
 	service       string
+	// artifactID and version are set when the dataset pins an artifact
+	artifactID *string
+	version    *string
 }
 
 type datasetItem struct {
@@ -100,6 +103,13 @@ func (m PlatoConfigModel) Update(msg tea.Msg) (PlatoConfigModel, tea.Cmd) {
 
 		return m, nil
 
+	case pinnedArtifactResolvedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, func() tea.Msg { return msg.launch }
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		if !m.loading && m.config != nil {
@@ -115,14 +125,13 @@ func (m PlatoConfigModel) Update(msg tea.Msg) (PlatoConfigModel, tea.Cmd) {
 				selectedItem := m.datasetList.SelectedItem()
 				if selectedItem != nil {
 					dataset := selectedItem.(datasetItem)
-					// Navigate to VMConfigModel with the dataset config
-					return m, func() tea.Msg {
-						return launchFromConfigMsg{
-							datasetName:   dataset.name,
-							datasetConfig: dataset.config,
-							service:       m.config.Service,
-						}
-					}
+					// Navigate to VMConfigModel with the dataset config, once
+					// the artifact the dataset may pin is known
+					return m, resolvePinnedArtifact(m.client, launchFromConfigMsg{
+						datasetName:   dataset.name,
+						datasetConfig: dataset.config,
+						service:       m.config.Service,
+					})
 				}
 			}
 			return m, nil
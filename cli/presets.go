@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// redactedPassword replaces preset passwords unless --show-secret is given
+const redactedPassword = "********"
+
+// presetEntry is one service in the `plato presets --json` output
+type presetEntry struct {
+	Service string `json:"service"`
+	DBConfig
+}
+
+// presetEntries returns the built-in DB presets sorted by service name,
+// including the variants registered in init()
+func presetEntries(showSecret bool) []presetEntry {
+	entries := make([]presetEntry, 0, len(simDBConfigs))
+	for service, config := range simDBConfigs {
+		if !showSecret && config.Password != "" {
+			config.Password = redactedPassword
+		}
+		entries = append(entries, presetEntry{Service: service, DBConfig: config})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Service < entries[j].Service })
+	return entries
+}
+
+// runPresets implements `plato presets`, listing the services with a built-in
+// database preset. Passwords are redacted unless --show-secret is passed.
+func runPresets(args []string) error {
+	fs := flag.NewFlagSet("presets", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the presets as JSON")
+	showSecret := fs.Bool("show-secret", false, "Include database passwords")
+	fs.Parse(args)
+
+	entries := presetEntries(*showSecret)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal presets: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tDB TYPE\tPORT\tUSER\tPASSWORD\tDATABASES")
+	for _, e := range entries {
+		password := e.Password
+		if e.PasswordEnv != "" {
+			// The variable is read at cleanup time and wins over a literal
+			password = "$" + e.PasswordEnv
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", e.Service, e.DBType, e.DestPort, e.User, password, strings.Join(e.Databases, ","))
+	}
+	return w.Flush()
+}
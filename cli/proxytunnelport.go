@@ -23,11 +23,16 @@ type openTunnelMsg struct {
 	remotePort int
 }
 
-func NewProxytunnelPortModel(publicID string) ProxytunnelPortModel {
+// NewProxytunnelPortModel prompts for the remote port, pre-filled with
+// defaultPort (usually the VM's app port) when it is set
+func NewProxytunnelPortModel(publicID string, defaultPort int) ProxytunnelPortModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter port number (1-65535)"
 	ti.CharLimit = 5
 	ti.Width = 40
+	if defaultPort > 0 {
+		ti.SetValue(strconv.Itoa(defaultPort))
+	}
 	ti.Focus()
 
 	return ProxytunnelPortModel{
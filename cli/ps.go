@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// listSandboxes implements `plato ps`, printing the caller's sandboxes
+func listSandboxes() error {
+	config := NewConfigModel()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sandboxes, err := config.client.Sandbox.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sandboxes: %w", err)
+	}
+
+	if len(sandboxes) == 0 {
+		fmt.Println("No running sandboxes")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPUBLIC ID\tSTATUS\tURL")
+	for _, sandbox := range sandboxes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", sandbox.DisplayName(), sandbox.PublicId, sandbox.Status, sandbox.Url)
+	}
+	return w.Flush()
+}
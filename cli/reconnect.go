@@ -0,0 +1,292 @@
+// Package main provides the reconnect view for the Plato CLI.
+//
+// This file implements the ReconnectModel which lists VMs saved in
+// ~/.plato/sessions.json by earlier runs and reopens the VM management view
+// for the one picked, restarting its heartbeat.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"plato-cli/internal/session"
+	"plato-cli/internal/ui/components"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+	"plato-sdk/models"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type ReconnectModel struct {
+	client  *plato.PlatoClient
+	list    list.Model
+	spinner spinner.Model
+	loading bool
+	// checking is the public ID of the VM being checked before reconnecting
+	checking string
+	message  string
+	err      error
+}
+
+// vmSessionItem is a saved VM in the reconnect list
+type vmSessionItem struct {
+	vm session.VM
+}
+
+func (i vmSessionItem) Title() string {
+	if i.vm.Alias != "" && i.vm.Alias != i.vm.PublicID {
+		return fmt.Sprintf("%s (%s)", i.vm.Alias, i.vm.PublicID)
+	}
+	return i.vm.PublicID
+}
+
+func (i vmSessionItem) Description() string {
+	var parts []string
+	if i.vm.Service != "" {
+		parts = append(parts, "service "+i.vm.Service)
+	}
+	if i.vm.Dataset != "" {
+		parts = append(parts, "dataset "+i.vm.Dataset)
+	}
+	parts = append(parts, "launched "+i.vm.CreatedAt.Local().Format("Jan 2 15:04"))
+	if i.vm.Dir != "" {
+		parts = append(parts, "in "+i.vm.Dir)
+	}
+	return strings.Join(parts, " • ")
+}
+
+func (i vmSessionItem) FilterValue() string { return i.Title() }
+
+type vmSessionsLoadedMsg struct {
+	vms []session.VM
+	err error
+}
+
+// vmSessionCheckedMsg is the result of checking that a saved VM is still alive
+type vmSessionCheckedMsg struct {
+	vm      session.VM
+	expired bool
+	err     error
+}
+
+// reconnectVMMsg asks the main model to open the VM management view for vm
+type reconnectVMMsg struct {
+	vm session.VM
+}
+
+func NewReconnectModel(client *plato.PlatoClient) ReconnectModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+
+	l := list.New(nil, list.NewDefaultDelegate(), 80, 15)
+	l.Title = "Reconnect to VM"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+
+	return ReconnectModel{client: client, list: l, spinner: s, loading: true}
+}
+
+func loadVMSessions() tea.Msg {
+	vms, err := session.ListVMs()
+	return vmSessionsLoadedMsg{vms: vms, err: err}
+}
+
+// checkVMSession sends a heartbeat for vm, which both shows whether the VM is
+// still alive and keeps it so until the VM view takes over. A VM the API no
+// longer knows has expired and is removed from the saved sessions.
+func checkVMSession(client *plato.PlatoClient, vm session.VM) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		err := client.Sandbox.SendHeartbeat(ctx, vm.JobGroupID)
+		if vmExpired(err) {
+			forgetVMSession(vm.PublicID)
+			// Keys and files stay tracked so `plato session --cleanup` removes them
+			forgetResource(session.KindVM, vm.PublicID)
+			return vmSessionCheckedMsg{vm: vm, expired: true}
+		}
+		return vmSessionCheckedMsg{vm: vm, err: err}
+	}
+}
+
+func (m ReconnectModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadVMSessions)
+}
+
+func (m ReconnectModel) Update(msg tea.Msg) (ReconnectModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, 15)
+		return m, nil
+
+	case vmSessionsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		items := make([]list.Item, 0, len(msg.vms))
+		for _, vm := range msg.vms {
+			items = append(items, vmSessionItem{vm: vm})
+		}
+		return m, m.list.SetItems(items)
+
+	case vmSessionCheckedMsg:
+		m.checking = ""
+		switch {
+		case msg.expired:
+			m.message = fmt.Sprintf("VM %s has expired and was removed from the list", msg.vm.PublicID)
+			m.loading = true
+			return m, tea.Batch(m.spinner.Tick, loadVMSessions)
+		case msg.err != nil:
+			m.err = fmt.Errorf("could not reach VM %s: %w", msg.vm.PublicID, msg.err)
+			return m, nil
+		}
+		vm := msg.vm
+		return m, func() tea.Msg {
+			return reconnectVMMsg{vm: vm}
+		}
+
+	case tea.KeyMsg:
+		if m.loading || m.checking != "" {
+			return m, nil
+		}
+		switch msg.String() {
+		case "r":
+			m.loading = true
+			m.message = ""
+			m.err = nil
+			return m, tea.Batch(m.spinner.Tick, loadVMSessions)
+		case "enter":
+			item, ok := m.list.SelectedItem().(vmSessionItem)
+			if !ok {
+				return m, nil
+			}
+			m.checking = item.vm.PublicID
+			m.message = ""
+			m.err = nil
+			return m, tea.Batch(m.spinner.Tick, checkVMSession(m.client, item.vm))
+		}
+
+	case spinner.TickMsg:
+		if !m.loading && m.checking == "" {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m ReconnectModel) View() string {
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B6B"))
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		MarginTop(1)
+
+	var content strings.Builder
+	content.WriteString(components.RenderHeader())
+	content.WriteString("\n")
+
+	var status string
+	switch {
+	case m.loading:
+		status = m.spinner.View() + " Loading saved VMs..."
+	case m.checking != "":
+		status = m.spinner.View() + fmt.Sprintf(" Checking that %s is still running...", m.checking)
+	case m.err != nil:
+		status = errorStyle.Render("❌ " + m.err.Error())
+	case m.message != "":
+		status = m.message
+	}
+
+	if !m.loading && len(m.list.Items()) == 0 {
+		if status != "" {
+			content.WriteString(lipgloss.NewStyle().MarginLeft(2).Render(status) + "\n\n")
+		}
+		content.WriteString(lipgloss.NewStyle().MarginLeft(2).Render("No saved VMs - VMs launched from this machine show up here until they are closed."))
+		content.WriteString("\n")
+	} else {
+		content.WriteString(m.list.View())
+		content.WriteString("\n")
+		if status != "" {
+			content.WriteString(lipgloss.NewStyle().MarginLeft(2).Render(status))
+			content.WriteString("\n")
+		}
+	}
+
+	content.WriteString(helpStyle.Render("  enter: reconnect • r: refresh • esc/q: back"))
+	return content.String()
+}
+
+// newReconnectedVMInfoModel rebuilds the VM management view from a saved VM.
+// Tunnels whose proxytunnel is still running are picked up again so Close VM
+// stops them; the heartbeat restarts when the view is initialised.
+func newReconnectedVMInfoModel(client *plato.PlatoClient, vm session.VM) VMInfoModel {
+	sandbox := &models.Sandbox{
+		JobId:      vm.JobID,
+		PublicId:   vm.PublicID,
+		JobGroupId: vm.JobGroupID,
+		Url:        vm.URL,
+		Status:     "running",
+		Alias:      vm.Alias,
+	}
+	var artifactID, version *string
+	if vm.ArtifactID != "" {
+		artifactID = &vm.ArtifactID
+	}
+	if vm.Version != "" {
+		version = &vm.Version
+	}
+
+	m := NewVMInfoModel(client, sandbox, vm.Dataset, artifactID != nil, artifactID, version)
+	m.setupComplete = true
+	m.launchDir = vm.Dir
+	m.sshURL = vm.SSHURL
+	m.sshHost = vm.SSHHost
+	m.sshConfigPath = vm.SSHConfigPath
+	m.sshPrivateKeyPath = vm.SSHPrivateKeyPath
+
+	for _, tunnel := range vm.Tunnels {
+		if !isProxytunnelProcess(tunnel.PID) {
+			continue
+		}
+		process, err := os.FindProcess(tunnel.PID)
+		if err != nil {
+			continue
+		}
+		m.proxytunnelProcesses = append(m.proxytunnelProcesses, &exec.Cmd{Process: process})
+		m.proxytunnelMappings = append(m.proxytunnelMappings, proxytunnelMapping{
+			bindAddress: tunnel.BindAddress,
+			localPort:   tunnel.LocalPort,
+			remotePort:  tunnel.RemotePort,
+		})
+	}
+
+	m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Reconnected to %s, heartbeats restarted", sandbox.DisplayName()))
+	if cwd, err := os.Getwd(); err == nil && vm.Dir != "" && cwd != vm.Dir {
+		m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  This VM was launched from %s; plato-config.yml is read from the current directory", vm.Dir))
+	}
+	if m.sshHost != "" && m.sshConfigPath != "" {
+		if _, err := os.Stat(m.sshConfigPath); err != nil {
+			utils.LogDebug("Saved SSH config %s for %s is gone: %v", m.sshConfigPath, vm.PublicID, err)
+			m.statusMessages = append(m.statusMessages, "⚠️  The SSH config for this VM no longer exists; SSH actions will fail")
+		}
+	}
+	m.refreshActionAvailability()
+	m.refreshViewport()
+	return m
+}
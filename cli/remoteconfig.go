@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path"
+
+	"plato-sdk/models"
+)
+
+const (
+	defaultRemoteWorktreePath = "/home/plato/worktree"
+	defaultRemoteDockerHost   = "unix:///var/run/docker-user.sock"
+)
+
+// remoteSettings describes the layout of the VM image that services are started on
+type remoteSettings struct {
+	worktreePath string
+	dockerHost   string
+}
+
+// resolveRemoteSettings reads the remote section of plato-config.yml, filling in
+// the defaults for the standard rootless-docker VM image
+func resolveRemoteSettings(config *models.PlatoConfig) remoteSettings {
+	settings := remoteSettings{
+		worktreePath: defaultRemoteWorktreePath,
+		dockerHost:   defaultRemoteDockerHost,
+	}
+	if config != nil && config.Remote != nil {
+		if config.Remote.WorktreePath != "" {
+			settings.worktreePath = config.Remote.WorktreePath
+		}
+		if config.Remote.DockerHost != "" {
+			settings.dockerHost = config.Remote.DockerHost
+		}
+	}
+	return settings
+}
+
+// repoDir returns where a service's repository is cloned on the VM
+func (s remoteSettings) repoDir(serviceName string) string {
+	return path.Join(s.worktreePath, serviceName)
+}
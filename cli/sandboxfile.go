@@ -1,13 +1,17 @@
 // Package main provides sandbox file management utilities for the Plato CLI.
 //
 // This file implements functions to write and remove a .sandbox.yaml file
-// in the current working directory when a VM is started or stopped.
+// in the current working directory when a VM is started or stopped. With
+// --no-sandbox-file or PLATO_NO_SANDBOX_FILE the file goes to
+// ~/.plato/sandboxes/<publicID>.yaml instead.
 package main
 
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"plato-sdk/models"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,6 +19,7 @@ import (
 // SandboxFileData represents the contents of .sandbox.yaml
 type SandboxFileData struct {
 	PublicID          string  `yaml:"public_id"`
+	Alias             string  `yaml:"alias,omitempty"`
 	JobGroupID        string  `yaml:"job_group_id"`
 	URL               string  `yaml:"url"`
 	Dataset           string  `yaml:"dataset"`
@@ -26,10 +31,48 @@ type SandboxFileData struct {
 	SSHPrivateKeyPath string  `yaml:"ssh_private_key_path"`
 }
 
-// WriteSandboxFile writes .sandbox.yaml to the current working directory
+const sandboxFileName = ".sandbox.yaml"
+
+// noSandboxFileEnv names the environment variable that keeps .sandbox.yaml
+// out of the working directory, like --no-sandbox-file
+const noSandboxFileEnv = "PLATO_NO_SANDBOX_FILE"
+
+// noSandboxFile is set by --no-sandbox-file
+var noSandboxFile bool
+
+// sandboxFileInCwd reports whether .sandbox.yaml is written to the current
+// working directory, which is the default
+func sandboxFileInCwd() bool {
+	if noSandboxFile {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(noSandboxFileEnv))) {
+	case "1", "true", "yes", "on":
+		return false
+	}
+	return true
+}
+
+// savedSandboxFilePath returns where the sandbox file for publicID is kept
+// when it is not written to the working directory
+func savedSandboxFilePath(publicID string) string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "sandboxes", publicID+".yaml")
+}
+
+// sandboxFilePath returns where the sandbox file for publicID is written
+func sandboxFilePath(publicID string) string {
+	if sandboxFileInCwd() {
+		return sandboxFileName
+	}
+	return savedSandboxFilePath(publicID)
+}
+
+// WriteSandboxFile writes .sandbox.yaml to the current working directory, or
+// to ~/.plato/sandboxes when that is turned off
 func WriteSandboxFile(sandbox *models.Sandbox, dataset string, platoConfigPath string, artifactID *string, version *string, sshHost string, sshConfigPath string, sshPrivateKeyPath string) error {
 	data := SandboxFileData{
 		PublicID:          sandbox.PublicId,
+		Alias:             sandbox.Alias,
 		JobGroupID:        sandbox.JobGroupId,
 		URL:               sandbox.Url,
 		Dataset:           dataset,
@@ -46,32 +89,44 @@ func WriteSandboxFile(sandbox *models.Sandbox, dataset string, platoConfigPath s
 		return fmt.Errorf("failed to marshal sandbox data: %w", err)
 	}
 
-	if err := os.WriteFile(".sandbox.yaml", yamlData, 0644); err != nil {
-		return fmt.Errorf("failed to write .sandbox.yaml: %w", err)
+	path := sandboxFilePath(sandbox.PublicId)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, yamlData, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
 
 	return nil
 }
 
-// RemoveSandboxFile removes .sandbox.yaml from the current working directory
-func RemoveSandboxFile() error {
-	err := os.Remove(".sandbox.yaml")
+// RemoveSandboxFile removes the sandbox file WriteSandboxFile wrote for publicID
+func RemoveSandboxFile(publicID string) error {
+	return removeSandboxFile(sandboxFilePath(publicID))
+}
+
+func removeSandboxFile(path string) error {
+	err := os.Remove(path)
 	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove .sandbox.yaml: %w", err)
+		return fmt.Errorf("failed to remove %s: %w", path, err)
 	}
 	return nil
 }
 
 // ReadSandboxFile reads .sandbox.yaml from the current working directory
 func ReadSandboxFile() (*SandboxFileData, error) {
-	data, err := os.ReadFile(".sandbox.yaml")
+	return readSandboxFile(sandboxFileName)
+}
+
+func readSandboxFile(path string) (*SandboxFileData, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read .sandbox.yaml: %w", err)
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
 	var sandboxData SandboxFileData
 	if err := yaml.Unmarshal(data, &sandboxData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal .sandbox.yaml: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
 	}
 
 	return &sandboxData, nil
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+)
+
+// serviceStartOrder returns the service names so that every service comes
+// after the ones it depends on. Services without ordering constraints are
+// started alphabetically so runs are reproducible.
+func serviceStartOrder(services map[string]models.SimConfigService) ([]string, error) {
+	remaining := make(map[string]int, len(services))
+	dependents := make(map[string][]string)
+	for name, service := range services {
+		remaining[name] = 0
+		for _, dep := range service.DependsOn {
+			if _, ok := services[dep]; !ok {
+				return nil, fmt.Errorf("service '%s' depends on unknown service '%s'", name, dep)
+			}
+			if dep == name {
+				return nil, fmt.Errorf("service '%s' depends on itself", name)
+			}
+			remaining[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready []string
+	for name, count := range remaining {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	var order []string
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) < len(services) {
+		var cycle []string
+		for name, count := range remaining {
+			if count > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("dependency cycle involving services: %s", strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+// hasDependents reports whether any service lists name in depends_on
+func hasDependents(services map[string]models.SimConfigService, name string) bool {
+	for _, service := range services {
+		for _, dep := range service.DependsOn {
+			if dep == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// composeContainer is one entry of `docker compose ps --format json`
+type composeContainer struct {
+	Name    string `json:"Name"`
+	Service string `json:"Service"`
+	State   string `json:"State"`
+	Health  string `json:"Health"`
+}
+
+// parseComposePS accepts both output styles of `docker compose ps --format
+// json`: a single array (older releases) or one object per line
+func parseComposePS(output string) ([]composeContainer, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(output, "[") {
+		var containers []composeContainer
+		if err := json.Unmarshal([]byte(output), &containers); err != nil {
+			return nil, err
+		}
+		return containers, nil
+	}
+
+	var containers []composeContainer
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var c composeContainer
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			return nil, err
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// unhealthyContainers lists the required containers that are not yet running
+// and healthy. A container without a health check only has to be running.
+func unhealthyContainers(containers []composeContainer, required []string) []string {
+	all := len(required) == 0
+	wanted := make(map[string]bool)
+	for _, name := range required {
+		if name == "all" {
+			all = true
+		}
+		wanted[name] = true
+	}
+
+	var pending []string
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		if !all && !wanted[c.Name] && !wanted[c.Service] {
+			continue
+		}
+		seen[c.Name], seen[c.Service] = true, true
+		if c.State != "running" || (c.Health != "" && c.Health != "healthy") {
+			pending = append(pending, c.Name)
+		}
+	}
+	if !all {
+		for _, name := range required {
+			if !seen[name] {
+				pending = append(pending, name)
+			}
+		}
+	}
+	return pending
+}
+
+// waitForComposeHealthy polls the compose project until the service's
+// required_healthy_containers are healthy or healthy_wait_timeout elapses
+func waitForComposeHealthy(ctx context.Context, sshConfigPath, sshHost, repoDir, dockerHost, composeFile string, service models.SimConfigService) error {
+	timeout := time.Duration(service.HealthyWaitTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 300 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	psCmd := fmt.Sprintf("cd %s && DOCKER_HOST=%s docker compose -f %s ps --format json", utils.ShellQuote(repoDir), utils.ShellQuote(dockerHost), utils.ShellQuote(composeFile))
+	var pending []string
+	for {
+		stdout, _, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, psCmd)
+		if err == nil {
+			containers, parseErr := parseComposePS(stdout)
+			if parseErr != nil {
+				return fmt.Errorf("failed to read compose status: %w", parseErr)
+			}
+			pending = unhealthyContainers(containers, service.RequiredHealthyContainers)
+			if len(pending) == 0 {
+				return nil
+			}
+		} else {
+			utils.LogDebug("docker compose ps failed, retrying: %v", err)
+		}
+
+		if time.Now().After(deadline) {
+			if len(pending) > 0 {
+				return fmt.Errorf("containers not healthy after %s: %s", timeout, strings.Join(pending, ", "))
+			}
+			return fmt.Errorf("could not check container health within %s", timeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"plato-cli/internal/session"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+	"plato-sdk/models"
+)
+
+// sessionEntry is a tracked resource together with its current state
+type sessionEntry struct {
+	resource session.Resource
+	status   string
+}
+
+// sessionSections lists the kinds shown in the session summary, in display order
+var sessionSections = []struct {
+	kind  session.Kind
+	title string
+}{
+	{session.KindVM, "Open VMs"},
+	{session.KindTunnel, "Active tunnels"},
+	{session.KindBranch, "Pushed branches not yet snapshotted"},
+	{session.KindSSHKey, "SSH keys"},
+	{session.KindTempFile, "Temp files"},
+}
+
+// recordResource tracks a created resource, logging rather than failing on errors
+func recordResource(kind session.Kind, id, publicID, detail string) {
+	if err := session.Record(session.Resource{Kind: kind, ID: id, PublicID: publicID, Detail: detail}); err != nil {
+		utils.LogDebug("Failed to record %s %s: %v", kind, id, err)
+	}
+}
+
+// forgetResource stops tracking a resource that was cleaned up
+func forgetResource(kind session.Kind, id string) {
+	if err := session.Forget(kind, id); err != nil {
+		utils.LogDebug("Failed to forget %s %s: %v", kind, id, err)
+	}
+}
+
+// recordLaunchedVM tracks a freshly set up VM along with its SSH key and config file
+func recordLaunchedVM(sandbox *models.Sandbox, sshHost, sshConfigPath, sshPrivateKeyPath string) {
+	publicID := sandbox.PublicId
+	recordResource(session.KindVM, publicID, publicID, sandbox.DisplayName())
+	if sshPrivateKeyPath != "" {
+		recordResource(session.KindSSHKey, sshPrivateKeyPath, publicID, sshHost)
+	}
+	if sshConfigPath != "" {
+		recordResource(session.KindTempFile, sshConfigPath, publicID, "SSH config")
+	}
+}
+
+// newVMSession describes a launched VM for Reconnect to VM. The SSH setup is
+// filled in by the caller once it exists.
+func newVMSession(sandbox *models.Sandbox, dataset, service string, artifactID, version *string) session.VM {
+	vm := session.VM{
+		PublicID:   sandbox.PublicId,
+		JobID:      sandbox.JobId,
+		JobGroupID: sandbox.JobGroupId,
+		Alias:      sandbox.Alias,
+		URL:        sandbox.Url,
+		Dataset:    dataset,
+		Service:    service,
+	}
+	if artifactID != nil {
+		vm.ArtifactID = *artifactID
+	}
+	if version != nil {
+		vm.Version = *version
+	}
+	if dir, err := os.Getwd(); err == nil {
+		vm.Dir = dir
+	}
+	return vm
+}
+
+// saveVMSession stores vm for Reconnect to VM, logging rather than failing on errors
+func saveVMSession(vm session.VM) {
+	if err := session.SaveVM(vm); err != nil {
+		utils.LogDebug("Failed to save VM session %s: %v", vm.PublicID, err)
+	}
+}
+
+// forgetVMSession drops a closed or expired VM from Reconnect to VM
+func forgetVMSession(publicID string) {
+	if err := session.RemoveVM(publicID); err != nil {
+		utils.LogDebug("Failed to remove VM session %s: %v", publicID, err)
+	}
+}
+
+// inspectSession loads tracked resources and checks which still exist.
+// Resources that are already gone are pruned from the session file.
+func inspectSession(client *plato.PlatoClient) ([]sessionEntry, error) {
+	resources, err := session.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// One API call covers every tracked VM; if it fails, VMs are kept as "unknown"
+	var liveVMs map[string]string
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if sandboxes, err := client.Sandbox.List(ctx); err == nil {
+		liveVMs = make(map[string]string, len(sandboxes))
+		for _, sb := range sandboxes {
+			liveVMs[sb.PublicId] = sb.Status
+		}
+	} else {
+		utils.LogDebug("Failed to list sandboxes for session view: %v", err)
+	}
+
+	var entries []sessionEntry
+	for _, r := range resources {
+		status, alive := resourceStatus(r, liveVMs)
+		if !alive {
+			forgetResource(r.Kind, r.ID)
+			continue
+		}
+		entries = append(entries, sessionEntry{resource: r, status: status})
+	}
+	return entries, nil
+}
+
+// resourceStatus reports whether r still exists and a short description of its state
+func resourceStatus(r session.Resource, liveVMs map[string]string) (string, bool) {
+	switch r.Kind {
+	case session.KindVM:
+		if liveVMs == nil {
+			return "unknown", true
+		}
+		status, ok := liveVMs[r.ID]
+		if status == "" {
+			status = "running"
+		}
+		return status, ok
+	case session.KindTunnel:
+		pid, err := strconv.Atoi(r.ID)
+		if err != nil || !isProxytunnelProcess(pid) {
+			return "", false
+		}
+		return "running", true
+	case session.KindSSHKey, session.KindTempFile:
+		if _, err := os.Stat(r.ID); err != nil {
+			return "", false
+		}
+		return "on disk", true
+	case session.KindBranch:
+		return "not snapshotted", true
+	}
+	return "", false
+}
+
+// isProxytunnelProcess checks that pid is alive and still a proxytunnel, so a
+// recycled PID never gets signalled
+func isProxytunnelProcess(pid int) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output()
+	return err == nil && strings.Contains(string(out), "proxytunnel")
+}
+
+// cleanupSession releases every entry except hub branches, which can only be
+// merged by a snapshot. It returns one result line per resource.
+func cleanupSession(client *plato.PlatoClient, entries []sessionEntry) []string {
+	var results []string
+	for _, e := range entries {
+		r := e.resource
+		var err error
+		switch r.Kind {
+		case session.KindBranch:
+			results = append(results, fmt.Sprintf("⏭  Kept branch %s (%s) - snapshot to merge it", r.ID, r.Detail))
+			continue
+		case session.KindTunnel:
+			pid, _ := strconv.Atoi(r.ID)
+			err = terminateProcess(pid)
+		case session.KindVM:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err = client.Sandbox.DeleteVM(ctx, r.ID)
+			cancel()
+			if vmExpired(err) {
+				err = nil
+			}
+			if err == nil {
+				forgetVMSession(r.ID)
+			}
+		case session.KindSSHKey:
+			err = utils.CleanupSSHKeyPair(r.ID)
+		case session.KindTempFile:
+			err = os.Remove(r.ID)
+		}
+
+		if err != nil {
+			results = append(results, fmt.Sprintf("❌ %s %s: %v", r.Kind, r.ID, err))
+			continue
+		}
+		forgetResource(r.Kind, r.ID)
+		results = append(results, fmt.Sprintf("✓ Removed %s %s", r.Kind, r.ID))
+	}
+	return results
+}
+
+// formatSessionSummary renders entries grouped by kind
+func formatSessionSummary(entries []sessionEntry) string {
+	if len(entries) == 0 {
+		return "Nothing tracked - everything created by the CLI has been cleaned up.\n"
+	}
+
+	var b strings.Builder
+	for _, section := range sessionSections {
+		var lines []string
+		for _, e := range entries {
+			if e.resource.Kind != section.kind {
+				continue
+			}
+			line := fmt.Sprintf("  • %s", e.resource.ID)
+			if e.resource.Detail != "" {
+				line += fmt.Sprintf(" (%s)", e.resource.Detail)
+			}
+			line += fmt.Sprintf(" - %s, since %s", e.status, e.resource.CreatedAt.Local().Format("Jan 2 15:04"))
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s (%d)\n%s\n\n", section.title, len(lines), strings.Join(lines, "\n"))
+	}
+	return b.String()
+}
+
+// runSession implements `plato session`, showing and optionally cleaning up tracked resources
+func runSession(args []string) error {
+	fs := flag.NewFlagSet("session", flag.ExitOnError)
+	cleanup := fs.Bool("cleanup", false, "Delete tracked VMs, stop tunnels and remove keys and temp files")
+	fs.Parse(args)
+
+	client := NewConfigModel().client
+	entries, err := inspectSession(client)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(formatSessionSummary(entries))
+	if !*cleanup {
+		for _, line := range sshConfigWarnings() {
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	if len(entries) > 0 {
+		fmt.Println("🧹 Cleaning up...")
+		for _, line := range cleanupSession(client, entries) {
+			fmt.Println(line)
+		}
+	}
+	for _, line := range reconcileSSHConfig() {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// sshConfigWarnings reports duplicate and colliding hosts in ~/.ssh/config
+// without changing it
+func sshConfigWarnings() []string {
+	existingConfig, err := utils.ReadSSHConfig()
+	if err != nil {
+		return []string{fmt.Sprintf("⚠️  Could not read ~/.ssh/config: %v", err)}
+	}
+
+	var lines []string
+	if dups := utils.DuplicateSSHHosts(existingConfig); len(dups) > 0 {
+		lines = append(lines, fmt.Sprintf("⚠️  ~/.ssh/config defines these hosts more than once: %s (run with --cleanup to remove stale Plato entries)", strings.Join(dups, ", ")))
+	}
+	for _, host := range utils.SSHHostCollisions(existingConfig) {
+		lines = append(lines, fmt.Sprintf("⚠️  Plato host %s collides with your own entry in ~/.ssh/config", host))
+	}
+	return lines
+}
+
+// reconcileSSHConfig removes stale duplicate Plato hosts from ~/.ssh/config,
+// returning one result line per change or warning
+func reconcileSSHConfig() []string {
+	removed, collisions, err := utils.ReconcileSSHConfig()
+	if err != nil {
+		return []string{fmt.Sprintf("❌ ssh config: %v", err)}
+	}
+
+	var lines []string
+	for _, host := range removed {
+		lines = append(lines, fmt.Sprintf("✓ Removed stale duplicate of SSH host %s", host))
+	}
+	for _, host := range collisions {
+		lines = append(lines, fmt.Sprintf("⚠️  Plato host %s collides with your own entry in ~/.ssh/config - rename yours to avoid connecting to the wrong machine", host))
+	}
+	return lines
+}
+
+// branchDetail describes a pushed hub branch by the service it belongs to
+func branchDetail(config *models.PlatoConfig) string {
+	if config == nil || config.Service == "" {
+		return ""
+	}
+	return "service " + config.Service
+}
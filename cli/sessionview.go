@@ -0,0 +1,141 @@
+// Package main provides the session view for the Plato CLI.
+//
+// This file implements the SessionModel which lists the VMs, tunnels, hub
+// branches, keys and temp files the CLI has created and offers bulk cleanup.
+package main
+
+import (
+	"strings"
+
+	"plato-cli/internal/ui/components"
+	plato "plato-sdk"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type SessionModel struct {
+	client  *plato.PlatoClient
+	spinner spinner.Model
+	loading bool
+	entries []sessionEntry
+	results []string
+	err     error
+}
+
+type sessionLoadedMsg struct {
+	entries []sessionEntry
+	err     error
+}
+
+type sessionCleanedMsg struct {
+	results []string
+}
+
+func NewSessionModel(client *plato.PlatoClient) SessionModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	return SessionModel{client: client, spinner: s, loading: true}
+}
+
+func loadSession(client *plato.PlatoClient) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := inspectSession(client)
+		return sessionLoadedMsg{entries: entries, err: err}
+	}
+}
+
+func (m SessionModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, loadSession(m.client))
+}
+
+func (m SessionModel) Update(msg tea.Msg) (SessionModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sessionLoadedMsg:
+		m.loading = false
+		m.entries = msg.entries
+		m.err = msg.err
+		return m, nil
+
+	case sessionCleanedMsg:
+		m.results = msg.results
+		// Reload to show what is left (branches, failures)
+		return m, loadSession(m.client)
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+		switch msg.String() {
+		case "r":
+			m.loading = true
+			m.results = nil
+			return m, tea.Batch(m.spinner.Tick, loadSession(m.client))
+		case "c":
+			if len(m.entries) == 0 {
+				return m, nil
+			}
+			m.loading = true
+			entries := m.entries
+			client := m.client
+			return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+				return sessionCleanedMsg{results: cleanupSession(client, entries)}
+			})
+		}
+
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m SessionModel) View() string {
+	containerStyle := lipgloss.NewStyle().
+		MarginLeft(2).
+		MarginTop(1)
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#7D56F4")).
+		Bold(true)
+
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FF6B6B"))
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		MarginTop(1)
+
+	var content strings.Builder
+	content.WriteString(components.RenderHeader())
+	content.WriteString("\n")
+	content.WriteString(containerStyle.Render(titleStyle.Render("Session Resources")))
+	content.WriteString("\n\n")
+
+	var body strings.Builder
+	if len(m.results) > 0 {
+		body.WriteString(strings.Join(m.results, "\n"))
+		body.WriteString("\n\n")
+	}
+	switch {
+	case m.loading:
+		body.WriteString(m.spinner.View() + " Checking resources...")
+	case m.err != nil:
+		body.WriteString(errorStyle.Render("❌ " + m.err.Error()))
+	default:
+		body.WriteString(formatSessionSummary(m.entries))
+	}
+	content.WriteString(lipgloss.NewStyle().MarginLeft(2).Render(body.String()))
+	content.WriteString("\n")
+
+	content.WriteString(helpStyle.Render("  c: clean up all • r: refresh • esc/q: back"))
+
+	return content.String()
+}
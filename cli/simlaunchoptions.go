@@ -30,10 +30,7 @@ type navigateToArtifactIDMsg struct {
 }
 
 type launchEnvironmentMsg struct {
-	simulator  *models.SimulatorListItem
-	artifactID *string
-	version    *string
-	dataset    *string // Dataset from artifact
+	options LaunchOptions
 }
 
 func NewSimLaunchOptionsModel(client *plato.PlatoClient, simulator *models.SimulatorListItem) SimLaunchOptionsModel {
@@ -81,7 +78,7 @@ func (m SimLaunchOptionsModel) Update(msg tea.Msg) (SimLaunchOptionsModel, tea.C
 				case "Launch Latest":
 					// Launch environment with latest version (no artifact ID)
 					return m, func() tea.Msg {
-						return launchEnvironmentMsg{simulator: m.simulator, artifactID: nil, version: nil, dataset: nil}
+						return launchEnvironmentMsg{options: LaunchOptions{Simulator: m.simulator}}
 					}
 				case "By Artifact ID":
 					// Navigate to artifact ID selection for this simulator
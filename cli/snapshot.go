@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cfgpkg "plato-cli/internal/config"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+	"plato-sdk/models"
+)
+
+// runSnapshot implements `plato snapshot <publicID>`.
+// It runs the same pre-snapshot cleanup as the TUI, creates the snapshot and,
+// with --wait, blocks until the artifact is available for launches. Several
+// comma-separated datasets snapshot the same cleaned-up state as each of them.
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	service := fs.String("service", "", "Service name (defaults to the service in plato-config.yml)")
+	dataset := fs.String("dataset", defaultDataset(), "Dataset to snapshot as, or several separated by commas")
+	skipCleanup := fs.Bool("skip-cleanup", false, "Skip clearing the audit log and env state before snapshotting")
+	wait := fs.Bool("wait", false, "Wait until the artifact is available")
+	timeout := fs.Duration("timeout", 30*time.Minute, "How long --wait polls before giving up")
+	pollInterval := fs.Duration("poll-interval", 5*time.Second, "How often --wait checks the snapshot status")
+	outputDir := fs.String("output-dir", "", "Directory to write snapshot-<artifactID>.json metadata to")
+	tags := fs.String("tags", "", "Tags to attach to the artifact, separated by commas (e.g. stable,ci)")
+
+	// Allow the public ID to come before or after the flags
+	var publicID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		publicID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if publicID == "" && fs.NArg() > 0 {
+		publicID = fs.Arg(0)
+	}
+	if publicID == "" {
+		return newUsageError("public ID is required")
+	}
+	if *pollInterval <= 0 {
+		return newUsageError("--poll-interval must be positive, got %s", *pollInterval)
+	}
+	client := NewConfigModel().client
+	publicID, err := utils.ResolvePublicID(client, publicID)
+	if err != nil {
+		return err
+	}
+
+	datasets := splitList(*dataset)
+	if len(datasets) == 0 {
+		return newUsageError("--dataset is required")
+	}
+
+	if *service == "" {
+		config, err := LoadPlatoConfig()
+		var cfgErr *cfgpkg.ConfigError
+		if errors.As(err, &cfgErr) && !cfgErr.Missing {
+			return err
+		}
+		if err != nil || config.Service == "" {
+			return newUsageError("--service is required when plato-config.yml does not name a service")
+		}
+		*service = config.Service
+	}
+
+	if !*skipCleanup {
+		jobGroupID, err := lookupJobGroupID(client, publicID)
+		if err != nil {
+			return err
+		}
+		if jobGroupID == "" {
+			return newNotFoundError("sandbox %s not found (use --skip-cleanup to snapshot without cleanup)", publicID)
+		}
+
+		// One cleanup covers every dataset: they all snapshot the same state
+		fmt.Println("🧹 Running pre-snapshot cleanup...")
+		needsDBConfig, err := utils.PreSnapshotCleanup(client, publicID, jobGroupID, *service, datasets[0])
+		if err != nil {
+			fmt.Printf("⚠️  Pre-snapshot cleanup failed: %v\n", err)
+		} else if needsDBConfig {
+			fmt.Printf("⚠️  No database config saved for %s/%s, skipped database cleanup\n", *service, datasets[0])
+		}
+	}
+
+	reqs := make([]models.CreateSnapshotRequest, len(datasets))
+	for i, name := range datasets {
+		reqs[i] = models.CreateSnapshotRequest{Service: *service, Dataset: name, Tags: splitList(*tags)}
+		for _, line := range applySnapshotDatasetConfig(&reqs[i], name) {
+			fmt.Println(line)
+		}
+	}
+
+	if len(datasets) == 1 {
+		fmt.Printf("📸 Creating snapshot of %s...\n", publicID)
+	} else {
+		fmt.Printf("📸 Creating %d snapshots of %s (%s)...\n", len(datasets), publicID, strings.Join(datasets, ", "))
+	}
+	// Cleanup already ran above, so CreateSnapshots is given no DB config
+	results, _, _ := client.Sandbox.CreateSnapshots(context.Background(), publicID, "", reqs, nil)
+
+	var created []snapshotMetadata
+	failed := 0
+	for _, result := range results {
+		if vmExpired(result.Err) {
+			return vmExpiredError(publicID)
+		}
+		if result.Err != nil {
+			if len(results) == 1 {
+				return result.Err
+			}
+			fmt.Printf("❌ Snapshot as %s failed: %v\n", result.Request.Dataset, result.Err)
+			failed++
+			continue
+		}
+		resp := result.Response
+		if len(results) == 1 {
+			fmt.Printf("✅ Snapshot created: %s (status: %s)\n", resp.ArtifactId, resp.Status)
+		} else {
+			fmt.Printf("✅ Snapshot as %s created: %s (status: %s)\n", result.Request.Dataset, resp.ArtifactId, resp.Status)
+		}
+		created = append(created, snapshotMetadata{
+			ArtifactID: resp.ArtifactId,
+			Status:     resp.Status,
+			S3Uri:      resp.S3Uri,
+			Service:    *service,
+			Dataset:    result.Request.Dataset,
+			Tags:       result.Request.Tags,
+			PublicID:   publicID,
+			CreatedAt:  time.Now().UTC(),
+		})
+	}
+
+	for i := range created {
+		meta := &created[i]
+		if *wait {
+			if err := waitForSnapshot(client, meta, *timeout, *pollInterval); err != nil {
+				if len(results) == 1 {
+					return err
+				}
+				fmt.Printf("❌ Snapshot as %s did not become available: %v\n", meta.Dataset, err)
+				failed++
+				continue
+			}
+		}
+		writeSnapshotMetadata(*outputDir, *meta)
+	}
+
+	// Datasets that worked are kept; the command still fails if any did not
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed", failed, len(results))
+	}
+	return nil
+}
+
+// waitForSnapshot blocks until the artifact in meta is available, updating
+// its status and S3 URI
+func waitForSnapshot(client *plato.PlatoClient, meta *snapshotMetadata, timeout, pollInterval time.Duration) error {
+	fmt.Printf("⏳ Waiting for %s to become available...\n", meta.ArtifactID)
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), timeout)
+	defer waitCancel()
+	status, err := client.Sandbox.WaitForSnapshot(waitCtx, meta.ArtifactID, pollInterval)
+	if endpointUnsupported(err) {
+		fmt.Printf("⚠️  This Plato API does not report snapshot status yet; %s was created but not confirmed available\n", meta.ArtifactID)
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return operationError(fmt.Sprintf("waiting for snapshot %s", meta.ArtifactID), fmt.Sprintf("waited %s, increase --timeout", timeout), err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Artifact %s is %s\n", status.ArtifactId, status.Status)
+	if status.S3Uri != "" {
+		fmt.Printf("   %s\n", status.S3Uri)
+		meta.S3Uri = status.S3Uri
+	}
+	meta.Status = status.Status
+	return nil
+}
+
+// snapshotMetadata is the record written by --output-dir for CI to archive
+type snapshotMetadata struct {
+	ArtifactID string    `json:"artifact_id"`
+	Status     string    `json:"status"`
+	S3Uri      string    `json:"s3_uri,omitempty"`
+	Service    string    `json:"service"`
+	Dataset    string    `json:"dataset"`
+	Tags       []string  `json:"tags,omitempty"`
+	PublicID   string    `json:"public_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// writeSnapshotMetadata saves meta as snapshot-<artifactID>.json in dir.
+// The snapshot already succeeded, so failures are reported but not returned.
+func writeSnapshotMetadata(dir string, meta snapshotMetadata) {
+	if dir == "" {
+		return
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("snapshot-%s.json", meta.ArtifactID))
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to write snapshot metadata to %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("📝 Wrote snapshot metadata to %s\n", path)
+}
+
+// splitList parses a comma-separated flag value such as --dataset or --tags,
+// dropping blanks and repeats
+func splitList(value string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
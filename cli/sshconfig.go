@@ -82,47 +82,7 @@ func writeSSHConfig(configContent string) error {
 
 // appendSSHHostEntry appends a new SSH host entry to config
 func appendSSHHostEntry(baseURL, hostname string, port int, jobGroupID string, username string) error {
-	configContent, err := readSSHConfig()
-	if err != nil {
-		return err
-	}
-
-	// Find proxytunnel path (checks bundled binary first)
-	proxytunnelPath, err := utils.FindProxytunnelPath()
-	if err != nil {
-		return fmt.Errorf("proxytunnel not found: %w", err)
-	}
-
-	// Get proxy configuration based on base URL
-	proxyConfig := utils.GetProxyConfig(baseURL)
-
-	// Build ProxyCommand
-	proxyCmd := proxytunnelPath
-	if proxyConfig.Secure {
-		proxyCmd += " -E"
-	}
-	proxyCmd += fmt.Sprintf(" -p %s -P '%s@22:newpass' -d %%h:%%p --no-check-certificate", proxyConfig.Server, jobGroupID)
-
-	configWithProxy := fmt.Sprintf(`Host %s
-    HostName localhost
-    Port %d
-    User %s
-    StrictHostKeyChecking no
-    UserKnownHostsFile /dev/null
-    ConnectTimeout 10
-    ProxyCommand %s
-    ServerAliveInterval 30
-    ServerAliveCountMax 3
-    TCPKeepAlive yes
-    `, hostname, port, username, proxyCmd)
-
-	if configContent != "" {
-		configContent = strings.TrimRight(configContent, "\n") + "\n\n" + configWithProxy
-	} else {
-		configContent = configWithProxy
-	}
-
-	return writeSSHConfig(configContent)
+	return utils.AppendSSHHostEntry(baseURL, hostname, port, jobGroupID, username)
 }
 
 // setupSSHConfig sets up SSH config with available hostname and returns the hostname
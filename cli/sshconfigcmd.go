@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"plato-cli/internal/utils"
+)
+
+// printSSHConfig implements `plato ssh-config <publicID>`.
+// It prints the SSH config block the TUI would generate for the sandbox without connecting.
+func printSSHConfig(args []string) error {
+	fs := flag.NewFlagSet("ssh-config", flag.ExitOnError)
+	user := fs.String("user", "plato", "SSH user (root for artifact-based VMs)")
+	host := fs.String("host", "", "Host alias for the block (defaults to sandbox-<publicID>)")
+	port := fs.Int("port", 2200, "Local port written to the Port line")
+	identity := fs.String("identity", "", "Private key path (defaults to your ~/.ssh key)")
+
+	// Allow the public ID to come before or after the flags
+	var publicID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		publicID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if publicID == "" && fs.NArg() > 0 {
+		publicID = fs.Arg(0)
+	}
+	if publicID == "" {
+		return newUsageError("public ID is required")
+	}
+	client := NewConfigModel().client
+	publicID, err := utils.ResolvePublicID(client, publicID)
+	if err != nil {
+		return err
+	}
+
+	hostname := *host
+	if hostname == "" {
+		hostname = fmt.Sprintf("sandbox-%s", publicID)
+	}
+
+	keyPath := *identity
+	if keyPath == "" {
+		if path, err := utils.GetSSHPrivateKeyPath(); err == nil {
+			keyPath = path
+		} else {
+			keyPath = "~/.ssh/id_ed25519"
+		}
+	}
+
+	baseURL := client.GetBaseURL()
+	proxyConfig := utils.GetProxyConfig(baseURL)
+
+	block, err := utils.BuildSSHConfigBlock(baseURL, hostname, *port, publicID, *user, keyPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "# Base URL: %s\n# Proxy server: %s (secure: %t)\n", baseURL, proxyConfig.Server, proxyConfig.Secure)
+	fmt.Print(block)
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"plato-sdk/models"
+	"plato-sdk/services"
+)
+
+// timeoutHintError replaces a bare "context deadline exceeded" with a message
+// naming the operation and how to give it more time. The original error is
+// still available through Unwrap.
+type timeoutHintError struct {
+	msg string
+	err error
+}
+
+func (e *timeoutHintError) Error() string {
+	return e.msg
+}
+
+func (e *timeoutHintError) Unwrap() error {
+	return e.err
+}
+
+// operationError wraps an error from operation as "<operation> failed: <err>",
+// except for timeouts, which become "<operation> timed out after 20m0s; <hint>"
+// so the user learns what ran out of time and how to allow more.
+func operationError(operation, hint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s failed: %w", operation, err)
+	}
+	msg := operation + " timed out"
+	var timeoutErr *services.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		msg = fmt.Sprintf("%s after %s", msg, timeoutErr.Timeout)
+	}
+	if hint != "" {
+		msg += "; " + hint
+	}
+	return &timeoutHintError{msg: msg, err: err}
+}
+
+// provisioningTimeoutHint points at the event stream of a VM whose
+// provisioning outlived the wait; the VM may still come up
+func provisioningTimeoutHint(correlationID string) string {
+	return fmt.Sprintf("the VM may still finish provisioning, follow it with `plato events %s` or allow more time with --provision-timeout or timeouts.provision", correlationID)
+}
+
+// setupTimeoutHint is provisioningTimeoutHint for the sandbox setup step
+func setupTimeoutHint(correlationID string) string {
+	return fmt.Sprintf("follow it with `plato events %s` or allow more time with --setup-timeout or timeouts.setup", correlationID)
+}
+
+// Default waits for VM provisioning and sandbox setup
+const (
+	defaultProvisionTimeout = 20 * time.Minute
+	defaultSetupTimeout     = 20 * time.Minute
+)
+
+// launchTimeouts bounds the provisioning and setup waits of a launch
+type launchTimeouts struct {
+	provision time.Duration
+	setup     time.Duration
+}
+
+// tuiLaunchTimeouts are the waits used by launches from the interactive TUI,
+// resolved once at startup from its flags and plato-config.yml
+var tuiLaunchTimeouts = launchTimeouts{provision: defaultProvisionTimeout, setup: defaultSetupTimeout}
+
+// parseTUIFlags parses the flags accepted by a bare `plato` and resolves
+// tuiLaunchTimeouts, so a bad value fails before the TUI starts. It also sets
+// noSandboxFile.
+func parseTUIFlags(args []string) error {
+	fs := flag.NewFlagSet("plato", flag.ExitOnError)
+	timeoutOverrides := addLaunchTimeoutFlags(fs)
+	fs.BoolVar(&noSandboxFile, "no-sandbox-file", false, "Write .sandbox.yaml to ~/.plato/sandboxes instead of the current directory")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return newUsageError("unexpected argument '%s'", fs.Arg(0))
+	}
+	overrides, err := timeoutOverrides()
+	if err != nil {
+		return err
+	}
+
+	// A missing or broken plato-config.yml is reported by the TUI itself
+	config, _ := LoadPlatoConfig()
+	tuiLaunchTimeouts, err = resolveLaunchTimeouts(config, overrides)
+	return err
+}
+
+// addLaunchTimeoutFlags registers --provision-timeout and --setup-timeout on
+// fs. After parsing, the returned function reports the values that were set
+// explicitly, rejecting ones that are not positive.
+func addLaunchTimeoutFlags(fs *flag.FlagSet) func() (launchTimeouts, error) {
+	provision := fs.Duration("provision-timeout", 0, "How long to wait for the VM to provision (default 20m, or timeouts.provision)")
+	setup := fs.Duration("setup-timeout", 0, "How long to wait for sandbox setup (default 20m, or timeouts.setup)")
+	return func() (launchTimeouts, error) {
+		set := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if set["provision-timeout"] && *provision <= 0 {
+			return launchTimeouts{}, newUsageError("--provision-timeout must be positive")
+		}
+		if set["setup-timeout"] && *setup <= 0 {
+			return launchTimeouts{}, newUsageError("--setup-timeout must be positive")
+		}
+		return launchTimeouts{provision: *provision, setup: *setup}, nil
+	}
+}
+
+// resolveLaunchTimeouts applies the timeouts section of plato-config.yml and
+// then overrides (zero fields are unset) on top of the defaults. config may
+// be nil.
+func resolveLaunchTimeouts(config *models.PlatoConfig, overrides launchTimeouts) (launchTimeouts, error) {
+	timeouts := launchTimeouts{provision: defaultProvisionTimeout, setup: defaultSetupTimeout}
+	if config != nil && config.Timeouts != nil {
+		var err error
+		if timeouts.provision, err = parseTimeoutSetting("timeouts.provision", config.Timeouts.Provision, timeouts.provision); err != nil {
+			return timeouts, err
+		}
+		if timeouts.setup, err = parseTimeoutSetting("timeouts.setup", config.Timeouts.Setup, timeouts.setup); err != nil {
+			return timeouts, err
+		}
+		// Only validated here; the client applies it through config.StreamIdleTimeout
+		if _, err = parseTimeoutSetting("timeouts.stream_idle", config.Timeouts.StreamIdle, 0); err != nil {
+			return timeouts, err
+		}
+	}
+	if overrides.provision > 0 {
+		timeouts.provision = overrides.provision
+	}
+	if overrides.setup > 0 {
+		timeouts.setup = overrides.setup
+	}
+	return timeouts, nil
+}
+
+// parseTimeoutSetting parses a duration from plato-config.yml, returning
+// fallback when it is empty
+func parseTimeoutSetting(key, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback, fmt.Errorf("%s in plato-config.yml must be a positive duration such as 45m, got %q", key, value)
+	}
+	return d, nil
+}
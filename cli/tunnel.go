@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+)
+
+// runTunnel implements `plato tunnel <publicID> --remote <port>`.
+// It forwards a local port to the sandbox through proxytunnel and stays in the
+// foreground until interrupted, restarting proxytunnel with backoff if it dies.
+func runTunnel(args []string) error {
+	fs := flag.NewFlagSet("tunnel", flag.ExitOnError)
+	remote := fs.Int("remote", 0, "Remote port on the VM to forward (required)")
+	local := fs.Int("local", 0, "Local port to listen on (defaults to the remote port)")
+	strict := fs.Bool("strict", false, "Fail if the local port is taken instead of picking another one")
+	bind := fs.String("bind", "", "Local address to bind (defaults to tunnel.bind_address or 127.0.0.1)")
+	reconnect := fs.Bool("reconnect", true, "Restart the tunnel when proxytunnel exits")
+
+	// Allow the public ID to come before or after the flags
+	var publicID string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		publicID = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if publicID == "" && fs.NArg() > 0 {
+		publicID = fs.Arg(0)
+	}
+	if publicID == "" {
+		return newUsageError("public ID is required")
+	}
+	client := NewConfigModel().client
+	publicID, err := utils.ResolvePublicID(client, publicID)
+	if err != nil {
+		return err
+	}
+	if *remote <= 0 {
+		return newUsageError("--remote port is required")
+	}
+
+	// plato-config.yml is optional here; it only supplies tunnel defaults
+	var config *models.PlatoConfig
+	if ConfigExists() {
+		config, _ = LoadPlatoConfig()
+	}
+
+	bindAddress := *bind
+	if bindAddress == "" {
+		bindAddress = tunnelBindAddress(config)
+	}
+	preferred := *local
+	if preferred == 0 {
+		preferred = *remote
+	}
+
+	localPort, err := utils.ResolveTunnelLocalPort(bindAddress, preferred, *strict || tunnelStrictPorts(config))
+	if err != nil {
+		return err
+	}
+
+	baseURL := client.GetBaseURL()
+	if localPort != preferred {
+		fmt.Printf("⚠️  Local port %d is in use, using %d instead\n", preferred, localPort)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	delay := tunnelRetryDelay
+	failures := 0
+	for {
+		cmd, err := startProxytunnel(baseURL, publicID, *remote, bindAddress, localPort, os.Stdout)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔗 Forwarding %s -> %s:%d (Ctrl+C to stop)\n", utils.ListenAddress(bindAddress, localPort), publicID, *remote)
+		started := time.Now()
+
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		select {
+		case <-sigCh:
+			cmd.Process.Kill()
+			<-done
+			fmt.Println("\n✅ Tunnel closed")
+			return nil
+		case err = <-done:
+		}
+
+		if !*reconnect {
+			if err != nil {
+				return fmt.Errorf("proxytunnel exited: %w", err)
+			}
+			return nil
+		}
+
+		// A tunnel that stayed up for a while dropped; one that keeps dying
+		// right away usually means the VM is gone
+		if time.Since(started) >= tunnelStableAfter {
+			delay, failures = tunnelRetryDelay, 0
+		}
+		failures++
+		if failures > tunnelMaxQuickFailures {
+			return fmt.Errorf("proxytunnel exited %d times in a row without staying up; is sandbox %s still running? (last error: %v)", failures, publicID, err)
+		}
+		fmt.Printf("⚠️  Tunnel dropped (%v), reconnecting in %s...\n", tunnelExitReason(err), delay)
+		select {
+		case <-sigCh:
+			fmt.Println("\n✅ Tunnel closed")
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, tunnelMaxRetryDelay)
+
+		// The port is normally still free; if something took it meanwhile,
+		// pick another one unless the port is pinned
+		if port, err := utils.ResolveTunnelLocalPort(bindAddress, localPort, *strict || tunnelStrictPorts(config)); err != nil {
+			return err
+		} else if port != localPort {
+			fmt.Printf("⚠️  Local port %d was taken while reconnecting, using %d instead\n", localPort, port)
+			localPort = port
+		}
+	}
+}
+
+// Reconnect behaviour of `plato tunnel`
+const (
+	tunnelRetryDelay       = time.Second
+	tunnelMaxRetryDelay    = 30 * time.Second
+	tunnelStableAfter      = time.Minute
+	tunnelMaxQuickFailures = 5
+)
+
+// tunnelExitReason describes how proxytunnel exited
+func tunnelExitReason(err error) string {
+	if err == nil {
+		return "proxytunnel exited"
+	}
+	return err.Error()
+}
+
+// startProxytunnel starts proxytunnel forwarding bindAddress:localPort to
+// remotePort on the sandbox. Its output goes to output, or is discarded when
+// output is nil.
+func startProxytunnel(baseURL, publicID string, remotePort int, bindAddress string, localPort int, output io.Writer) (*exec.Cmd, error) {
+	// Find proxytunnel path (checks bundled binary first)
+	proxytunnelPath, err := utils.FindProxytunnelPath()
+	if err != nil {
+		utils.LogDebug("proxytunnel not found: %v", err)
+		return nil, fmt.Errorf("proxytunnel not found: %w", err)
+	}
+	utils.LogDebug("Found proxytunnel at: %s", proxytunnelPath)
+
+	cmd := exec.Command(proxytunnelPath, utils.BuildProxytunnelArgs(baseURL, publicID, remotePort, bindAddress, localPort)...)
+	if output != nil {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
+	utils.LogDebug("Starting proxytunnel command: %v", cmd.Args)
+
+	if err := cmd.Start(); err != nil {
+		utils.LogDebug("Failed to start proxytunnel: %v", err)
+		return nil, fmt.Errorf("failed to start proxytunnel: %w", err)
+	}
+	utils.LogDebug("Proxytunnel started successfully with PID: %d", cmd.Process.Pid)
+	return cmd, nil
+}
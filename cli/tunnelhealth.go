@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"plato-cli/internal/session"
+	"plato-cli/internal/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tunnelHealthInterval is how often the VM view checks that its proxytunnels
+// still accept connections
+const tunnelHealthInterval = 15 * time.Second
+
+// tunnelProbeTimeout bounds the TCP dial to a tunnel's local port
+const tunnelProbeTimeout = 2 * time.Second
+
+// tunnelHealthMonitor is shared by copies of a VMInfoModel so the periodic
+// check is started once, and is stopped by Close VM
+type tunnelHealthMonitor struct {
+	started bool
+	stopped bool
+}
+
+// tunnelHealthTickMsg asks the VM view to check its proxytunnels
+type tunnelHealthTickMsg struct {
+	monitor *tunnelHealthMonitor
+}
+
+// tunnelsProbedMsg lists the proxytunnels that stopped accepting connections
+type tunnelsProbedMsg struct {
+	monitor *tunnelHealthMonitor
+	dead    []proxytunnelMapping
+}
+
+// proxytunnelRestartedMsg is the result of restarting a dead proxytunnel on
+// its old local port
+type proxytunnelRestartedMsg struct {
+	monitor *tunnelHealthMonitor
+	mapping proxytunnelMapping
+	oldPID  int
+	cmd     *exec.Cmd
+	err     error
+}
+
+// startTunnelHealth returns the command that starts the periodic proxytunnel
+// check. Init runs again whenever the view is shown, so only the first call
+// starts anything.
+func (m VMInfoModel) startTunnelHealth() tea.Cmd {
+	if m.tunnelHealth == nil || m.tunnelHealth.started {
+		return nil
+	}
+	m.tunnelHealth.started = true
+	return tunnelHealthTick(m.tunnelHealth)
+}
+
+func tunnelHealthTick(monitor *tunnelHealthMonitor) tea.Cmd {
+	return tea.Tick(tunnelHealthInterval, func(time.Time) tea.Msg {
+		return tunnelHealthTickMsg{monitor: monitor}
+	})
+}
+
+// probeProxytunnels dials the local port of every mapping
+func probeProxytunnels(monitor *tunnelHealthMonitor, mappings []proxytunnelMapping) tea.Cmd {
+	return func() tea.Msg {
+		var dead []proxytunnelMapping
+		for _, mapping := range mappings {
+			if !tunnelAcceptsConnections(mapping) {
+				dead = append(dead, mapping)
+			}
+		}
+		return tunnelsProbedMsg{monitor: monitor, dead: dead}
+	}
+}
+
+// tunnelAcceptsConnections reports whether something still listens on the
+// mapping's local port. A wildcard bind address is dialled over loopback.
+func tunnelAcceptsConnections(mapping proxytunnelMapping) bool {
+	host := mapping.bindAddress
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	conn, err := net.DialTimeout("tcp", utils.ListenAddress(host, mapping.localPort), tunnelProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// restartProxytunnel stops what is left of old and starts proxytunnel again
+// for mapping, on the same local port
+func restartProxytunnel(monitor *tunnelHealthMonitor, baseURL, publicID string, mapping proxytunnelMapping, old *exec.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		oldPID := 0
+		if old != nil && old.Process != nil {
+			oldPID = old.Process.Pid
+			// The process may be hung rather than gone; either way it no
+			// longer serves the port
+			_ = old.Process.Kill()
+			_ = old.Wait()
+		}
+		cmd, err := startProxytunnel(baseURL, publicID, mapping.remotePort, mapping.bindAddress, mapping.localPort, nil)
+		return proxytunnelRestartedMsg{monitor: monitor, mapping: mapping, oldPID: oldPID, cmd: cmd, err: err}
+	}
+}
+
+// sameTunnel reports whether a and b forward the same local port
+func (a proxytunnelMapping) sameTunnel(b proxytunnelMapping) bool {
+	return a.bindAddress == b.bindAddress && a.localPort == b.localPort && a.remotePort == b.remotePort
+}
+
+// findTunnel returns the index of the mapping forwarding the same port as
+// mapping, or -1
+func (m VMInfoModel) findTunnel(mapping proxytunnelMapping) int {
+	for i, existing := range m.proxytunnelMappings {
+		if existing.sameTunnel(mapping) {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateTunnelHealth handles the messages of the periodic proxytunnel check
+func (m VMInfoModel) updateTunnelHealth(msg tea.Msg) (VMInfoModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tunnelHealthTickMsg:
+		// Ticks of a closed VM, or of an earlier VM view, end here
+		if msg.monitor != m.tunnelHealth || m.tunnelHealth.stopped {
+			return m, nil
+		}
+		if len(m.proxytunnelMappings) == 0 {
+			return m, tunnelHealthTick(m.tunnelHealth)
+		}
+		mappings := append([]proxytunnelMapping(nil), m.proxytunnelMappings...)
+		return m, probeProxytunnels(m.tunnelHealth, mappings)
+
+	case tunnelsProbedMsg:
+		if msg.monitor != m.tunnelHealth || m.tunnelHealth.stopped {
+			return m, nil
+		}
+		cmds := []tea.Cmd{tunnelHealthTick(m.tunnelHealth)}
+		for i := range m.proxytunnelMappings {
+			mapping := &m.proxytunnelMappings[i]
+			dead := false
+			for _, d := range msg.dead {
+				if d.sameTunnel(*mapping) {
+					dead = true
+					break
+				}
+			}
+			listen := utils.ListenAddress(mapping.bindAddress, mapping.localPort)
+			switch {
+			case dead:
+				// A tunnel still reconnecting is retried quietly
+				if !mapping.reconnecting {
+					m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  Proxytunnel %s → remote:%d dropped, reconnecting...", listen, mapping.remotePort))
+				}
+				mapping.reconnecting = true
+				var old *exec.Cmd
+				if i < len(m.proxytunnelProcesses) {
+					old = m.proxytunnelProcesses[i]
+				}
+				cmds = append(cmds, restartProxytunnel(m.tunnelHealth, m.client.GetBaseURL(), m.sandbox.PublicId, *mapping, old))
+			case mapping.reconnecting:
+				mapping.reconnecting = false
+				m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Proxytunnel %s → remote:%d reconnected", listen, mapping.remotePort))
+			}
+		}
+		m.refreshViewport()
+		return m, tea.Batch(cmds...)
+
+	case proxytunnelRestartedMsg:
+		i := m.findTunnel(msg.mapping)
+		if msg.monitor != m.tunnelHealth || m.tunnelHealth.stopped || i < 0 || i >= len(m.proxytunnelProcesses) {
+			// The VM was closed meanwhile, so the new process is not wanted
+			if msg.cmd != nil && msg.cmd.Process != nil {
+				_ = msg.cmd.Process.Kill()
+				go msg.cmd.Wait()
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			// The next check tries again
+			utils.LogDebug("Failed to restart proxytunnel for remote port %d: %v", msg.mapping.remotePort, msg.err)
+			return m, nil
+		}
+		utils.LogDebug("Restarted proxytunnel for remote port %d with PID %d", msg.mapping.remotePort, msg.cmd.Process.Pid)
+		m.proxytunnelProcesses[i] = msg.cmd
+		if msg.oldPID != 0 {
+			forgetResource(session.KindTunnel, strconv.Itoa(msg.oldPID))
+		}
+		recordResource(session.KindTunnel, strconv.Itoa(msg.cmd.Process.Pid), m.sandbox.PublicId,
+			fmt.Sprintf("%s → remote:%d", utils.ListenAddress(msg.mapping.bindAddress, msg.mapping.localPort), msg.mapping.remotePort))
+		m.saveSession()
+		return m, nil
+	}
+	return m, nil
+}
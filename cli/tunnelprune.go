@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"plato-cli/internal/session"
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+)
+
+// runTunnelsPrune implements `plato tunnels prune`, stopping the proxytunnel
+// processes left behind by a CLI that crashed or was killed. Only tunnels to
+// a Plato proxy whose parent has exited are touched, and those saved with a
+// live VM for Reconnect to VM are kept.
+func runTunnelsPrune(args []string) error {
+	fs := flag.NewFlagSet("tunnels prune", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "List the orphaned tunnels without stopping them")
+	fs.Parse(args)
+
+	client := NewConfigModel().client
+	tunnels, err := utils.FindPlatoProxytunnels(client.GetBaseURL())
+	if err != nil {
+		return err
+	}
+
+	var orphaned []utils.ProxytunnelProcess
+	saved := savedSessionTunnels(client)
+	for _, tunnel := range tunnels {
+		if !tunnel.Orphaned {
+			continue
+		}
+		if publicID, ok := saved[tunnel.PID]; ok {
+			fmt.Printf("⏭  Kept PID %d: %s → remote:%d (saved session of sandbox %s, re-adopted by Reconnect to VM)\n", tunnel.PID, tunnelListenLabel(tunnel), tunnel.RemotePort, publicID)
+			continue
+		}
+		orphaned = append(orphaned, tunnel)
+	}
+	if len(orphaned) == 0 {
+		fmt.Printf("No orphaned proxytunnels (%d found)\n", len(tunnels))
+		return nil
+	}
+
+	failed := 0
+	for _, tunnel := range orphaned {
+		desc := fmt.Sprintf("PID %d: %s → remote:%d", tunnel.PID, tunnelListenLabel(tunnel), tunnel.RemotePort)
+		if *dryRun {
+			fmt.Printf("Would stop %s (sandbox %s)\n", desc, tunnel.PublicID)
+			continue
+		}
+		// The PID was read moments ago, but make sure it was not reused
+		if !isProxytunnelProcess(tunnel.PID) {
+			continue
+		}
+		if err := terminateProcess(tunnel.PID); err != nil {
+			failed++
+			fmt.Printf("❌ Could not stop %s: %v\n", desc, err)
+			continue
+		}
+		utils.LogDebug("Stopped orphaned proxytunnel %d for %s", tunnel.PID, tunnel.PublicID)
+		forgetResource(session.KindTunnel, strconv.Itoa(tunnel.PID))
+		fmt.Printf("✓ Stopped %s (sandbox %s)\n", desc, tunnel.PublicID)
+	}
+	if *dryRun {
+		fmt.Printf("%d orphaned proxytunnel(s); run without --dry-run to stop them\n", len(orphaned))
+		return nil
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d orphaned proxytunnels could not be stopped", failed, len(orphaned))
+	}
+	return nil
+}
+
+// tunnelListenLabel is the local side of a tunnel, which an SSH ProxyCommand
+// does not have
+func tunnelListenLabel(tunnel utils.ProxytunnelProcess) string {
+	if tunnel.Listen == "" {
+		return "ssh"
+	}
+	return tunnel.Listen
+}
+
+// savedSessionTunnels maps the PIDs of tunnels saved in ~/.plato/sessions.json
+// to their VM, for VMs that still exist. If the VMs cannot be listed, every
+// saved tunnel is kept.
+func savedSessionTunnels(client *plato.PlatoClient) map[int]string {
+	vms, err := session.ListVMs()
+	if err != nil {
+		utils.LogDebug("Failed to read saved VM sessions: %v", err)
+		return nil
+	}
+
+	var live map[string]bool
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if sandboxes, err := client.Sandbox.List(ctx); err == nil {
+		live = make(map[string]bool, len(sandboxes))
+		for _, sandbox := range sandboxes {
+			live[sandbox.PublicId] = true
+		}
+	} else {
+		utils.LogDebug("Failed to list sandboxes, keeping every saved tunnel: %v", err)
+	}
+
+	saved := make(map[int]string)
+	for _, vm := range vms {
+		if live != nil && !live[vm.PublicID] {
+			continue
+		}
+		for _, tunnel := range vm.Tunnels {
+			saved[tunnel.PID] = vm.PublicID
+		}
+	}
+	return saved
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cfgpkg "plato-cli/internal/config"
+	"plato-sdk/models"
+)
+
+// runVersions implements `plato versions [service]`, listing the published
+// versions of a service, newest first, optionally only those of one dataset
+// or carrying a tag
+func runVersions(args []string) error {
+	fs := flag.NewFlagSet("versions", flag.ExitOnError)
+	dataset := fs.String("dataset", "", "Only list versions of this dataset")
+	tag := fs.String("tag", "", "Only list versions whose artifact has this tag (e.g. stable)")
+	asJSON := fs.Bool("json", false, "Print the versions as JSON")
+
+	// Allow the service to come before or after the flags
+	var service string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		service = args[0]
+		args = args[1:]
+	}
+	fs.Parse(args)
+	if service == "" && fs.NArg() > 0 {
+		service = fs.Arg(0)
+	}
+	if service == "" {
+		config, err := LoadPlatoConfig()
+		var cfgErr *cfgpkg.ConfigError
+		if errors.As(err, &cfgErr) && !cfgErr.Missing {
+			return err
+		}
+		if err != nil || config.Service == "" {
+			return newUsageError("a service is required when plato-config.yml does not name one")
+		}
+		service = config.Service
+	}
+
+	client := NewConfigModel().client
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	versions, err := client.Simulator.GetVersions(ctx, service)
+	if err != nil {
+		return fmt.Errorf("failed to list versions of %s: %w", service, err)
+	}
+
+	matching := make([]*models.SimulatorVersion, 0, len(versions))
+	for _, v := range versions {
+		if *dataset != "" && v.Dataset != *dataset {
+			continue
+		}
+		if *tag != "" && !v.HasTag(*tag) {
+			continue
+		}
+		matching = append(matching, v)
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].CreatedAt > matching[j].CreatedAt })
+
+	if *asJSON {
+		data, err := json.MarshalIndent(matching, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal versions: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(matching) == 0 {
+		switch {
+		case *tag != "":
+			fmt.Printf("No versions of %s are tagged '%s'\n", service, *tag)
+		case *dataset != "":
+			fmt.Printf("Dataset '%s' has no published versions of %s\n", *dataset, service)
+		default:
+			fmt.Printf("%s has no published versions yet\n", service)
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tDATASET\tARTIFACT\tTAGS\tCREATED")
+	for _, v := range matching {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Version, v.Dataset, v.ArtifactID, strings.Join(v.Tags, ","), v.CreatedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// vmActionDelegate renders the VM actions list, greying out actions that
+// cannot run yet
+type vmActionDelegate struct {
+	list.DefaultDelegate
+}
+
+func newVMActionDelegate() vmActionDelegate {
+	return vmActionDelegate{DefaultDelegate: list.NewDefaultDelegate()}
+}
+
+func (d vmActionDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if action, ok := item.(vmAction); ok && action.disabledReason != "" {
+		dimmed := d.DefaultDelegate
+		dimmed.Styles.NormalTitle = dimmed.Styles.DimmedTitle
+		dimmed.Styles.NormalDesc = dimmed.Styles.DimmedDesc
+		dimmed.Styles.SelectedTitle = dimmed.Styles.SelectedTitle.Foreground(dimmed.Styles.DimmedTitle.GetForeground())
+		dimmed.Styles.SelectedDesc = dimmed.Styles.SelectedDesc.Foreground(dimmed.Styles.DimmedDesc.GetForeground())
+		dimmed.Render(w, m, index, item)
+		return
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
+// actionAvailability explains why each gated action cannot run yet. Actions
+// missing from the map are available.
+func (m VMInfoModel) actionAvailability() map[string]string {
+	var configReason, datasetReason, sshReason string
+	switch {
+	case m.config == nil:
+		configReason = "plato-config.yml not loaded"
+	case m.config.Service == "":
+		configReason = "no service in plato-config.yml"
+	}
+	if m.config != nil {
+		if _, ok := m.config.Datasets[m.dataset]; !ok {
+			datasetReason = fmt.Sprintf("dataset '%s' not in plato-config.yml", m.dataset)
+		}
+	}
+	switch {
+	case m.setupErr != nil:
+		sshReason = "sandbox setup failed"
+	case m.sshHost == "" || m.sshConfigPath == "":
+		sshReason = "waiting for SSH setup"
+	}
+
+	// firstReason returns the first non-empty reason
+	firstReason := func(reasons ...string) string {
+		for _, r := range reasons {
+			if r != "" {
+				return r
+			}
+		}
+		return ""
+	}
+	return map[string]string{
+		"Start Service":            firstReason(configReason, datasetReason, sshReason),
+		"Start Plato Worker":       firstReason(configReason, datasetReason),
+		"Connect to Cursor/VSCode": sshReason,
+		"Snapshot VM":              configReason,
+		"Commit State":             firstReason(configReason, datasetReason, sshReason),
+		"Clean State":              configReason,
+	}
+}
+
+// refreshActionAvailability recomputes which actions can run and updates the
+// list; call it whenever the config or the SSH setup changes
+func (m *VMInfoModel) refreshActionAvailability() {
+	reasons := m.actionAvailability()
+	items := m.actionList.Items()
+	for i, item := range items {
+		if action, ok := item.(vmAction); ok {
+			action.disabledReason = reasons[action.title]
+			items[i] = action
+		}
+	}
+	m.actionList.SetItems(items)
+}
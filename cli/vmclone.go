@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"plato-cli/internal/utils"
+	plato "plato-sdk"
+)
+
+// errBranchNotOnHub is returned by cloneOnVM when the hub repository does not
+// have the branch that was pushed for the VM
+var errBranchNotOnHub = errors.New("branch does not exist on the hub")
+
+// cloneOnVM clones branch of cloneURL into repoDir on the VM and returns the
+// clone output. The branch is looked up on the hub first, so a push that never
+// reached it is reported as errBranchNotOnHub rather than as a failed clone.
+//
+// The VM's git may be older than the local one, so a partial clone is retried
+// as a regular clone. If cloning the branch directly still fails, which git
+// versions do for some freshly created repositories, the default branch is
+// cloned and the branch fetched and checked out on top of it.
+func cloneOnVM(ctx context.Context, client *plato.PlatoClient, sshConfigPath, sshHost, cloneURL, repoDir, branch string) (string, error) {
+	// Each git command on the VM gets its own deadline
+	run := func(args ...string) (string, error) {
+		gitCtx, cancel := utils.WithGitTimeout(ctx)
+		defer cancel()
+		stdout, _, err := utils.RunSSHCommand(gitCtx, sshConfigPath, sshHost, utils.ShellJoin(args...))
+		return stdout, err
+	}
+	removeRepoDir := func() {
+		utils.RunSSHCommand(ctx, sshConfigPath, sshHost, "rm -rf "+utils.ShellQuote(repoDir))
+	}
+
+	// ls-remote --exit-code exits with 2 when no ref matched
+	_, err := run("git", "ls-remote", "--exit-code", "--heads", cloneURL, branch)
+	var sshErr *utils.SSHCommandError
+	if errors.As(err, &sshErr) && sshErr.ExitCode == 2 {
+		return "", fmt.Errorf("%w: '%s' was not found in the repository; push the workspace again", errBranchNotOnHub, branch)
+	}
+	if err != nil {
+		// The clone below reports why the hub could not be reached
+		utils.LogDebug("Could not list branches on the hub from the VM: %v", err)
+	}
+
+	cloneArgs := client.Gitea.CloneArgs(cloneURL, repoDir, branch)
+	output, err := run(append([]string{"git"}, cloneArgs...)...)
+	if fallbackArgs, partial := withoutPartialClone(cloneArgs); err != nil && partial {
+		utils.LogDebug("Partial clone on VM failed, retrying without --filter: %v", err)
+		removeRepoDir()
+		cloneArgs = fallbackArgs
+		output, err = run(append([]string{"git"}, cloneArgs...)...)
+	}
+	if err == nil {
+		return output, nil
+	}
+
+	utils.LogDebug("Cloning branch '%s' on VM failed, retrying with a checkout: %v", branch, err)
+	removeRepoDir()
+	defaultArgs, _ := withoutPartialClone(client.Gitea.CloneArgs(cloneURL, repoDir, ""))
+	for _, args := range [][]string{
+		append([]string{"git"}, defaultArgs...),
+		{"git", "-C", repoDir, "fetch", "origin", branch},
+		{"git", "-C", repoDir, "checkout", "-B", branch, "FETCH_HEAD"},
+	} {
+		out, retryErr := run(args...)
+		if retryErr != nil {
+			utils.LogDebug("Clone fallback on VM failed at %s: %v", args[1], retryErr)
+			return "", fmt.Errorf("failed to clone repo on VM: %w", err)
+		}
+		output += out
+	}
+	return output, nil
+}
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -17,6 +18,7 @@ import (
 	"plato-cli/internal/utils"
 	plato "plato-sdk"
 	"plato-sdk/models"
+	"plato-sdk/services"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +29,30 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// LaunchOptions selects what a VM is launched from. Every field is optional;
+// the zero value launches a blank VM configured through the form.
+type LaunchOptions struct {
+	Simulator  *models.SimulatorListItem
+	ArtifactID *string
+	// Version is the artifact's version, shown alongside the artifact ID
+	Version *string
+	// Dataset defaults to default_dataset from plato-config.yml, or "base"
+	Dataset *string
+	// AppPort and MessagingPort override the dataset's compute ports;
+	// zero keeps plato-config.yml's value, or 8080 and 7000
+	AppPort       int
+	MessagingPort int
+}
+
+// Ports used when neither the launch options nor plato-config.yml set them
+const (
+	defaultAppPort       = 8080
+	defaultMessagingPort = 7000
+)
+
+// defaultVMLifetime is the lifetime in seconds requested for new VMs
+const defaultVMLifetime = 7200
+
 type VMConfigModel struct {
 	client            *plato.PlatoClient
 	simulator         *models.SimulatorListItem // Optional: for launching from existing sim
@@ -51,7 +77,9 @@ type VMConfigModel struct {
 	sshHost           string
 	sshConfigPath     string
 	sshPrivateKeyPath string
-	skipForm          bool // Skip form and use defaults when launching from simulator
+	skipForm          bool  // Skip form and use defaults when launching from simulator
+	launchErr         error // Provisioning or setup failure, reported in the exit code
+	timeouts          launchTimeouts
 }
 
 var (
@@ -75,16 +103,16 @@ type statusUpdateMsg struct {
 	message string
 }
 
-func createSandbox(client *plato.PlatoClient, config models.SimConfigDataset, dataset string, statusChan chan<- string, artifactID *string, service string) tea.Cmd {
+func createSandbox(client *plato.PlatoClient, opts models.CreateSandboxOptions, provisionTimeout time.Duration, statusChan chan<- string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
 		// Debug: Log the exact config being sent
-		configJSON, _ := json.Marshal(config)
+		configJSON, _ := json.Marshal(opts.Config)
 		statusChan <- "=== CREATE SANDBOX CONFIG ==="
-		statusChan <- fmt.Sprintf("Dataset: %s", dataset)
-		if artifactID != nil {
-			statusChan <- fmt.Sprintf("Artifact ID: %s", *artifactID)
+		statusChan <- fmt.Sprintf("Dataset: %s", opts.Dataset)
+		if opts.ArtifactID != nil {
+			statusChan <- fmt.Sprintf("Artifact ID: %s", *opts.ArtifactID)
 		}
 		
 		// Pretty-print the config JSON
@@ -98,14 +126,15 @@ func createSandbox(client *plato.PlatoClient, config models.SimConfigDataset, da
 
 		// Create the sandbox
 		statusChan <- "Creating VM via API..."
-		// Use simulator name as alias if available in metadata, otherwise "sandbox"
-		alias := "sandbox"
-		if config.Metadata.Name != "" && config.Metadata.Name != "Plato Simulator" {
-			alias = config.Metadata.Name
+		if opts.Alias == "" {
+			opts.Alias = sandboxAlias(*opts.Config)
 		}
 
-		timeout := 7200 // 2 hour default timeout
-		sandbox, err := client.Sandbox.Create(ctx, &config, dataset, alias, artifactID, service, &timeout)
+		if opts.Timeout == nil {
+			timeout := defaultVMLifetime
+			opts.Timeout = &timeout
+		}
+		sandbox, err := client.Sandbox.CreateWithOptions(ctx, &opts)
 		if err != nil {
 			close(statusChan)
 			return sandboxCreatedMsg{sandbox: nil, err: err}
@@ -117,9 +146,9 @@ func createSandbox(client *plato.PlatoClient, config models.SimConfigDataset, da
 
 		// Monitor the operation until completion using the correlation_id from the API
 		// Pass statusChan to get real-time event details
-		err = client.Sandbox.MonitorOperationWithEvents(ctx, sandbox.CorrelationId, 20*time.Minute, statusChan)
+		err = client.Sandbox.MonitorOperationWithEvents(ctx, sandbox.CorrelationId, provisionTimeout, statusChan)
 		if err != nil {
-			return sandboxCreatedMsg{sandbox: sandbox, err: fmt.Errorf("VM provisioning failed: %w", err)}
+			return sandboxCreatedMsg{sandbox: sandbox, err: operationError("VM provisioning", provisioningTimeoutHint(sandbox.CorrelationId), err)}
 		}
 
 		// Don't send another success message here - MonitorOperation already sent events
@@ -128,6 +157,15 @@ func createSandbox(client *plato.PlatoClient, config models.SimConfigDataset, da
 	}
 }
 
+// sandboxAlias uses the simulator name from the dataset metadata as the VM
+// alias when it is set, otherwise "sandbox"
+func sandboxAlias(config models.SimConfigDataset) string {
+	if config.Metadata.Name != "" && config.Metadata.Name != "Plato Simulator" {
+		return config.Metadata.Name
+	}
+	return "sandbox"
+}
+
 func setupSSHForArtifact(client *plato.PlatoClient, sandbox *models.Sandbox, statusChan chan<- string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
@@ -151,9 +189,22 @@ func setupSSHForArtifact(client *plato.PlatoClient, sandbox *models.Sandbox, sta
 
 		statusChan <- fmt.Sprintf("SSH configured: ssh -F %s %s", configPath, sshHost)
 
+		// Pin the host key before the root access probe connects
+		if utils.StrictHostKeyEnabled() {
+			statusChan <- "Pinning the VM host key..."
+			if err := utils.PinHostKey(ctx, configPath, sshHost); err != nil {
+				close(statusChan)
+				return sandboxSetupCompleteMsg{err: err}
+			}
+		}
+
 		// Setup root SSH access with public key
 		statusChan <- "Setting up root SSH access..."
-		err = client.Sandbox.SetupRootPassword(ctx, sandbox.PublicId, sshPublicKey)
+		correlationID, err := client.Sandbox.SetupRootAccess(ctx, sandbox.PublicId, sshPublicKey)
+		if err == nil {
+			statusChan <- "Waiting for root SSH access to become available..."
+			err = confirmRootAccess(ctx, client, correlationID, configPath, sshHost)
+		}
 		if err != nil {
 			// Check if this is a 403 error (unauthorized organization)
 			// If so, treat it as a warning and continue
@@ -190,7 +241,7 @@ func setupSSHForArtifact(client *plato.PlatoClient, sandbox *models.Sandbox, sta
 	}
 }
 
-func setupSandboxFromConfig(client *plato.PlatoClient, sandbox *models.Sandbox, config models.SimConfigDataset, dataset string, statusChan chan<- string) tea.Cmd {
+func setupSandboxFromConfig(client *plato.PlatoClient, sandbox *models.Sandbox, config models.SimConfigDataset, dataset string, setupTimeout time.Duration, statusChan chan<- string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
@@ -217,8 +268,10 @@ func setupSandboxFromConfig(client *plato.PlatoClient, sandbox *models.Sandbox,
 
 		statusChan <- "Calling setup-sandbox API..."
 
-		// Call the setup-sandbox API with full config and SSH public key
-		_, err = client.Sandbox.SetupSandbox(ctx, sandbox.PublicId, &config, dataset, sshPublicKey)
+		// Call the setup-sandbox API with full config and SSH public key.
+		// This is the only place setup is started; monitorSetup below only ever
+		// re-attaches to its event stream so a dropped connection never restarts setup.
+		correlationID, err := client.Sandbox.SetupSandbox(ctx, sandbox.PublicId, &config, dataset, sshPublicKey)
 		if err != nil {
 			close(statusChan)
 			return sandboxSetupCompleteMsg{
@@ -231,6 +284,19 @@ func setupSandboxFromConfig(client *plato.PlatoClient, sandbox *models.Sandbox,
 		}
 
 		statusChan <- "Monitoring sandbox setup..."
+		if correlationID != "" {
+			if err := monitorSetup(client, correlationID, setupTimeout, statusChan); err != nil {
+				close(statusChan)
+				return sandboxSetupCompleteMsg{err: operationError("sandbox setup", setupTimeoutHint(correlationID), err)}
+			}
+		}
+		if utils.StrictHostKeyEnabled() {
+			statusChan <- "Pinning the VM host key..."
+			if err := utils.PinHostKey(ctx, configPath, sshHost); err != nil {
+				close(statusChan)
+				return sandboxSetupCompleteMsg{err: err}
+			}
+		}
 
 		// Inform user how to connect
 		statusChan <- fmt.Sprintf("SSH configured: ssh -F %s %s", configPath, sshHost)
@@ -250,6 +316,37 @@ func setupSandboxFromConfig(client *plato.PlatoClient, sandbox *models.Sandbox,
 	}
 }
 
+// maxSetupReattaches is how many dropped event streams setup survives
+const maxSetupReattaches = 5
+
+// monitorSetup follows the setup operation's events until it finishes, for at
+// most timeout across re-attaches. The SDK already re-opens a stream that ends
+// early; this also re-attaches after a stalled stream or once the SDK gives
+// up, with a short backoff. It deliberately takes no way to re-issue the
+// setup request.
+func monitorSetup(client *plato.PlatoClient, correlationID string, timeout time.Duration, statusChan chan<- string) error {
+	deadline := time.Now().Add(timeout)
+	backoff := 2 * time.Second
+	for reattaches := 0; ; reattaches++ {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return &services.TimeoutError{Timeout: timeout}
+		}
+
+		err := client.Sandbox.MonitorOperationWithEvents(context.Background(), correlationID, remaining, statusChan)
+		var streamErr *services.StreamError
+		if err == nil || !errors.As(err, &streamErr) || reattaches >= maxSetupReattaches {
+			return err
+		}
+
+		statusChan <- fmt.Sprintf("⚠️  Lost connection to setup events (%v), re-attaching...", err)
+		time.Sleep(backoff)
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
 func waitForStatusUpdates(statusChan <-chan string) tea.Cmd {
 	return func() tea.Msg {
 		select {
@@ -266,7 +363,21 @@ func waitForStatusUpdates(statusChan <-chan string) tea.Cmd {
 	}
 }
 
-func NewVMConfigModelFromConfig(client *plato.PlatoClient, datasetName string, datasetConfig models.SimConfigDataset, service string) VMConfigModel {
+// keepalivePrefix starts the status line that shows a quiet operation is alive
+const keepalivePrefix = "⏳ Still working..."
+
+// appendKeepalive records a keepalive from the event stream, updating the
+// previous keepalive line instead of adding one per keepalive
+func appendKeepalive(messages []string, now time.Time) []string {
+	line := fmt.Sprintf("%s (last heartbeat %s)", keepalivePrefix, now.Format("15:04:05"))
+	if n := len(messages); n > 0 && strings.HasPrefix(messages[n-1], keepalivePrefix) {
+		messages[n-1] = line
+		return messages
+	}
+	return append(messages, line)
+}
+
+func NewVMConfigModelFromConfig(client *plato.PlatoClient, datasetName string, datasetConfig models.SimConfigDataset, service string, artifactID, version *string) VMConfigModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -274,8 +385,8 @@ func NewVMConfigModelFromConfig(client *plato.PlatoClient, datasetName string, d
 	m := VMConfigModel{
 		client:         client,
 		simulator:      nil,
-		artifactID:     nil,
-		version:        nil,
+		artifactID:     artifactID,
+		version:        version,
 		service:        service,
 		width:          80,
 		spinner:        s,
@@ -287,6 +398,10 @@ func NewVMConfigModelFromConfig(client *plato.PlatoClient, datasetName string, d
 		creating:       true,
 		started:        true,
 		statusChan:     make(chan string, 50), // Larger buffer for debug messages
+		timeouts:       tuiLaunchTimeouts,
+	}
+	if artifactID != nil {
+		m.statusMessages[0] = fmt.Sprintf("Starting VM creation for dataset: %s from artifact %s pinned in %s...", datasetName, *artifactID, platoConfigFilename)
 	}
 	m.lg = lipgloss.DefaultRenderer()
 
@@ -302,7 +417,8 @@ func NewVMConfigModelFromConfig(client *plato.PlatoClient, datasetName string, d
 	return m
 }
 
-func NewVMConfigModel(client *plato.PlatoClient, simulator *models.SimulatorListItem, artifactID *string, version *string, dataset *string) VMConfigModel {
+func NewVMConfigModel(client *plato.PlatoClient, opts LaunchOptions) VMConfigModel {
+	simulator := opts.Simulator
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -310,23 +426,25 @@ func NewVMConfigModel(client *plato.PlatoClient, simulator *models.SimulatorList
 	// Skip form if simulator is provided
 	skipForm := simulator != nil
 
-	// Use provided dataset or default to "base"
-	datasetValue := "base"
-	if dataset != nil {
-		datasetValue = *dataset
+	// Use provided dataset or the configured default
+	datasetValue := ""
+	if opts.Dataset != nil {
+		datasetValue = *opts.Dataset
 	}
+	datasetValue = datasetOrDefault(datasetValue)
 
 	m := VMConfigModel{
 		client:         client,
 		simulator:      simulator,
-		artifactID:     artifactID,
-		version:        version,
+		artifactID:     opts.ArtifactID,
+		version:        opts.Version,
 		width:          80,
 		spinner:        s,
 		stopwatch:      components.NewStopwatch(),
 		statusMessages: []string{},
 		skipForm:       skipForm,
 		dataset:        datasetValue,
+		timeouts:       tuiLaunchTimeouts,
 	}
 	m.lg = lipgloss.DefaultRenderer()
 
@@ -334,9 +452,15 @@ func NewVMConfigModel(client *plato.PlatoClient, simulator *models.SimulatorList
 	if skipForm {
 		m.creating = true
 		m.started = true
-		m.statusMessages = []string{fmt.Sprintf("Starting VM creation for %s...", simulator.Name)}
+		cpu, memory, disk, source := resolveLaunchCompute(simulator, datasetValue)
+		appPort, messagingPort := resolveLaunchPorts(opts, simulator, datasetValue)
+		m.statusMessages = []string{
+			fmt.Sprintf("Starting VM creation for %s...", simulator.Name),
+			fmt.Sprintf("Using %d CPU, %d MB memory, %d MB disk (%s)", cpu, memory, disk, source),
+			fmt.Sprintf("App port %d, messaging port %d", appPort, messagingPort),
+		}
 		m.statusChan = make(chan string, 50) // Larger buffer for debug messages
-		m.datasetConfig = m.buildConfig(1, 512, 10240)
+		m.datasetConfig = m.buildConfig(cpu, memory, disk, appPort, messagingPort)
 	}
 
 	theme := huh.ThemeCharm()
@@ -349,6 +473,14 @@ func NewVMConfigModel(client *plato.PlatoClient, simulator *models.SimulatorList
 	defaultCPU := "1"
 	defaultMemory := "512"
 	defaultDisk := "10240"
+	defaultApp := strconv.Itoa(defaultAppPort)
+	defaultMessaging := strconv.Itoa(defaultMessagingPort)
+	if opts.AppPort > 0 {
+		defaultApp = strconv.Itoa(opts.AppPort)
+	}
+	if opts.MessagingPort > 0 {
+		defaultMessaging = strconv.Itoa(opts.MessagingPort)
+	}
 
 	m.form = huh.NewForm(
 		huh.NewGroup(
@@ -409,11 +541,25 @@ func NewVMConfigModel(client *plato.PlatoClient, simulator *models.SimulatorList
 					return nil
 				}),
 
+			huh.NewInput().
+				Key("app_port").
+				Title("App Port").
+				Description("Port your service listens on inside the VM").
+				Value(&defaultApp).
+				Validate(validateFormPort),
+
+			huh.NewInput().
+				Key("messaging_port").
+				Title("Messaging Port").
+				Description("Port the Plato worker uses for messaging").
+				Value(&defaultMessaging).
+				Validate(validateFormPort),
+
 			huh.NewInput().
 				Key("dataset").
 				Title("Dataset Name").
 				Description("Name for the dataset in plato-config.yml").
-				Placeholder("base"),
+				Placeholder(defaultDataset()),
 
 			huh.NewInput().
 				Key("service").
@@ -450,15 +596,95 @@ func (m VMConfigModel) Init() tea.Cmd {
 		return tea.Batch(
 			m.spinner.Tick,
 			m.stopwatch.Start(),
-			createSandbox(m.client, m.datasetConfig, m.dataset, m.statusChan, m.artifactID, m.service),
+			createSandbox(m.client, models.CreateSandboxOptions{
+				Config:     &m.datasetConfig,
+				Dataset:    m.dataset,
+				Service:    m.service,
+				ArtifactID: m.artifactID,
+			}, m.timeouts.provision, m.statusChan),
 			waitForStatusUpdates(m.statusChan),
 		)
 	}
 	return m.form.Init()
 }
 
+// resolveLaunchCompute picks compute for a skip-form simulator launch.
+// The dataset's compute in plato-config.yml wins when the config belongs to this
+// simulator, then the simulator's recommended compute, then the minimum defaults.
+// Each field falls back independently so partially specified compute still works.
+func resolveLaunchCompute(simulator *models.SimulatorListItem, dataset string) (cpu, memory, disk int, source string) {
+	cpu, memory, disk, source = 1, 512, 10240, "defaults"
+
+	apply := func(c models.SimConfigCompute, from string) bool {
+		if c.Cpus <= 0 && c.Memory <= 0 && c.Disk <= 0 {
+			return false
+		}
+		if c.Cpus > 0 {
+			cpu = int(c.Cpus)
+		}
+		if c.Memory > 0 {
+			memory = int(c.Memory)
+		}
+		if c.Disk > 0 {
+			disk = int(c.Disk)
+		}
+		source = from
+		return true
+	}
+
+	if config, err := LoadPlatoConfig(); err == nil && config.Service == simulator.Name {
+		if datasetConfig, ok := config.Datasets[dataset]; ok && apply(datasetConfig.Compute, platoConfigFilename) {
+			return
+		}
+	}
+
+	if simulator.Compute != nil {
+		apply(*simulator.Compute, "simulator recommendation")
+	}
+	return
+}
+
+// resolveLaunchPorts picks the app and messaging ports for a skip-form launch:
+// explicit options first, then the dataset in plato-config.yml when it belongs
+// to this simulator, then the defaults
+func resolveLaunchPorts(opts LaunchOptions, simulator *models.SimulatorListItem, dataset string) (appPort, messagingPort int) {
+	appPort, messagingPort = defaultAppPort, defaultMessagingPort
+	if config, err := LoadPlatoConfig(); err == nil && simulator != nil && config.Service == simulator.Name {
+		if datasetConfig, ok := config.Datasets[dataset]; ok {
+			if datasetConfig.Compute.AppPort > 0 {
+				appPort = int(datasetConfig.Compute.AppPort)
+			}
+			if datasetConfig.Compute.PlatoMessagingPort > 0 {
+				messagingPort = int(datasetConfig.Compute.PlatoMessagingPort)
+			}
+		}
+	}
+	if opts.AppPort > 0 {
+		appPort = opts.AppPort
+	}
+	if opts.MessagingPort > 0 {
+		messagingPort = opts.MessagingPort
+	}
+	return
+}
+
+// validateFormPort accepts an empty value (use the default) or a valid TCP port
+func validateFormPort(s string) error {
+	if s == "" {
+		return nil
+	}
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("must be between 1-65535")
+	}
+	return nil
+}
+
 // buildConfig creates a SimConfigDataset with the given parameters
-func (m VMConfigModel) buildConfig(cpu, memory, disk int) models.SimConfigDataset {
+func (m VMConfigModel) buildConfig(cpu, memory, disk, appPort, messagingPort int) models.SimConfigDataset {
 	var name, description string
 	if m.simulator != nil {
 		name = m.simulator.Name
@@ -474,8 +700,8 @@ func (m VMConfigModel) buildConfig(cpu, memory, disk int) models.SimConfigDatase
 		Cpus:               int32(cpu),
 		Memory:             int32(memory),
 		Disk:               int32(disk),
-		AppPort:            8080,
-		PlatoMessagingPort: 7000,
+		AppPort:            int32(appPort),
+		PlatoMessagingPort: int32(messagingPort),
 	}
 
 	var variables []models.Variable
@@ -488,7 +714,7 @@ func (m VMConfigModel) buildConfig(cpu, memory, disk int) models.SimConfigDatase
 		Name:          name,
 		Description:   description,
 		SourceCodeUrl: "https://github.com/useplato/plato",
-		StartUrl:      "http://localhost:8080",
+		StartUrl:      fmt.Sprintf("http://localhost:%d", appPort),
 		License:       "MIT",
 		Variables:     variables,
 	}
@@ -508,7 +734,9 @@ func (m VMConfigModel) buildConfig(cpu, memory, disk int) models.SimConfigDatase
 func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case statusUpdateMsg:
-		if msg.message != "" {
+		if msg.message == services.KeepaliveEvent {
+			m.statusMessages = appendKeepalive(m.statusMessages, time.Now())
+		} else if msg.message != "" {
 			m.statusMessages = append(m.statusMessages, msg.message)
 		}
 		// Continue listening for more status updates if still creating or setting up
@@ -526,6 +754,7 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 		if msg.err != nil {
 			// Show error inline with other status messages instead of switching to error view
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ VM provisioning failed: %v", msg.err))
+			m.launchErr = msg.err
 			return m, m.stopwatch.Stop()
 		}
 		// Don't add another success message - SSE events already showed completion
@@ -545,7 +774,7 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 		m.settingUp = true
 		m.statusChan = make(chan string, 50) // Larger buffer for debug messages
 		return m, tea.Batch(
-			setupSandboxFromConfig(m.client, msg.sandbox, m.datasetConfig, m.dataset, m.statusChan),
+			setupSandboxFromConfig(m.client, msg.sandbox, m.datasetConfig, m.dataset, m.timeouts.setup, m.statusChan),
 			waitForStatusUpdates(m.statusChan),
 		)
 
@@ -554,6 +783,7 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 		if msg.err != nil {
 			// Show error inline with other status messages instead of switching to error view
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Sandbox setup failed: %v", msg.err))
+			m.launchErr = msg.err
 			// write error to file
 			errFile, err := os.Create("setup_error.txt")
 			if err != nil {
@@ -585,6 +815,7 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 					fromExistingSim:   m.artifactID != nil, // True if launched with artifact ID
 					artifactID:        m.artifactID,
 					version:           m.version,
+					appPort:           int(m.datasetConfig.Compute.AppPort),
 				}
 			},
 		)
@@ -646,10 +877,15 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 		if diskVal == "" {
 			diskVal = "10240"
 		}
-		datasetVal := m.form.GetString("dataset")
-		if datasetVal == "" {
-			datasetVal = "base"
+		appPort, err := strconv.Atoi(m.form.GetString("app_port"))
+		if err != nil {
+			appPort = defaultAppPort
+		}
+		messagingPort, err := strconv.Atoi(m.form.GetString("messaging_port"))
+		if err != nil {
+			messagingPort = defaultMessagingPort
 		}
+		datasetVal := datasetOrDefault(m.form.GetString("dataset"))
 		serviceVal := m.form.GetString("service")
 		if serviceVal == "" {
 			serviceVal = "my-service"
@@ -660,7 +896,7 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 		disk, _ := strconv.Atoi(diskVal)
 
 		// Build SimConfigDataset using helper method
-		datasetConfig := m.buildConfig(cpu, memory, disk)
+		datasetConfig := m.buildConfig(cpu, memory, disk, appPort, messagingPort)
 
 		// Save config if requested
 		saveConfig := m.form.GetBool("save_config")
@@ -692,7 +928,11 @@ func (m VMConfigModel) Update(msg tea.Msg) (VMConfigModel, tea.Cmd) {
 
 		cmds = append(cmds, m.spinner.Tick)
 		cmds = append(cmds, m.stopwatch.Start())
-		cmds = append(cmds, createSandbox(m.client, datasetConfig, datasetVal, m.statusChan, nil, m.service))
+		cmds = append(cmds, createSandbox(m.client, models.CreateSandboxOptions{
+			Config:  &datasetConfig,
+			Dataset: datasetVal,
+			Service: m.service,
+		}, m.timeouts.provision, m.statusChan))
 		cmds = append(cmds, waitForStatusUpdates(m.statusChan))
 	}
 
@@ -10,15 +10,21 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"plato-cli/internal/session"
 	"plato-cli/internal/ui/components"
 	"plato-cli/internal/utils"
 	plato "plato-sdk"
 	"plato-sdk/models"
+	"plato-sdk/services"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -51,8 +57,11 @@ func logErrorToFile(filename, message string) error {
 }
 
 type proxytunnelMapping struct {
-	localPort  int
-	remotePort int
+	bindAddress string
+	localPort   int
+	remotePort  int
+	// reconnecting is set while a tunnel found dead is being restarted
+	reconnecting bool
 }
 
 type VMInfoModel struct {
@@ -75,28 +84,55 @@ type VMInfoModel struct {
 	sshPrivateKeyPath    string
 	viewport             viewport.Model
 	viewportReady        bool
-	heartbeatStop        chan struct{}
-	heartbeatStopped     bool
+	heartbeats           *services.HeartbeatManager
+	heartbeatWarnings    chan heartbeatWarningMsg
 	fromExistingSim      bool
 	rootPasswordSetup    bool
 	proxytunnelProcesses []*exec.Cmd
 	proxytunnelMappings  []proxytunnelMapping
+	tunnelHealth         *tunnelHealthMonitor
 	config               *models.PlatoConfig
-	lastPushedBranch     string // Tracks the last branch pushed to hub
-	cachedCloneCmd       string // Cached clone command to avoid repeated API calls
-	hubRepoURL           string // Cached hub repository URL
-	infoPanelFocused     bool   // Whether the info panel has focus (vs actions list)
-	runningCommand       bool   // Whether a command is currently running
-	ecrAuthenticated     bool   // Whether ECR authentication has been completed
+	appPort              int                // Port the service listens on, 0 if unknown
+	lastPushedBranch     string             // Tracks the last branch pushed to hub
+	cachedCloneCmd       string             // Cached clone command to avoid repeated API calls
+	hubRepoURL           string             // Cached hub repository URL
+	infoPanelFocused     bool               // Whether the info panel has focus (vs actions list)
+	runningCommand       bool               // Whether a command is currently running
+	ecrAuthenticated     bool               // Whether ECR authentication has been completed
+	auditUIProcess       *exec.Cmd          // Running streamlit audit UI, if launched
+	stateFetchCancel     context.CancelFunc // Cancels an in-flight Get State request
+	stateFetchBytes      *atomic.Int64      // Bytes received so far by the in-flight Get State
+	stateView            string             // Retrieved state JSON shown in the info panel, if any
+	opCancel             context.CancelFunc // Cancels the running operation started by runOperation
+	opName               string             // Name of the running operation, for the cancel message
+	opSeq                int                // Identifies the latest operation so results of cancelled ones are dropped
+	setupErr             error              // Sandbox setup failure, reported in the exit code
+	commitPending        bool               // Commit State is waiting for the user to confirm
+	committing           bool               // Commit State is chaining service start into a snapshot
+	commitService        string             // Service being committed
+	progressLine         string             // Last transient progress line, replaced by the next one
+	launchDir            string             // Directory the VM was launched from, when reconnected to it
+	logsOpen             bool               // Whether the info panel shows the View Logs tail
+	logsCancel           context.CancelFunc // Stops the log stream of View Logs
+	logsSeq              int                // Identifies the latest log stream so lines of closed ones are dropped
+	logLines             []string           // Most recent log lines, at most maxLogLines
 }
 
 type vmAction struct {
 	title       string
 	description string
+	// disabledReason is set when the action cannot run yet; see
+	// refreshActionAvailability
+	disabledReason string
 }
 
-func (v vmAction) Title() string       { return v.title }
-func (v vmAction) Description() string { return v.description }
+func (v vmAction) Title() string { return v.title }
+func (v vmAction) Description() string {
+	if v.disabledReason != "" {
+		return "Unavailable: " + v.disabledReason
+	}
+	return v.description
+}
 func (v vmAction) FilterValue() string { return v.title }
 
 type sandboxSetupMsg struct {
@@ -110,31 +146,55 @@ type rootPasswordSetupMsg struct {
 	err error
 }
 
+type sshKeyRotatedMsg struct {
+	privateKeyPath string
+	err            error
+}
+
 type snapshotCreatedMsg struct {
 	err       error
 	response  *models.CreateSnapshotResponse
 	debugInfo []string
 }
 
+// datasetSnapshotResult is the outcome of one snapshot in a batch
+type datasetSnapshotResult struct {
+	dataset  string
+	response *models.CreateSnapshotResponse
+	err      error
+}
+
+type snapshotsCreatedMsg struct {
+	err     error
+	results []datasetSnapshotResult
+	// debugInfo holds cleanup warnings and the dataset config applied
+	debugInfo []string
+}
+
 type checkpointCreatedMsg struct {
 	err      error
 	response *models.CreateSnapshotResponse
 }
 
 type proxytunnelOpenedMsg struct {
-	localPort  int
-	remotePort int
-	cmd        *exec.Cmd
-	err        error
+	bindAddress string
+	localPort   int
+	remotePort  int
+	cmd         *exec.Cmd
+	err         error
 }
 
 type workerStartedMsg struct {
 	err      error
 	response *models.StartWorkerResponse
+	// logs holds the tail of the worker output when setup failed
+	logs []string
 }
 
 type cursorOpenedMsg struct {
 	err error
+	// warnings describe SSH config entries that may shadow Plato's hosts
+	warnings []string
 }
 
 type hubPushMsg struct {
@@ -170,12 +230,15 @@ func NewVMInfoModel(client *plato.PlatoClient, sandbox *models.Sandbox, dataset
 		vmAction{title: "Start Service", description: "Start the service defined in plato-config.yml"},
 		vmAction{title: "Start Plato Worker", description: "Start the Plato worker process"},
 		vmAction{title: "Connect to Cursor/VSCode", description: "Open Cursor/VSCode editor connected to VM via SSH"},
+		vmAction{title: "View Logs", description: "Tail the container logs of the VM"},
 		vmAction{title: "Snapshot VM", description: "Create snapshot of current VM state"},
+		vmAction{title: "Commit State", description: "Push, restart the service and snapshot in one step"},
+		vmAction{title: "Clean State", description: "Clear the audit log and env state without snapshotting"},
 		vmAction{title: "Advanced", description: "Advanced VM management options"},
 		vmAction{title: "Close VM", description: "Shutdown and cleanup VM"},
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 40, 24)
+	l := list.New(items, newVMActionDelegate(), 40, 24)
 	l.Title = "Actions"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
@@ -189,13 +252,28 @@ func NewVMInfoModel(client *plato.PlatoClient, sandbox *models.Sandbox, dataset
 		BorderForeground(vmInfoIndigo).
 		PaddingLeft(1)
 
+	// Heartbeat failures are shown in the status panel, since the VM expires
+	// if they keep failing
+	heartbeatWarnings := make(chan heartbeatWarningMsg, 1)
+	heartbeats := services.NewHeartbeatManager(client.Sandbox, services.WithHeartbeatFailures(services.DefaultHeartbeatFailureThreshold, func(_ string, failures int, err error) {
+		// Drop the warning rather than hold up heartbeats if the UI is behind
+		select {
+		case heartbeatWarnings <- heartbeatWarningMsg{failures: failures, err: err}:
+		default:
+		}
+	}))
+
 	// Try to load plato-config.yml
 	var config *models.PlatoConfig
+	appPort := 0
 	if cfg, err := LoadPlatoConfig(); err == nil {
 		config = cfg
+		if datasetConfig, ok := cfg.Datasets[dataset]; ok {
+			appPort = int(datasetConfig.Compute.AppPort)
+		}
 	}
 
-	return VMInfoModel{
+	m := VMInfoModel{
 		client:               client,
 		sandbox:              sandbox,
 		dataset:              dataset,
@@ -210,43 +288,73 @@ func NewVMInfoModel(client *plato.PlatoClient, sandbox *models.Sandbox, dataset
 		statusMessages:       []string{},
 		viewport:             vp,
 		viewportReady:        true,
-		heartbeatStop:        make(chan struct{}),
-		heartbeatStopped:     false,
+		heartbeats:           heartbeats,
+		heartbeatWarnings:    heartbeatWarnings,
 		fromExistingSim:      fromExistingSim,
 		rootPasswordSetup:    false,
 		proxytunnelProcesses: []*exec.Cmd{},
 		proxytunnelMappings:  []proxytunnelMapping{},
+		tunnelHealth:         &tunnelHealthMonitor{},
 		config:               config,
+		appPort:              appPort,
 		infoPanelFocused:     false, // Start with actions list focused
 		ecrAuthenticated:     false,
 	}
+	m.refreshActionAvailability()
+	return m
 }
 
-func (m VMInfoModel) startHeartbeat() {
-	// Start heartbeat goroutine
-	go func() {
-		ticker := time.NewTicker(30 * time.Second) // Send heartbeat every 30 seconds
-		defer ticker.Stop()
+// heartbeatWarningMsg reports heartbeats failing in a row (failures > 0) or
+// recovering (failures == 0)
+type heartbeatWarningMsg struct {
+	failures int
+	err      error
+}
 
-		ctx := context.Background()
+// startHeartbeat keeps the VM alive until Close VM and returns a command that
+// delivers heartbeat warnings to the model. Init runs again whenever the view
+// is shown, so only the first call starts anything.
+func (m VMInfoModel) startHeartbeat() tea.Cmd {
+	if !m.heartbeats.Start(context.Background(), m.sandbox.JobGroupId) {
+		return nil
+	}
+	return waitForHeartbeatWarning(m.heartbeatWarnings)
+}
 
-		// Send initial heartbeat immediately
-		_ = m.client.Sandbox.SendHeartbeat(ctx, m.sandbox.JobGroupId)
+// saveSession stores the VM with its SSH setup and open tunnels, so Reconnect
+// to VM can pick it up again if the TUI exits before Close VM
+func (m VMInfoModel) saveSession() {
+	if m.sandbox == nil || !m.setupComplete || m.setupErr != nil {
+		return
+	}
+	service := ""
+	if m.config != nil {
+		service = m.config.Service
+	}
+	vm := newVMSession(m.sandbox, m.dataset, service, m.artifactID, m.version)
+	if m.launchDir != "" {
+		vm.Dir = m.launchDir
+	}
+	vm.SSHURL, vm.SSHHost, vm.SSHConfigPath, vm.SSHPrivateKeyPath = m.sshURL, m.sshHost, m.sshConfigPath, m.sshPrivateKeyPath
+	for i, mapping := range m.proxytunnelMappings {
+		if i >= len(m.proxytunnelProcesses) || m.proxytunnelProcesses[i] == nil || m.proxytunnelProcesses[i].Process == nil {
+			continue
+		}
+		vm.Tunnels = append(vm.Tunnels, session.Tunnel{
+			PID:         m.proxytunnelProcesses[i].Process.Pid,
+			BindAddress: mapping.bindAddress,
+			LocalPort:   mapping.localPort,
+			RemotePort:  mapping.remotePort,
+		})
+	}
+	saveVMSession(vm)
+}
 
-		for {
-			select {
-			case <-ticker.C:
-				// Send heartbeat
-				if err := m.client.Sandbox.SendHeartbeat(ctx, m.sandbox.JobGroupId); err != nil {
-					// Silently fail - don't interrupt the UI
-					continue
-				}
-			case <-m.heartbeatStop:
-				// Stop the heartbeat
-				return
-			}
-		}
-	}()
+// waitForHeartbeatWarning waits for the next heartbeat warning
+func waitForHeartbeatWarning(warnings chan heartbeatWarningMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-warnings
+	}
 }
 
 // wrapText wraps text to the specified width, breaking on word boundaries
@@ -291,13 +399,11 @@ func wrapText(text string, width int) string {
 func (m VMInfoModel) Init() tea.Cmd {
 	// Setup should already be done when we reach this view
 	// Start sending heartbeats to keep the VM alive
-	m.startHeartbeat()
-
-	var cmds []tea.Cmd
+	cmds := []tea.Cmd{m.startHeartbeat(), m.startTunnelHealth()}
 
 	// Automatically authenticate with ECR if setup is complete and not already authenticated
 	// This handles the case where the VM is initialized via navigateToVMInfoMsg (bypassing sandboxSetupMsg)
-	if m.setupComplete && !m.ecrAuthenticated && m.sshHost != "" && m.sshConfigPath != "" {
+	if m.setupComplete && !m.ecrAuthenticated && m.sshHost != "" && m.sshConfigPath != "" && ecrAutoAuthEnabled(m.config) {
 		cmds = append(cmds, func() tea.Msg {
 			return triggerECRAuthMsg{}
 		})
@@ -308,11 +414,18 @@ func (m VMInfoModel) Init() tea.Cmd {
 		cmds = append(cmds, fetchHubRepoURL(m.client, m.config.Service))
 	}
 
-	if len(cmds) > 0 {
-		return tea.Batch(cmds...)
-	}
+	return tea.Batch(cmds...)
+}
 
-	return nil
+// refreshViewport re-renders the info panel. While the panel is focused and
+// already scrolled to the bottom it stays pinned there, so new status lines
+// are visible; if the user has scrolled up their position is kept.
+func (m *VMInfoModel) refreshViewport() {
+	follow := m.infoPanelFocused && m.viewport.AtBottom()
+	m.viewport.SetContent(m.renderVMInfoMarkdown())
+	if follow {
+		m.viewport.GotoBottom()
+	}
 }
 
 func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
@@ -325,7 +438,7 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 				m.runningCommand = false
 			}
 			// Update viewport content to reflect new status
-			m.viewport.SetContent(m.renderVMInfoMarkdown())
+			m.refreshViewport()
 		}
 		if m.settingUp && m.statusChan != nil {
 			return m, waitForStatusUpdates(m.statusChan)
@@ -337,21 +450,24 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 		m.setupComplete = true
 		if msg.err != nil {
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Setup failed: %v", msg.err))
+			m.setupErr = msg.err
+			m.refreshActionAvailability()
 			return m, nil
 		} else {
 			m.sshURL = msg.sshURL
 			m.sshHost = msg.sshHost
 			m.sshConfigPath = msg.sshConfigPath
+			m.refreshActionAvailability()
+			m.saveSession()
 			m.statusMessages = append(m.statusMessages, "✓ Sandbox ready!")
 			// Automatically authenticate with ECR for 2 hours (ECR tokens are valid for 12 hours by default)
-			if !m.ecrAuthenticated && m.sshHost != "" && m.sshConfigPath != "" {
+			if !m.ecrAuthenticated && m.sshHost != "" && m.sshConfigPath != "" && ecrAutoAuthEnabled(m.config) {
 				m.statusMessages = append(m.statusMessages, "🔐 Authenticating Docker with AWS ECR...")
-				m.runningCommand = true
-				return m, tea.Batch(m.spinner.Tick, authenticateECR(m.sshHost, m.sshConfigPath))
+				return m, m.startECRAuth()
 			}
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case rootPasswordSetupMsg:
@@ -376,7 +492,81 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			}
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
+		return m, nil
+
+	case sshKeyRotatedMsg:
+		m.runningCommand = false
+		if msg.err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ SSH key rotation failed: %v", msg.err))
+		} else {
+			forgetResource(session.KindSSHKey, m.sshPrivateKeyPath)
+			m.sshPrivateKeyPath = msg.privateKeyPath
+			recordResource(session.KindSSHKey, m.sshPrivateKeyPath, m.sandbox.PublicId, m.sshHost)
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ SSH key rotated: %s", msg.privateKeyPath))
+
+			// Keep .sandbox.yaml pointing at the new key
+			platoConfigPath := ""
+			if configDir, err := GetPlatoConfigDir(); err == nil {
+				platoConfigPath = filepath.Join(configDir, platoConfigFilename)
+			}
+			if err := WriteSandboxFile(m.sandbox, m.dataset, platoConfigPath, m.artifactID, m.version, m.sshHost, m.sshConfigPath, m.sshPrivateKeyPath); err != nil {
+				utils.LogDebug("Failed to update .sandbox.yaml after key rotation: %v", err)
+			}
+			m.saveSession()
+		}
+		m.refreshViewport()
+		return m, nil
+
+	case timeoutExtendedMsg:
+		m.runningCommand = false
+		if msg.err != nil {
+			if endpointUnsupported(msg.err) {
+				m.statusMessages = append(m.statusMessages, "❌ Extending timeout failed: this Plato API cannot extend VM timeouts yet")
+			} else if vmExpired(msg.err) {
+				m.statusMessages = append(m.statusMessages, "❌ Extending timeout failed: the VM has expired or was deleted")
+			} else {
+				m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Extending timeout failed: %v", msg.err))
+			}
+		} else {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Extended VM lifetime by %s. %s", msg.extension, describeExpiry(msg.response, time.Now())))
+		}
+		m.refreshViewport()
+		return m, nil
+
+	case stateCleanedMsg:
+		m.runningCommand = false
+		m.statusMessages = append(m.statusMessages, msg.report.Lines()...)
+		if msg.err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Cleanup failed: %v", msg.err))
+		} else {
+			m.statusMessages = append(m.statusMessages, "✓ State cleaned")
+		}
+		m.refreshViewport()
+		return m, nil
+
+	case tunnelHealthTickMsg, tunnelsProbedMsg, proxytunnelRestartedMsg:
+		return m.updateTunnelHealth(msg)
+
+	case heartbeatWarningMsg:
+		if vmExpired(msg.err) {
+			m.statusMessages = append(m.statusMessages, "❌ The VM has expired or was deleted; close it and launch a new one")
+		} else if msg.failures > 0 {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  %d heartbeats in a row failed; the VM will expire if this continues: %v", msg.failures, msg.err))
+		} else {
+			m.statusMessages = append(m.statusMessages, "✓ Heartbeats are getting through again")
+		}
+		m.refreshViewport()
+		return m, waitForHeartbeatWarning(m.heartbeatWarnings)
+
+	case envStateResetMsg:
+		m.runningCommand = false
+		if msg.err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Env state reset failed: %v", msg.err))
+		} else {
+			m.statusMessages = append(m.statusMessages, "✓ Env state reset")
+		}
+		m.refreshViewport()
 		return m, nil
 
 	case snapshotCreatedMsg:
@@ -400,11 +590,44 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 				m.statusMessages = append(m.statusMessages, fmt.Sprintf("   S3 URI: %s", msg.response.S3Uri))
 			}
 			// Clear the last pushed branch and cached clone cmd since it's been merged
+			if m.lastPushedBranch != "" {
+				forgetResource(session.KindBranch, m.lastPushedBranch)
+			}
 			m.lastPushedBranch = ""
 			m.cachedCloneCmd = ""
+			if m.committing {
+				m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Committed current state as a new version of dataset '%s'", m.dataset))
+			}
 		}
+		m.committing = false
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
+		return m, nil
+
+	case snapshotsCreatedMsg:
+		m.runningCommand = false
+		if msg.err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Snapshot failed: %v", msg.err))
+		} else {
+			m.statusMessages = append(m.statusMessages, msg.debugInfo...)
+			succeeded := 0
+			for _, r := range msg.results {
+				if r.err != nil {
+					m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ %s: %v", r.dataset, r.err))
+					continue
+				}
+				succeeded++
+				m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ %s: %s (%s)", r.dataset, r.response.ArtifactId, r.response.Status))
+			}
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("Created %d of %d snapshots", succeeded, len(msg.results)))
+			// The branch was merged before the first snapshot
+			if m.lastPushedBranch != "" {
+				forgetResource(session.KindBranch, m.lastPushedBranch)
+			}
+			m.lastPushedBranch = ""
+			m.cachedCloneCmd = ""
+		}
+		m.refreshViewport()
 		return m, nil
 
 	case checkpointCreatedMsg:
@@ -420,34 +643,40 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			}
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case workerStartedMsg:
 		if msg.err != nil {
 			m.runningCommand = false
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Worker start failed: %v", msg.err))
+			if len(msg.logs) > 0 {
+				m.statusMessages = append(m.statusMessages, "   Recent worker logs:")
+				for _, line := range msg.logs {
+					m.statusMessages = append(m.statusMessages, "   "+line)
+				}
+			}
 			// Update viewport content to reflect new status
-			m.viewport.SetContent(m.renderVMInfoMarkdown())
+			m.refreshViewport()
 		} else if msg.response != nil {
 			m.statusMessages = append(m.statusMessages, "✓ Worker start initiated!")
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Status: %s", msg.response.Status))
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Monitoring progress via correlation ID: %s", msg.response.CorrelationId))
 			// Update viewport content to reflect new status
-			m.viewport.SetContent(m.renderVMInfoMarkdown())
+			m.refreshViewport()
 			// Monitor the operation using SSE events
-			return m, tea.Batch(
-				m.spinner.Tick,
-				func() tea.Msg {
-					ctx := context.Background()
-					err := m.client.Sandbox.MonitorOperation(ctx, msg.response.CorrelationId, 10*time.Minute)
+			client, publicID, correlationID := m.client, m.sandbox.PublicId, msg.response.CorrelationId
+			return m, m.runOperation("worker setup", func(ctx context.Context) tea.Cmd {
+				return func() tea.Msg {
+					err := client.Sandbox.MonitorOperation(ctx, correlationID, 10*time.Minute)
 					if err != nil {
-						return workerStartedMsg{err: fmt.Errorf("worker setup failed: %w", err), response: nil}
+						err = operationError("worker setup", fmt.Sprintf("the worker may still be starting, follow it with `plato events %s`", correlationID), err)
+						return workerStartedMsg{err: err, response: nil, logs: fetchWorkerLogTail(client, publicID)}
 					}
 					// Success - add a final message
 					return statusUpdateMsg{message: "✓ Worker setup complete!"}
-				},
-			)
+				}
+			})
 		}
 		return m, nil
 
@@ -458,6 +687,7 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 		} else {
 			m.lastPushedBranch = msg.branchName
 			m.cachedCloneCmd = msg.cloneCmd // Cache the clone command
+			recordResource(session.KindBranch, msg.branchName, m.sandbox.PublicId, branchDetail(m.config))
 			m.statusMessages = append(m.statusMessages, "✓ Successfully pushed to Plato Hub!")
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Repository: %s", msg.repoURL))
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Branch: %s", msg.branchName))
@@ -466,7 +696,7 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   %s", msg.cloneCmd))
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case serviceStartedMsg:
@@ -485,6 +715,7 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			}
 		} else {
 			m.lastPushedBranch = msg.branchName
+			recordResource(session.KindBranch, msg.branchName, m.sandbox.PublicId, branchDetail(m.config))
 			m.statusMessages = append(m.statusMessages, "✓ Service started successfully!")
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Repository: %s", msg.repoURL))
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("   Branch: %s", msg.branchName))
@@ -493,22 +724,31 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 				m.statusMessages = append(m.statusMessages, info)
 			}
 		}
+		if m.committing {
+			if msg.err != nil {
+				m.committing = false
+				m.statusMessages = append(m.statusMessages, "❌ Commit stopped: the service did not start, no snapshot was taken")
+			} else {
+				m.refreshViewport()
+				return m, m.startCommitSnapshot()
+			}
+		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case triggerECRAuthMsg:
 		// Trigger ECR authentication
 		m.statusMessages = append(m.statusMessages, "🔐 Authenticating Docker with AWS ECR...")
-		m.runningCommand = true
-		return m, tea.Batch(m.spinner.Tick, authenticateECR(m.sshHost, m.sshConfigPath))
+		return m, m.startECRAuth()
 
 	case auditUILaunchedMsg:
 		m.runningCommand = false
 		if msg.err != nil {
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ %v", msg.err))
 		} else {
-			m.statusMessages = append(m.statusMessages, "✅ Audit Ignore UI launched at http://localhost:8501")
+			m.auditUIProcess = msg.cmd
+			m.statusMessages = append(m.statusMessages, "✅ Audit Ignore UI launched at http://localhost:8501 (press x to stop it)")
 		}
 		return m, nil
 
@@ -538,12 +778,23 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			}
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
+	case logStreamOpenedMsg, logLinesMsg, logStreamEndedMsg:
+		return m.updateLogs(msg)
+
 	case stateRetrievedMsg:
 		m.runningCommand = false
-		if msg.err != nil {
+		received := int64(0)
+		if m.stateFetchBytes != nil {
+			received = m.stateFetchBytes.Load()
+		}
+		m.stateFetchCancel = nil
+		m.stateFetchBytes = nil
+		if errors.Is(msg.err, context.Canceled) {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  Get State cancelled after %s", formatByteCount(received)))
+		} else if msg.err != nil {
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Failed to get state: %v", msg.err))
 		} else {
 			// Save state to file
@@ -577,10 +828,17 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 						m.statusMessages = append(m.statusMessages, fmt.Sprintf("   💡 View with: cat %s", relPath))
 					}
 				}
+
+				// Show the state in the info panel
+				m.stateView = string(stateJSON)
+				m.infoPanelFocused = true
+				m.viewport.SetContent(m.renderVMInfoMarkdown())
+				m.viewport.GotoTop()
+				return m, nil
 			}
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case ecrAuthenticatedMsg:
@@ -601,14 +859,14 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 			m.statusMessages = append(m.statusMessages, "✓ Successfully authenticated Docker with AWS ECR (valid for 12 hours)")
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case hubRepoURLMsg:
 		// Cache the hub repo URL for display
 		m.hubRepoURL = msg.url
 		// Update viewport content with new info
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case proxytunnelOpenedMsg:
@@ -619,31 +877,72 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 		} else {
 			m.proxytunnelProcesses = append(m.proxytunnelProcesses, msg.cmd)
 			m.proxytunnelMappings = append(m.proxytunnelMappings, proxytunnelMapping{
-				localPort:  msg.localPort,
-				remotePort: msg.remotePort,
+				bindAddress: msg.bindAddress,
+				localPort:   msg.localPort,
+				remotePort:  msg.remotePort,
 			})
-			m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Proxytunnel: localhost:%d → remote:%d", msg.localPort, msg.remotePort))
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("✓ Proxytunnel: %s → remote:%d", utils.ListenAddress(msg.bindAddress, msg.localPort), msg.remotePort))
+			if msg.cmd != nil && msg.cmd.Process != nil {
+				recordResource(session.KindTunnel, strconv.Itoa(msg.cmd.Process.Pid), m.sandbox.PublicId,
+					fmt.Sprintf("%s → remote:%d", utils.ListenAddress(msg.bindAddress, msg.localPort), msg.remotePort))
+			}
 			utils.LogDebug("Added to lists, now have %d processes and %d mappings", len(m.proxytunnelProcesses), len(m.proxytunnelMappings))
+			m.saveSession()
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
 	case cursorOpenedMsg:
 		utils.LogDebug("cursorOpenedMsg received, err=%v", msg.err)
 		m.runningCommand = false
+		for _, warning := range msg.warnings {
+			m.statusMessages = append(m.statusMessages, "⚠️  "+warning)
+		}
 		if msg.err != nil {
 			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Failed to open Cursor: %v", msg.err))
 		} else {
 			m.statusMessages = append(m.statusMessages, "✓ Cursor opened successfully")
 		}
 		// Update viewport content to reflect new status
-		m.viewport.SetContent(m.renderVMInfoMarkdown())
+		m.refreshViewport()
 		return m, nil
 
+	case operationDoneMsg:
+		if msg.seq != m.opSeq {
+			utils.LogDebug("Ignoring result of cancelled operation: %T", msg.msg)
+			return m, nil
+		}
+		if m.opCancel != nil {
+			m.opCancel()
+			m.opCancel = nil
+		}
+		return m.Update(msg.msg)
+
+	case operationProgressMsg:
+		if msg.seq != m.opSeq {
+			return m, nil
+		}
+		last := len(m.statusMessages) - 1
+		if msg.transient && last >= 0 && m.progressLine != "" && m.statusMessages[last] == m.progressLine {
+			m.statusMessages[last] = msg.message
+		} else {
+			m.statusMessages = append(m.statusMessages, msg.message)
+		}
+		m.progressLine = ""
+		if msg.transient {
+			m.progressLine = msg.message
+		}
+		m.refreshViewport()
+		return m, waitForOperationProgress(msg.seq, msg.updates)
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
+		// Refresh the Get State progress line on every spinner frame
+		if m.stateFetchBytes != nil && len(m.statusMessages) > 0 {
+			m.statusMessages[len(m.statusMessages)-1] = fmt.Sprintf("Fetching simulator state... %s received (esc to cancel)", formatByteCount(m.stateFetchBytes.Load()))
+		}
 		return m, cmd
 
 	case tea.WindowSizeMsg:
@@ -659,16 +958,73 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
+			m.stopAuditUI()
+			m.closeLogs()
 			return m, tea.Quit
+		case "esc":
+			if m.stateFetchCancel != nil {
+				m.stateFetchCancel()
+				return m, nil
+			}
+			if m.runningCommand && m.opCancel != nil {
+				m.cancelOperation()
+				return m, nil
+			}
+			if m.commitPending {
+				m.cancelCommit()
+				return m, nil
+			}
+			if m.stateView != "" && !m.runningCommand {
+				m.stateView = ""
+				m.refreshViewport()
+				m.viewport.GotoTop()
+				return m, nil
+			}
+			if m.logsOpen {
+				m.closeLogs()
+				m.refreshViewport()
+				m.viewport.GotoTop()
+				return m, nil
+			}
+		case "c":
+			if m.runningCommand && m.opCancel != nil {
+				m.cancelOperation()
+				return m, nil
+			}
+		case "y":
+			if m.commitPending && !m.runningCommand {
+				return m, m.startCommit()
+			}
+		case "n":
+			if m.commitPending {
+				m.cancelCommit()
+				return m, nil
+			}
+		case "x":
+			if m.auditUIProcess != nil && !m.settingUp && !m.runningCommand {
+				m.stopAuditUI()
+				m.statusMessages = append(m.statusMessages, "✓ Stopped Audit Ignore UI")
+				m.refreshViewport()
+				return m, nil
+			}
 		case "i":
 			// Toggle focus between actions list and info panel
 			m.infoPanelFocused = !m.infoPanelFocused
 			// Update viewport content when focusing
 			if m.infoPanelFocused {
-				renderedMarkdown := m.renderVMInfoMarkdown()
-				m.viewport.SetContent(renderedMarkdown)
+				m.refreshViewport()
 			}
 			return m, nil
+		case "g", "home":
+			if m.infoPanelFocused {
+				m.viewport.GotoTop()
+				return m, nil
+			}
+		case "G", "end":
+			if m.infoPanelFocused {
+				m.viewport.GotoBottom()
+				return m, nil
+			}
 		case "enter":
 			if !m.settingUp && !m.runningCommand {
 				selectedItem := m.actionList.SelectedItem()
@@ -705,18 +1061,34 @@ func (m VMInfoModel) Update(msg tea.Msg) (VMInfoModel, tea.Cmd) {
 func (m VMInfoModel) renderVMInfoMarkdown() string {
 	var output strings.Builder
 
+	// Retrieved state replaces the VM info until dismissed with esc
+	if m.stateView != "" {
+		output.WriteString("SIMULATOR STATE (esc to close)\n")
+		output.WriteString(strings.Repeat("─", 50) + "\n\n")
+		output.WriteString(m.stateView)
+		output.WriteString("\n")
+		return output.String()
+	}
+	if m.logsOpen {
+		return m.renderLogs()
+	}
+
 	// VM Information section
 	output.WriteString("VM INFORMATION\n")
 	output.WriteString(strings.Repeat("─", 50) + "\n\n")
+	output.WriteString(fmt.Sprintf("Name:     %s\n", m.sandbox.DisplayName()))
 	output.WriteString(fmt.Sprintf("Job ID:   %s\n", m.sandbox.PublicId))
 	output.WriteString(fmt.Sprintf("Dataset:  %s\n", m.dataset))
 	if m.artifactID != nil {
-		output.WriteString(fmt.Sprintf("Artifact: %s\n", *m.artifactID))
+		output.WriteString(fmt.Sprintf("Artifact: %s%s\n", *m.artifactID, pinnedArtifactLabel(m.config, m.dataset, m.artifactID, m.version)))
 	}
 	if m.version != nil {
 		output.WriteString(fmt.Sprintf("Version:  %s\n", *m.version))
 	}
 	output.WriteString(fmt.Sprintf("URL:      %s\n", m.sandbox.Url))
+	if m.appPort > 0 {
+		output.WriteString(fmt.Sprintf("App Port: %d\n", m.appPort))
+	}
 
 	// Show hub.plato.so repository link if we have it cached
 	if m.hubRepoURL != "" {
@@ -738,7 +1110,11 @@ func (m VMInfoModel) renderVMInfoMarkdown() string {
 		if len(m.proxytunnelMappings) > 0 {
 			output.WriteString("\nActive Proxytunnels:\n")
 			for _, mapping := range m.proxytunnelMappings {
-				output.WriteString(fmt.Sprintf("  • localhost:%d → remote:%d\n", mapping.localPort, mapping.remotePort))
+				state := ""
+				if mapping.reconnecting {
+					state = " (reconnecting)"
+				}
+				output.WriteString(fmt.Sprintf("  • %s → remote:%d%s\n", utils.ListenAddress(mapping.bindAddress, mapping.localPort), mapping.remotePort, state))
 			}
 		}
 
@@ -752,7 +1128,7 @@ func (m VMInfoModel) renderVMInfoMarkdown() string {
 			if m.cachedCloneCmd != "" {
 				output.WriteString("\nClone Command (with auth):\n")
 				output.WriteString(fmt.Sprintf("  %s\n", m.cachedCloneCmd))
-				output.WriteString("\nThis branch will be merged into main when you snapshot.\n")
+				output.WriteString(fmt.Sprintf("\nThis branch will be merged into %s when you snapshot.\n", snapshotBranchLabel(hubSnapshotBranch(m.config))))
 			}
 		}
 	}
@@ -791,148 +1167,184 @@ func (m VMInfoModel) renderVMInfoMarkdown() string {
 	return output.String()
 }
 
-func createSnapshotWithCleanup(client *plato.PlatoClient, publicID, jobGroupID, service string, dataset *string, branchName string) tea.Cmd {
-	return func() tea.Msg {
-		// Step 1: Perform pre-snapshot cleanup
-		datasetName := "base"
-		if dataset != nil {
-			datasetName = *dataset
-		}
-		utils.LogDebug("Starting pre-snapshot cleanup for service: %s, dataset: %s", service, datasetName)
-		needsDBConfig, err := utils.PreSnapshotCleanup(client, publicID, jobGroupID, service, datasetName)
-		if err != nil {
-			utils.LogDebug("Pre-snapshot cleanup failed: %v", err)
-			// Don't fail the snapshot if cleanup fails, just log it
-		}
-		if needsDBConfig {
-			// This shouldn't happen here since we check before calling this function
-			utils.LogDebug("Warning: DB config needed but not provided")
-		}
-
-		// Step 2: Create the snapshot
-		// Use a timeout context to prevent hanging (snapshots can take a while)
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		var gitHash *string
-
-		// If a branch was pushed, merge it to main and get the commit hash
-		if branchName != "" {
-			hash, err := mergeHubBranchToMain(client, service, branchName)
-			if err != nil {
-				logErr := logErrorToFile("plato_error.log", fmt.Sprintf("Failed to merge branch to main: %v", err))
-				if logErr != nil {
-					fmt.Printf("Failed to write error log: %v\n", logErr)
-				}
-				return snapshotCreatedMsg{err: fmt.Errorf("failed to merge branch to main: %w", err), response: nil}
+// applySnapshotDatasetConfig fills the dataset config, ports and flows for datasetName
+// from plato-config.yml into req, returning status lines describing what was loaded
+func applySnapshotDatasetConfig(req *models.CreateSnapshotRequest, datasetName string) []string {
+	var statusInfo []string
+	if config, err := LoadPlatoConfig(); err == nil {
+		// Get the dataset config for the current dataset
+		if datasetConfig, ok := config.Datasets[datasetName]; ok {
+			// Serialize dataset config to YAML
+			configYAML, err := yaml.Marshal(datasetConfig)
+			if err == nil {
+				req.DatasetConfig = string(configYAML)
+				statusInfo = append(statusInfo, fmt.Sprintf("  ✓ Loaded dataset config (%d bytes)", len(configYAML)))
 			}
-			gitHash = &hash
-		}
 
-		req := models.CreateSnapshotRequest{
-			Service: service,
-		}
-		if dataset != nil {
-			req.Dataset = *dataset
-		}
-		if gitHash != nil {
-			req.GitHash = *gitHash
-		}
+			// Extract port information from compute config
+			if datasetConfig.Compute.AppPort > 0 {
+				req.InternalAppPort = &datasetConfig.Compute.AppPort
+			}
+			if datasetConfig.Compute.PlatoMessagingPort > 0 {
+				req.MessagingPort = &datasetConfig.Compute.PlatoMessagingPort
+			}
 
-		// Try to load plato-config.yml and get dataset config
-		var statusInfo []string
-		if config, err := LoadPlatoConfig(); err == nil {
-			// Get the dataset config for the current dataset
-			if datasetConfig, ok := config.Datasets[datasetName]; ok {
-				// Serialize dataset config to YAML
-				configYAML, err := yaml.Marshal(datasetConfig)
+			// Check if there's a flow path in the metadata
+			if datasetConfig.Metadata.FlowsPath != "" {
+				configDir, err := GetPlatoConfigDir()
 				if err == nil {
-					req.DatasetConfig = string(configYAML)
-					statusInfo = append(statusInfo, fmt.Sprintf("  ✓ Loaded dataset config (%d bytes)", len(configYAML)))
-				}
-
-				// Extract port information from compute config
-				if datasetConfig.Compute.AppPort > 0 {
-					req.InternalAppPort = &datasetConfig.Compute.AppPort
-				}
-				if datasetConfig.Compute.PlatoMessagingPort > 0 {
-					req.MessagingPort = &datasetConfig.Compute.PlatoMessagingPort
-				}
-
-				// Check if there's a flow path in the metadata
-				if datasetConfig.Metadata.FlowsPath != "" {
-					configDir, err := GetPlatoConfigDir()
+					flowPath := filepath.Join(configDir, datasetConfig.Metadata.FlowsPath)
+					flowData, err := os.ReadFile(flowPath)
 					if err == nil {
-						flowPath := filepath.Join(configDir, datasetConfig.Metadata.FlowsPath)
-						flowData, err := os.ReadFile(flowPath)
-						if err == nil {
-							req.Flows = string(flowData)
-							statusInfo = append(statusInfo, fmt.Sprintf("  ✓ Loaded flows (%d bytes)", len(flowData)))
-						}
+						req.Flows = string(flowData)
+						statusInfo = append(statusInfo, fmt.Sprintf("  ✓ Loaded flows (%d bytes)", len(flowData)))
 					}
 				}
 			}
 		}
+	}
+	return statusInfo
+}
 
-		utils.LogDebug("Calling CreateSnapshot for: %s (service: %s)", publicID, service)
-		resp, err := client.Sandbox.CreateSnapshot(ctx, publicID, &req)
+func createSnapshotWithCleanup(ctx context.Context, client *plato.PlatoClient, publicID, jobGroupID, service string, dataset *string, branchName, snapshotBranch string, progress progressFunc) tea.Cmd {
+	return func() tea.Msg {
+		datasetName := ""
+		if dataset != nil {
+			datasetName = *dataset
+		}
+		datasetName = datasetOrDefault(datasetName)
+
+		// If a branch was pushed, merge it into the snapshot branch and get the commit hash
+		gitHash, err := mergePushedBranch(ctx, client, service, branchName, snapshotBranch, progress)
 		if err != nil {
-			// Log error to file
-			utils.LogDebug("CreateSnapshot failed: %v", err)
-			logErr := logErrorToFile("plato_error.log", fmt.Sprintf("API: CreateSnapshot failed for %s: %v", publicID, err))
-			if logErr != nil {
-				fmt.Printf("Failed to write error log: %v\n", logErr)
-			}
-			return snapshotCreatedMsg{err: err, response: nil, debugInfo: statusInfo}
+			return snapshotCreatedMsg{err: err, response: nil}
 		}
 
-		utils.LogDebug("Snapshot created successfully: %s", resp.ArtifactId)
-		return snapshotCreatedMsg{err: nil, response: resp, debugInfo: statusInfo}
+		resp, statusInfo, err := snapshotDataset(ctx, client, publicID, jobGroupID, service, datasetName, dataset != nil, gitHash)
+		return snapshotCreatedMsg{err: err, response: resp, debugInfo: statusInfo}
 	}
 }
 
-func createSnapshotWithConfig(client *plato.PlatoClient, publicID, jobGroupID, service string, dataset *string, dbConfig utils.DBConfig) tea.Cmd {
+// createSnapshotsForDatasets snapshots the VM once per dataset, like plato
+// snapshot: the pushed branch is merged and the pre-snapshot cleanup runs
+// once, then CreateSnapshots snapshots that same state as every dataset.
+func createSnapshotsForDatasets(ctx context.Context, client *plato.PlatoClient, publicID, jobGroupID, service string, datasets []string, branchName, snapshotBranch string, progress progressFunc) tea.Cmd {
 	return func() tea.Msg {
-		// Step 1: Perform pre-snapshot cleanup with provided config
-		utils.LogDebug("Starting pre-snapshot cleanup with provided DB config for service: %s", service)
-		if err := utils.PreSnapshotCleanupWithConfig(client, publicID, jobGroupID, dbConfig); err != nil {
+		gitHash, err := mergePushedBranch(ctx, client, service, branchName, snapshotBranch, progress)
+		if err != nil {
+			return snapshotsCreatedMsg{err: err}
+		}
+
+		// One cleanup covers every dataset: they all snapshot the same state
+		progress.report("Running pre-snapshot cleanup...", false)
+		var statusInfo []string
+		if _, err := utils.PreSnapshotCleanup(client, publicID, jobGroupID, service, datasets[0]); err != nil {
 			utils.LogDebug("Pre-snapshot cleanup failed: %v", err)
-			// Don't fail the snapshot if cleanup fails, just log it
+			// Don't fail the snapshots if cleanup fails, but flag an unreachable database
+			var unreachable *utils.DBUnreachableError
+			if errors.As(err, &unreachable) {
+				statusInfo = append(statusInfo, fmt.Sprintf("⚠️  %v", err))
+			}
 		}
 
-		// Step 2: Create the snapshot
-		// Use a timeout context to prevent hanging (snapshots can take a while)
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		reqs := make([]models.CreateSnapshotRequest, len(datasets))
+		for i, name := range datasets {
+			reqs[i] = models.CreateSnapshotRequest{Service: service, Dataset: name, GitHash: gitHash}
+			statusInfo = append(statusInfo, applySnapshotDatasetConfig(&reqs[i], name)...)
+		}
 
-		req := models.CreateSnapshotRequest{
-			Service: service,
+		// Cleanup already ran above, so CreateSnapshots is given no DB config
+		snapshots, _, _ := client.Sandbox.CreateSnapshots(ctx, publicID, "", reqs, nil)
+		results := make([]datasetSnapshotResult, len(snapshots))
+		for i, snapshot := range snapshots {
+			if snapshot.Err != nil {
+				logErrorToFile("plato_error.log", fmt.Sprintf("API: CreateSnapshot failed for %s as %s: %v", publicID, snapshot.Request.Dataset, snapshot.Err))
+			}
+			results[i] = datasetSnapshotResult{dataset: snapshot.Request.Dataset, response: snapshot.Response, err: snapshot.Err}
 		}
-		if dataset != nil {
-			req.Dataset = *dataset
+		return snapshotsCreatedMsg{results: results, debugInfo: statusInfo}
+	}
+}
+
+// mergePushedBranch merges branchName into snapshotBranch (the default branch
+// when empty) on the hub and returns the resulting commit hash, or "" when
+// nothing was pushed
+func mergePushedBranch(ctx context.Context, client *plato.PlatoClient, service, branchName, snapshotBranch string, progress progressFunc) (string, error) {
+	if branchName == "" {
+		return "", nil
+	}
+	target := snapshotBranchLabel(snapshotBranch)
+	hash, err := mergeHubBranch(ctx, client, service, branchName, snapshotBranch, progress)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", operationError(fmt.Sprintf("merging branch '%s' into %s", branchName, target), fmt.Sprintf("the hub did not finish within %s; no snapshot was taken", hubMergeTimeout), err)
+	}
+	if err != nil {
+		logErr := logErrorToFile("plato_error.log", fmt.Sprintf("Failed to merge branch into %s: %v", target, err))
+		if logErr != nil {
+			fmt.Printf("Failed to write error log: %v\n", logErr)
 		}
+		return "", fmt.Errorf("failed to merge branch into %s: %w", target, err)
+	}
+	progress.report(fmt.Sprintf("Merged '%s' into %s at %s", branchName, target, hash[:min(len(hash), 12)]), false)
+	return hash, nil
+}
 
-		utils.LogDebug("Calling CreateSnapshot for: %s (service: %s)", publicID, service)
-		resp, err := client.Sandbox.CreateSnapshot(ctx, publicID, &req)
-		if err != nil {
-			// Log error to file
-			utils.LogDebug("CreateSnapshot failed: %v", err)
-			logErr := logErrorToFile("plato_error.log", fmt.Sprintf("API: CreateSnapshot failed for %s: %v", publicID, err))
-			if logErr != nil {
-				fmt.Printf("Failed to write error log: %v\n", logErr)
-			}
-			return snapshotCreatedMsg{err: err, response: nil}
+// snapshotDataset runs the pre-snapshot cleanup for datasetName and creates
+// the snapshot. setDataset controls whether the dataset is sent in the request.
+func snapshotDataset(ctx context.Context, client *plato.PlatoClient, publicID, jobGroupID, service, datasetName string, setDataset bool, gitHash string) (*models.CreateSnapshotResponse, []string, error) {
+	// Step 1: Perform pre-snapshot cleanup
+	utils.LogDebug("Starting pre-snapshot cleanup for service: %s, dataset: %s", service, datasetName)
+	needsDBConfig, err := utils.PreSnapshotCleanup(client, publicID, jobGroupID, service, datasetName)
+	var cleanupWarnings []string
+	if err != nil {
+		utils.LogDebug("Pre-snapshot cleanup failed: %v", err)
+		// Don't fail the snapshot if cleanup fails, but say so when the
+		// database was never reached: the snapshot may contain stale data
+		var unreachable *utils.DBUnreachableError
+		if errors.As(err, &unreachable) {
+			cleanupWarnings = append(cleanupWarnings, fmt.Sprintf("⚠️  %v", err))
 		}
+	}
+	if needsDBConfig {
+		// This shouldn't happen here since we check before calling this function
+		utils.LogDebug("Warning: DB config needed but not provided")
+	}
 
-		utils.LogDebug("Snapshot created successfully: %s", resp.ArtifactId)
-		return snapshotCreatedMsg{err: nil, response: resp}
+	// Step 2: Create the snapshot
+	// Use a timeout context to prevent hanging (snapshots can take a while)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req := models.CreateSnapshotRequest{
+		Service: service,
+		GitHash: gitHash,
+	}
+	if setDataset {
+		req.Dataset = datasetName
 	}
+
+	statusInfo := append(cleanupWarnings, applySnapshotDatasetConfig(&req, datasetName)...)
+
+	utils.LogDebug("Calling CreateSnapshot for: %s (service: %s, dataset: %s)", publicID, service, datasetName)
+	resp, err := client.Sandbox.CreateSnapshot(ctx, publicID, &req)
+	if err != nil {
+		// Log error to file
+		utils.LogDebug("CreateSnapshot failed: %v", err)
+		logErr := logErrorToFile("plato_error.log", fmt.Sprintf("API: CreateSnapshot failed for %s: %v", publicID, err))
+		if logErr != nil {
+			fmt.Printf("Failed to write error log: %v\n", logErr)
+		}
+		return nil, statusInfo, err
+	}
+
+	utils.LogDebug("Snapshot created successfully: %s", resp.ArtifactId)
+	return resp, statusInfo, nil
 }
 
-func createCheckpoint(client *plato.PlatoClient, publicID, service string, dataset *string) tea.Cmd {
+func createCheckpoint(ctx context.Context, client *plato.PlatoClient, publicID, service string, dataset *string) tea.Cmd {
 	return func() tea.Msg {
 		// Create checkpoint without cleanup or git merge
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
 
 		req := models.CreateSnapshotRequest{
@@ -958,10 +1370,8 @@ func createCheckpoint(client *plato.PlatoClient, publicID, service string, datas
 	}
 }
 
-func startWorker(client *plato.PlatoClient, publicID string, service string, dataset string, datasetConfig models.SimConfigDataset) tea.Cmd {
+func startWorker(ctx context.Context, client *plato.PlatoClient, publicID string, service string, dataset string, datasetConfig models.SimConfigDataset) tea.Cmd {
 	return func() tea.Msg {
-		ctx := context.Background()
-
 		timeout := int32(600)
 		req := models.StartWorkerRequest{
 			Service:            service,
@@ -984,9 +1394,49 @@ func startWorker(client *plato.PlatoClient, publicID string, service string, dat
 	}
 }
 
-// mergeHubBranchToMain merges a branch into main in the hub repository and returns the merge commit hash
-func mergeHubBranchToMain(client *plato.PlatoClient, serviceName string, branchName string) (string, error) {
-	ctx := context.Background()
+// workerLogTailLines is how much worker output is shown after a failed start
+const workerLogTailLines = 20
+
+// fetchWorkerLogTail returns the last lines of the worker logs, or nil if they
+// cannot be fetched; it only adds context to an error that is already reported
+func fetchWorkerLogTail(client *plato.PlatoClient, publicID string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	logs, err := client.Sandbox.GetWorkerLogs(ctx, publicID)
+	if err != nil {
+		utils.LogDebug("Failed to fetch worker logs for %s: %v", publicID, err)
+		return nil
+	}
+	return logs.Tail(workerLogTailLines)
+}
+
+// hubMergeTimeout bounds the clone and push that move a pushed branch onto
+// the snapshot branch
+const hubMergeTimeout = 5 * time.Minute
+
+// hubSnapshotBranch returns hub.snapshot_branch from plato-config.yml, or ""
+// for the repository's default branch
+func hubSnapshotBranch(config *models.PlatoConfig) string {
+	if config == nil || config.Hub == nil {
+		return ""
+	}
+	return config.Hub.SnapshotBranch
+}
+
+// snapshotBranchLabel names a snapshot branch in messages
+func snapshotBranchLabel(branch string) string {
+	if branch == "" {
+		return "the default branch"
+	}
+	return "'" + branch + "'"
+}
+
+// mergeHubBranch merges a branch into snapshotBranch of the hub repository,
+// or its default branch (normally main) when snapshotBranch is empty, and
+// returns the merge commit hash
+func mergeHubBranch(ctx context.Context, client *plato.PlatoClient, serviceName, branchName, snapshotBranch string, progress progressFunc) (hash string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, hubMergeTimeout)
+	defer cancel()
 
 	// Get Gitea credentials
 	creds, err := client.Gitea.GetCredentials(ctx)
@@ -995,21 +1445,9 @@ func mergeHubBranchToMain(client *plato.PlatoClient, serviceName string, branchN
 	}
 
 	// Find simulator by service name
-	simulators, err := client.Gitea.ListSimulators(ctx)
+	simulator, err := client.Gitea.GetSimulatorByName(ctx, serviceName)
 	if err != nil {
-		return "", fmt.Errorf("failed to list simulators: %w", err)
-	}
-
-	var simulator *models.GiteaSimulator
-	for i := range simulators {
-		if strings.EqualFold(simulators[i].Name, serviceName) {
-			simulator = &simulators[i]
-			break
-		}
-	}
-
-	if simulator == nil {
-		return "", fmt.Errorf("simulator '%s' not found in hub", serviceName)
+		return "", err
 	}
 
 	// Get repository
@@ -1018,10 +1456,21 @@ func mergeHubBranchToMain(client *plato.PlatoClient, serviceName string, branchN
 		return "", fmt.Errorf("failed to get repository: %w", err)
 	}
 
-	// Build authenticated clone URL
-	cloneURL := repo.CloneURL
-	if strings.HasPrefix(cloneURL, "https://") {
-		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	// Build the clone URL for the configured transport
+	cloneURL, err := client.Gitea.RemoteURL(repo, creds)
+	if err != nil {
+		return "", err
+	}
+
+	// Record where the snapshot branch points now, so the push below cannot
+	// clobber commits someone else adds to it meanwhile
+	targetBranch := snapshotBranch
+	if targetBranch == "" {
+		targetBranch = client.Gitea.DefaultBranch(ctx, repo, cloneURL)
+	}
+	targetTip, err := utils.RemoteBranchTip(ctx, cloneURL, client.Gitea.GitEnv(), targetBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", targetBranch, err)
 	}
 
 	// Clone repo to temp directory
@@ -1029,23 +1478,18 @@ func mergeHubBranchToMain(client *plato.PlatoClient, serviceName string, branchN
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
-	defer os.RemoveAll(tempDir)
+	defer func() { err = utils.CleanupTempDir(tempDir, err) }()
 
+	// Only the tip of the branch is needed, so the clone is shallow unless
+	// hub.clone_depth asks for more history
 	tempRepo := filepath.Join(tempDir, "repo")
-	cloneCmd := exec.Command("git", "clone", cloneURL, tempRepo)
-	if output, err := cloneCmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, string(output))
-	}
-
-	// Checkout the branch
-	gitCheckoutBranch := exec.Command("git", "checkout", branchName)
-	gitCheckoutBranch.Dir = tempRepo
-	if output, err := gitCheckoutBranch.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to checkout branch: %w\nOutput: %s", err, string(output))
+	progress.report(fmt.Sprintf("Cloning branch '%s' from the hub...", branchName), false)
+	if err := runGitWithProgress(ctx, "", client.Gitea.GitEnv(), progress, client.Gitea.CloneArgs(cloneURL, tempRepo, branchName)...); err != nil {
+		return "", fmt.Errorf("failed to clone repo: %w", err)
 	}
 
 	// Get the current commit hash from the branch
-	gitRevParse := exec.Command("git", "rev-parse", "HEAD")
+	gitRevParse := utils.GitCommand(ctx, "rev-parse", "HEAD")
 	gitRevParse.Dir = tempRepo
 	hashOutput, err := gitRevParse.Output()
 	if err != nil {
@@ -1053,20 +1497,19 @@ func mergeHubBranchToMain(client *plato.PlatoClient, serviceName string, branchN
 	}
 	commitHash := strings.TrimSpace(string(hashOutput))
 
-	// Force push the branch to main (avoiding merge conflicts)
-	gitPush := exec.Command("git", "push", "origin", fmt.Sprintf("%s:main", branchName), "--force")
-	gitPush.Dir = tempRepo
-	if output, err := gitPush.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("failed to push to main: %w\nOutput: %s", err, string(output))
+	// Force push the branch to the snapshot branch (avoiding merge conflicts),
+	// unless the snapshot branch moved since its tip was recorded. The hub
+	// already has the branch history, so pushing from a shallow clone works.
+	progress.report(fmt.Sprintf("Pushing '%s' to %s...", branchName, targetBranch), false)
+	if err := runGitWithProgress(ctx, tempRepo, client.Gitea.GitEnv(), progress, utils.LeasePushArgs(targetBranch, targetTip)...); err != nil {
+		return "", fmt.Errorf("failed to push to %s: %w", targetBranch, utils.LeaseError(err, err.Error()))
 	}
 
 	return commitHash, nil
 }
 
-func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-
+func pushToHub(ctx context.Context, client *plato.PlatoClient, serviceName string) tea.Cmd {
+	return func() (result tea.Msg) {
 		// Get Gitea credentials
 		creds, err := client.Gitea.GetCredentials(ctx)
 		if err != nil {
@@ -1075,22 +1518,10 @@ func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
 		}
 
 		// Find simulator by service name
-		simulators, err := client.Gitea.ListSimulators(ctx)
+		simulator, err := client.Gitea.GetSimulatorByName(ctx, serviceName)
 		if err != nil {
-			logErrorToFile("plato_error.log", fmt.Sprintf("Failed to list simulators: %v", err))
-			return hubPushMsg{err: fmt.Errorf("failed to list simulators: %w", err)}
-		}
-
-		var simulator *models.GiteaSimulator
-		for i := range simulators {
-			if strings.EqualFold(simulators[i].Name, serviceName) {
-				simulator = &simulators[i]
-				break
-			}
-		}
-
-		if simulator == nil {
-			return hubPushMsg{err: fmt.Errorf("simulator '%s' not found in hub", serviceName)}
+			logErrorToFile("plato_error.log", fmt.Sprintf("Failed to find simulator: %v", err))
+			return hubPushMsg{err: err}
 		}
 
 		// Get or create repository
@@ -1107,10 +1538,10 @@ func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
 			}
 		}
 
-		// Build authenticated clone URL
-		cloneURL := repo.CloneURL
-		if strings.HasPrefix(cloneURL, "https://") {
-			cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+		// Build the clone URL for the configured transport
+		cloneURL, err := client.Gitea.RemoteURL(repo, creds)
+		if err != nil {
+			return hubPushMsg{err: err}
 		}
 
 		// Clone repo to temp directory
@@ -1118,14 +1549,22 @@ func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
 		if err != nil {
 			return hubPushMsg{err: fmt.Errorf("failed to create temp dir: %w", err)}
 		}
-		defer os.RemoveAll(tempDir)
+		defer func() {
+			msg, _ := result.(hubPushMsg)
+			msg.err = utils.CleanupTempDir(tempDir, utils.GitInterrupted(ctx, msg.err))
+			if msg.err != nil {
+				result = msg
+			}
+		}()
 
 		tempRepo := filepath.Join(tempDir, "repo")
-		cloneCmd := exec.Command("git", "clone", cloneURL, tempRepo)
-		cloneOutput, err := cloneCmd.CombinedOutput()
+		cloneOutput, err := utils.RunRemoteGit(ctx, "", client.Gitea.GitEnv(), client.Gitea.CloneArgs(cloneURL, tempRepo, "")...)
 		if err != nil {
 			return hubPushMsg{err: fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, string(cloneOutput))}
 		}
+		if creds, err = client.Gitea.EnsureDefaultBranch(ctx, tempRepo, repo, creds); err != nil {
+			return hubPushMsg{err: fmt.Errorf("failed to create the default branch: %w", err)}
+		}
 
 		// Get current directory
 		currentDir, err := os.Getwd()
@@ -1137,26 +1576,26 @@ func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
 		branchName := fmt.Sprintf("workspace-%d", time.Now().Unix())
 
 		// Create and checkout new branch
-		gitCheckout := exec.Command("git", "checkout", "-b", branchName)
+		gitCheckout := utils.GitCommand(ctx, "checkout", "-b", branchName)
 		gitCheckout.Dir = tempRepo
 		if output, err := gitCheckout.CombinedOutput(); err != nil {
 			return hubPushMsg{err: fmt.Errorf("git checkout failed: %w\nOutput: %s", err, string(output))}
 		}
 
 		// Copy files respecting .gitignore
-		if err := copyFilesRespectingGitignore(currentDir, tempRepo); err != nil {
+		if err := copyWorkspace(currentDir, tempRepo, nil); err != nil {
 			return hubPushMsg{err: fmt.Errorf("failed to copy files: %w", err)}
 		}
 
 		// Commit and push
-		gitAdd := exec.Command("git", "add", ".")
+		gitAdd := utils.GitCommand(ctx, "add", ".")
 		gitAdd.Dir = tempRepo
 		if output, err := gitAdd.CombinedOutput(); err != nil {
 			return hubPushMsg{err: fmt.Errorf("git add failed: %w\nOutput: %s", err, string(output))}
 		}
 
 		// Check if there are changes
-		gitStatus := exec.Command("git", "status", "--porcelain")
+		gitStatus := utils.GitCommand(ctx, "status", "--porcelain")
 		gitStatus.Dir = tempRepo
 		statusOutput, err := gitStatus.Output()
 		if err != nil {
@@ -1164,44 +1603,50 @@ func pushToHub(client *plato.PlatoClient, serviceName string) tea.Cmd {
 		}
 
 		if len(strings.TrimSpace(string(statusOutput))) == 0 {
-			// No changes to push - still return authenticated clone URL
-			authenticatedCloneURL := repo.CloneURL
-			if strings.HasPrefix(authenticatedCloneURL, "https://") {
-				authenticatedCloneURL = strings.Replace(authenticatedCloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
-			}
-			return hubPushMsg{err: nil, repoURL: repo.CloneURL, cloneCmd: fmt.Sprintf("git clone -b %s %s", branchName, authenticatedCloneURL), branchName: branchName}
+			// No changes to push - still return the clone command
+			return hubPushMsg{err: nil, repoURL: repo.CloneURL, cloneCmd: fmt.Sprintf("git clone -b %s %s", branchName, cloneURL), branchName: branchName}
 		}
 
 		// Commit changes
-		gitCommit := exec.Command("git", "commit", "-m", fmt.Sprintf("Sync from local workspace"))
+		gitCommit := utils.GitCommand(ctx, "commit", "-m", fmt.Sprintf("Sync from local workspace"))
 		gitCommit.Dir = tempRepo
 		if output, err := gitCommit.CombinedOutput(); err != nil {
 			return hubPushMsg{err: fmt.Errorf("git commit failed: %w\nOutput: %s", err, string(output))}
 		}
 
-		// Push to remote branch
-		gitPush := exec.Command("git", "push", "-u", "origin", branchName)
-		gitPush.Dir = tempRepo
-		if output, err := gitPush.CombinedOutput(); err != nil {
-			return hubPushMsg{err: fmt.Errorf("git push failed: %w\nOutput: %s", err, string(output))}
+		// Push to remote branch, refreshing the credentials if they rotated
+		if creds, err = client.Gitea.Push(ctx, tempRepo, repo, creds, "-u", "origin", branchName); err != nil {
+			return hubPushMsg{err: err}
 		}
-
-		// Build authenticated clone URL for the user
-		authenticatedCloneURL := repo.CloneURL
-		if strings.HasPrefix(authenticatedCloneURL, "https://") {
-			authenticatedCloneURL = strings.Replace(authenticatedCloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+		if cloneURL, err = client.Gitea.RemoteURL(repo, creds); err != nil {
+			return hubPushMsg{err: err}
 		}
 
-		// Return success with authenticated clone command
-		cloneCommand := fmt.Sprintf("git clone -b %s %s", branchName, authenticatedCloneURL)
+		// Return success with the clone command for the configured transport
+		cloneCommand := fmt.Sprintf("git clone -b %s %s", branchName, cloneURL)
 		return hubPushMsg{err: nil, repoURL: repo.CloneURL, cloneCmd: cloneCommand, branchName: branchName}
 	}
 }
 
 // startService pushes code to hub, clones it on the VM, and starts services
-func startService(client *plato.PlatoClient, serviceName string, datasetName string, datasetConfig models.SimConfigDataset, sshHost string, sshConfigPath string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+func startService(ctx context.Context, client *plato.PlatoClient, serviceName string, datasetName string, datasetConfig models.SimConfigDataset, sshHost string, sshConfigPath string, remote remoteSettings, progress progressFunc) tea.Cmd {
+	return func() (result tea.Msg) {
+		// Resolve the start order up front so a bad depends_on fails before anything is pushed
+		serviceOrder, err := serviceStartOrder(datasetConfig.Services)
+		if err != nil {
+			return serviceStartedMsg{err: err}
+		}
+
+		// Each git command of steps 1 and 2 talks to the hub and gets its own
+		// deadline; starting the services afterwards can legitimately take longer
+		hubDone := false
+		defer func() {
+			msg, ok := result.(serviceStartedMsg)
+			if ok && msg.err != nil && !hubDone && errors.Is(msg.err, context.DeadlineExceeded) && ctx.Err() == nil {
+				msg.err = operationError("pushing the workspace to the VM", fmt.Sprintf("the hub did not respond within %s; check the network and start the service again", utils.DefaultGitTimeout), msg.err)
+				result = msg
+			}
+		}()
 
 		// Step 1: Push code to hub (reuse pushToHub logic)
 		utils.LogDebug("Step 1: Pushing code to hub for service: %s", serviceName)
@@ -1213,21 +1658,9 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 		}
 
 		// Find simulator by service name
-		simulators, err := client.Gitea.ListSimulators(ctx)
+		simulator, err := client.Gitea.GetSimulatorByName(ctx, serviceName)
 		if err != nil {
-			return serviceStartedMsg{err: fmt.Errorf("failed to list simulators: %w", err)}
-		}
-
-		var simulator *models.GiteaSimulator
-		for i := range simulators {
-			if strings.EqualFold(simulators[i].Name, serviceName) {
-				simulator = &simulators[i]
-				break
-			}
-		}
-
-		if simulator == nil {
-			return serviceStartedMsg{err: fmt.Errorf("simulator '%s' not found in hub", serviceName)}
+			return serviceStartedMsg{err: err}
 		}
 
 		// Get or create repository
@@ -1244,10 +1677,10 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 			}
 		}
 
-		// Build authenticated clone URL
-		cloneURL := repo.CloneURL
-		if strings.HasPrefix(cloneURL, "https://") {
-			cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+		// Build the clone URL for the configured transport
+		cloneURL, err := client.Gitea.RemoteURL(repo, creds)
+		if err != nil {
+			return serviceStartedMsg{err: err}
 		}
 
 		// Clone repo to temp directory
@@ -1255,14 +1688,22 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 		if err != nil {
 			return serviceStartedMsg{err: fmt.Errorf("failed to create temp dir: %w", err)}
 		}
-		defer os.RemoveAll(tempDir)
+		defer func() {
+			msg, _ := result.(serviceStartedMsg)
+			msg.err = utils.CleanupTempDir(tempDir, msg.err)
+			if msg.err != nil {
+				result = msg
+			}
+		}()
 
 		tempRepo := filepath.Join(tempDir, "repo")
-		cloneCmd := exec.Command("git", "clone", cloneURL, tempRepo)
-		cloneOutput, err := cloneCmd.CombinedOutput()
+		cloneOutput, err := utils.RunRemoteGit(ctx, "", client.Gitea.GitEnv(), client.Gitea.CloneArgs(cloneURL, tempRepo, "")...)
 		if err != nil {
 			return serviceStartedMsg{err: fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, string(cloneOutput))}
 		}
+		if creds, err = client.Gitea.EnsureDefaultBranch(ctx, tempRepo, repo, creds); err != nil {
+			return serviceStartedMsg{err: fmt.Errorf("failed to create the default branch: %w", err)}
+		}
 
 		// Get current directory
 		currentDir, err := os.Getwd()
@@ -1274,26 +1715,26 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 		branchName := fmt.Sprintf("workspace-%d", time.Now().Unix())
 
 		// Create and checkout new branch
-		gitCheckout := exec.Command("git", "checkout", "-b", branchName)
+		gitCheckout := utils.GitCommand(ctx, "checkout", "-b", branchName)
 		gitCheckout.Dir = tempRepo
 		if output, err := gitCheckout.CombinedOutput(); err != nil {
 			return serviceStartedMsg{err: fmt.Errorf("git checkout failed: %w\nOutput: %s", err, string(output))}
 		}
 
 		// Copy files respecting .gitignore
-		if err := copyFilesRespectingGitignore(currentDir, tempRepo); err != nil {
+		if err := copyWorkspace(currentDir, tempRepo, progress); err != nil {
 			return serviceStartedMsg{err: fmt.Errorf("failed to copy files: %w", err)}
 		}
 
 		// Commit and push
-		gitAdd := exec.Command("git", "add", ".")
+		gitAdd := utils.GitCommand(ctx, "add", ".")
 		gitAdd.Dir = tempRepo
 		if output, err := gitAdd.CombinedOutput(); err != nil {
 			return serviceStartedMsg{err: fmt.Errorf("git add failed: %w\nOutput: %s", err, string(output))}
 		}
 
 		// Check if there are changes
-		gitStatus := exec.Command("git", "status", "--porcelain")
+		gitStatus := utils.GitCommand(ctx, "status", "--porcelain")
 		gitStatus.Dir = tempRepo
 		statusOutput, err := gitStatus.Output()
 		if err != nil {
@@ -1302,7 +1743,7 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 
 		// Commit and push if there are changes, otherwise push the branch anyway
 		if len(strings.TrimSpace(string(statusOutput))) > 0 {
-			gitCommit := exec.Command("git", "commit", "-m", fmt.Sprintf("Sync from local workspace"))
+			gitCommit := utils.GitCommand(ctx, "commit", "-m", fmt.Sprintf("Sync from local workspace"))
 			gitCommit.Dir = tempRepo
 			if output, err := gitCommit.CombinedOutput(); err != nil {
 				return serviceStartedMsg{err: fmt.Errorf("git commit failed: %w\nOutput: %s", err, string(output))}
@@ -1310,10 +1751,9 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 		}
 
 		// Always push the branch (even if no changes, to ensure it exists on remote)
-		gitPush := exec.Command("git", "push", "-u", "origin", branchName)
-		gitPush.Dir = tempRepo
-		if output, err := gitPush.CombinedOutput(); err != nil {
-			return serviceStartedMsg{err: fmt.Errorf("git push failed: %w\nOutput: %s", err, string(output))}
+		// The VM clone below reuses creds, so keep the refreshed ones if they rotated
+		if creds, err = client.Gitea.Push(ctx, tempRepo, repo, creds, "-u", "origin", branchName); err != nil {
+			return serviceStartedMsg{err: err}
 		}
 
 		utils.LogDebug("Code pushed successfully, branch: %s", branchName)
@@ -1321,41 +1761,41 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 		// Step 2: Clone repo on VM via SSH
 		utils.LogDebug("Step 2: Cloning repo on VM via SSH")
 
-		// Build authenticated clone URL for SSH command
+		// Build authenticated clone URL for SSH command. The VM has no access to the
+		// user's SSH key, so it always clones over HTTPS regardless of the transport.
 		authenticatedCloneURL := repo.CloneURL
 		if strings.HasPrefix(authenticatedCloneURL, "https://") {
 			authenticatedCloneURL = strings.Replace(authenticatedCloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
 		}
 
-		// Determine target directory on VM - use /home/plato/worktree
-		repoDir := fmt.Sprintf("/home/plato/worktree/%s", serviceName)
+		// Determine target directory on VM under the configured worktree path
+		repoDir := remote.repoDir(serviceName)
 
 		// Ensure worktree directory exists
-		mkdirCmd := exec.Command("ssh", "-F", sshConfigPath, sshHost, "mkdir -p /home/plato/worktree")
-		if output, err := mkdirCmd.CombinedOutput(); err != nil {
-			utils.LogDebug("Failed to create worktree directory: %v\nOutput: %s", err, string(output))
+		if _, _, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, "mkdir -p "+utils.ShellQuote(remote.worktreePath)); err != nil {
+			utils.LogDebug("Failed to create worktree directory: %v", err)
 		}
 
 		// Remove existing directory if it exists
-		rmCmd := exec.Command("ssh", "-F", sshConfigPath, sshHost, fmt.Sprintf("rm -rf %s", repoDir))
-		if output, err := rmCmd.CombinedOutput(); err != nil {
-			utils.LogDebug("Failed to remove existing directory (may not exist): %v\nOutput: %s", err, string(output))
+		if _, _, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, "rm -rf "+utils.ShellQuote(repoDir)); err != nil {
+			utils.LogDebug("Failed to remove existing directory (may not exist): %v", err)
 		}
 
 		// Clone the repository on the VM
-		cloneVMCmd := exec.Command("ssh", "-F", sshConfigPath, sshHost, fmt.Sprintf("git clone -b %s %s %s", branchName, authenticatedCloneURL, repoDir))
-		cloneVMOutput, err := cloneVMCmd.CombinedOutput()
+		cloneVMOutput, err := cloneOnVM(ctx, client, sshConfigPath, sshHost, authenticatedCloneURL, repoDir, branchName)
 		if err != nil {
-			return serviceStartedMsg{err: fmt.Errorf("failed to clone repo on VM: %w\nOutput: %s", err, string(cloneVMOutput))}
+			return serviceStartedMsg{err: err}
 		}
 
-		utils.LogDebug("Repo cloned on VM: %s", string(cloneVMOutput))
+		utils.LogDebug("Repo cloned on VM: %s", cloneVMOutput)
+		hubDone = true
 
 		// Step 3: Start services based on their type
 		utils.LogDebug("Step 3: Starting services from dataset config")
 		var servicesInfo []string
 
-		for serviceName, service := range datasetConfig.Services {
+		for _, serviceName := range serviceOrder {
+			service := datasetConfig.Services[serviceName]
 			utils.LogDebug("Starting service: %s (type: %s)", serviceName, service.Type)
 
 			switch service.Type {
@@ -1367,18 +1807,24 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 				}
 
 				// Build the docker compose command (V2 syntax without hyphen)
-				// Set DOCKER_HOST to use rootless docker daemon socket
-				composeCmd := fmt.Sprintf("cd %s && DOCKER_HOST=unix:///var/run/docker-user.sock docker compose -f %s up -d", repoDir, composeFile)
-				sshCmd := exec.Command("ssh", "-F", sshConfigPath, sshHost, composeCmd)
-
-				output, err := sshCmd.CombinedOutput()
+				// Set DOCKER_HOST to the configured docker daemon socket
+				composeCmd := fmt.Sprintf("cd %s && DOCKER_HOST=%s docker compose -f %s up -d", utils.ShellQuote(repoDir), utils.ShellQuote(remote.dockerHost), utils.ShellQuote(composeFile))
+				_, output, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, composeCmd)
 				if err != nil {
-					return serviceStartedMsg{err: fmt.Errorf("failed to start docker compose service '%s': %w\nOutput: %s", serviceName, err, string(output))}
+					return serviceStartedMsg{err: fmt.Errorf("failed to start docker compose service '%s': %w", serviceName, err)}
 				}
 
-				utils.LogDebug("Docker compose service '%s' started: %s", serviceName, string(output))
+				utils.LogDebug("Docker compose service '%s' started: %s", serviceName, output)
 				servicesInfo = append(servicesInfo, fmt.Sprintf("✓ Started docker compose service: %s", serviceName))
 
+				// Dependents only start once this service is healthy
+				if hasDependents(datasetConfig.Services, serviceName) {
+					if err := waitForComposeHealthy(ctx, sshConfigPath, sshHost, repoDir, remote.dockerHost, composeFile, service); err != nil {
+						return serviceStartedMsg{err: fmt.Errorf("service '%s' did not become healthy: %w", serviceName, err)}
+					}
+					servicesInfo = append(servicesInfo, fmt.Sprintf("✓ Service healthy: %s", serviceName))
+				}
+
 			default:
 				utils.LogDebug("Unknown service type: %s for service: %s", service.Type, serviceName)
 				servicesInfo = append(servicesInfo, fmt.Sprintf("⚠ Skipped service '%s' (unknown type: %s)", serviceName, service.Type))
@@ -1397,13 +1843,14 @@ func startService(client *plato.PlatoClient, serviceName string, datasetName str
 // authenticateECR authenticates Docker with AWS ECR on the VM.
 // ECR authentication tokens are valid for 12 hours by default.
 // This function is called automatically when the VM starts up.
-func authenticateECR(sshHost string, sshConfigPath string) tea.Cmd {
+// Registry settings come from resolveECRSettings (env, then plato-config.yml, then defaults).
+func authenticateECR(ctx context.Context, sshHost string, sshConfigPath string, settings ecrSettings, remote remoteSettings) tea.Cmd {
 	return func() tea.Msg {
 		utils.LogDebug("Starting ECR authentication process")
 
 		// Step 1: Get ECR login token on local machine
 		utils.LogDebug("Step 1: Getting ECR login token from local AWS CLI")
-		ecrCmd := exec.Command("aws", "ecr", "get-login-password", "--region", "us-west-1")
+		ecrCmd := exec.CommandContext(ctx, "aws", settings.getLoginPasswordArgs()...)
 		tokenBytes, err := ecrCmd.Output()
 		if err != nil {
 			return ecrAuthenticatedMsg{err: fmt.Errorf("failed to get ECR login token: %w", err)}
@@ -1418,161 +1865,146 @@ func authenticateECR(sshHost string, sshConfigPath string) tea.Cmd {
 
 		// Step 2: Login to ECR on the VM using the token
 		utils.LogDebug("Step 2: Logging into ECR on VM")
-		ecrRegistry := "383806609161.dkr.ecr.us-west-1.amazonaws.com"
+		ecrRegistry := settings.registry
 
-		// Use echo to pipe the token to docker login
-		// Set DOCKER_HOST to use rootless docker daemon socket
-		dockerLoginCmd := fmt.Sprintf("echo '%s' | DOCKER_HOST=unix:///var/run/docker-user.sock docker login --username AWS --password-stdin %s", token, ecrRegistry)
-		sshCmd := exec.Command("ssh", "-F", sshConfigPath, sshHost, dockerLoginCmd)
-
-		output, err := sshCmd.CombinedOutput()
+		// Use printf to pipe the token to docker login
+		// Set DOCKER_HOST to the configured docker daemon socket
+		dockerLoginCmd := fmt.Sprintf("printf '%%s' %s | DOCKER_HOST=%s docker login --username AWS --password-stdin %s", utils.ShellQuote(token), utils.ShellQuote(remote.dockerHost), utils.ShellQuote(ecrRegistry))
+		output, _, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, dockerLoginCmd)
 		if err != nil {
-			return ecrAuthenticatedMsg{err: fmt.Errorf("failed to login to ECR on VM: %w\nOutput: %s", err, string(output))}
+			return ecrAuthenticatedMsg{err: fmt.Errorf("failed to login to ECR on VM: %w", err)}
 		}
 
-		utils.LogDebug("ECR authentication successful: %s", string(output))
+		utils.LogDebug("ECR authentication successful: %s", output)
 		return ecrAuthenticatedMsg{err: nil}
 	}
 }
 
-// copyFilesRespectingGitignore copies files from src to dst respecting .gitignore
-func copyFilesRespectingGitignore(src, dst string) error {
-	// First copy .gitignore if it exists
-	gitignoreSrc := filepath.Join(src, ".gitignore")
-	if _, err := os.Stat(gitignoreSrc); err == nil {
-		gitignoreDst := filepath.Join(dst, ".gitignore")
-		if _, err := os.Stat(gitignoreDst); os.IsNotExist(err) {
-			input, err := os.ReadFile(gitignoreSrc)
-			if err != nil {
-				return err
-			}
-			if err := os.WriteFile(gitignoreDst, input, 0644); err != nil {
-				return err
-			}
+// copyWorkspace copies the files of src that git does not ignore into the
+// hub clone dst, reporting "Copied 412/1200 files" as it goes
+func copyWorkspace(src, dst string, progress progressFunc) error {
+	var lastReport time.Time
+	return utils.CopyFilesRespectingGitignore(src, dst, func(p utils.CopyProgress) {
+		if p.FilesCopied < p.TotalFiles && time.Since(lastReport) < gitProgressInterval {
+			return
 		}
-	}
+		lastReport = time.Now()
+		progress.report(fmt.Sprintf("  Copied %d/%d files (%s of %s)", p.FilesCopied, p.TotalFiles, formatByteCount(p.BytesCopied), formatByteCount(p.TotalBytes)), true)
+	})
+}
 
-	// Helper to check if path should be copied
-	shouldCopy := func(path string) bool {
-		baseName := filepath.Base(path)
-		// Skip .git directories and .plato-hub.json
-		if strings.HasPrefix(baseName, ".git") || baseName == ".plato-hub.json" {
-			return false
-		}
+// These functions are now in internal/utils/network.go
+// Keeping empty stubs here for reference, but they should be removed
+// and all calls should use utils.FindFreePort() and utils.FindFreePortPreferred()
 
-		// Use git check-ignore to respect .gitignore rules
-		cmd := exec.Command("git", "check-ignore", "-q", path)
-		cmd.Dir = src
-		err := cmd.Run()
-		// git check-ignore returns 0 if path IS ignored, 1 if NOT ignored
-		return err != nil // Return true if NOT ignored
+// tunnelBindAddress returns the configured tunnel.bind_address, defaulting to loopback
+func tunnelBindAddress(config *models.PlatoConfig) string {
+	if config != nil && config.Tunnel != nil && config.Tunnel.BindAddress != "" {
+		return config.Tunnel.BindAddress
 	}
+	return utils.DefaultBindAddress
+}
 
-	// Walk through source directory
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// tunnelStrictPorts reports whether tunnel.strict_ports requires local ports to match remote ports
+func tunnelStrictPorts(config *models.PlatoConfig) bool {
+	return config != nil && config.Tunnel != nil && config.Tunnel.StrictPorts
+}
 
-		// Get relative path
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
+// findOpenTunnel returns the mapping of a live tunnel already forwarding
+// remotePort on this VM, so opening the same port twice reuses it
+func (m VMInfoModel) findOpenTunnel(publicID, bindAddress string, remotePort int) (proxytunnelMapping, bool) {
+	if m.sandbox == nil || m.sandbox.PublicId != strings.TrimSpace(publicID) {
+		return proxytunnelMapping{}, false
+	}
+	for i, mapping := range m.proxytunnelMappings {
+		if mapping.remotePort != remotePort || mapping.bindAddress != bindAddress || i >= len(m.proxytunnelProcesses) {
+			continue
 		}
-
-		// Skip root directory
-		if relPath == "." {
-			return nil
+		cmd := m.proxytunnelProcesses[i]
+		if cmd == nil || cmd.Process == nil || cmd.ProcessState != nil {
+			continue
 		}
-
-		// Check if should copy
-		if !shouldCopy(path) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
+		// A signalable process that still holds the local port is a working tunnel
+		if cmd.Process.Signal(syscall.Signal(0)) == nil && !utils.IsPortAvailableOn(mapping.bindAddress, mapping.localPort) {
+			return mapping, true
 		}
+	}
+	return proxytunnelMapping{}, false
+}
 
-		dstPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			return os.MkdirAll(dstPath, info.Mode())
-		}
+func openProxytunnelWithPort(client *plato.PlatoClient, publicID string, remotePort int, bindAddress string, strict bool) tea.Cmd {
+	return func() tea.Msg {
+		utils.LogDebug("openProxytunnelWithPort called, publicID=%s, remotePort=%d, bind=%s, strict=%t", publicID, remotePort, bindAddress, strict)
 
-		// Copy file
-		input, err := os.ReadFile(path)
+		publicID, err := utils.ResolvePublicID(client, publicID)
 		if err != nil {
-			return err
+			return proxytunnelOpenedMsg{err: err}
 		}
-		return os.WriteFile(dstPath, input, info.Mode())
-	})
-}
 
-// These functions are now in internal/utils/network.go
-// Keeping empty stubs here for reference, but they should be removed
-// and all calls should use utils.FindFreePort() and utils.FindFreePortPreferred()
-
-func openProxytunnelWithPort(client *plato.PlatoClient, publicID string, remotePort int) tea.Cmd {
-	return func() tea.Msg {
-		utils.LogDebug("openProxytunnelWithPort called, publicID=%s, remotePort=%d", publicID, remotePort)
-
-		// Try to use the same port as remote, fall back to any free port
-		localPort, err := utils.FindFreePortPreferred(remotePort)
+		// Try to use the same port as remote, falling back to any free port unless strict
+		localPort, err := utils.ResolveTunnelLocalPort(bindAddress, remotePort, strict)
 		if err != nil {
 			utils.LogDebug("Failed to find free port: %v", err)
 			return proxytunnelOpenedMsg{err: fmt.Errorf("failed to find free port: %w", err)}
 		}
 		utils.LogDebug("Found free local port: %d (requested: %d)", localPort, remotePort)
 
-		// Find proxytunnel path (checks bundled binary first)
-		proxytunnelPath, err := utils.FindProxytunnelPath()
+		cmd, err := startProxytunnel(client.GetBaseURL(), publicID, remotePort, bindAddress, localPort, nil)
 		if err != nil {
-			utils.LogDebug("proxytunnel not found: %v", err)
-			return proxytunnelOpenedMsg{err: fmt.Errorf("proxytunnel not found: %w", err)}
+			return proxytunnelOpenedMsg{err: err}
 		}
-		utils.LogDebug("Found proxytunnel at: %s", proxytunnelPath)
-
-		// Get proxy configuration based on base URL
-		proxyConfig := utils.GetProxyConfig(client.GetBaseURL())
-		utils.LogDebug("Using proxy server: %s (secure: %v)", proxyConfig.Server, proxyConfig.Secure)
 
-		// Build proxytunnel command arguments
-		args := []string{}
-		if proxyConfig.Secure {
-			args = append(args, "-E")
+		return proxytunnelOpenedMsg{
+			bindAddress: bindAddress,
+			localPort:   localPort,
+			remotePort:  remotePort,
+			cmd:         cmd,
+			err:         nil,
 		}
-		args = append(args,
-			"-p", proxyConfig.Server,
-			"-P", fmt.Sprintf("%s@%d:newpass", publicID, remotePort),
-			"-d", fmt.Sprintf("127.0.0.1:%d", remotePort),
-			"-a", fmt.Sprintf("%d", localPort),
-			"-v",
-			"--no-check-certificate",
-		)
+	}
+}
 
-		cmd := exec.Command(proxytunnelPath, args...)
-		utils.LogDebug("Starting proxytunnel command: %v", cmd.Args)
+// rootSetupTimeout bounds how long we wait for root SSH access to become usable
+const rootSetupTimeout = 90 * time.Second
 
-		// Start the process
-		if err := cmd.Start(); err != nil {
-			utils.LogDebug("Failed to start proxytunnel: %v", err)
-			return proxytunnelOpenedMsg{err: fmt.Errorf("failed to start proxytunnel: %w", err)}
+// confirmRootAccess waits until root SSH access set up by SetupRootAccess is usable.
+// If the server returned a correlation ID the operation is monitored over SSE;
+// otherwise we probe `ssh root@host true` until it succeeds or the timeout elapses.
+func confirmRootAccess(ctx context.Context, client *plato.PlatoClient, correlationID string, sshConfigPath string, sshHost string) error {
+	if correlationID != "" {
+		ctx, cancel := context.WithTimeout(ctx, rootSetupTimeout)
+		defer cancel()
+		if err := client.Sandbox.MonitorOperation(ctx, correlationID, rootSetupTimeout); err != nil {
+			return operationError("root access setup", fmt.Sprintf("follow it with `plato events %s`", correlationID), err)
 		}
-		utils.LogDebug("Proxytunnel started successfully with PID: %d", cmd.Process.Pid)
+		return nil
+	}
 
-		return proxytunnelOpenedMsg{
-			localPort:  localPort,
-			remotePort: remotePort,
-			cmd:        cmd,
-			err:        nil,
+	if sshConfigPath == "" || sshHost == "" {
+		return nil
+	}
+
+	deadline := time.Now().Add(rootSetupTimeout)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		probeOptions := []string{"-o", "User=root", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5"}
+		_, _, err := utils.RunSSHCommandWithOptions(ctx, sshConfigPath, sshHost, probeOptions, "true")
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		utils.LogDebug("Root SSH not ready yet: %v", lastErr)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(3 * time.Second):
 		}
 	}
+	return fmt.Errorf("root SSH access not ready after %s (last error: %v)", rootSetupTimeout, lastErr)
 }
 
-func setupRootPassword(client *plato.PlatoClient, publicID string, privateKeyPath string, sshHost string) tea.Cmd {
+func setupRootPassword(ctx context.Context, client *plato.PlatoClient, publicID string, privateKeyPath string, sshHost string, sshConfigPath string) tea.Cmd {
 	return func() tea.Msg {
-		ctx := context.Background()
-
 		utils.LogDebug("Setting up root SSH access for VM: %s", publicID)
 
 		// Determine the correct public key path
@@ -1591,19 +2023,108 @@ func setupRootPassword(client *plato.PlatoClient, publicID string, privateKeyPat
 		}
 		sshPublicKey := strings.TrimSpace(string(publicKeyData))
 
-		// Call the SetupRootPassword API with SSH public key
-		err = client.Sandbox.SetupRootPassword(ctx, publicID, sshPublicKey)
+		// Call the SetupRootAccess API with SSH public key
+		correlationID, err := client.Sandbox.SetupRootAccess(ctx, publicID, sshPublicKey)
 		if err != nil {
-			utils.LogDebug("SetupRootPassword API failed: %v", err)
-			logErrorToFile("plato_error.log", fmt.Sprintf("API: SetupRootPassword failed for %s: %v", publicID, err))
+			utils.LogDebug("SetupRootAccess API failed: %v", err)
+			logErrorToFile("plato_error.log", fmt.Sprintf("API: SetupRootAccess failed for %s: %v", publicID, err))
 			return rootPasswordSetupMsg{err: fmt.Errorf("failed to set up root SSH access: %w", err)}
 		}
 
+		// Don't report success (and switch the SSH user) until root login actually works
+		if err := confirmRootAccess(ctx, client, correlationID, sshConfigPath, sshHost); err != nil {
+			utils.LogDebug("Root SSH access not confirmed for %s: %v", publicID, err)
+			return rootPasswordSetupMsg{err: err}
+		}
+
 		utils.LogDebug("Root SSH access setup successful for VM: %s", publicID)
 		return rootPasswordSetupMsg{err: nil}
 	}
 }
 
+// rotateSSHKey generates a new key pair for the VM, authorizes it over SSH with
+// the current key, switches the SSH configs over to it and revokes the old key
+// once the new one is confirmed to work. Only the key is pushed; the sandbox
+// setup is not re-run. If the new key can't be verified the config is restored
+// and the old key kept.
+func rotateSSHKey(ctx context.Context, sandbox *models.Sandbox, sshHost, sshConfigPath, oldPrivateKeyPath string) tea.Cmd {
+	return func() tea.Msg {
+		if sshHost == "" || sshConfigPath == "" || oldPrivateKeyPath == "" {
+			return sshKeyRotatedMsg{err: fmt.Errorf("SSH is not configured for this VM")}
+		}
+
+		// Strip any previous rotation suffix so names don't keep growing
+		baseKeyPath := oldPrivateKeyPath
+		if i := strings.LastIndex(baseKeyPath, "_key"); i >= 0 {
+			baseKeyPath = baseKeyPath[:i+len("_key")]
+		}
+		newPrivateKeyPath := fmt.Sprintf("%s_%d", baseKeyPath, time.Now().Unix())
+
+		utils.LogDebug("Rotating SSH key for %s: %s -> %s", sandbox.PublicId, oldPrivateKeyPath, newPrivateKeyPath)
+		publicKey, err := utils.GenerateSSHKeyPairAt(newPrivateKeyPath, fmt.Sprintf("plato-%s", sshHost))
+		if err != nil {
+			return sshKeyRotatedMsg{err: fmt.Errorf("failed to generate key pair: %w", err)}
+		}
+
+		// Authorize the new key for whichever user the current key logs in as
+		installCmd := fmt.Sprintf("umask 077 && mkdir -p ~/.ssh && printf '%%s\\n' %s >> ~/.ssh/authorized_keys", utils.ShellQuote(strings.TrimSpace(publicKey)))
+		if _, _, err := utils.RunSSHCommand(ctx, sshConfigPath, sshHost, installCmd); err != nil {
+			utils.CleanupSSHKeyPair(newPrivateKeyPath)
+			return sshKeyRotatedMsg{err: fmt.Errorf("failed to install new key: %w", err)}
+		}
+
+		if err := utils.UpdateSSHConfigFileIdentity(sshConfigPath, sshHost, newPrivateKeyPath); err != nil {
+			utils.CleanupSSHKeyPair(newPrivateKeyPath)
+			return sshKeyRotatedMsg{err: fmt.Errorf("failed to update SSH config: %w", err)}
+		}
+
+		// Verify the new key before throwing the old one away
+		var verifyErr error
+		for attempt := 0; attempt < 10; attempt++ {
+			// Skip multiplexing so an existing connection made with the old key can't mask a failure
+			probeOptions := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "-o", "ControlPath=none"}
+			_, _, err := utils.RunSSHCommandWithOptions(ctx, sshConfigPath, sshHost, probeOptions, "true")
+			if err == nil {
+				verifyErr = nil
+				break
+			}
+			verifyErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			time.Sleep(3 * time.Second)
+		}
+		if verifyErr != nil {
+			utils.UpdateSSHConfigFileIdentity(sshConfigPath, sshHost, oldPrivateKeyPath)
+			utils.CleanupSSHKeyPair(newPrivateKeyPath)
+			return sshKeyRotatedMsg{err: fmt.Errorf("could not connect with the new key, kept the old one: %w", verifyErr)}
+		}
+
+		// The ~/.ssh/config entry written by Connect to Cursor/VSCode names the key too
+		if err := utils.UpdateSSHConfigIdentity(sshHost, newPrivateKeyPath); err != nil {
+			utils.LogDebug("Failed to update ~/.ssh/config for %s: %v", sshHost, err)
+		}
+
+		// Revoke the old key on the VM
+		if oldPublicKey, err := os.ReadFile(oldPrivateKeyPath + ".pub"); err != nil {
+			utils.LogDebug("Failed to read old public key %s.pub: %v", oldPrivateKeyPath, err)
+		} else {
+			revokeCmd := fmt.Sprintf("{ grep -vxF -e %s ~/.ssh/authorized_keys || true; } > ~/.ssh/authorized_keys.tmp && mv ~/.ssh/authorized_keys.tmp ~/.ssh/authorized_keys", utils.ShellQuote(strings.TrimSpace(string(oldPublicKey))))
+			probeOptions := []string{"-o", "ControlPath=none"}
+			if _, _, err := utils.RunSSHCommandWithOptions(ctx, sshConfigPath, sshHost, probeOptions, revokeCmd); err != nil {
+				utils.LogDebug("Failed to revoke old SSH key on %s: %v", sshHost, err)
+			}
+		}
+
+		if err := utils.CleanupSSHKeyPair(oldPrivateKeyPath); err != nil {
+			utils.LogDebug("Failed to remove old SSH key %s: %v", oldPrivateKeyPath, err)
+		}
+
+		utils.LogDebug("SSH key rotation complete for %s", sandbox.PublicId)
+		return sshKeyRotatedMsg{privateKeyPath: newPrivateKeyPath}
+	}
+}
+
 func openCursor(sshHost string, sshConfigPath string) tea.Cmd {
 	return func() tea.Msg {
 		utils.LogDebug("Opening VS Code for SSH host: %s with config: %s", sshHost, sshConfigPath)
@@ -1623,15 +2144,19 @@ func openCursor(sshHost string, sshConfigPath string) tea.Cmd {
 			return cursorOpenedMsg{err: fmt.Errorf("failed to read existing SSH config: %w", err)}
 		}
 
-		// Check if host already exists
-		if !strings.Contains(existingConfig, fmt.Sprintf("Host %s", sshHost)) {
-			// Append temp config to user's SSH config
-			newConfig := existingConfig
-			if newConfig != "" && !strings.HasSuffix(newConfig, "\n\n") {
-				newConfig += "\n\n"
-			}
-			newConfig += string(tempConfig)
+		// Replace any entry left for this host by an earlier session, so SSH
+		// resolves it to this VM, and drop stale duplicates of other hosts
+		newConfig := utils.RemoveManagedSSHHost(sshHost, existingConfig)
+		newConfig, removed := utils.DedupeSSHHosts(newConfig)
+		if len(removed) > 0 {
+			utils.LogDebug("Removed duplicate SSH config entries for: %s", strings.Join(removed, ", "))
+		}
+		if newConfig != "" && !strings.HasSuffix(newConfig, "\n\n") {
+			newConfig = strings.TrimRight(newConfig, "\n") + "\n\n"
+		}
+		newConfig += string(tempConfig)
 
+		if newConfig != existingConfig {
 			if err := utils.WriteSSHConfig(newConfig); err != nil {
 				utils.LogDebug("Failed to write SSH config: %v", err)
 				return cursorOpenedMsg{err: fmt.Errorf("failed to update SSH config: %w", err)}
@@ -1639,6 +2164,11 @@ func openCursor(sshHost string, sshConfigPath string) tea.Cmd {
 			utils.LogDebug("Added SSH host to ~/.ssh/config")
 		}
 
+		var warnings []string
+		for _, host := range utils.SSHHostCollisions(newConfig) {
+			warnings = append(warnings, fmt.Sprintf("~/.ssh/config has your own 'Host %s' entry, which SSH may use instead of the Plato one", host))
+		}
+
 		// Find code command
 		codePath, err := exec.LookPath("code")
 		if err != nil {
@@ -1663,17 +2193,28 @@ func openCursor(sshHost string, sshConfigPath string) tea.Cmd {
 		// Release the process so it continues independently
 		go cmd.Wait()
 
-		return cursorOpenedMsg{err: nil}
+		return cursorOpenedMsg{warnings: warnings}
 	}
 }
 
 func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
+	// Choosing another action abandons an unconfirmed commit and stops
+	// tailing logs
+	m.commitPending = false
+	m.closeLogs()
+
+	if action.disabledReason != "" {
+		m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  %s is unavailable: %s", action.title, action.disabledReason))
+		m.refreshViewport()
+		return m, nil
+	}
+
 	switch action.title {
 	case "Start Plato Worker":
 		// Load the config to get dataset configuration
 		config, err := LoadPlatoConfig()
 		if err != nil {
-			errMsg := fmt.Sprintf("❌ Failed to load plato-config.yml: %v", err)
+			errMsg := fmt.Sprintf("❌ %v", err)
 			m.statusMessages = append(m.statusMessages, errMsg)
 			logErrorToFile("plato_error.log", errMsg)
 			return m, nil
@@ -1698,8 +2239,10 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		}
 
 		m.statusMessages = append(m.statusMessages, fmt.Sprintf("Starting Plato worker for service: %s, dataset: %s", service, m.dataset))
-		m.runningCommand = true
-		return m, tea.Batch(m.spinner.Tick, startWorker(m.client, m.sandbox.PublicId, service, m.dataset, datasetConfig))
+		client, publicID, dataset := m.client, m.sandbox.PublicId, m.dataset
+		return m, m.runOperation("worker start", func(ctx context.Context) tea.Cmd {
+			return startWorker(ctx, client, publicID, service, dataset, datasetConfig)
+		})
 	case "Set up root SSH":
 		// Check if root password is already set up
 		if m.rootPasswordSetup {
@@ -1714,8 +2257,7 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		}
 
 		m.statusMessages = append(m.statusMessages, "Setting up root SSH password...")
-		m.runningCommand = true
-		return m, tea.Batch(m.spinner.Tick, setupRootPassword(m.client, m.sandbox.PublicId, m.sshPrivateKeyPath, m.sshHost))
+		return m, m.startRootSetup()
 	case "Connect to Cursor/VSCode":
 		if m.sshHost == "" {
 			m.statusMessages = append(m.statusMessages, "❌ SSH host not set up yet")
@@ -1730,6 +2272,8 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		m.statusMessages = append(m.statusMessages, "Opening VS Code...")
 		m.runningCommand = true
 		return m, tea.Batch(m.spinner.Tick, openCursor(m.sshHost, m.sshConfigPath))
+	case "View Logs":
+		return m, m.startLogs()
 	case "Advanced":
 		// Navigate to advanced menu
 		return m, func() tea.Msg {
@@ -1740,7 +2284,7 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		// Load the config to get service name and dataset config
 		config, err := LoadPlatoConfig()
 		if err != nil {
-			errMsg := fmt.Sprintf("❌ Failed to load plato-config.yml: %v", err)
+			errMsg := fmt.Sprintf("❌ %v", err)
 			m.statusMessages = append(m.statusMessages, errMsg)
 			logErrorToFile("plato_error.log", errMsg)
 			return m, nil
@@ -1765,13 +2309,15 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		}
 
 		m.statusMessages = append(m.statusMessages, fmt.Sprintf("Starting service: %s", service))
-		m.runningCommand = true
-		return m, tea.Batch(m.spinner.Tick, startService(m.client, service, m.dataset, datasetConfig, m.sshHost, m.sshConfigPath))
+		client, dataset, sshHost, sshConfigPath, remote := m.client, m.dataset, m.sshHost, m.sshConfigPath, resolveRemoteSettings(config)
+		return m, m.runOperationWithProgress("service start", func(ctx context.Context, progress progressFunc) tea.Cmd {
+			return startService(ctx, client, service, dataset, datasetConfig, sshHost, sshConfigPath, remote, progress)
+		})
 	case "Snapshot VM":
 		// Load the config to get service
 		config, err := LoadPlatoConfig()
 		if err != nil {
-			errMsg := fmt.Sprintf("❌ Failed to load plato-config.yml: %v", err)
+			errMsg := fmt.Sprintf("❌ %v", err)
 			m.statusMessages = append(m.statusMessages, errMsg)
 			logErrorToFile("plato_error.log", errMsg)
 			return m, nil
@@ -1795,13 +2341,64 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 				lastPushedBranch: m.lastPushedBranch,
 			}
 		}
-	case "Close VM":
-		// Stop heartbeat goroutine (only if not already stopped)
-		if !m.heartbeatStopped {
-			close(m.heartbeatStop)
-			m.heartbeatStopped = true
-			utils.LogDebug("Stopped heartbeat goroutine")
+	case "Clean State":
+		config, err := LoadPlatoConfig()
+		if err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ %v", err))
+			return m, nil
+		}
+		if config.Service == "" {
+			m.statusMessages = append(m.statusMessages, "❌ Service not specified in plato-config.yml")
+			return m, nil
+		}
+		dbConfig, ok := utils.GetDBConfigForDataset(config.Service, m.dataset)
+		if !ok {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ No database config for %s yet; run Snapshot VM once to enter it", config.Service))
+			return m, nil
+		}
+
+		m.statusMessages = append(m.statusMessages, fmt.Sprintf("🧹 Cleaning %s state (%s)...", config.Service, dbConfig.DBType))
+		client, publicID, jobGroupID := m.client, m.sandbox.PublicId, m.sandbox.JobGroupId
+		return m, m.runOperation("state cleanup", func(ctx context.Context) tea.Cmd {
+			return cleanState(client, publicID, jobGroupID, dbConfig)
+		})
+	case "Commit State":
+		config, err := LoadPlatoConfig()
+		if err != nil {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ %v", err))
+			return m, nil
+		}
+		if config.Service == "" {
+			m.statusMessages = append(m.statusMessages, "❌ Service not specified in plato-config.yml")
+			return m, nil
+		}
+		if _, ok := config.Datasets[m.dataset]; !ok {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Dataset '%s' not found in plato-config.yml", m.dataset))
+			return m, nil
 		}
+		if m.sshHost == "" {
+			m.statusMessages = append(m.statusMessages, "❌ SSH host not set up yet")
+			return m, nil
+		}
+		// The cleanup step needs DB credentials; Snapshot VM asks for them once
+		if _, ok := utils.GetDBConfig(config.Service); !ok {
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ No database config for %s yet; run Snapshot VM once to enter it", config.Service))
+			return m, nil
+		}
+
+		m.commitPending = true
+		m.commitService = config.Service
+		m.statusMessages = append(m.statusMessages,
+			fmt.Sprintf("Commit current state of %s as a new version of dataset '%s'?", config.Service, m.dataset),
+			"   This pushes your workspace, restarts the service, cleans up the databases and snapshots the VM.",
+			"   Press y to confirm or n to cancel.")
+		m.refreshViewport()
+		return m, nil
+	case "Close VM":
+		// Stop sending heartbeats
+		m.heartbeats.StopAll()
+		utils.LogDebug("Stopped heartbeats")
+		m.tunnelHealth.stopped = true
 		// Kill all proxytunnel processes
 		for i, cmd := range m.proxytunnelProcesses {
 			if cmd.Process != nil {
@@ -1820,35 +2417,16 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 		}
 		utils.LogDebug("Finished killing %d proxytunnel processes", len(m.proxytunnelProcesses))
 
-		// Cleanup SSH config entry if exists
-		if m.sshHost != "" {
-			if err := utils.CleanupSSHConfig(m.sshHost); err != nil {
-				utils.LogDebug("Error cleaning up SSH config: %v", err)
-			} else {
-				utils.LogDebug("Successfully cleaned up SSH config for host: %s", m.sshHost)
-			}
-		}
+		// Stop the audit UI if it is still running
+		m.stopAuditUI()
 
-		// Delete the temporary SSH config file
-		if m.sshConfigPath != "" {
-			if err := os.Remove(m.sshConfigPath); err != nil {
-				utils.LogDebug("Error removing SSH config file %s: %v", m.sshConfigPath, err)
-			} else {
-				utils.LogDebug("Successfully removed SSH config file: %s", m.sshConfigPath)
-			}
-		}
-
-		// Delete the SSH key pair files
-		if m.sshPrivateKeyPath != "" {
-			if err := utils.CleanupSSHKeyPair(m.sshPrivateKeyPath); err != nil {
-				utils.LogDebug("Error cleaning up SSH key pair: %v", err)
-			} else {
-				utils.LogDebug("Successfully cleaned up SSH key pair: %s", m.sshPrivateKeyPath)
-			}
+		// Remove the SSH config entry, pinned host key, temp SSH config and key pair
+		for _, line := range cleanupLocalSSH(m.sshHost, m.sshConfigPath, m.sshPrivateKeyPath) {
+			utils.LogDebug("%s", line)
 		}
 
-		// Remove .sandbox.yaml file from current working directory
-		if err := RemoveSandboxFile(); err != nil {
+		// Remove the .sandbox.yaml written at launch
+		if err := RemoveSandboxFile(m.sandbox.PublicId); err != nil {
 			utils.LogDebug("Error removing .sandbox.yaml: %v", err)
 		} else {
 			utils.LogDebug("Successfully removed .sandbox.yaml")
@@ -1861,11 +2439,15 @@ func (m VMInfoModel) handleAction(action vmAction) (VMInfoModel, tea.Cmd) {
 			defer cancel()
 
 			utils.LogDebug("Calling DeleteVM for: %s", m.sandbox.PublicId)
-			if err := m.client.Sandbox.DeleteVM(ctx, m.sandbox.PublicId); err != nil {
+			if err := m.client.Sandbox.DeleteVM(ctx, m.sandbox.PublicId); err != nil && !vmExpired(err) {
 				// Log error but still navigate away
 				utils.LogDebug("Warning: failed to delete VM: %v", err)
 			} else {
 				utils.LogDebug("Successfully deleted VM: %s", m.sandbox.PublicId)
+				if err := session.ForgetVM(m.sandbox.PublicId); err != nil {
+					utils.LogDebug("Failed to forget session resources: %v", err)
+				}
+				forgetVMSession(m.sandbox.PublicId)
 			}
 			return NavigateMsg{view: ViewMainMenu}
 		}
@@ -1933,6 +2515,13 @@ func (m VMInfoModel) View() string {
 		}
 
 		body := lipgloss.NewStyle().MarginTop(1).Render(statusContent.String())
+		if m.runningCommand && m.opCancel != nil {
+			cancelHint := m.lg.NewStyle().
+				Foreground(lipgloss.Color("240")).
+				MarginLeft(2).
+				Render("esc/c: cancel (the VM keeps running)")
+			body += "\n" + cancelHint
+		}
 		return components.RenderHeader() + "\n" + header + "\n" + body
 	}
 
@@ -1965,39 +2554,212 @@ func (m VMInfoModel) View() string {
 	// Update help text based on which panel is focused
 	var helpText string
 	if m.infoPanelFocused {
-		helpText = "↑/↓: scroll • pgup/pgdn: page • i: focus actions • ctrl+c: quit"
+		helpText = "↑/↓: scroll • pgup/pgdn: page • g/G: top/bottom • i: focus actions • ctrl+c: quit"
 	} else {
 		helpText = "enter: select action • i: focus info • ctrl+c: quit"
 	}
+	if m.auditUIProcess != nil {
+		helpText += " • x: stop audit UI"
+	}
+	if m.stateView != "" {
+		helpText += " • esc: close state"
+	} else if m.logsOpen {
+		helpText += " • esc: close logs"
+	}
+	if m.commitPending {
+		helpText = "y: commit state • n/esc: cancel • ctrl+c: quit"
+	}
 	footer := helpStyle.Render(helpText)
 
 	return components.RenderHeader() + "\n" + header + "\n" + body + "\n" + footer
 }
 
+// startStateFetch begins a cancelable Get State request, tracking bytes received
+func (m *VMInfoModel) startStateFetch() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stateFetchCancel = cancel
+	m.stateFetchBytes = &atomic.Int64{}
+	m.statusMessages = append(m.statusMessages, "Fetching simulator state... (esc to cancel)")
+	m.runningCommand = true
+	return tea.Batch(m.spinner.Tick, getEnvironmentState(ctx, m.client, m.sandbox.JobGroupId, m.stateFetchBytes))
+}
+
+// operationDoneMsg carries the result of an operation started by runOperation
+type operationDoneMsg struct {
+	seq int
+	msg tea.Msg
+}
+
+// runOperation starts a long-running VM operation that the user can cancel
+// with esc or c. op builds the command from a context that is cancelled then;
+// the VM itself is left running.
+func (m *VMInfoModel) runOperation(name string, op func(ctx context.Context) tea.Cmd) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.opSeq++
+	seq := m.opSeq
+	m.opCancel = cancel
+	m.opName = name
+	m.runningCommand = true
+	cmd := op(ctx)
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return operationDoneMsg{seq: seq, msg: cmd()}
+	})
+}
+
+// operationProgressMsg is a status line streamed by an operation started with
+// runOperationWithProgress
+type operationProgressMsg struct {
+	seq       int
+	message   string
+	transient bool
+	updates   <-chan operationProgressMsg
+}
+
+// runOperationWithProgress is runOperation for operations that report
+// progress while they run, such as the hub clone and push before a snapshot
+func (m *VMInfoModel) runOperationWithProgress(name string, op func(ctx context.Context, progress progressFunc) tea.Cmd) tea.Cmd {
+	updates := make(chan operationProgressMsg, 50)
+	progress := func(message string, transient bool) {
+		// Drop updates rather than stall the operation if nobody is reading
+		select {
+		case updates <- operationProgressMsg{message: message, transient: transient}:
+		default:
+		}
+	}
+	cmd := m.runOperation(name, func(ctx context.Context) tea.Cmd {
+		inner := op(ctx, progress)
+		return func() tea.Msg {
+			defer close(updates)
+			return inner()
+		}
+	})
+	return tea.Batch(cmd, waitForOperationProgress(m.opSeq, updates))
+}
+
+// waitForOperationProgress delivers the next progress line of operation seq
+func waitForOperationProgress(seq int, updates <-chan operationProgressMsg) tea.Cmd {
+	return func() tea.Msg {
+		update, ok := <-updates
+		if !ok {
+			return nil
+		}
+		update.seq = seq
+		update.updates = updates
+		return update
+	}
+}
+
+// startECRAuth runs authenticateECR as a cancelable operation
+func (m *VMInfoModel) startECRAuth() tea.Cmd {
+	sshHost, sshConfigPath := m.sshHost, m.sshConfigPath
+	settings, remote := resolveECRSettings(m.config), resolveRemoteSettings(m.config)
+	return m.runOperation("ECR authentication", func(ctx context.Context) tea.Cmd {
+		return authenticateECR(ctx, sshHost, sshConfigPath, settings, remote)
+	})
+}
+
+// startRootSetup runs setupRootPassword as a cancelable operation
+func (m *VMInfoModel) startRootSetup() tea.Cmd {
+	client, publicID, privateKeyPath, sshHost, sshConfigPath := m.client, m.sandbox.PublicId, m.sshPrivateKeyPath, m.sshHost, m.sshConfigPath
+	return m.runOperation("root SSH setup", func(ctx context.Context) tea.Cmd {
+		return setupRootPassword(ctx, client, publicID, privateKeyPath, sshHost, sshConfigPath)
+	})
+}
+
+// startCommit begins Commit State after confirmation: the workspace is pushed
+// and the service restarted, then serviceStartedMsg chains into the snapshot
+func (m *VMInfoModel) startCommit() tea.Cmd {
+	config, err := LoadPlatoConfig()
+	if err != nil {
+		m.commitPending = false
+		m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ %v", err))
+		m.refreshViewport()
+		return nil
+	}
+	datasetConfig := config.Datasets[m.dataset]
+
+	m.commitPending = false
+	m.committing = true
+	m.statusMessages = append(m.statusMessages, fmt.Sprintf("Commit 1/2: pushing workspace and restarting %s...", m.commitService))
+	client, service, dataset, sshHost, sshConfigPath, remote := m.client, m.commitService, m.dataset, m.sshHost, m.sshConfigPath, resolveRemoteSettings(config)
+	return m.runOperationWithProgress("commit", func(ctx context.Context, progress progressFunc) tea.Cmd {
+		return startService(ctx, client, service, dataset, datasetConfig, sshHost, sshConfigPath, remote, progress)
+	})
+}
+
+// startCommitSnapshot is the second Commit State step: clean up and snapshot
+// the VM as the current dataset, merging the branch that was just pushed
+func (m *VMInfoModel) startCommitSnapshot() tea.Cmd {
+	m.statusMessages = append(m.statusMessages, fmt.Sprintf("Commit 2/2: cleaning up databases and snapshotting dataset '%s'...", m.dataset))
+	client, publicID, jobGroupID, service, dataset, branch, snapshotBranch := m.client, m.sandbox.PublicId, m.sandbox.JobGroupId, m.commitService, m.dataset, m.lastPushedBranch, hubSnapshotBranch(m.config)
+	return m.runOperationWithProgress("commit", func(ctx context.Context, progress progressFunc) tea.Cmd {
+		return createSnapshotWithCleanup(ctx, client, publicID, jobGroupID, service, &dataset, branch, snapshotBranch, progress)
+	})
+}
+
+// cancelCommit drops an unconfirmed Commit State
+func (m *VMInfoModel) cancelCommit() {
+	m.commitPending = false
+	m.statusMessages = append(m.statusMessages, "Commit cancelled")
+	m.refreshViewport()
+}
+
+// cancelOperation cancels the operation started by runOperation and returns
+// control to the action list. Its result, if it still arrives, is ignored.
+func (m *VMInfoModel) cancelOperation() {
+	m.opCancel()
+	m.opCancel = nil
+	m.opSeq++
+	m.runningCommand = false
+	m.committing = false
+	m.statusMessages = append(m.statusMessages, fmt.Sprintf("⚠️  Cancelled %s; the VM is still running", m.opName))
+	m.refreshViewport()
+}
+
+// formatByteCount renders a byte count using binary units
+func formatByteCount(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// stopAuditUI kills the streamlit audit UI process group, if one was started
+func (m *VMInfoModel) stopAuditUI() {
+	if m.auditUIProcess == nil || m.auditUIProcess.Process == nil {
+		return
+	}
+	pid := m.auditUIProcess.Process.Pid
+	utils.LogDebug("Stopping audit UI process group (PID: %d)", pid)
+	// uv spawns streamlit as a child, so signal the whole process group
+	if err := terminateProcessGroup(pid); err != nil {
+		utils.LogDebug("Error stopping audit UI process group %d: %v", pid, err)
+		m.auditUIProcess.Process.Kill()
+	}
+	go m.auditUIProcess.Wait()
+	m.auditUIProcess = nil
+}
+
 // fetchHubRepoURL fetches the hub repository URL for a service
 func fetchHubRepoURL(client *plato.PlatoClient, serviceName string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
-		// Fetch simulators
-		simulators, err := client.Gitea.ListSimulators(ctx)
-		if err != nil {
-			return hubRepoURLMsg{url: ""}
-		}
-
 		// Find the simulator by service name
-		for _, sim := range simulators {
-			if strings.EqualFold(sim.Name, serviceName) {
-				if sim.HasRepo {
-					// Get the repository
-					repo, err := client.Gitea.GetSimulatorRepository(ctx, sim.ID)
-					if err == nil {
-						// Return the CloneURL without .git suffix
-						hubURL := strings.TrimSuffix(repo.CloneURL, ".git")
-						return hubRepoURLMsg{url: hubURL}
-					}
-				}
-				break
+		sim, err := client.Gitea.GetSimulatorByName(ctx, serviceName)
+		if err == nil && sim.HasRepo {
+			// Get the repository
+			repo, err := client.Gitea.GetSimulatorRepository(ctx, sim.ID)
+			if err == nil {
+				// Return the CloneURL without .git suffix
+				hubURL := strings.TrimSuffix(repo.CloneURL, ".git")
+				return hubRepoURLMsg{url: hubURL}
 			}
 		}
 
@@ -2013,22 +2775,5 @@ func getSandboxPublicURL(client *plato.PlatoClient, sandbox *models.Sandbox) str
 		identifier = sandbox.PublicId
 	}
 
-	// Determine environment based on base_url
-	if strings.Contains(baseURL, "localhost:8080") {
-		return fmt.Sprintf("http://%s.sims.localhost:8080", identifier)
-	} else if strings.Contains(baseURL, "plato.so") {
-		// Parse subdomain from base URL
-		parts := strings.Split(baseURL, ".")
-		if len(parts) >= 3 {
-			// Extract subdomain (e.g., "dev", "staging")
-			subdomain := strings.TrimPrefix(parts[0], "https://")
-			subdomain = strings.TrimPrefix(subdomain, "http://")
-			if subdomain != "plato" {
-				return fmt.Sprintf("https://%s.%s.sims.plato.so", identifier, subdomain)
-			}
-		}
-		return fmt.Sprintf("https://%s.sims.plato.so", identifier)
-	}
-
-	return ""
+	return models.ComputePublicURL(baseURL, identifier)
 }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"plato-cli/internal/utils"
+	"plato-sdk/models"
+	"plato-sdk/services"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxLogLines bounds how many log lines the View Logs panel keeps
+const maxLogLines = 2000
+
+// logBatchSize is how many buffered log lines are handed to the view at once
+const logBatchSize = 100
+
+// logTailLines is how much history the SSH fallback shows before following
+const logTailLines = 200
+
+// logStreamOpenedMsg carries a log stream opened by View Logs
+type logStreamOpenedMsg struct {
+	seq    int
+	stream <-chan string
+	// source says where the lines come from, the API or ssh
+	source string
+	err    error
+}
+
+// logLinesMsg carries the lines that arrived since the last one
+type logLinesMsg struct {
+	seq    int
+	lines  []string
+	stream <-chan string
+}
+
+// logStreamEndedMsg reports that the log stream closed
+type logStreamEndedMsg struct {
+	seq int
+}
+
+// startLogs opens the View Logs panel and starts tailing the container logs.
+// The API stream is preferred; without one, the dataset's docker compose
+// logs are followed over SSH.
+func (m *VMInfoModel) startLogs() tea.Cmd {
+	m.closeLogs()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.logsSeq++
+	m.logsCancel = cancel
+	m.logsOpen = true
+	m.infoPanelFocused = true
+	m.refreshViewport()
+	m.viewport.GotoBottom()
+
+	seq, client, publicID := m.logsSeq, m.client, m.sandbox.PublicId
+	sshHost, sshConfigPath := m.sshHost, m.sshConfigPath
+	sshCmd, sshCmdErr := composeLogsCommand(m.config, m.dataset)
+	return func() tea.Msg {
+		stream, err := client.Sandbox.StreamLogs(ctx, publicID, "")
+		if err == nil {
+			return logStreamOpenedMsg{seq: seq, stream: stream, source: "API"}
+		}
+		if !errors.Is(err, services.ErrLogStreamUnsupported) {
+			return logStreamOpenedMsg{seq: seq, err: err}
+		}
+		utils.LogDebug("Log streaming unavailable (%v), following docker compose logs over SSH", err)
+		switch {
+		case sshHost == "" || sshConfigPath == "":
+			return logStreamOpenedMsg{seq: seq, err: fmt.Errorf("the API cannot stream logs and SSH is not set up yet")}
+		case sshCmdErr != nil:
+			return logStreamOpenedMsg{seq: seq, err: fmt.Errorf("the API cannot stream logs and they cannot be followed over SSH: %w", sshCmdErr)}
+		}
+		stream, err = utils.StreamSSHCommand(ctx, sshConfigPath, sshHost, sshCmd)
+		return logStreamOpenedMsg{seq: seq, stream: stream, source: "ssh", err: err}
+	}
+}
+
+// closeLogs stops the log stream, if any, and closes the View Logs panel
+func (m *VMInfoModel) closeLogs() {
+	if m.logsCancel != nil {
+		m.logsCancel()
+		m.logsCancel = nil
+	}
+	m.logsOpen = false
+	m.logLines = nil
+}
+
+// waitForLogLines blocks for the next log line, then takes whatever else is
+// already buffered so a burst of output is rendered once
+func waitForLogLines(seq int, stream <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-stream
+		if !ok {
+			return logStreamEndedMsg{seq: seq}
+		}
+		lines := []string{line}
+		for len(lines) < logBatchSize {
+			select {
+			case line, ok := <-stream:
+				if !ok {
+					return logLinesMsg{seq: seq, lines: lines, stream: stream}
+				}
+				lines = append(lines, line)
+			default:
+				return logLinesMsg{seq: seq, lines: lines, stream: stream}
+			}
+		}
+		return logLinesMsg{seq: seq, lines: lines, stream: stream}
+	}
+}
+
+// updateLogs handles the messages of the View Logs stream. Messages of a
+// stream that was closed or replaced are dropped.
+func (m VMInfoModel) updateLogs(msg tea.Msg) (VMInfoModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case logStreamOpenedMsg:
+		if msg.seq != m.logsSeq || !m.logsOpen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.closeLogs()
+			m.statusMessages = append(m.statusMessages, fmt.Sprintf("❌ Failed to stream logs: %v", msg.err))
+			m.refreshViewport()
+			return m, nil
+		}
+		utils.LogDebug("Streaming logs of %s from %s", m.sandbox.PublicId, msg.source)
+		return m, waitForLogLines(msg.seq, msg.stream)
+
+	case logLinesMsg:
+		if msg.seq != m.logsSeq || !m.logsOpen {
+			return m, nil
+		}
+		m.logLines = append(m.logLines, msg.lines...)
+		if extra := len(m.logLines) - maxLogLines; extra > 0 {
+			m.logLines = append([]string(nil), m.logLines[extra:]...)
+		}
+		m.refreshViewport()
+		return m, waitForLogLines(msg.seq, msg.stream)
+
+	case logStreamEndedMsg:
+		if msg.seq != m.logsSeq || !m.logsOpen {
+			return m, nil
+		}
+		if m.logsCancel != nil {
+			m.logsCancel()
+			m.logsCancel = nil
+		}
+		m.logLines = append(m.logLines, "── log stream ended ──")
+		m.refreshViewport()
+		return m, nil
+	}
+	return m, nil
+}
+
+// renderLogs renders the View Logs panel
+func (m VMInfoModel) renderLogs() string {
+	var output strings.Builder
+	output.WriteString("CONTAINER LOGS (esc to close)\n")
+	output.WriteString(strings.Repeat("─", 50) + "\n\n")
+	if len(m.logLines) == 0 {
+		output.WriteString("Waiting for log output...\n")
+		return output.String()
+	}
+	output.WriteString(strings.Join(m.logLines, "\n"))
+	output.WriteString("\n")
+	return output.String()
+}
+
+// composeLogsCommand builds the remote command that follows the logs of the
+// dataset's docker compose services, run from where Start Service cloned them
+func composeLogsCommand(config *models.PlatoConfig, dataset string) (string, error) {
+	if config == nil || config.Service == "" {
+		return "", fmt.Errorf("plato-config.yml does not name a service")
+	}
+	datasetConfig, ok := config.Datasets[dataset]
+	if !ok {
+		return "", fmt.Errorf("dataset '%s' not found in plato-config.yml", dataset)
+	}
+	order, err := serviceStartOrder(datasetConfig.Services)
+	if err != nil {
+		return "", err
+	}
+
+	remote := resolveRemoteSettings(config)
+	repoDir := remote.repoDir(config.Service)
+	var cmds []string
+	for _, name := range order {
+		service := datasetConfig.Services[name]
+		if service.Type != "docker-compose" {
+			continue
+		}
+		composeFile := service.File
+		if composeFile == "" {
+			composeFile = "docker-compose.yml"
+		}
+		cmds = append(cmds, fmt.Sprintf("cd %s && DOCKER_HOST=%s docker compose -f %s logs -f --tail %d",
+			utils.ShellQuote(repoDir), utils.ShellQuote(remote.dockerHost), utils.ShellQuote(composeFile), logTailLines))
+	}
+	switch len(cmds) {
+	case 0:
+		return "", fmt.Errorf("dataset '%s' has no docker-compose services", dataset)
+	case 1:
+		return cmds[0], nil
+	}
+	return "(" + strings.Join(cmds, ") & (") + ") & wait", nil
+}
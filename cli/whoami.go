@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"plato-cli/internal/config"
+)
+
+// runWhoami implements `plato whoami`. It prints which environment and
+// credentials the CLI will use, without contacting the API.
+func runWhoami() error {
+	baseURL := config.GetBaseURL()
+	environment := config.DetectEnvironment(baseURL)
+	apiKey, provider := config.ResolveAPIKeyWithProvider()
+
+	fmt.Printf("Environment: %s\n", RenderEnvironmentBadge(environment))
+	fmt.Printf("Base URL:    %s\n", baseURL)
+	hubURL := os.Getenv("PLATO_HUB_API_URL")
+	if hubURL == "" {
+		hubURL = "https://plato.so/api"
+	}
+	fmt.Printf("Hub URL:     %s\n", hubURL)
+	if apiKey == "" {
+		fmt.Println("API key:     not configured (run `plato login`)")
+	} else {
+		fmt.Printf("API key:     %s (from %s)\n", maskAPIKey(apiKey), provider)
+	}
+	if environment == config.EnvironmentProd {
+		fmt.Println("⚠️  This CLI is pointed at production")
+	}
+	return nil
+}
+
+// maskAPIKey hides all but the last four characters of key
+func maskAPIKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
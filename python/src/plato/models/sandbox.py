@@ -101,15 +101,17 @@ class DBConfig(BaseModel):
     """Database configuration for pre-snapshot cleanup"""
     db_type: str  # "postgresql" or "mysql"
     user: str
-    password: str
+    password: str = ""
     dest_port: int
     databases: List[str]
+    password_env: Optional[str] = None  # Env var holding the password; wins over password
 
 
 class CreateSnapshotRequest(BaseModel):
     service: Optional[str] = None
     git_hash: Optional[str] = None
     dataset: Optional[str] = None
+    tags: Optional[List[str]] = None
 
 
 class CreateSnapshotResponse(BaseModel):
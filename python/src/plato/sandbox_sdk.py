@@ -9,7 +9,7 @@ import json
 import logging
 import os
 from pathlib import Path
-from typing import Optional, Dict, Any, List, Union
+from typing import Callable, Optional, Dict, Any, List, Union
 
 from plato.models.sandbox import (
     Sandbox,
@@ -62,6 +62,10 @@ def _find_lib():
     )
 
 
+# Signature of plato_progress_callback: receives one progress event as JSON
+_PROGRESS_CALLBACK = ctypes.CFUNCTYPE(None, ctypes.c_char_p)
+
+
 # Load the shared library (do this lazily to avoid import-time errors)
 _lib = None
 _lib_path = None
@@ -89,6 +93,18 @@ def _get_lib():
         ]
         _lib.plato_create_sandbox.restype = ctypes.c_void_p
 
+        _lib.plato_create_sandbox_and_wait.argtypes = [
+            ctypes.c_char_p,     # clientID
+            ctypes.c_char_p,     # configJSON
+            ctypes.c_char_p,     # dataset
+            ctypes.c_char_p,     # alias
+            ctypes.c_char_p,     # artifactID
+            ctypes.c_char_p,     # service
+            ctypes.c_int,        # timeoutSeconds
+            _PROGRESS_CALLBACK,  # progressCallback
+        ]
+        _lib.plato_create_sandbox_and_wait.restype = ctypes.c_void_p
+
         _lib.plato_delete_sandbox.argtypes = [ctypes.c_char_p, ctypes.c_char_p]
         _lib.plato_delete_sandbox.restype = ctypes.c_void_p
 
@@ -140,6 +156,9 @@ def _get_lib():
         _lib.plato_proxytunnel_list.argtypes = [ctypes.c_char_p]
         _lib.plato_proxytunnel_list.restype = ctypes.c_void_p
 
+        _lib.plato_proxytunnel_health_check.argtypes = [ctypes.c_char_p, ctypes.c_char_p]
+        _lib.plato_proxytunnel_health_check.restype = ctypes.c_void_p
+
         _lib.plato_gitea_push_to_hub.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.c_char_p]
         _lib.plato_gitea_push_to_hub.restype = ctypes.c_void_p
 
@@ -293,31 +312,7 @@ class PlatoSandboxClient:
                 "or 'artifact_id' to create from an existing snapshot."
             )
 
-        # If artifact_id is provided but no config, use a default boilerplate config
-        # TODO(API): The API should fetch the proper config from the artifact metadata
-        # instead of requiring the client to send a boilerplate config
-        if config is None and artifact_id is not None:
-            # Default boilerplate config - actual config should be fetched from artifact on API side
-            config_dict = {
-                "compute": {
-                    "cpus": 1,
-                    "memory": 512,
-                    "disk": 10240,
-                    "app_port": 8080,
-                    "plato_messaging_port": 7000
-                },
-                "metadata": {
-                    "name": "Default"
-                }
-            }
-            config_json = json.dumps(config_dict)
-        elif config is not None:
-            # Convert config to dict if it's a Pydantic model
-            # Use mode='json' to properly serialize enums to their values
-            config_dict = config.model_dump(mode='json', exclude_none=True)
-            config_json = json.dumps(config_dict)
-        else:
-            config_json = "{}"
+        config_json = self._config_json(config, artifact_id)
 
         logger.info(f"Creating sandbox: artifact_id={artifact_id}, service={service}, dataset={dataset}, sandbox_timeout={sandbox_timeout}")
         lib = _get_lib()
@@ -359,6 +354,124 @@ class PlatoSandboxClient:
 
         return sandbox
 
+    def create_sandbox_and_wait(
+        self,
+        config: Optional[SimConfigDataset] = None,
+        dataset: str = "base",
+        alias: str = "sandbox",
+        artifact_id: Optional[str] = None,
+        service: str = "",
+        timeout: int = 1200,
+        on_progress: Optional[Callable[[Dict[str, Any]], None]] = None,
+    ) -> Sandbox:
+        """
+        Create a VM sandbox and wait until it is provisioned, reporting progress
+
+        Unlike create_sandbox(wait=True), creating and waiting is a single call
+        into the Go SDK, which passes each provisioning event to on_progress as
+        it arrives.
+
+        Args:
+            config: Sandbox configuration, as for create_sandbox
+            dataset: Dataset name (default: 'base')
+            alias: Human-readable alias (default: 'sandbox')
+            artifact_id: Optional artifact ID to launch from snapshot
+            service: Service name
+            timeout: Seconds to wait for provisioning (default: 1200)
+            on_progress: Called with a dict per step: 'stage' is 'created',
+                'event' (with the raw provisioning event under 'event') or
+                'ready', and 'public_id' names the sandbox
+
+        Returns:
+            The ready Sandbox, with status 'running'
+
+        Raises:
+            ValueError: If neither config nor artifact_id is provided
+            RuntimeError: If creation or provisioning fails or times out
+
+        Example:
+            >>> sandbox = client.create_sandbox_and_wait(
+            ...     artifact_id="art_123456",
+            ...     on_progress=lambda e: print(e["stage"], e.get("event", {}).get("message", "")),
+            ... )
+        """
+        if config is None and artifact_id is None:
+            raise ValueError("Must provide either 'config' or 'artifact_id'.")
+        config_json = self._config_json(config, artifact_id)
+
+        def progress(event_json: bytes) -> None:
+            if on_progress is None:
+                return
+            try:
+                on_progress(json.loads(event_json.decode('utf-8')))
+            except Exception:
+                # Exceptions cannot propagate through the C callback
+                logger.exception("Progress callback failed")
+
+        # Keep a reference to the callback until the call returns
+        callback = _PROGRESS_CALLBACK(progress)
+
+        logger.info(f"Creating sandbox and waiting: artifact_id={artifact_id}, service={service}, dataset={dataset}, timeout={timeout}")
+        lib = _get_lib()
+        result_ptr = lib.plato_create_sandbox_and_wait(
+            self._client_id.encode('utf-8'),
+            config_json.encode('utf-8'),
+            dataset.encode('utf-8'),
+            alias.encode('utf-8'),
+            artifact_id.encode('utf-8') if artifact_id else b'',
+            service.encode('utf-8'),
+            ctypes.c_int(timeout),
+            callback,
+        )
+
+        result_str = _call_and_free(lib, result_ptr)
+        response = json.loads(result_str)
+
+        if 'error' in response:
+            public_id = response.get('public_id')
+            logger.error(f"Failed to create sandbox {public_id or ''}: {response['error']}")
+            raise RuntimeError(f"Failed to create sandbox: {response['error']}")
+
+        sandbox = Sandbox(**response)
+        logger.info(f"Sandbox {sandbox.public_id} is ready")
+
+        if config is not None:
+            self._sandbox_configs[sandbox.public_id] = {
+                'config': config,
+                'dataset': dataset
+            }
+        return sandbox
+
+    @staticmethod
+    def _config_json(config: Optional[SimConfigDataset], artifact_id: Optional[str]) -> str:
+        """Serialize a sandbox config for the C bindings"""
+        # If artifact_id is provided but no config, use a default boilerplate config
+        # TODO(API): The API should fetch the proper config from the artifact metadata
+        # instead of requiring the client to send a boilerplate config
+        if config is None and artifact_id is not None:
+            # Default boilerplate config - actual config should be fetched from artifact on API side
+            config_dict = {
+                "compute": {
+                    "cpus": 1,
+                    "memory": 512,
+                    "disk": 10240,
+                    "app_port": 8080,
+                    "plato_messaging_port": 7000
+                },
+                "metadata": {
+                    "name": "Default"
+                }
+            }
+            config_json = json.dumps(config_dict)
+        elif config is not None:
+            # Convert config to dict if it's a Pydantic model
+            # Use mode='json' to properly serialize enums to their values
+            config_dict = config.model_dump(mode='json', exclude_none=True)
+            config_json = json.dumps(config_dict)
+        else:
+            config_json = "{}"
+        return config_json
+
     def close_sandbox(self, public_id: str) -> None:
         """
         Close a VM sandbox
@@ -495,6 +608,8 @@ class PlatoSandboxClient:
                 - db_type: "postgresql" or "mysql"
                 - user: Database user
                 - password: Database password
+                - password_env: Optional environment variable holding the password,
+                  read at cleanup time instead of password
                 - dest_port: Database port (e.g., 5432 for PostgreSQL, 3306 for MySQL)
                 - databases: List of database names to clean
 
@@ -900,6 +1015,7 @@ class PlatoSandboxClient:
 
         Returns:
             List of tunnel dicts with 'ID', 'LocalPort', 'RemotePort', 'PublicID'
+            and 'Status' ('active' or 'reconnecting')
 
         Raises:
             RuntimeError: If listing fails
@@ -920,6 +1036,38 @@ class PlatoSandboxClient:
         logger.info(f"Listed {len(response)} proxy tunnels")
         return response
 
+    def check_proxy_tunnel(self, tunnel_id: str) -> Dict[str, Any]:
+        """
+        Check that a proxy tunnel still accepts connections on its local port,
+        restarting it on the same port if it died.
+
+        Args:
+            tunnel_id: ID of the tunnel to check
+
+        Returns:
+            Dict with 'tunnel_id', 'healthy' and 'restarted'
+
+        Raises:
+            RuntimeError: If the tunnel is unknown or could not be restarted
+        """
+        logger.debug(f"Checking proxy tunnel: tunnel_id={tunnel_id}")
+        lib = _get_lib()
+        result_ptr = lib.plato_proxytunnel_health_check(
+            self._client_id.encode('utf-8'),
+            tunnel_id.encode('utf-8')
+        )
+
+        result_str = _call_and_free(lib, result_ptr)
+        response = json.loads(result_str)
+
+        if 'error' in response:
+            logger.error(f"Proxy tunnel health check failed: {response['error']}")
+            raise RuntimeError(f"Proxy tunnel health check failed: {response['error']}")
+
+        if response['restarted']:
+            logger.warning(f"Proxy tunnel {tunnel_id} was down and has been restarted")
+        return response
+
     def push_to_gitea(self, service_name: str, source_dir: str = "") -> Dict[str, Any]:
         """
         Push local code to Gitea repository on a timestamped branch.
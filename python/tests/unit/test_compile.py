@@ -0,0 +1,20 @@
+"""Byte-compile every module of the package so syntax errors fail the suite."""
+
+import compileall
+from pathlib import Path
+
+import pytest
+
+
+SRC = Path(__file__).resolve().parents[2] / "src" / "plato"
+
+
+@pytest.mark.unit
+def test_package_compiles():
+    assert compileall.compile_dir(str(SRC), quiet=1, force=True, legacy=False)
+
+
+@pytest.mark.unit
+def test_sandbox_sdk_compiles():
+    source = (SRC / "sandbox_sdk.py").read_text()
+    compile(source, "sandbox_sdk.py", "exec")
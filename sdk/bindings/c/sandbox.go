@@ -3,6 +3,14 @@ package main
 
 /*
 #include <stdlib.h>
+
+// plato_progress_callback receives one JSON-encoded progress event; the string
+// is freed after the callback returns
+typedef void (*plato_progress_callback)(const char* event_json);
+
+static void plato_call_progress(plato_progress_callback cb, const char* event_json) {
+	cb(event_json);
+}
 */
 import "C"
 import (
@@ -11,16 +19,19 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
 	"unsafe"
 
 	plato "plato-sdk"
 	"plato-sdk/models"
+	"plato-sdk/services"
+	"plato-sdk/utils"
 )
 
 var clients = make(map[string]*plato.PlatoClient)
 var nextID = 0
-var heartbeatStoppers = make(map[string]chan struct{})
 var debugLogger *log.Logger
 
 func init() {
@@ -95,7 +106,7 @@ func plato_create_sandbox(clientID *C.char, configJSON *C.char, dataset *C.char,
 		return C.CString(fmt.Sprintf(`{"error": "failed to marshal result: %v"}`, err))
 	}
 
-	// Start automatic heartbeat goroutine for this sandbox
+	// Keep the sandbox alive with the client's heartbeat scheduler
 	if sandbox.JobGroupId != "" {
 		logDebug("Starting heartbeat for sandbox %s (job_group_id: %s)", sandbox.PublicId, sandbox.JobGroupId)
 		startHeartbeat(client, sandbox.JobGroupId)
@@ -104,51 +115,135 @@ func plato_create_sandbox(clientID *C.char, configJSON *C.char, dataset *C.char,
 	return C.CString(string(result))
 }
 
-// startHeartbeat starts a goroutine that sends periodic heartbeats for a sandbox
-func startHeartbeat(client *plato.PlatoClient, jobGroupID string) {
-	// Don't start if already running
-	if _, exists := heartbeatStoppers[jobGroupID]; exists {
-		logDebug("Heartbeat already running for job_group_id: %s", jobGroupID)
+// defaultProvisionTimeout bounds plato_create_sandbox_and_wait when no
+// timeout is given, matching the CLI's default
+const defaultProvisionTimeout = 20 * time.Minute
+
+// progressEvent is what plato_create_sandbox_and_wait passes to its callback,
+// JSON-encoded. Stage is "created" once the VM is requested, "event" for each
+// provisioning event and "ready" when provisioning completed.
+type progressEvent struct {
+	Stage    string `json:"stage"`
+	PublicID string `json:"public_id"`
+	// Event is the provisioning event as sent by the API, for stage "event"
+	Event json.RawMessage `json:"event,omitempty"`
+}
+
+// sendProgress passes event to callback, if there is one
+func sendProgress(callback C.plato_progress_callback, event progressEvent) {
+	if callback == nil {
 		return
 	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		logDebug("Failed to marshal progress event: %v", err)
+		return
+	}
+	cs := C.CString(string(data))
+	defer C.free(unsafe.Pointer(cs))
+	C.plato_call_progress(callback, cs)
+}
+
+// plato_create_sandbox_and_wait is plato_create_sandbox followed by waiting
+// for provisioning to finish, the way the TUI launches VMs. progressCallback,
+// if not NULL, is called on this thread with a progressEvent as JSON for each
+// step. timeoutSeconds bounds the wait; 0 or less waits up to 20 minutes. The
+// sandbox JSON is returned once the VM is ready; if provisioning fails the
+// error JSON also carries the public_id so the caller can delete the VM.
+//
+//export plato_create_sandbox_and_wait
+func plato_create_sandbox_and_wait(clientID *C.char, configJSON *C.char, dataset *C.char, alias *C.char, artifactID *C.char, service *C.char, timeoutSeconds C.int, progressCallback C.plato_progress_callback) *C.char {
+	client, ok := clients[C.GoString(clientID)]
+	if !ok {
+		return C.CString(`{"error": "invalid client ID"}`)
+	}
+
+	var config models.SimConfigDataset
+	if err := json.Unmarshal([]byte(C.GoString(configJSON)), &config); err != nil {
+		return C.CString(fmt.Sprintf(`{"error": "failed to parse config: %v"}`, err))
+	}
 
-	stopChan := make(chan struct{})
-	heartbeatStoppers[jobGroupID] = stopChan
+	var aid *string
+	if artifactID != nil && C.GoString(artifactID) != "" {
+		s := C.GoString(artifactID)
+		aid = &s
+	}
 
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
+	timeout := defaultProvisionTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
 
-		// Send initial heartbeat
-		ctx := context.Background()
-		logDebug("Sending initial heartbeat for job_group_id: %s", jobGroupID)
-		err := client.Sandbox.SendHeartbeat(ctx, jobGroupID)
+	ctx := context.Background()
+	sandbox, err := client.Sandbox.Create(ctx, &config, C.GoString(dataset), C.GoString(alias), aid, C.GoString(service), nil)
+	if err != nil {
+		return C.CString(fmt.Sprintf(`{"error": "%v"}`, err))
+	}
+	if sandbox.JobGroupId != "" {
+		logDebug("Starting heartbeat for sandbox %s (job_group_id: %s)", sandbox.PublicId, sandbox.JobGroupId)
+		startHeartbeat(client, sandbox.JobGroupId)
+	}
+	sendProgress(progressCallback, progressEvent{Stage: "created", PublicID: sandbox.PublicId})
+
+	if sandbox.CorrelationId != "" {
+		err = client.Sandbox.StreamRawEvents(ctx, sandbox.CorrelationId, timeout, func(line string) {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok || !json.Valid([]byte(data)) {
+				return
+			}
+			sendProgress(progressCallback, progressEvent{Stage: "event", PublicID: sandbox.PublicId, Event: json.RawMessage(data)})
+		})
 		if err != nil {
-			logDebug("Initial heartbeat failed for %s: %v", jobGroupID, err)
-		} else {
-			logDebug("Initial heartbeat successful for %s", jobGroupID)
+			return C.CString(fmt.Sprintf(`{"error": "%v", "public_id": "%s"}`, err, sandbox.PublicId))
 		}
+	}
+	sandbox.Status = "running"
+	sendProgress(progressCallback, progressEvent{Stage: "ready", PublicID: sandbox.PublicId})
 
-		for {
-			select {
-			case <-ticker.C:
-				// Send heartbeat
-				ctx := context.Background()
-				logDebug("Sending heartbeat for job_group_id: %s", jobGroupID)
-				err := client.Sandbox.SendHeartbeat(ctx, jobGroupID)
-				if err != nil {
-					logDebug("Heartbeat failed for %s: %v", jobGroupID, err)
-				} else {
-					logDebug("Heartbeat successful for %s", jobGroupID)
-				}
-			case <-stopChan:
-				// Stop signal received
-				logDebug("Stopping heartbeat for job_group_id: %s", jobGroupID)
-				delete(heartbeatStoppers, jobGroupID)
-				return
+	result, err := json.Marshal(sandbox)
+	if err != nil {
+		return C.CString(fmt.Sprintf(`{"error": "failed to marshal result: %v"}`, err))
+	}
+	return C.CString(string(result))
+}
+
+var (
+	heartbeatMu       sync.Mutex
+	heartbeatManagers = make(map[*plato.PlatoClient]*services.HeartbeatManager)
+)
+
+// startHeartbeat keeps a sandbox alive with its client's heartbeat manager,
+// which sends one batched heartbeat per interval for all of the client's sandboxes
+func startHeartbeat(client *plato.PlatoClient, jobGroupID string) {
+	heartbeatMu.Lock()
+	manager, ok := heartbeatManagers[client]
+	if !ok {
+		manager = services.NewHeartbeatManager(client.Sandbox, services.WithHeartbeatFailures(services.DefaultHeartbeatFailureThreshold, func(id string, failures int, err error) {
+			if err != nil {
+				logDebug("Heartbeat failed %d times in a row for %s: %v", failures, id, err)
+			} else {
+				logDebug("Heartbeat recovered for %s", id)
 			}
+		}))
+		heartbeatManagers[client] = manager
+	}
+	heartbeatMu.Unlock()
+
+	if !manager.Start(context.Background(), jobGroupID) {
+		logDebug("Heartbeat already running for job_group_id: %s", jobGroupID)
+	}
+}
+
+// stopHeartbeat stops the heartbeats of a sandbox, whichever client started them
+func stopHeartbeat(jobGroupID string) {
+	heartbeatMu.Lock()
+	defer heartbeatMu.Unlock()
+	for _, manager := range heartbeatManagers {
+		if manager.Running(jobGroupID) {
+			logDebug("Stopping heartbeat for job_group_id: %s", jobGroupID)
+			manager.Stop(jobGroupID)
 		}
-	}()
+	}
 }
 
 //export plato_delete_sandbox
@@ -167,10 +262,7 @@ func plato_delete_sandbox(clientID *C.char, publicID *C.char) *C.char {
 	sandbox, err := client.Sandbox.Get(ctx, publicIDStr)
 	if err == nil && sandbox.JobGroupId != "" {
 		// Stop heartbeat if running
-		if stopChan, exists := heartbeatStoppers[sandbox.JobGroupId]; exists {
-			logDebug("Stopping heartbeat for sandbox %s (job_group_id: %s)", publicIDStr, sandbox.JobGroupId)
-			close(stopChan)
-		}
+		stopHeartbeat(sandbox.JobGroupId)
 	}
 
 	if err := client.Sandbox.DeleteVM(ctx, C.GoString(publicID)); err != nil {
@@ -263,7 +355,21 @@ func plato_create_snapshot_with_cleanup(clientID *C.char, publicID *C.char, jobG
 	defer cancel()
 
 	logDebug("Creating snapshot with cleanup for publicID=%s, jobGroupID=%s", C.GoString(publicID), C.GoString(jobGroupID))
-	resp, err := client.Sandbox.CreateSnapshotWithCleanup(ctx, C.GoString(publicID), C.GoString(jobGroupID), &req, dbConfig)
+	resp, cleanup, err := client.Sandbox.CreateSnapshotWithCleanupResults(ctx, C.GoString(publicID), C.GoString(jobGroupID), &req, dbConfig)
+	for _, r := range cleanup {
+		if r.Cleared {
+			logDebug("Cleared audit_log in %s", r.Database)
+		} else {
+			logDebug("audit_log not cleared in %s: %v", r.Database, r.Err)
+		}
+		for _, stmt := range r.CleanupSQL {
+			if stmt.Err != nil {
+				logDebug("Cleanup SQL %q failed in %s: %v", stmt.Statement, r.Database, stmt.Err)
+			} else {
+				logDebug("Ran cleanup SQL %q in %s", stmt.Statement, r.Database)
+			}
+		}
+	}
 	if err != nil {
 		logDebug("CreateSnapshotWithCleanup failed: %v", err)
 		return C.CString(fmt.Sprintf(`{"error": "%v"}`, err))
@@ -533,6 +639,36 @@ func plato_proxytunnel_list(clientID *C.char) *C.char {
 	return C.CString(string(result))
 }
 
+//export plato_proxytunnel_health_check
+func plato_proxytunnel_health_check(clientID *C.char, tunnelID *C.char) *C.char {
+	client, ok := clients[C.GoString(clientID)]
+	if !ok {
+		return C.CString(`{"error": "invalid client ID"}`)
+	}
+
+	tidStr := C.GoString(tunnelID)
+	healthy, err := client.ProxyTunnel.HealthCheck(tidStr)
+	if err != nil {
+		logDebug("Proxytunnel health check failed: tunnelID=%s: %v", tidStr, err)
+		return C.CString(fmt.Sprintf(`{"error": "%v"}`, err))
+	}
+	if !healthy {
+		logDebug("Proxytunnel %s was down and has been restarted", tidStr)
+	}
+
+	result := map[string]interface{}{
+		"tunnel_id": tidStr,
+		"healthy":   healthy,
+		"restarted": !healthy,
+	}
+	resultJSON, _ := json.Marshal(result)
+	return C.CString(string(resultJSON))
+}
+
+// pushProgressFiles is how many copied files apart plato_gitea_push_to_hub
+// logs its progress
+const pushProgressFiles = 100
+
 //export plato_gitea_push_to_hub
 func plato_gitea_push_to_hub(clientID *C.char, serviceName *C.char, sourceDir *C.char) *C.char {
 	client, ok := clients[C.GoString(clientID)]
@@ -544,8 +680,14 @@ func plato_gitea_push_to_hub(clientID *C.char, serviceName *C.char, sourceDir *C
 	sourceDirStr := C.GoString(sourceDir)
 	logDebug("Pushing to hub: service=%s, sourceDir=%s", serviceNameStr, sourceDirStr)
 
+	// Copying a large workspace takes a while, so with PLATO_DEBUG set the
+	// copy is logged every pushProgressFiles files
 	ctx := context.Background()
-	result, err := client.Gitea.PushToHub(ctx, serviceNameStr, sourceDirStr)
+	result, err := client.Gitea.PushToHubWithProgress(ctx, serviceNameStr, sourceDirStr, func(p utils.CopyProgress) {
+		if p.FilesCopied%pushProgressFiles == 0 || p.FilesCopied == p.TotalFiles {
+			logDebug("Copied %d/%d files (%d/%d bytes)", p.FilesCopied, p.TotalFiles, p.BytesCopied, p.TotalBytes)
+		}
+	})
 	if err != nil {
 		logDebug("Failed to push to hub: %v", err)
 		return C.CString(fmt.Sprintf(`{"error": "%v"}`, err))
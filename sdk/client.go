@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"os"
 	"time"
@@ -29,16 +30,26 @@ type ClientOption func(*PlatoClient)
 type PlatoClient struct {
 	baseURL    string
 	hubBaseURL string // Separate base URL for Gitea/Hub operations
-	apiKey     string
-	httpClient *http.Client
+	hubGit     services.GitTransport
+	hubClone   services.CloneOptions
+	sseIdle    time.Duration
+	// sseReconnects and sseReconnectDelay bound how operation monitors
+	// re-open a dropped event stream
+	sseReconnects     int
+	sseReconnectDelay time.Duration
+	apiKey            string
+	httpClient        *http.Client
 
 	// Custom headers to include in all requests
 	headers map[string]string
+	// headerErr records a credentials header passed to WithDefaultHeaders
+	headerErr error
 
 	// Feature flags cache
 	featureFlags map[string]interface{}
 
 	// Session configuration
+	// timeout bounds each request whose context has no deadline
 	timeout     time.Duration
 	retryConfig *RetryConfig
 
@@ -51,9 +62,15 @@ type PlatoClient struct {
 	ProxyTunnel  *services.ProxyTunnelService
 }
 
+// DefaultRequestTimeout is how long a request may take, retries and reading
+// the response included, when its context has no deadline
+const DefaultRequestTimeout = 30 * time.Second
+
 // RetryConfig configures retry behavior for failed requests
 type RetryConfig struct {
+	// MaxRetries is how many times a request is retried after the first attempt
 	MaxRetries int
+	// RetryDelay is the base of the exponential backoff between attempts
 	RetryDelay time.Duration
 }
 
@@ -65,15 +82,18 @@ func NewClient(apiKey string, opts ...ClientOption) *PlatoClient {
 	// }
 
 	client := &PlatoClient{
-		baseURL:      "https://plato.so/api",
-		hubBaseURL:   "https://plato.so/api", // Default hub to same as base
-		apiKey:       apiKey,
-		headers:      make(map[string]string),
-		featureFlags: make(map[string]interface{}),
-		timeout:      30 * time.Second,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:           "https://plato.so/api",
+		hubBaseURL:        "https://plato.so/api", // Default hub to same as base
+		hubClone:          services.DefaultCloneOptions(),
+		sseReconnects:     services.DefaultStreamReconnects,
+		sseReconnectDelay: services.DefaultStreamReconnectDelay,
+		apiKey:            apiKey,
+		headers:           make(map[string]string),
+		featureFlags:      make(map[string]interface{}),
+		timeout:           DefaultRequestTimeout,
+		// Requests are bounded by their context instead of a client-wide
+		// timeout, which would also cut event streams off
+		httpClient: &http.Client{},
 		retryConfig: &RetryConfig{
 			MaxRetries: 3,
 			RetryDelay: time.Second,
@@ -86,11 +106,14 @@ func NewClient(apiKey string, opts ...ClientOption) *PlatoClient {
 	}
 
 	// Initialize services
-	client.Sandbox = services.NewSandboxService(client)
+	client.Sandbox = services.NewSandboxServiceWithIdleTimeout(client, client.sseIdle)
+	client.Sandbox.SetStreamReconnects(client.sseReconnects, client.sseReconnectDelay)
 	client.Organization = services.NewOrganizationService(client)
-	client.Simulator = services.NewSimulatorService(client)
+	simulators := services.NewSimulatorCache()
+	client.Simulator = services.NewSimulatorServiceWithCache(client, simulators)
 	client.Environment = services.NewEnvironmentService(client)
-	client.Gitea = services.NewGiteaService(client)
+	client.Gitea = services.NewGiteaServiceWithCloneOptions(client, client.hubGit, client.hubClone)
+	client.Gitea.SetSimulatorCache(simulators)
 	client.ProxyTunnel = services.NewProxyTunnelService(client)
 
 	return client
@@ -110,11 +133,60 @@ func WithHubBaseURL(url string) ClientOption {
 	}
 }
 
-// WithTimeout sets the HTTP client timeout
+// WithHubGitTransport sets how git clones from and pushes to hub repositories.
+// The default is services.GitTransportHTTPS.
+func WithHubGitTransport(transport services.GitTransport) ClientOption {
+	return func(c *PlatoClient) {
+		c.hubGit = transport
+	}
+}
+
+// WithHubCloneOptions sets how much of a hub repository git clones for hub
+// operations. The default is services.DefaultCloneOptions (a depth-1 clone).
+func WithHubCloneOptions(clone services.CloneOptions) ClientOption {
+	return func(c *PlatoClient) {
+		c.hubClone = clone
+	}
+}
+
+// WithSSEIdleTimeout sets how long operation monitors wait without receiving
+// an event or keepalive before treating the stream as stalled and returning a
+// *services.StreamError. The default is services.DefaultSSEIdleTimeout.
+func WithSSEIdleTimeout(idle time.Duration) ClientOption {
+	return func(c *PlatoClient) {
+		c.sseIdle = idle
+	}
+}
+
+// WithStreamReconnects sets how many times operation monitors re-open an
+// event stream that ends before the operation finishes, and how long they
+// wait before each attempt. The default is services.DefaultStreamReconnects
+// attempts services.DefaultStreamReconnectDelay apart; 0 disables it.
+func WithStreamReconnects(max int, delay time.Duration) ClientOption {
+	return func(c *PlatoClient) {
+		c.sseReconnects = max
+		c.sseReconnectDelay = delay
+	}
+}
+
+// WithTimeout sets how long a request may take when its context has no
+// deadline, instead of DefaultRequestTimeout. Event streams marked with
+// services.WithStreaming are not bound by it. 0 disables the default.
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(c *PlatoClient) {
 		c.timeout = timeout
-		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetry makes each retryable request up to maxAttempts times in total,
+// waiting about baseDelay before the first retry and doubling it each time.
+// A maxAttempts of 1 disables retries.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *PlatoClient) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		c.retryConfig = &RetryConfig{MaxRetries: maxAttempts - 1, RetryDelay: baseDelay}
 	}
 }
 
@@ -132,22 +204,96 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
-// WithHeader adds a custom header that will be included in all requests
+// WithHeader adds a custom header that will be included in all requests.
+// See WithDefaultHeaders for how it combines with other headers.
 func WithHeader(key, value string) ClientOption {
-	return func(c *PlatoClient) {
-		c.headers[key] = value
-	}
+	return WithDefaultHeaders(map[string]string{key: value})
 }
 
-// WithHeaders adds multiple custom headers that will be included in all requests
+// WithHeaders adds multiple custom headers that will be included in all requests.
+// It is the same as WithDefaultHeaders.
 func WithHeaders(headers map[string]string) ClientOption {
+	return WithDefaultHeaders(headers)
+}
+
+// WithDefaultHeaders adds headers to every request made with NewRequest and
+// NewHubRequest, such as a tenant routing header or a trace ID.
+//
+// Headers are applied in this order, later ones winning: the client's own
+// Content-Type and Accept, then these defaults, then headers attached to the
+// request's context with WithRequestHeaders. X-API-Key is always set by the
+// client from its API key. X-API-Key and Authorization are reserved for
+// credentials: a custom header with either name is not sent, and NewRequest
+// and NewHubRequest return ErrProtectedHeader instead.
+func WithDefaultHeaders(headers map[string]string) ClientOption {
 	return func(c *PlatoClient) {
 		for k, v := range headers {
+			if protectedHeaders[http.CanonicalHeaderKey(k)] {
+				c.headerErr = fmt.Errorf("%w: WithDefaultHeaders sets %s", ErrProtectedHeader, http.CanonicalHeaderKey(k))
+				continue
+			}
 			c.headers[k] = v
 		}
 	}
 }
 
+// requestHeadersKey is the context key for WithRequestHeaders
+type requestHeadersKey struct{}
+
+// WithRequestHeaders returns a context whose requests carry headers on top of
+// the client's defaults, e.g. an X-Request-ID for one call. Headers already
+// attached to ctx are kept unless headers replaces them. See
+// WithDefaultHeaders for the precedence.
+func WithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	merged := make(map[string]string)
+	if existing, ok := ctx.Value(requestHeadersKey{}).(map[string]string); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range headers {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, requestHeadersKey{}, merged)
+}
+
+// protectedHeaders carry the client's credentials and are never taken from
+// custom headers
+var protectedHeaders = map[string]bool{
+	"X-Api-Key":     true,
+	"Authorization": true,
+}
+
+// setHeaders sets the credentials, the default headers and any headers from
+// the request's context on req, in the order WithDefaultHeaders documents. It
+// returns ErrProtectedHeader when a custom header names a credentials header.
+func (c *PlatoClient) setHeaders(req *http.Request) error {
+	if c.headerErr != nil {
+		return c.headerErr
+	}
+
+	// Set default headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	// Set custom headers
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if perRequest, ok := req.Context().Value(requestHeadersKey{}).(map[string]string); ok {
+		for key, value := range perRequest {
+			if protectedHeaders[http.CanonicalHeaderKey(key)] {
+				return fmt.Errorf("%w: WithRequestHeaders sets %s", ErrProtectedHeader, http.CanonicalHeaderKey(key))
+			}
+			req.Header.Set(key, value)
+		}
+	}
+
+	// Set auth header
+	req.Header.Set("X-API-Key", c.apiKey)
+	return nil
+}
+
 // WithFeatureFlag sets a feature flag value
 func WithFeatureFlag(key string, value interface{}) ClientOption {
 	return func(c *PlatoClient) {
@@ -204,18 +350,9 @@ func (c *PlatoClient) NewRequest(ctx context.Context, method, path string, body
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set auth header
-	req.Header.Set("X-API-Key", c.apiKey)
-
-	// Set default headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	// Set custom headers
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+	if err := c.setHeaders(req); err != nil {
+		return nil, err
 	}
-
 	return req, nil
 }
 
@@ -228,22 +365,12 @@ func (c *PlatoClient) NewHubRequest(ctx context.Context, method, path string, bo
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set auth header
-	req.Header.Set("X-API-Key", c.apiKey)
-
-	// Set default headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	// Set custom headers
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+	if err := c.setHeaders(req); err != nil {
+		return nil, err
 	}
-
 	return req, nil
 }
 
-// Do executes an HTTP request with retry logic
 // logAPICall logs API calls to plato_error.log
 func logAPICall(method, path string, statusCode int, err error) {
 	f, fileErr := os.OpenFile("plato_error.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -264,32 +391,163 @@ func logAPICall(method, path string, statusCode int, err error) {
 	_, _ = f.WriteString(logMsg) // Ignore write errors for logging
 }
 
+// maxRetryDelay caps the backoff between retries
+const maxRetryDelay = 30 * time.Second
+
+// Do executes an HTTP request, retrying transient failures (network errors
+// and 429, 502, 503 and 504 responses) with exponential backoff and jitter.
+// Only idempotent methods and requests whose context is marked with
+// services.WithRetrySafe are retried. Retries stop when the request context
+// ends or its deadline would pass before the next attempt. When every attempt
+// fails, the error is a *RetryError carrying the number of attempts.
+//
+// A request whose context has no deadline gets the client's default timeout
+// (see WithTimeout), which also covers reading the response body, unless it
+// is marked with services.WithStreaming.
 func (c *PlatoClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 || services.IsStreaming(ctx) {
+		return c.do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The timeout stays in force until the caller is done with the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// do is Do without the default timeout
+func (c *PlatoClient) do(req *http.Request) (*http.Response, error) {
+	maxAttempts := 1
+	if c.retryConfig != nil && isRetryable(req) {
+		maxAttempts += c.retryConfig.MaxRetries
+	}
+	ctx := req.Context()
+
 	var resp *http.Response
 	var err error
+	attempt := 0
+	for {
+		attempt++
+		if attempt > 1 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, &RetryError{Attempts: attempt - 1, Err: bodyErr}
+			}
+			req.Body = body
+		}
 
-	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
 		resp, err = c.httpClient.Do(req)
-
-		// Success or non-retryable error
-		if err == nil && resp.StatusCode < 500 {
-			// Log the API call
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
 			logAPICall(req.Method, req.URL.Path, resp.StatusCode, nil)
 			return resp, nil
 		}
+		if err != nil && ctx.Err() != nil {
+			// Cancelled or timed out by the caller, not a transient failure
+			break
+		}
+		if attempt >= maxAttempts {
+			break
+		}
 
-		// Don't retry on last attempt
-		if attempt < c.retryConfig.MaxRetries {
-			time.Sleep(c.retryConfig.RetryDelay * time.Duration(attempt+1))
+		delay := c.retryDelay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+		if err == nil {
+			// Drain so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logAPICall(req.Method, req.URL.Path, statusOf(resp, err), fmt.Errorf("attempt %d failed, retrying in %v", attempt, delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetryError{Attempts: attempt, Err: ctx.Err()}
+		case <-timer.C:
 		}
 	}
 
-	// Log failed API call
 	if err != nil {
 		logAPICall(req.Method, req.URL.Path, 0, err)
-	} else if resp != nil {
-		logAPICall(req.Method, req.URL.Path, resp.StatusCode, fmt.Errorf("request failed after retries"))
+		if attempt > 1 {
+			return nil, &RetryError{Attempts: attempt, Err: err}
+		}
+		return nil, err
 	}
 
-	return resp, err
+	logAPICall(req.Method, req.URL.Path, resp.StatusCode, fmt.Errorf("request failed after %d attempts", attempt))
+	if attempt > 1 {
+		defer resp.Body.Close()
+		return nil, &RetryError{Attempts: attempt, Err: services.ParseAPIError(resp)}
+	}
+	return resp, nil
+}
+
+// retryDelay returns the backoff before retry number attempt (starting at 1):
+// the base delay doubled per attempt, capped at maxRetryDelay, with up to
+// half of it randomised so clients retrying together spread out
+func (c *PlatoClient) retryDelay(attempt int) time.Duration {
+	delay := c.retryConfig.RetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// isRetryable reports whether req may be sent again: idempotent methods
+// always, other methods only when marked with services.WithRetrySafe
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		if !services.IsRetrySafe(req.Context()) {
+			return false
+		}
+	}
+	// A body that cannot be replayed cannot be retried
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// statusOf returns the response status for logging, or 0 for a transport error
+func statusOf(resp *http.Response, err error) int {
+	if err != nil || resp == nil {
+		return 0
+	}
+	return resp.StatusCode
 }
@@ -2,10 +2,15 @@ package plato
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"plato-sdk/services"
 )
 // i lost a dolar
 
@@ -206,6 +211,65 @@ func TestNewRequest(t *testing.T) {
 	}
 }
 
+func TestNewRequest_HeaderPrecedence(t *testing.T) {
+	client := NewClient("test-api-key",
+		WithDefaultHeaders(map[string]string{
+			"X-Tenant":   "default-tenant",
+			"X-Trace-ID": "default-trace",
+			"Accept":     "application/x-ndjson",
+		}),
+	)
+
+	ctx := WithRequestHeaders(context.Background(), map[string]string{"X-Trace-ID": "request-trace"})
+	ctx = WithRequestHeaders(ctx, map[string]string{"X-Request-ID": "req-1"})
+	req, err := client.NewHubRequest(ctx, "GET", "/test", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"X-Tenant":     "default-tenant",
+		"X-Trace-ID":   "request-trace",
+		"X-Request-ID": "req-1",
+		"Accept":       "application/x-ndjson",
+		"Content-Type": "application/json",
+	}
+	for key, value := range want {
+		if got := req.Header.Get(key); got != value {
+			t.Errorf("expected %s %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestNewRequest_AuthHeadersProtected(t *testing.T) {
+	for _, headers := range []map[string]string{{"x-api-key": "other-key"}, {"Authorization": "Bearer other"}} {
+		client := NewClient("test-api-key", WithDefaultHeaders(headers))
+		if _, err := client.NewRequest(context.Background(), "GET", "/test", nil); !errors.Is(err, ErrProtectedHeader) {
+			t.Errorf("expected ErrProtectedHeader for default headers %v, got %v", headers, err)
+		}
+		if _, err := client.NewHubRequest(context.Background(), "GET", "/test", nil); !errors.Is(err, ErrProtectedHeader) {
+			t.Errorf("expected ErrProtectedHeader from NewHubRequest for default headers %v, got %v", headers, err)
+		}
+	}
+
+	client := NewClient("test-api-key")
+	ctx := WithRequestHeaders(context.Background(), map[string]string{"X-API-Key": "request-key"})
+	if _, err := client.NewRequest(ctx, "GET", "/test", nil); !errors.Is(err, ErrProtectedHeader) {
+		t.Errorf("expected ErrProtectedHeader for a per-request X-API-Key, got %v", err)
+	}
+
+	req, err := client.NewRequest(context.Background(), "GET", "/test", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := req.Header.Values("X-API-Key"); len(got) != 1 || got[0] != "test-api-key" {
+		t.Errorf("expected X-API-Key [test-api-key], got %v", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("expected no Authorization header, got %q", got)
+	}
+}
+
 func TestDo_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -235,7 +299,7 @@ func TestDo_RetryOn5xx(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		attempts++
 		if attempts < 3 {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusServiceUnavailable)
 		} else {
 			w.WriteHeader(http.StatusOK)
 		}
@@ -292,6 +356,133 @@ func TestDo_NoRetryOn4xx(t *testing.T) {
 	}
 }
 
+func TestDo_PostRetriedOnlyWhenMarkedSafe(t *testing.T) {
+	var attempts int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetry(3, time.Millisecond))
+
+	req, _ := client.NewRequest(context.Background(), "POST", "/test", strings.NewReader(`{"a":1}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.StatusCode != http.StatusBadGateway || attempts != 1 {
+		t.Fatalf("expected an unmarked POST to be sent once, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+
+	attempts, bodies = 0, nil
+	req, _ = client.NewRequest(services.WithRetrySafe(context.Background()), "POST", "/test", strings.NewReader(`{"a":1}`))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("expected a marked POST to succeed on the second attempt, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	if bodies[1] != `{"a":1}` {
+		t.Errorf("expected the body to be resent, got %q", bodies[1])
+	}
+}
+
+func TestDo_RetriesExhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetry(3, time.Millisecond))
+
+	req, _ := client.NewRequest(context.Background(), "GET", "/test", nil)
+	_, err := client.Do(req)
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected a *RetryError, got %v", err)
+	}
+	if retryErr.Attempts != 3 || attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d (server saw %d)", retryErr.Attempts, attempts)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Message != "slow down" {
+		t.Errorf("expected the last response as an *APIError, got %v", err)
+	}
+}
+
+func TestDo_StopsRetryingWhenContextEnds(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithRetry(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := client.NewRequest(ctx, "GET", "/test", nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected the first response when the backoff outlasts the deadline, got %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+		t.Errorf("expected one attempt, got status %d after %d attempts", resp.StatusCode, attempts)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected Do to return without waiting out the backoff, took %v", time.Since(start))
+	}
+}
+
+func TestDo_DefaultTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", WithBaseURL(server.URL), WithTimeout(50*time.Millisecond), WithRetry(1, 0))
+
+	req, _ := client.NewRequest(context.Background(), "GET", "/test", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a request without a deadline to time out, got %v", err)
+	}
+
+	// Streams and callers with their own deadline are not cut off
+	deadlineCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for name, ctx := range map[string]context.Context{
+		"streaming": services.WithStreaming(context.Background()),
+		"deadline":  deadlineCtx,
+	} {
+		req, _ := client.NewRequest(ctx, "GET", "/test", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("%s: expected no default timeout, got %v", name, err)
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
 func TestWithRetryConfig(t *testing.T) {
 	customRetry := &RetryConfig{
 		MaxRetries: 5,
@@ -6,21 +6,32 @@
 // structured information about the failure for better error handling.
 package plato
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
 
-// APIError represents an error from the Plato API
-type APIError struct {
-	StatusCode int
-	Message    string
-	RequestID  string
-}
+	"plato-sdk/services"
+)
 
-func (e *APIError) Error() string {
-	if e.RequestID != "" {
-		return fmt.Sprintf("plato api error (status %d, request_id: %s): %s", e.StatusCode, e.RequestID, e.Message)
-	}
-	return fmt.Sprintf("plato api error (status %d): %s", e.StatusCode, e.Message)
-}
+// APIError represents an error status from the Plato API. It is defined in
+// services, which returns it, and matches the sentinel errors below with
+// errors.Is.
+type APIError = services.APIError
+
+// Sentinel errors an *APIError matches with errors.Is, so callers can tell
+// failures apart without looking at status codes or messages
+var (
+	ErrNotFound            = services.ErrNotFound
+	ErrSandboxNotFound     = services.ErrSandboxNotFound
+	ErrUnauthorized        = services.ErrUnauthorized
+	ErrRateLimited         = services.ErrRateLimited
+	ErrInvalidRequest      = services.ErrInvalidRequest
+	ErrEndpointUnsupported = services.ErrEndpointUnsupported
+)
+
+// ErrProtectedHeader is returned by NewRequest and NewHubRequest when a custom
+// header would replace the credentials the client sends
+var ErrProtectedHeader = errors.New("custom headers cannot set the credentials headers")
 
 // NetworkError represents a network-level error
 type NetworkError struct {
@@ -53,3 +64,19 @@ type RateLimitError struct {
 func (e *RateLimitError) Error() string {
 	return fmt.Sprintf("rate limit exceeded, retry after %d seconds", e.RetryAfter)
 }
+
+// RetryError reports a request that still failed after the client retried it.
+// Err is the last failure: a *APIError for an error status, or the transport
+// error.
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("request failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
@@ -5,11 +5,51 @@
 // monitoring Plato environments and their associated jobs.
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Environment represents a Plato environment
 type Environment struct {
-	JobID string
-	EnvID string
-	Alias string
+	JobID  string `json:"job_id"`
+	EnvID  string `json:"env_id"`
+	Alias  string `json:"alias,omitempty"`
+	Status string `json:"status,omitempty"`
+	// PublicURL is computed from the client's base URL rather than returned by the API
+	PublicURL string `json:"public_url,omitempty"`
+}
+
+// ComputePublicURL returns the URL a running environment or sandbox is served on.
+// identifier is its alias, job group ID or public ID; baseURL is the API base URL,
+// which determines the sims domain (local, a plato.so subdomain, or production).
+// An empty string is returned for base URLs with no known sims domain.
+func ComputePublicURL(baseURL, identifier string) string {
+	if strings.Contains(baseURL, "localhost:8080") {
+		return fmt.Sprintf("http://%s.sims.localhost:8080", identifier)
+	} else if strings.Contains(baseURL, "plato.so") {
+		// Parse subdomain from base URL
+		parts := strings.Split(baseURL, ".")
+		if len(parts) >= 3 {
+			// Extract subdomain (e.g., "dev", "staging")
+			subdomain := strings.TrimPrefix(parts[0], "https://")
+			subdomain = strings.TrimPrefix(subdomain, "http://")
+			if subdomain != "plato" {
+				return fmt.Sprintf("https://%s.%s.sims.plato.so", identifier, subdomain)
+			}
+		}
+		return fmt.Sprintf("https://%s.sims.plato.so", identifier)
+	}
+
+	return ""
+}
+
+// URLIdentifier returns the identifier used in the environment's public URL
+func (e *Environment) URLIdentifier() string {
+	if e.Alias != "" {
+		return e.Alias
+	}
+	return e.JobID
 }
 
 // JobStatus represents the status of a job
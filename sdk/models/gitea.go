@@ -31,4 +31,6 @@ type GiteaRepository struct {
 	Description string `json:"description"`
 	Private     bool   `json:"private"`
 	HasRepo     bool   `json:"has_repo"`
+	// DefaultBranch is the branch the hub reports as default, if it does
+	DefaultBranch string `json:"default_branch,omitempty"`
 }
@@ -16,11 +16,24 @@ type SimulatorListItem struct {
 	InternalAppPort *int    `json:"internal_app_port"`
 	VersionTag      string  `json:"version_tag"`
 	ImageURI        *string `json:"image_uri"`
+	// Compute is the simulator's recommended VM resources, when the server provides them
+	Compute *SimConfigCompute `json:"compute,omitempty"`
 }
 
 type SimulatorVersion struct {
-	ArtifactID string `json:"artifact_id"`
-	Version    string `json:"version"`
-	Dataset    string `json:"dataset"`
-	CreatedAt  string `json:"created_at"`
+	ArtifactID string   `json:"artifact_id"`
+	Version    string   `json:"version"`
+	Dataset    string   `json:"dataset"`
+	CreatedAt  string   `json:"created_at"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// HasTag reports whether the version's artifact was tagged with tag
+func (v *SimulatorVersion) HasTag(tag string) bool {
+	for _, t := range v.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
 }
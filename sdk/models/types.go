@@ -2,6 +2,11 @@
 // Generated from OpenAPI schema: sdk/openapi/plato.yaml
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 // SimConfigCompute defines compute resource configuration
 type SimConfigCompute struct {
 	Cpus               int32 `json:"cpus" yaml:"cpus"`
@@ -35,6 +40,8 @@ type SimConfigService struct {
 	File                      string   `json:"file,omitempty" yaml:"file,omitempty"`
 	RequiredHealthyContainers []string `json:"required_healthy_containers,omitempty" yaml:"required_healthy_containers,omitempty"`
 	HealthyWaitTimeout        int32    `json:"healthy_wait_timeout,omitempty" yaml:"healthy_wait_timeout,omitempty"`
+	// DependsOn names services that must be started and healthy first
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
 }
 
 // SimConfigListener defines a listener configuration (DB, File, or Proxy)
@@ -48,6 +55,11 @@ type SimConfigListener struct {
 	DbUser     string `json:"db_user,omitempty" yaml:"db_user,omitempty"`
 	DbPassword string `json:"db_password,omitempty" yaml:"db_password,omitempty"`
 	DbDatabase string `json:"db_database,omitempty" yaml:"db_database,omitempty"`
+	// DbPasswordEnv names an environment variable holding the password for
+	// pre-snapshot cleanup; it wins over DbPassword when set
+	DbPasswordEnv string `json:"db_password_env,omitempty" yaml:"db_password_env,omitempty"`
+	// CleanupTables lists tables emptied before a snapshot along with audit_log
+	CleanupTables []string `json:"cleanup_tables,omitempty" yaml:"cleanup_tables,omitempty"`
 
 	// File listener fields
 	TargetDir      string   `json:"target_dir,omitempty" yaml:"target_dir,omitempty"`
@@ -67,12 +79,103 @@ type SimConfigDataset struct {
 	Metadata  SimConfigMetadata            `json:"metadata" yaml:"metadata"`
 	Services  map[string]SimConfigService  `json:"services" yaml:"services,omitempty"`
 	Listeners map[string]SimConfigListener `json:"listeners" yaml:"listeners,omitempty"`
+
+	// ArtifactID pins the snapshot this dataset is launched from, and Version
+	// pins it by its published version instead. They only steer the launch,
+	// so they are not sent to the API with the dataset's config.
+	ArtifactID string `json:"-" yaml:"artifact_id,omitempty"`
+	Version    string `json:"-" yaml:"version,omitempty"`
 }
 
+// DefaultDatasetName is used when plato-config.yml does not set default_dataset
+const DefaultDatasetName = "base"
+
+// PlatoConfigVersion is the current plato-config.yml schema version. Files
+// without a version field predate versioning and count as version 0.
+//
+// Version 1 is the schema of this PlatoConfig: service, default_dataset,
+// datasets, aws, tunnel, remote, ecr, ssh, hub and timeouts.
+const PlatoConfigVersion = 1
+
 // PlatoConfig is the root plato-config.yml structure
 type PlatoConfig struct {
-	Service  string                      `json:"service,omitempty" yaml:"service,omitempty"`
-	Datasets map[string]SimConfigDataset `json:"datasets,omitempty" yaml:"datasets,omitempty"`
+	// Version is the schema version the file was written for; see PlatoConfigVersion
+	Version        int                         `json:"version,omitempty" yaml:"version,omitempty"`
+	Service        string                      `json:"service,omitempty" yaml:"service,omitempty"`
+	DefaultDataset string                      `json:"default_dataset,omitempty" yaml:"default_dataset,omitempty"`
+	Datasets       map[string]SimConfigDataset `json:"datasets,omitempty" yaml:"datasets,omitempty"`
+	AWS            *AWSConfig                  `json:"aws,omitempty" yaml:"aws,omitempty"`
+	Tunnel         *TunnelConfig               `json:"tunnel,omitempty" yaml:"tunnel,omitempty"`
+	Remote         *RemoteConfig               `json:"remote,omitempty" yaml:"remote,omitempty"`
+	ECR            *ECRConfig                  `json:"ecr,omitempty" yaml:"ecr,omitempty"`
+	SSH            *SSHConfig                  `json:"ssh,omitempty" yaml:"ssh,omitempty"`
+	Hub            *HubConfig                  `json:"hub,omitempty" yaml:"hub,omitempty"`
+	Timeouts       *TimeoutsConfig             `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
+}
+
+// GetDefaultDataset returns default_dataset, falling back to DefaultDatasetName
+func (c *PlatoConfig) GetDefaultDataset() string {
+	if c == nil || c.DefaultDataset == "" {
+		return DefaultDatasetName
+	}
+	return c.DefaultDataset
+}
+
+// SSHConfig tunes the connection settings written to generated SSH configs.
+// Zero values fall back to the defaults (10s connect timeout, 30s keepalive, 3 misses).
+// StrictHostKey pins each VM's host key on first connection and verifies it
+// afterwards instead of skipping host key checks.
+type SSHConfig struct {
+	ConnectTimeout      int  `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`
+	ServerAliveInterval int  `json:"server_alive_interval,omitempty" yaml:"server_alive_interval,omitempty"`
+	ServerAliveCountMax int  `json:"server_alive_count_max,omitempty" yaml:"server_alive_count_max,omitempty"`
+	StrictHostKey       bool `json:"strict_host_key,omitempty" yaml:"strict_host_key,omitempty"`
+}
+
+// TimeoutsConfig overrides how long launches wait for the VM. Values are Go
+// durations such as "45m"; empty keeps the 20 minute default. StreamIdle is
+// how long an event stream may stay silent, keepalives included, before it is
+// treated as stalled and re-attached (default 90s).
+type TimeoutsConfig struct {
+	Provision  string `json:"provision,omitempty" yaml:"provision,omitempty"`
+	Setup      string `json:"setup,omitempty" yaml:"setup,omitempty"`
+	StreamIdle string `json:"stream_idle,omitempty" yaml:"stream_idle,omitempty"`
+}
+
+// HubConfig tunes the git operations against the hub repository.
+// CloneDepth is the number of commits cloned (default 1, 0 clones the full
+// history); PartialClone adds --filter=blob:none when git supports it.
+type HubConfig struct {
+	CloneDepth   *int `json:"clone_depth,omitempty" yaml:"clone_depth,omitempty"`
+	PartialClone bool `json:"partial_clone,omitempty" yaml:"partial_clone,omitempty"`
+	// SnapshotBranch is the branch snapshots merge pushed work into; empty
+	// means the repository's default branch
+	SnapshotBranch string `json:"snapshot_branch,omitempty" yaml:"snapshot_branch,omitempty"`
+}
+
+// ECRConfig controls automatic Docker authentication with ECR on the VM
+type ECRConfig struct {
+	// Enabled turns automatic authentication on or off; unset means auto-detect
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// RemoteConfig describes the VM image layout used when starting services over SSH
+type RemoteConfig struct {
+	WorktreePath string `json:"worktree_path,omitempty" yaml:"worktree_path,omitempty"`
+	DockerHost   string `json:"docker_host,omitempty" yaml:"docker_host,omitempty"`
+}
+
+// TunnelConfig defines how local proxytunnel ports are exposed
+type TunnelConfig struct {
+	BindAddress string `json:"bind_address,omitempty" yaml:"bind_address,omitempty"`
+	StrictPorts bool   `json:"strict_ports,omitempty" yaml:"strict_ports,omitempty"`
+}
+
+// AWSConfig defines the AWS settings used to authenticate Docker with ECR
+type AWSConfig struct {
+	Region   string `json:"region,omitempty" yaml:"region,omitempty"`
+	Profile  string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Registry string `json:"registry,omitempty" yaml:"registry,omitempty"`
 }
 
 // Sandbox represents a VM sandbox
@@ -83,10 +186,58 @@ type Sandbox struct {
 	Url           string `json:"url,omitempty" yaml:"url,omitempty"`
 	Status        string `json:"status,omitempty" yaml:"status,omitempty"`
 	CorrelationId string `json:"correlation_id,omitempty" yaml:"correlation_id,omitempty"`
+	Alias         string `json:"alias,omitempty" yaml:"alias,omitempty"`
+}
+
+// DisplayName returns the sandbox alias, falling back to the public ID
+func (s *Sandbox) DisplayName() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.PublicId
 }
 
 // Environment and SimulatorListItem are defined in environment.go and simulator.go
 
+// Validate checks the fields the server requires to create a sandbox, so
+// incomplete configs fail locally with a readable message instead of a 422
+func (d *SimConfigDataset) Validate() error {
+	if d == nil {
+		return fmt.Errorf("sandbox config is required")
+	}
+	var problems []string
+	if d.Compute.Cpus <= 0 {
+		problems = append(problems, "compute.cpus must be greater than 0")
+	}
+	if d.Compute.Memory <= 0 {
+		problems = append(problems, "compute.memory must be greater than 0")
+	}
+	if d.Compute.Disk <= 0 {
+		problems = append(problems, "compute.disk must be greater than 0")
+	}
+	if strings.TrimSpace(d.Metadata.Name) == "" {
+		problems = append(problems, "metadata.name is required")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid sandbox config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CreateSandboxOptions describes a sandbox to create. Only Config and Dataset
+// are required; optional fields left at their zero value are omitted so the
+// server applies its defaults.
+type CreateSandboxOptions struct {
+	Config  *SimConfigDataset
+	Dataset string
+	Alias   string
+	Service string
+	// ArtifactID launches from a snapshot instead of a blank VM
+	ArtifactID *string
+	// Timeout is the sandbox lifetime in seconds
+	Timeout *int
+}
+
 // CreateSnapshotRequest is a request to create a VM snapshot
 type CreateSnapshotRequest struct {
 	Service         string `json:"service,omitempty"`
@@ -96,9 +247,13 @@ type CreateSnapshotRequest struct {
 	Flows           string `json:"flows,omitempty"`
 	InternalAppPort *int32 `json:"internal_app_port,omitempty"`
 	MessagingPort   *int32 `json:"messaging_port,omitempty"`
+	// Tags label the resulting artifact, e.g. "stable" or a branch name
+	Tags []string `json:"tags,omitempty"`
 }
 
-// CreateSnapshotResponse is the response from creating a snapshot
+// CreateSnapshotResponse is the response from creating a snapshot or
+// checkpoint. The SDK, CLI and C bindings all share this one definition; the
+// bindings pass it on re-encoded with the same JSON keys.
 type CreateSnapshotResponse struct {
 	ArtifactId    string `json:"artifact_id"`
 	Status        string `json:"status"`
@@ -108,6 +263,39 @@ type CreateSnapshotResponse struct {
 	GitHash       string `json:"git_hash,omitempty"`
 }
 
+// Snapshot artifact states reported by the snapshot status endpoint
+const (
+	SnapshotStatusAvailable = "available"
+	SnapshotStatusFailed    = "failed"
+)
+
+// SnapshotStatus is the processing state of a snapshot artifact
+type SnapshotStatus struct {
+	ArtifactId string `json:"artifact_id"`
+	Status     string `json:"status"`
+	S3Uri      string `json:"s3_uri,omitempty"`
+	Error      string `json:"error,omitempty"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+// HeartbeatBatchRequest keeps several VMs alive with a single request
+type HeartbeatBatchRequest struct {
+	JobGroupIDs []string `json:"job_group_ids"`
+}
+
+// ExtendTimeoutRequest asks for more lifetime for a VM
+type ExtendTimeoutRequest struct {
+	AdditionalSeconds int `json:"additional_seconds"`
+}
+
+// ExtendTimeoutResponse reports a VM's lifetime after an extension
+type ExtendTimeoutResponse struct {
+	PublicId  string `json:"public_id,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	// Timeout is the VM's total lifetime in seconds
+	Timeout int `json:"timeout,omitempty"`
+}
+
 // StartWorkerRequest is a request to start the Plato worker
 type StartWorkerRequest struct {
 	Service            string            `json:"service,omitempty"`
@@ -123,6 +311,26 @@ type StartWorkerResponse struct {
 	CorrelationId string `json:"correlation_id"`
 }
 
+// WorkerLogs is the recent output of the Plato worker on a VM
+type WorkerLogs struct {
+	Logs      string `json:"logs"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// Tail returns the last n non-empty lines of the logs
+func (w *WorkerLogs) Tail(n int) []string {
+	var lines []string
+	for _, line := range strings.Split(w.Logs, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
 // SSHInfo contains SSH connection information for a sandbox
 type SSHInfo struct {
 	SSHCommand     string `json:"ssh_command"`
@@ -141,4 +349,10 @@ type DBConfig struct {
 	Password  string   `json:"password"`
 	DestPort  int      `json:"dest_port"`
 	Databases []string `json:"databases"`
+	// CleanupTables lists tables emptied along with audit_log
+	CleanupTables []string `json:"cleanup_tables,omitempty"`
+	CleanupSQL    []string `json:"cleanup_sql,omitempty"`
+	// PasswordEnv names an environment variable that holds the password; it
+	// is read at cleanup time and wins over Password
+	PasswordEnv string `json:"password_env,omitempty"`
 }
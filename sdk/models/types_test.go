@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCreateSnapshotResponseDecode(t *testing.T) {
+	body := `{
+		"artifact_id": "art_123",
+		"status": "pending",
+		"timestamp": "2030-01-01T00:00:00Z",
+		"correlation_id": "corr_456",
+		"s3_uri": "s3://bucket/art_123",
+		"git_hash": "abc123"
+	}`
+	var resp CreateSnapshotResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := CreateSnapshotResponse{
+		ArtifactId:    "art_123",
+		Status:        "pending",
+		Timestamp:     "2030-01-01T00:00:00Z",
+		CorrelationId: "corr_456",
+		S3Uri:         "s3://bucket/art_123",
+		GitHash:       "abc123",
+	}
+	if resp != want {
+		t.Errorf("decoded %+v, want %+v", resp, want)
+	}
+
+	// The C bindings hand the re-encoded response to Python, which reads the same keys
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var keys map[string]any
+	json.Unmarshal(encoded, &keys)
+	for _, key := range []string{"artifact_id", "status", "correlation_id", "s3_uri", "git_hash"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("encoded response is missing %q: %s", key, encoded)
+		}
+	}
+}
+
+// snakeCase matches the JSON keys the Plato API uses
+var snakeCase = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+func TestAPIModelsUseSnakeCaseJSONTags(t *testing.T) {
+	for _, model := range []any{
+		Sandbox{},
+		CreateSnapshotRequest{},
+		CreateSnapshotResponse{},
+		SnapshotStatus{},
+		StartWorkerRequest{},
+		StartWorkerResponse{},
+		WorkerLogs{},
+		ExtendTimeoutRequest{},
+		ExtendTimeoutResponse{},
+		HeartbeatBatchRequest{},
+		SimulatorVersion{},
+		GiteaRepository{},
+	} {
+		typ := reflect.TypeOf(model)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag, ok := field.Tag.Lookup("json")
+			name, _, _ := strings.Cut(tag, ",")
+			if !ok || !snakeCase.MatchString(name) {
+				t.Errorf("%s.%s has json tag %q, want a snake_case key", typ.Name(), field.Name, tag)
+			}
+		}
+	}
+}
+
+func TestPlatoConfigGetDefaultDataset(t *testing.T) {
+	var missing *PlatoConfig
+	for config, want := range map[*PlatoConfig]string{
+		missing:                    DefaultDatasetName,
+		{}:                         DefaultDatasetName,
+		{DefaultDataset: "seeded"}: "seeded",
+	} {
+		if got := config.GetDefaultDataset(); got != want {
+			t.Errorf("GetDefaultDataset() of %+v = %q, want %q", config, got, want)
+		}
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strings"
+	"time"
 
 	"plato-sdk/models"
 )
@@ -128,7 +131,7 @@ func (s *EnvironmentService) Make(ctx context.Context, envID string, opts *MakeO
 	fmt.Printf("Make response (status %d): %s\n", resp.StatusCode, string(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, apiErrorFromBody(resp, bodyBytes)
 	}
 
 	var makeResp struct {
@@ -148,10 +151,43 @@ func (s *EnvironmentService) Make(ctx context.Context, envID string, opts *MakeO
 	if makeResp.Alias != nil {
 		env.Alias = *makeResp.Alias
 	}
+	env.PublicURL = models.ComputePublicURL(s.client.GetBaseURL(), env.URLIdentifier())
 
 	return env, nil
 }
 
+// List returns the caller's active environments. The endpoint is not in
+// sdk/openapi/openapi.json yet; an API without it returns an error matching
+// ErrEndpointUnsupported.
+func (s *EnvironmentService) List(ctx context.Context) ([]*models.Environment, error) {
+	req, err := s.client.NewRequest(ctx, "GET", "/env/list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, markUnsupported(ParseAPIError(resp))
+	}
+
+	var envs []*models.Environment
+	if err := json.NewDecoder(resp.Body).Decode(&envs); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	baseURL := s.client.GetBaseURL()
+	for _, env := range envs {
+		env.PublicURL = models.ComputePublicURL(baseURL, env.URLIdentifier())
+	}
+
+	return envs, nil
+}
+
 // GetWorkerReady checks if the worker for a job is ready
 func (s *EnvironmentService) GetWorkerReady(ctx context.Context, jobID string) (*models.WorkerStatus, error) {
 	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/env/%s/worker_ready", jobID), nil)
@@ -174,7 +210,7 @@ func (s *EnvironmentService) GetWorkerReady(ctx context.Context, jobID string) (
 	fmt.Printf("GetWorkerReady response (status %d): %s\n", resp.StatusCode, string(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, apiErrorFromBody(resp, bodyBytes)
 	}
 
 	var status models.WorkerStatus
@@ -216,7 +252,7 @@ func (s *EnvironmentService) Reset(ctx context.Context, jobID string) (*models.R
 	fmt.Printf("Reset response (status %d): %s\n", resp.StatusCode, string(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, apiErrorFromBody(resp, bodyBytes)
 	}
 
 	var resetResp models.ResetResponse
@@ -231,6 +267,12 @@ func (s *EnvironmentService) Reset(ctx context.Context, jobID string) (*models.R
 
 // GetState retrieves the current state of an environment
 func (s *EnvironmentService) GetState(ctx context.Context, jobID string, mergeMutations bool) (map[string]interface{}, error) {
+	return s.GetStateWithProgress(ctx, jobID, mergeMutations, nil)
+}
+
+// GetStateWithProgress is GetState with a callback invoked with the running total
+// of response bytes read. Cancel ctx to abort a slow or oversized download.
+func (s *EnvironmentService) GetStateWithProgress(ctx context.Context, jobID string, mergeMutations bool, progress func(bytesRead int64)) (map[string]interface{}, error) {
 	params := fmt.Sprintf("?merge_mutations=%t", mergeMutations)
 	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/env/%s/state%s", jobID, params), nil)
 	if err != nil {
@@ -244,8 +286,7 @@ func (s *EnvironmentService) GetState(ctx context.Context, jobID string, mergeMu
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
 	var result struct {
@@ -254,13 +295,192 @@ func (s *EnvironmentService) GetState(ctx context.Context, jobID string, mergeMu
 		} `json:"data"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{r: resp.Body, progress: progress}
+	}
+
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return result.Data.State, nil
 }
 
+// StateEvent is one state update received by StreamState
+type StateEvent struct {
+	// Time is when the server produced the update, or when it was received
+	// if the server did not say
+	Time time.Time
+	// State is the decoded state (or state diff) exactly as sent
+	State map[string]interface{}
+	// Err is set on the last event when the stream broke before ctx ended
+	Err error
+}
+
+// statePollInterval is how often StreamState polls an API that does not
+// stream state
+var statePollInterval = 2 * time.Second
+
+// StreamState tails an environment's state over SSE, sending each update on
+// the returned channel until ctx is cancelled or the server ends the stream.
+// The channel is closed when streaming stops; if the stream broke for any
+// other reason than ctx ending, the last event carries the error.
+//
+// Streaming is not in sdk/openapi/openapi.json yet. An API that ignores
+// stream=true answers with the plain state instead, and StreamState then
+// polls GetState, sending the state whenever it changes.
+func (s *EnvironmentService) StreamState(ctx context.Context, jobID string) (<-chan StateEvent, error) {
+	req, err := s.client.NewRequest(WithStreaming(ctx), "GET", fmt.Sprintf("/env/%s/state?stream=true", jobID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SSE request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("SSE request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := ParseAPIError(resp)
+		resp.Body.Close()
+		return nil, fmt.Errorf("SSE connection failed: %w", apiErr)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		defer resp.Body.Close()
+		var result struct {
+			Data struct {
+				State map[string]interface{} `json:"state"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to decode state: %w", err)
+		}
+		return s.pollState(ctx, jobID, result.Data.State), nil
+	}
+
+	body, err := sseBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	events := make(chan StateEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		send := func(event StateEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		scanner := newSSEScanner(body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if isSSEComment(line) || !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			event, ok := parseStateEvent(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			if !ok {
+				continue // Skip malformed JSON and informational events
+			}
+			if !send(event) {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = fmt.Errorf("state stream ended")
+		}
+		send(StateEvent{Time: time.Now(), Err: &StreamError{Err: err}})
+	}()
+	return events, nil
+}
+
+// pollState is StreamState for an API without state streaming: it sends
+// initial, then polls GetState and sends the state each time it changes
+func (s *EnvironmentService) pollState(ctx context.Context, jobID string, initial map[string]interface{}) <-chan StateEvent {
+	events := make(chan StateEvent)
+	go func() {
+		defer close(events)
+
+		send := func(event StateEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		last := initial
+		if !send(StateEvent{Time: time.Now(), State: last}) {
+			return
+		}
+		ticker := time.NewTicker(statePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			state, err := s.GetState(ctx, jobID, false)
+			if err != nil {
+				if ctx.Err() == nil {
+					send(StateEvent{Time: time.Now(), Err: &StreamError{Err: err}})
+				}
+				return
+			}
+			if reflect.DeepEqual(state, last) {
+				continue
+			}
+			last = state
+			if !send(StateEvent{Time: time.Now(), State: state}) {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// parseStateEvent decodes one SSE data payload. The state may be sent bare or
+// wrapped like the GetState response as {"data": {"state": ...}}.
+func parseStateEvent(data string) (StateEvent, bool) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil || payload == nil {
+		return StateEvent{}, false
+	}
+	if t, _ := payload["type"].(string); t == "connected" {
+		return StateEvent{}, false
+	}
+
+	event := StateEvent{Time: time.Now(), State: payload}
+	if ts, ok := payload["timestamp"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			event.Time = parsed
+		}
+	}
+	if inner, ok := payload["data"].(map[string]interface{}); ok {
+		payload = inner
+	}
+	if state, ok := payload["state"].(map[string]interface{}); ok {
+		event.State = state
+	}
+	return event, true
+}
+
 // Close closes an environment
 func (s *EnvironmentService) Close(ctx context.Context, jobID string) error {
 	req, err := s.client.NewRequest(ctx, "POST", fmt.Sprintf("/env/%s/close", jobID), nil)
@@ -275,9 +495,24 @@ func (s *EnvironmentService) Close(ctx context.Context, jobID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return ParseAPIError(resp)
 	}
 
 	return nil
 }
+
+// progressReader reports the cumulative number of bytes read through it
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	progress func(int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.total += int64(n)
+		p.progress(p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamState_EmitsUpdatesUntilCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/env/job-1/state" || r.URL.Query().Get("stream") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"connected\"}\n\n")
+		fmt.Fprint(w, "data: {\"timestamp\":\"2024-05-01T10:00:00Z\",\"data\":{\"state\":{\"users\":1}}}\n\n")
+		fmt.Fprint(w, "data: not json\n\n")
+		fmt.Fprint(w, "data: {\"orders\":2}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc := NewEnvironmentService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	events, err := svc.StreamState(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("StreamState: %v", err)
+	}
+
+	first := <-events
+	if first.Err != nil || first.State["users"] != float64(1) {
+		t.Fatalf("first event = %+v", first)
+	}
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC); !first.Time.Equal(want) {
+		t.Errorf("first event time = %v, want %v", first.Time, want)
+	}
+	second := <-events
+	if second.Err != nil || second.State["orders"] != float64(2) || second.Time.IsZero() {
+		t.Fatalf("second event = %+v", second)
+	}
+
+	cancel()
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Fatalf("expected channel to close after cancel, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestStreamState_ReportsEndedStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"state\":{\"a\":true}}\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewEnvironmentService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	events, err := svc.StreamState(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("StreamState: %v", err)
+	}
+
+	var got []StateEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 || got[0].State["a"] != true {
+		t.Fatalf("events = %+v", got)
+	}
+	var streamErr *StreamError
+	if !errors.As(got[1].Err, &streamErr) {
+		t.Errorf("last event error = %v, want *StreamError", got[1].Err)
+	}
+}
+
+func TestStreamState_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such job", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	svc := NewEnvironmentService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	if _, err := svc.StreamState(context.Background(), "missing"); err == nil {
+		t.Fatal("expected an error for a 404")
+	}
+}
+
+func TestStreamState_PollsWhenNotStreamed(t *testing.T) {
+	defer func(interval time.Duration) { statePollInterval = interval }(statePollInterval)
+	statePollInterval = 10 * time.Millisecond
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An API without streaming ignores stream=true and returns the state
+		users := 1
+		if requests.Add(1) > 2 {
+			users = 2
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"state":{"users":%d}}}`, users)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc := NewEnvironmentService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	events, err := svc.StreamState(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("StreamState: %v", err)
+	}
+
+	first := <-events
+	if first.Err != nil || first.State["users"] != float64(1) {
+		t.Fatalf("first event = %+v", first)
+	}
+	// The unchanged second poll is not sent
+	second := <-events
+	if second.Err != nil || second.State["users"] != float64(2) {
+		t.Fatalf("second event = %+v", second)
+	}
+
+	cancel()
+	for range events {
+	}
+}
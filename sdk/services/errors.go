@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors an *APIError matches with errors.Is, by status code
+var (
+	// ErrNotFound matches 404 responses
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized matches 401 and 403 responses
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited matches 429 responses
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInvalidRequest matches 400 and 422 responses
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// ErrSandboxNotFound matches a 404 from an operation on a single sandbox,
+// which usually means the VM expired or was deleted. It also matches
+// ErrNotFound.
+var ErrSandboxNotFound = fmt.Errorf("sandbox %w", ErrNotFound)
+
+// ErrEndpointUnsupported matches an *APIError from an API that does not offer
+// the endpoint at all. Endpoints the SDK uses ahead of sdk/openapi/openapi.json
+// report it so callers can fall back instead of failing.
+var ErrEndpointUnsupported = errors.New("the API does not support this endpoint")
+
+// maxErrorBody bounds how much of an error response is kept
+const maxErrorBody = 64 << 10
+
+// APIError is a response from the Plato API with an error status. Use
+// errors.Is with the sentinel errors above to tell failures apart.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code, when the API sends one
+	Code string
+	// Message is the most readable explanation found in the body, or the
+	// body itself
+	Message   string
+	RequestID string
+	// Body is the raw response body, up to 64 KiB
+	Body []byte
+	// notFound is the sentinel a 404 also matches, such as ErrSandboxNotFound
+	notFound error
+	// unsupported is set when the endpoint itself is unknown to the API
+	unsupported bool
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("API error (%d, request_id: %s): %s", e.StatusCode, e.RequestID, e.Message)
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Message)
+}
+
+// Is matches the sentinel error for e's status code
+func (e *APIError) Is(target error) bool {
+	if target == ErrEndpointUnsupported {
+		return e.unsupported
+	}
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound || (e.notFound != nil && target == e.notFound)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return target == ErrInvalidRequest
+	}
+	return false
+}
+
+// ParseAPIError reads an error response into an *APIError. It understands
+// the {"error"}, {"message"} and {"detail"} bodies the API sends, including
+// validation details, and falls back to the raw body. The caller still
+// closes resp.Body.
+func ParseAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return apiErrorFromBody(resp, body)
+}
+
+// apiErrorFromBody is ParseAPIError for a response whose body was already read
+func apiErrorFromBody(resp *http.Response, body []byte) *APIError {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Body:       body,
+		Message:    strings.TrimSpace(string(body)),
+	}
+
+	var parsed struct {
+		Error   string          `json:"error"`
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Detail  json.RawMessage `json:"detail"`
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+	e.Code = parsed.Code
+	switch {
+	case parsed.Error != "":
+		e.Message = parsed.Error
+	case parsed.Message != "":
+		e.Message = parsed.Message
+	case len(parsed.Detail) > 0:
+		if detail := detailMessage(parsed.Detail); detail != "" {
+			e.Message = detail
+		}
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+// parseSandboxError is ParseAPIError for operations on a single sandbox,
+// whose 404 also matches ErrSandboxNotFound
+func parseSandboxError(resp *http.Response) *APIError {
+	e := ParseAPIError(resp)
+	e.notFound = ErrSandboxNotFound
+	return e
+}
+
+// markUnsupported flags e as ErrEndpointUnsupported when the API answered
+// that the route, rather than the resource, does not exist: 405, 501, or the
+// framework's bare "Not Found" 404. Use it on endpoints that are missing from
+// sdk/openapi/openapi.json.
+func markUnsupported(e *APIError) *APIError {
+	switch e.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		e.unsupported = true
+	case http.StatusNotFound:
+		e.unsupported = e.Message == http.StatusText(http.StatusNotFound)
+	}
+	return e
+}
+
+// detailMessage reads a "detail" field, either a string or a list of
+// validation errors, of which the first is reported as "field: message"
+func detailMessage(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var validation []struct {
+		Msg string `json:"msg"`
+		Loc []any  `json:"loc"`
+	}
+	if json.Unmarshal(raw, &validation) != nil || len(validation) == 0 {
+		return ""
+	}
+	first := validation[0]
+	if len(first.Loc) > 0 {
+		return fmt.Sprintf("%v: %s", first.Loc[len(first.Loc)-1], first.Msg)
+	}
+	return first.Msg
+}
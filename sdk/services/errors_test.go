@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIError_Bodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		wantCode    string
+	}{
+		{"error field", 400, `{"error": "bad dataset", "code": "invalid_dataset"}`, "bad dataset", "invalid_dataset"},
+		{"message field", 500, `{"message": "boom"}`, "boom", ""},
+		{"detail string", 404, `{"detail": "Job not found"}`, "Job not found", ""},
+		{"validation detail", 422, `{"detail": [{"loc": ["body", "dataset"], "msg": "field required"}]}`, "dataset: field required", ""},
+		{"plain text", 502, "upstream unavailable\n", "upstream unavailable", ""},
+		{"empty body", 503, "", "Service Unavailable", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: http.NoBody}
+			if tt.body != "" {
+				resp.Body = io.NopCloser(strings.NewReader(tt.body))
+			}
+			got := ParseAPIError(resp)
+			if got.StatusCode != tt.status || got.Message != tt.wantMessage || got.Code != tt.wantCode {
+				t.Errorf("got status %d, message %q, code %q; want %d, %q, %q", got.StatusCode, got.Message, got.Code, tt.status, tt.wantMessage, tt.wantCode)
+			}
+			if string(got.Body) != tt.body {
+				t.Errorf("Body = %q, want the raw body %q", got.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestAPIError_IsSentinels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		switch r.URL.Path {
+		case "/sandboxes":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.Error(w, `{"detail": "Job not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	err := svc.DeleteVM(context.Background(), "vm-1")
+	if !errors.Is(err, ErrSandboxNotFound) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a sandbox 404 to match ErrSandboxNotFound and ErrNotFound, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "req-1" || apiErr.Message != "Job not found" {
+		t.Errorf("expected an *APIError with the request ID and detail, got %#v", apiErr)
+	}
+
+	_, err = svc.List(context.Background())
+	if !errors.Is(err, ErrRateLimited) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrSandboxNotFound) {
+		t.Errorf("expected a 429 to match only ErrRateLimited, got %v", err)
+	}
+}
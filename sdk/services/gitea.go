@@ -9,12 +9,12 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,14 +22,142 @@ import (
 	"plato-sdk/utils"
 )
 
+// GitTransport selects how git talks to hub repositories
+type GitTransport string
+
+const (
+	// GitTransportHTTPS embeds the hub credentials in the HTTPS clone URL (default)
+	GitTransportHTTPS GitTransport = "https"
+	// GitTransportSSH uses the repository's SSH URL and the user's own SSH key
+	GitTransportSSH GitTransport = "ssh"
+)
+
+// CloneOptions controls how much of a hub repository is cloned for hub operations
+type CloneOptions struct {
+	// Depth is the number of commits to fetch; 0 clones the full history
+	Depth int
+	// PartialClone fetches blobs on demand (--filter=blob:none) when the
+	// local git supports it, keeping all refs and commits
+	PartialClone bool
+}
+
+// DefaultCloneOptions clones only the tip, which is all hub operations need
+func DefaultCloneOptions() CloneOptions {
+	return CloneOptions{Depth: 1}
+}
+
 // GiteaService handles Gitea-related API operations
 type GiteaService struct {
-	client ClientInterface
+	client    ClientInterface
+	transport GitTransport
+	clone     CloneOptions
+	simCache  *SimulatorCache
 }
 
-// NewGiteaService creates a new Gitea service
+// NewGiteaService creates a new Gitea service using the HTTPS transport
 func NewGiteaService(client ClientInterface) *GiteaService {
-	return &GiteaService{client: client}
+	return NewGiteaServiceWithTransport(client, GitTransportHTTPS)
+}
+
+// NewGiteaServiceWithTransport creates a new Gitea service that clones and pushes over transport
+func NewGiteaServiceWithTransport(client ClientInterface, transport GitTransport) *GiteaService {
+	return NewGiteaServiceWithCloneOptions(client, transport, DefaultCloneOptions())
+}
+
+// NewGiteaServiceWithCloneOptions creates a new Gitea service that clones and
+// pushes over transport, cloning hub repositories as described by clone
+func NewGiteaServiceWithCloneOptions(client ClientInterface, transport GitTransport, clone CloneOptions) *GiteaService {
+	if transport == "" {
+		transport = GitTransportHTTPS
+	}
+	if clone.Depth < 0 {
+		clone.Depth = 0
+	}
+	return &GiteaService{client: client, transport: transport, clone: clone, simCache: NewSimulatorCache()}
+}
+
+// SetSimulatorCache makes GetSimulatorByName use cache, typically the one the
+// client's SimulatorService uses. Call it before the service is used.
+func (s *GiteaService) SetSimulatorCache(cache *SimulatorCache) {
+	if cache != nil {
+		s.simCache = cache
+	}
+}
+
+// Transport returns the git transport used for hub repositories
+func (s *GiteaService) Transport() GitTransport {
+	return s.transport
+}
+
+// RemoteURL returns the URL git should use to clone from and push to repo.
+// Over HTTPS the hub credentials are embedded in the URL; over SSH the
+// repository's SSH URL is returned and authentication uses the user's key.
+func (s *GiteaService) RemoteURL(repo *models.GiteaRepository, creds *models.GiteaCredentials) (string, error) {
+	if s.transport == GitTransportSSH {
+		if repo.SSHURL == "" {
+			return "", fmt.Errorf("hub did not return an SSH clone URL for %s; use the https transport", repo.FullName)
+		}
+		return repo.SSHURL, nil
+	}
+
+	cloneURL := repo.CloneURL
+	if strings.HasPrefix(cloneURL, "https://") {
+		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	}
+	return cloneURL, nil
+}
+
+// GitEnv returns the environment for git commands that contact the hub, or nil
+// to inherit the current one. Over SSH, git is kept from prompting so a missing
+// key fails fast instead of hanging.
+func (s *GiteaService) GitEnv() []string {
+	if s.transport != GitTransportSSH || os.Getenv("GIT_SSH_COMMAND") != "" {
+		return nil
+	}
+	return append(os.Environ(), "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+}
+
+// CloneArgs returns the git arguments that clone repo into dir for a hub
+// operation, following the service's CloneOptions. By default the clone is
+// shallow, since hub operations only need the tip. With a branch, only that
+// branch is checked out (and, for shallow clones, fetched).
+func (s *GiteaService) CloneArgs(cloneURL, dir, branch string) []string {
+	return s.cloneArgs(cloneURL, dir, branch, s.clone.PartialClone && utils.GitSupportsPartialClone())
+}
+
+func (s *GiteaService) cloneArgs(cloneURL, dir, branch string, partial bool) []string {
+	args := []string{"clone"}
+	if s.clone.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(s.clone.Depth))
+	}
+	if partial {
+		args = append(args, "--filter=blob:none")
+	}
+	if branch != "" {
+		if s.clone.Depth > 0 {
+			args = append(args, "--single-branch")
+		}
+		args = append(args, "--branch", branch)
+	}
+	return append(args, cloneURL, dir)
+}
+
+// fallbackDefaultBranch is used when neither git nor the hub names a default
+// branch, i.e. for a repository without commits on its default branch
+const fallbackDefaultBranch = "main"
+
+// DefaultBranch returns the default branch of repo: the branch its HEAD
+// points at, else the one the hub reports, else "main" so that merging into
+// an empty repository creates it
+func (s *GiteaService) DefaultBranch(ctx context.Context, repo *models.GiteaRepository, cloneURL string) string {
+	branch, err := utils.RemoteDefaultBranch(ctx, cloneURL, s.GitEnv())
+	if err == nil && branch != "" {
+		return branch
+	}
+	if repo.DefaultBranch != "" {
+		return repo.DefaultBranch
+	}
+	return fallbackDefaultBranch
 }
 
 // GetCredentials retrieves Gitea credentials for the organization
@@ -46,8 +174,7 @@ func (s *GiteaService) GetCredentials(ctx context.Context) (*models.GiteaCredent
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
 	var creds models.GiteaCredentials
@@ -58,8 +185,36 @@ func (s *GiteaService) GetCredentials(ctx context.Context) (*models.GiteaCredent
 	return &creds, nil
 }
 
-// ListSimulators lists all simulators with Gitea repository information
+// ListSimulators lists all simulators with Gitea repository information,
+// refreshing the cache GetSimulatorByName uses
 func (s *GiteaService) ListSimulators(ctx context.Context) ([]models.GiteaSimulator, error) {
+	simulators, err := s.listSimulators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.simCache.storeHubSimulators(simulators)
+	return simulators, nil
+}
+
+// GetSimulatorByName returns the hub simulator called name
+// (case-insensitively), listing simulators only if they are not cached yet.
+// It returns a *SimulatorNotFoundError if the hub has no such simulator.
+func (s *GiteaService) GetSimulatorByName(ctx context.Context, name string) (*models.GiteaSimulator, error) {
+	simulators, err := s.simCache.hubSimulatorList(ctx, s.listSimulators)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list simulators: %w", err)
+	}
+	for i := range simulators {
+		if strings.EqualFold(simulators[i].Name, name) {
+			sim := simulators[i]
+			return &sim, nil
+		}
+	}
+	return nil, &SimulatorNotFoundError{Name: name, Hub: true}
+}
+
+// listSimulators fetches the hub simulator list without touching the cache
+func (s *GiteaService) listSimulators(ctx context.Context) ([]models.GiteaSimulator, error) {
 	req, err := s.client.NewHubRequest(ctx, "GET", "/gitea/simulators", nil)
 	if err != nil {
 		return nil, err
@@ -72,8 +227,7 @@ func (s *GiteaService) ListSimulators(ctx context.Context) ([]models.GiteaSimula
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
 	var simulators []models.GiteaSimulator
@@ -98,8 +252,7 @@ func (s *GiteaService) GetSimulatorRepository(ctx context.Context, simulatorID i
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
 	var repo models.GiteaRepository
@@ -124,10 +277,12 @@ func (s *GiteaService) CreateSimulatorRepository(ctx context.Context, simulatorI
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
+	// The cached hub list still says the simulator has no repository
+	s.simCache.invalidateHub()
+
 	var repo models.GiteaRepository
 	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -136,6 +291,106 @@ func (s *GiteaService) CreateSimulatorRepository(ctx context.Context, simulatorI
 	return &repo, nil
 }
 
+// ErrHubAuth reports that the hub rejected git's credentials, even after they
+// were fetched again
+var ErrHubAuth = errors.New("hub rejected the git credentials")
+
+// gitAuthFailures are git and hub messages for rejected credentials
+var gitAuthFailures = []string{
+	"authentication failed",
+	"invalid username or password",
+	"could not read username",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+	"permission denied (publickey",
+}
+
+// isGitAuthFailure reports whether git output shows that the remote rejected
+// its credentials
+func isGitAuthFailure(output string) bool {
+	output = strings.ToLower(output)
+	for _, failure := range gitAuthFailures {
+		if strings.Contains(output, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// Push runs `git push args...` in repoDir, whose origin is repo. Hub
+// credentials can rotate during a session, so when the hub rejects them Push
+// fetches them again, points origin at them and retries once. It returns the
+// credentials it used last, for callers that reuse them afterwards. If the
+// retry is also rejected, or the transport is SSH, the error wraps ErrHubAuth.
+func (s *GiteaService) Push(ctx context.Context, repoDir string, repo *models.GiteaRepository, creds *models.GiteaCredentials, args ...string) (*models.GiteaCredentials, error) {
+	push := func() (string, error) {
+		output, err := utils.RunRemoteGit(ctx, repoDir, s.GitEnv(), append([]string{"push"}, args...)...)
+		return string(output), err
+	}
+
+	output, err := push()
+	if err == nil {
+		return creds, nil
+	}
+	if !isGitAuthFailure(output) {
+		return creds, fmt.Errorf("git push failed: %w\nOutput: %s", err, output)
+	}
+	if s.transport == GitTransportSSH {
+		return creds, fmt.Errorf("%w: check that your SSH key is added to your hub account\nOutput: %s", ErrHubAuth, output)
+	}
+
+	fresh, err := s.GetCredentials(ctx)
+	if err != nil {
+		return creds, fmt.Errorf("%w and fetching new ones failed: %v\nOutput: %s", ErrHubAuth, err, output)
+	}
+	remoteURL, err := s.RemoteURL(repo, fresh)
+	if err != nil {
+		return creds, err
+	}
+	setURL := utils.GitCommand(ctx, "remote", "set-url", "origin", remoteURL)
+	setURL.Dir = repoDir
+	if out, err := setURL.CombinedOutput(); err != nil {
+		return creds, fmt.Errorf("git remote set-url failed: %w\nOutput: %s", err, string(out))
+	}
+
+	output, err = push()
+	if err == nil {
+		return fresh, nil
+	}
+	if isGitAuthFailure(output) {
+		return fresh, fmt.Errorf("%w after refreshing them; check that your API key can push to %s\nOutput: %s", ErrHubAuth, repo.FullName, output)
+	}
+	return fresh, fmt.Errorf("git push failed: %w\nOutput: %s", err, output)
+}
+
+// EnsureDefaultBranch gives a newly created repository its default branch.
+// repoDir is a fresh clone of repo; if it has no commits, an empty initial
+// commit is pushed to the default branch, so that branches created in the
+// clone afterwards have a base and clones of the default branch succeed. It
+// returns the credentials Push used last.
+func (s *GiteaService) EnsureDefaultBranch(ctx context.Context, repoDir string, repo *models.GiteaRepository, creds *models.GiteaCredentials) (*models.GiteaCredentials, error) {
+	if utils.HasCommits(repoDir) {
+		return creds, nil
+	}
+
+	// An empty repository has no HEAD to ask git about, so trust the hub
+	branch := repo.DefaultBranch
+	if branch == "" {
+		branch = fallbackDefaultBranch
+	}
+	for _, args := range [][]string{
+		{"symbolic-ref", "HEAD", "refs/heads/" + branch},
+		{"commit", "--allow-empty", "-m", "Initial commit"},
+	} {
+		cmd := utils.GitCommand(ctx, args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return creds, fmt.Errorf("git %s failed: %w\nOutput: %s", args[0], err, string(output))
+		}
+	}
+	return s.Push(ctx, repoDir, repo, creds, "origin", "HEAD:refs/heads/"+branch)
+}
+
 // PushResult contains information about a successful push to Gitea
 type PushResult struct {
 	RepoURL    string
@@ -144,8 +399,15 @@ type PushResult struct {
 	GitHash    string
 }
 
-// PushToHub pushes local code to a Gitea repository on a timestamped branch
+// PushToHub pushes local code to a Gitea repository on a timestamped branch.
+// Without a deadline on ctx, the clone and push each get utils.DefaultGitTimeout.
 func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, sourceDir string) (*PushResult, error) {
+	return s.PushToHubWithProgress(ctx, serviceName, sourceDir, nil)
+}
+
+// PushToHubWithProgress is PushToHub for large workspaces: progress, if not
+// nil, is called as each file is copied into the clone that is pushed
+func (s *GiteaService) PushToHubWithProgress(ctx context.Context, serviceName string, sourceDir string, progress func(utils.CopyProgress)) (result *PushResult, err error) {
 	if sourceDir == "" {
 		var err error
 		sourceDir, err = os.Getwd()
@@ -161,21 +423,9 @@ func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, source
 	}
 
 	// Find simulator by service name
-	simulators, err := s.ListSimulators(ctx)
+	simulator, err := s.GetSimulatorByName(ctx, serviceName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list simulators: %w", err)
-	}
-
-	var simulator *models.GiteaSimulator
-	for i := range simulators {
-		if strings.EqualFold(simulators[i].Name, serviceName) {
-			simulator = &simulators[i]
-			break
-		}
-	}
-
-	if simulator == nil {
-		return nil, fmt.Errorf("simulator '%s' not found in hub", serviceName)
+		return nil, err
 	}
 
 	// Get or create repository
@@ -192,10 +442,10 @@ func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, source
 		}
 	}
 
-	// Build authenticated clone URL
-	cloneURL := repo.CloneURL
-	if strings.HasPrefix(cloneURL, "https://") {
-		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	// Build the clone URL for the configured transport
+	cloneURL, err := s.RemoteURL(repo, creds)
+	if err != nil {
+		return nil, err
 	}
 
 	// Clone repo to temp directory
@@ -203,39 +453,43 @@ func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, source
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
-	defer os.RemoveAll(tempDir)
+	defer func() { err = utils.CleanupTempDir(tempDir, utils.GitInterrupted(ctx, err)) }()
 
+	// The new branch starts from the tip of the default branch, so a
+	// shallow clone is enough; the hub already has the history behind it
 	tempRepo := filepath.Join(tempDir, "repo")
-	cloneCmd := exec.Command("git", "clone", cloneURL, tempRepo)
-	cloneOutput, err := cloneCmd.CombinedOutput()
+	cloneOutput, err := utils.RunRemoteGit(ctx, "", s.GitEnv(), s.CloneArgs(cloneURL, tempRepo, "")...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, string(cloneOutput))
 	}
+	if creds, err = s.EnsureDefaultBranch(ctx, tempRepo, repo, creds); err != nil {
+		return nil, fmt.Errorf("failed to create the default branch: %w", err)
+	}
 
 	// Generate branch name with timestamp
 	branchName := fmt.Sprintf("workspace-%d", time.Now().Unix())
 
 	// Create and checkout new branch
-	gitCheckout := exec.Command("git", "checkout", "-b", branchName)
+	gitCheckout := utils.GitCommand(ctx, "checkout", "-b", branchName)
 	gitCheckout.Dir = tempRepo
 	if output, err := gitCheckout.CombinedOutput(); err != nil {
 		return nil, fmt.Errorf("git checkout failed: %w\nOutput: %s", err, string(output))
 	}
 
 	// Copy files respecting .gitignore
-	if err := utils.CopyFilesRespectingGitignore(sourceDir, tempRepo); err != nil {
+	if err := utils.CopyFilesRespectingGitignore(sourceDir, tempRepo, progress); err != nil {
 		return nil, fmt.Errorf("failed to copy files: %w", err)
 	}
 
 	// Commit and push
-	gitAdd := exec.Command("git", "add", ".")
+	gitAdd := utils.GitCommand(ctx, "add", ".")
 	gitAdd.Dir = tempRepo
 	if output, err := gitAdd.CombinedOutput(); err != nil {
 		return nil, fmt.Errorf("git add failed: %w\nOutput: %s", err, string(output))
 	}
 
 	// Check if there are changes
-	gitStatus := exec.Command("git", "status", "--porcelain")
+	gitStatus := utils.GitCommand(ctx, "status", "--porcelain")
 	gitStatus.Dir = tempRepo
 	statusOutput, err := gitStatus.Output()
 	if err != nil {
@@ -243,40 +497,31 @@ func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, source
 	}
 
 	if len(strings.TrimSpace(string(statusOutput))) == 0 {
-		// No changes to push - still return authenticated clone URL
-		authenticatedCloneURL := repo.CloneURL
-		if strings.HasPrefix(authenticatedCloneURL, "https://") {
-			authenticatedCloneURL = strings.Replace(authenticatedCloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
-		}
+		// No changes to push - still return the clone command
 		return &PushResult{
 			RepoURL:    repo.CloneURL,
-			CloneCmd:   fmt.Sprintf("git clone -b %s %s", branchName, authenticatedCloneURL),
+			CloneCmd:   fmt.Sprintf("git clone -b %s %s", branchName, cloneURL),
 			BranchName: branchName,
 		}, nil
 	}
 
 	// Commit changes
-	gitCommit := exec.Command("git", "commit", "-m", "Sync from local workspace")
+	gitCommit := utils.GitCommand(ctx, "commit", "-m", "Sync from local workspace")
 	gitCommit.Dir = tempRepo
 	if output, err := gitCommit.CombinedOutput(); err != nil {
 		return nil, fmt.Errorf("git commit failed: %w\nOutput: %s", err, string(output))
 	}
 
 	// Push to remote branch
-	gitPush := exec.Command("git", "push", "-u", "origin", branchName)
-	gitPush.Dir = tempRepo
-	if output, err := gitPush.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("git push failed: %w\nOutput: %s", err, string(output))
+	if creds, err = s.Push(ctx, tempRepo, repo, creds, "-u", "origin", branchName); err != nil {
+		return nil, err
 	}
-
-	// Build authenticated clone URL for the user
-	authenticatedCloneURL := repo.CloneURL
-	if strings.HasPrefix(authenticatedCloneURL, "https://") {
-		authenticatedCloneURL = strings.Replace(authenticatedCloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	if cloneURL, err = s.RemoteURL(repo, creds); err != nil {
+		return nil, err
 	}
 
-	// Return success with authenticated clone command
-	cloneCommand := fmt.Sprintf("git clone -b %s %s", branchName, authenticatedCloneURL)
+	// Return success with the clone command for the configured transport
+	cloneCommand := fmt.Sprintf("git clone -b %s %s", branchName, cloneURL)
 	return &PushResult{
 		RepoURL:    repo.CloneURL,
 		CloneCmd:   cloneCommand,
@@ -284,8 +529,19 @@ func (s *GiteaService) PushToHub(ctx context.Context, serviceName string, source
 	}, nil
 }
 
-// MergeToMain merges a workspace branch to main and returns the git hash
-func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, branchName string) (string, error) {
+// MergeToMain merges a workspace branch into the default branch of the
+// repository (main, unless the repository says otherwise) and returns the git hash
+func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, branchName string) (hash string, err error) {
+	return s.MergeToBranch(ctx, serviceName, branchName, "")
+}
+
+// MergeToBranch merges a workspace branch into targetBranch, creating it if
+// needed, and returns the git hash. An empty targetBranch means the default
+// branch of the repository, as in MergeToMain. The push fails with
+// utils.ErrBranchMoved when targetBranch changed since the merge began. Without
+// a deadline on ctx, the ls-remote, clone and push each get
+// utils.DefaultGitTimeout.
+func (s *GiteaService) MergeToBranch(ctx context.Context, serviceName string, branchName string, targetBranch string) (hash string, err error) {
 	// Get Gitea credentials
 	creds, err := s.GetCredentials(ctx)
 	if err != nil {
@@ -293,21 +549,9 @@ func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, bran
 	}
 
 	// Find simulator by service name
-	simulators, err := s.ListSimulators(ctx)
+	simulator, err := s.GetSimulatorByName(ctx, serviceName)
 	if err != nil {
-		return "", fmt.Errorf("failed to list simulators: %w", err)
-	}
-
-	var simulator *models.GiteaSimulator
-	for i := range simulators {
-		if strings.EqualFold(simulators[i].Name, serviceName) {
-			simulator = &simulators[i]
-			break
-		}
-	}
-
-	if simulator == nil {
-		return "", fmt.Errorf("simulator '%s' not found in hub", serviceName)
+		return "", err
 	}
 
 	// Get repository
@@ -316,10 +560,20 @@ func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, bran
 		return "", fmt.Errorf("failed to get repository: %w", err)
 	}
 
-	// Build authenticated clone URL
-	cloneURL := repo.CloneURL
-	if strings.HasPrefix(cloneURL, "https://") {
-		cloneURL = strings.Replace(cloneURL, "https://", fmt.Sprintf("https://%s:%s@", creds.Username, creds.Password), 1)
+	// Build the clone URL for the configured transport
+	cloneURL, err := s.RemoteURL(repo, creds)
+	if err != nil {
+		return "", err
+	}
+
+	// Record where the target points now, so the push below cannot clobber
+	// commits someone else adds to it meanwhile
+	if targetBranch == "" {
+		targetBranch = s.DefaultBranch(ctx, repo, cloneURL)
+	}
+	targetTip, err := utils.RemoteBranchTip(ctx, cloneURL, s.GitEnv(), targetBranch)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", targetBranch, err)
 	}
 
 	// Clone repo to temp directory
@@ -327,24 +581,17 @@ func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, bran
 	if err != nil {
 		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
-	defer os.RemoveAll(tempDir)
+	defer func() { err = utils.CleanupTempDir(tempDir, utils.GitInterrupted(ctx, err)) }()
 
+	// Clone only the workspace branch tip; the target is reset to it by the push
 	tempRepo := filepath.Join(tempDir, "repo")
-	cloneCmd := exec.Command("git", "clone", cloneURL, tempRepo)
-	cloneOutput, err := cloneCmd.CombinedOutput()
+	cloneOutput, err := utils.RunRemoteGit(ctx, "", s.GitEnv(), s.CloneArgs(cloneURL, tempRepo, branchName)...)
 	if err != nil {
 		return "", fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, string(cloneOutput))
 	}
 
-	// Checkout the workspace branch
-	gitCheckout := exec.Command("git", "checkout", branchName)
-	gitCheckout.Dir = tempRepo
-	if output, err := gitCheckout.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git checkout failed: %w\nOutput: %s", err, string(output))
-	}
-
 	// Get the current commit hash
-	gitRevParse := exec.Command("git", "rev-parse", "HEAD")
+	gitRevParse := utils.GitCommand(ctx, "rev-parse", "HEAD")
 	gitRevParse.Dir = tempRepo
 	hashOutput, err := gitRevParse.Output()
 	if err != nil {
@@ -352,25 +599,11 @@ func (s *GiteaService) MergeToMain(ctx context.Context, serviceName string, bran
 	}
 	gitHash := strings.TrimSpace(string(hashOutput))
 
-	// Checkout main branch
-	gitCheckoutMain := exec.Command("git", "checkout", "main")
-	gitCheckoutMain.Dir = tempRepo
-	if output, err := gitCheckoutMain.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git checkout main failed: %w\nOutput: %s", err, string(output))
-	}
-
-	// Force merge (reset main to workspace branch)
-	gitReset := exec.Command("git", "reset", "--hard", branchName)
-	gitReset.Dir = tempRepo
-	if output, err := gitReset.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git reset failed: %w\nOutput: %s", err, string(output))
-	}
-
-	// Force push to main
-	gitPush := exec.Command("git", "push", "-f", "origin", "main")
-	gitPush.Dir = tempRepo
-	if output, err := gitPush.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git push main failed: %w\nOutput: %s", err, string(output))
+	// Force push to the target branch (resetting it to the workspace
+	// branch), unless it moved since its tip was recorded. If the branch has
+	// no commits yet, this creates it.
+	if output, err := utils.RunRemoteGit(ctx, tempRepo, s.GitEnv(), utils.LeasePushArgs(targetBranch, targetTip)...); err != nil {
+		return "", fmt.Errorf("git push %s failed: %w\nOutput: %s", targetBranch, utils.LeaseError(err, string(output)), string(output))
 	}
 
 	return gitHash, nil
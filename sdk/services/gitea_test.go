@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"plato-sdk/models"
+)
+
+func TestCloneArgs(t *testing.T) {
+	svc := NewGiteaService(nil)
+
+	got := svc.CloneArgs("https://hub/repo.git", "/tmp/repo", "")
+	want := []string{"clone", "--depth", "1", "https://hub/repo.git", "/tmp/repo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CloneArgs without branch = %v, want %v", got, want)
+	}
+
+	got = svc.CloneArgs("https://hub/repo.git", "/tmp/repo", "workspace-1")
+	want = []string{"clone", "--depth", "1", "--single-branch", "--branch", "workspace-1", "https://hub/repo.git", "/tmp/repo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CloneArgs with branch = %v, want %v", got, want)
+	}
+}
+
+func TestCloneArgs_CloneOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		clone   CloneOptions
+		branch  string
+		partial bool
+		want    []string
+	}{
+		{"full history", CloneOptions{Depth: 0}, "workspace-1", false,
+			[]string{"clone", "--branch", "workspace-1", "url", "dir"}},
+		{"deeper clone", CloneOptions{Depth: 10}, "", false,
+			[]string{"clone", "--depth", "10", "url", "dir"}},
+		{"partial full clone", CloneOptions{PartialClone: true}, "main", true,
+			[]string{"clone", "--filter=blob:none", "--branch", "main", "url", "dir"}},
+		{"partial unsupported by git", CloneOptions{PartialClone: true}, "", false,
+			[]string{"clone", "url", "dir"}},
+		{"negative depth means full", CloneOptions{Depth: -1}, "", false,
+			[]string{"clone", "url", "dir"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewGiteaServiceWithCloneOptions(nil, GitTransportHTTPS, tt.clone)
+			got := svc.cloneArgs("url", "dir", tt.branch, tt.partial)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cloneArgs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// runGit runs git in dir and returns its trimmed output, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newHubRepo creates a bare repository whose HEAD names defaultBranch, with a
+// workspace-1 branch and, if withDefault, a commit on the default branch
+func newHubRepo(t *testing.T, defaultBranch string, withDefault bool) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	bare := filepath.Join(root, "hub.git")
+	runGit(t, root, "init", "--bare", "--initial-branch", defaultBranch, bare)
+
+	work := filepath.Join(root, "work")
+	runGit(t, root, "init", "--initial-branch", defaultBranch, work)
+	runGit(t, work, "commit", "--allow-empty", "-m", "initial")
+	if withDefault {
+		runGit(t, work, "push", bare, defaultBranch)
+	}
+	runGit(t, work, "checkout", "-b", "workspace-1")
+	runGit(t, work, "commit", "--allow-empty", "-m", "workspace")
+	runGit(t, work, "push", bare, "workspace-1")
+	return bare
+}
+
+// newHubServer serves the hub endpoints MergeToMain uses for a simulator
+// named "sim" whose repository is cloned from cloneURL
+func newHubServer(t *testing.T, cloneURL string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gitea/credentials":
+			json.NewEncoder(w).Encode(models.GiteaCredentials{Username: "u", Password: "p"})
+		case "/gitea/simulators":
+			json.NewEncoder(w).Encode([]models.GiteaSimulator{{ID: 1, Name: "sim", HasRepo: true}})
+		case "/gitea/simulators/1/repo":
+			json.NewEncoder(w).Encode(models.GiteaRepository{Name: "sim", CloneURL: cloneURL})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIsGitAuthFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"remote: Invalid username or password.\nfatal: Authentication failed for 'https://hub/repo.git/'", true},
+		{"fatal: unable to access 'https://hub/repo.git/': The requested URL returned error: 401", true},
+		{"git@hub: Permission denied (publickey).", true},
+		{"! [rejected] workspace-1 -> workspace-1 (non-fast-forward)", false},
+		{"fatal: unable to access 'https://hub/repo.git/': Could not resolve host: hub", false},
+	}
+	for _, tt := range tests {
+		if got := isGitAuthFailure(tt.output); got != tt.want {
+			t.Errorf("isGitAuthFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestMergeToMain_MasterDefaultBranch(t *testing.T) {
+	bare := newHubRepo(t, "master", true)
+	server := newHubServer(t, "file://"+bare)
+	svc := NewGiteaService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	hash, err := svc.MergeToMain(context.Background(), "sim", "workspace-1")
+	if err != nil {
+		t.Fatalf("MergeToMain: %v", err)
+	}
+	if want := runGit(t, bare, "rev-parse", "workspace-1"); hash != want {
+		t.Errorf("hash = %s, want workspace-1 tip %s", hash, want)
+	}
+	if got := runGit(t, bare, "rev-parse", "master"); got != hash {
+		t.Errorf("master = %s, want %s", got, hash)
+	}
+	if refs := runGit(t, bare, "for-each-ref", "--format=%(refname)", "refs/heads/main"); refs != "" {
+		t.Errorf("expected no main branch to be created, got %s", refs)
+	}
+}
+
+func TestMergeToBranch_SnapshotBranch(t *testing.T) {
+	bare := newHubRepo(t, "main", true)
+	server := newHubServer(t, "file://"+bare)
+	svc := NewGiteaService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	mainBefore := runGit(t, bare, "rev-parse", "main")
+
+	hash, err := svc.MergeToBranch(context.Background(), "sim", "workspace-1", "snapshots")
+	if err != nil {
+		t.Fatalf("MergeToBranch: %v", err)
+	}
+	if got := runGit(t, bare, "rev-parse", "snapshots"); got != hash {
+		t.Errorf("snapshots = %s, want %s", got, hash)
+	}
+	if got := runGit(t, bare, "rev-parse", "main"); got != mainBefore {
+		t.Errorf("expected main to stay at %s, got %s", mainBefore, got)
+	}
+}
+
+func TestMergeToMain_EmptyDefaultBranchCreatesMain(t *testing.T) {
+	bare := newHubRepo(t, "main", false)
+	server := newHubServer(t, "file://"+bare)
+	svc := NewGiteaService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	hash, err := svc.MergeToMain(context.Background(), "sim", "workspace-1")
+	if err != nil {
+		t.Fatalf("MergeToMain: %v", err)
+	}
+	if got := runGit(t, bare, "rev-parse", "main"); got != hash {
+		t.Errorf("main = %s, want %s", got, hash)
+	}
+}
+
+func TestEnsureDefaultBranch_EmptyRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "test")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "test@example.com")
+	}
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "hub.git")
+	runGit(t, root, "init", "--bare", "--initial-branch", "trunk", bare)
+	clone := filepath.Join(root, "clone")
+	runGit(t, root, "clone", "file://"+bare, clone)
+
+	svc := NewGiteaService(nil)
+	repo := &models.GiteaRepository{Name: "sim", CloneURL: "file://" + bare, DefaultBranch: "trunk"}
+	if _, err := svc.EnsureDefaultBranch(context.Background(), clone, repo, nil); err != nil {
+		t.Fatalf("EnsureDefaultBranch: %v", err)
+	}
+	tip := runGit(t, bare, "rev-parse", "trunk")
+	if got := runGit(t, clone, "rev-parse", "HEAD"); got != tip {
+		t.Errorf("clone HEAD = %s, want pushed trunk %s", got, tip)
+	}
+
+	// A repository that already has commits is left alone
+	if _, err := svc.EnsureDefaultBranch(context.Background(), clone, repo, nil); err != nil {
+		t.Fatalf("EnsureDefaultBranch: %v", err)
+	}
+	if got := runGit(t, bare, "rev-parse", "trunk"); got != tip {
+		t.Errorf("expected trunk to stay at %s, got %s", tip, got)
+	}
+}
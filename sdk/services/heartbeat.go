@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is how often HeartbeatManager keeps VMs alive
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// DefaultHeartbeatFailureThreshold is how many heartbeats in a row must fail
+// before HeartbeatManager reports it
+const DefaultHeartbeatFailureThreshold = 3
+
+// DefaultHeartbeatRetries and DefaultHeartbeatRetryDelay are how often and how
+// soon HeartbeatManager resends a failed heartbeat before counting it as failed
+const (
+	DefaultHeartbeatRetries    = 2
+	DefaultHeartbeatRetryDelay = 2 * time.Second
+)
+
+// HeartbeatSender sends heartbeats; *SandboxService implements it
+type HeartbeatSender interface {
+	SendHeartbeat(ctx context.Context, jobGroupID string) error
+	SendHeartbeatBatch(ctx context.Context, jobGroupIDs []string) error
+}
+
+// HeartbeatFailureFunc is called from HeartbeatManager's goroutine when a
+// VM's heartbeats have failed failures times in a row (at least the
+// threshold), with the latest error. A heartbeat only counts as failed once
+// its retries have failed too. Once a heartbeat succeeds again it is
+// called with 0 failures and a nil err.
+type HeartbeatFailureFunc func(jobGroupID string, failures int, err error)
+
+// HeartbeatOption configures a HeartbeatManager
+type HeartbeatOption func(*HeartbeatManager)
+
+// WithHeartbeatInterval sets how often heartbeats are sent
+func WithHeartbeatInterval(interval time.Duration) HeartbeatOption {
+	return func(h *HeartbeatManager) {
+		h.interval = interval
+	}
+}
+
+// WithHeartbeatRetries resends a failed heartbeat up to retries times, waiting
+// delay before the first retry and doubling it each time. Retries stop when the
+// next heartbeat is due. Zero retries sends each heartbeat once.
+func WithHeartbeatRetries(retries int, delay time.Duration) HeartbeatOption {
+	return func(h *HeartbeatManager) {
+		h.retries = retries
+		h.retryDelay = delay
+	}
+}
+
+// WithHeartbeatFailures reports VMs whose last threshold heartbeats failed to
+// fn, so a UI can warn that the VM may expire
+func WithHeartbeatFailures(threshold int, fn HeartbeatFailureFunc) HeartbeatOption {
+	return func(h *HeartbeatManager) {
+		h.threshold = threshold
+		h.onFailure = fn
+	}
+}
+
+// heartbeatJob is one VM kept alive by a HeartbeatManager
+type heartbeatJob struct {
+	// stopWatch stops watching the context the job was started with
+	stopWatch func() bool
+	failures  int
+	reported  bool
+}
+
+// HeartbeatManager keeps VMs alive with one batched heartbeat per interval,
+// instead of a goroutine and a request per VM. Starting a VM that is already
+// running is a no-op, and a VM stops when Stop is called or the context it
+// was started with ends. It is safe for concurrent use.
+type HeartbeatManager struct {
+	sender     HeartbeatSender
+	interval   time.Duration
+	retries    int
+	retryDelay time.Duration
+	threshold  int
+	onFailure  HeartbeatFailureFunc
+	// newTicker is replaced in tests to drive the loop without waiting
+	newTicker func(time.Duration) (<-chan time.Time, func())
+
+	mu       sync.Mutex
+	jobs     map[string]*heartbeatJob
+	loopStop chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewHeartbeatManager returns a HeartbeatManager sending through sender
+func NewHeartbeatManager(sender HeartbeatSender, opts ...HeartbeatOption) *HeartbeatManager {
+	h := &HeartbeatManager{
+		sender:     sender,
+		interval:   DefaultHeartbeatInterval,
+		retries:    DefaultHeartbeatRetries,
+		retryDelay: DefaultHeartbeatRetryDelay,
+		threshold:  DefaultHeartbeatFailureThreshold,
+		jobs:       make(map[string]*heartbeatJob),
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			ticker := time.NewTicker(d)
+			return ticker.C, ticker.Stop
+		},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.threshold < 1 {
+		h.threshold = 1
+	}
+	return h
+}
+
+// Start keeps jobGroupID alive until Stop is called or ctx ends, sending a
+// first heartbeat right away. It returns false if the VM was already running.
+func (h *HeartbeatManager) Start(ctx context.Context, jobGroupID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.jobs[jobGroupID]; ok {
+		return false
+	}
+
+	h.jobs[jobGroupID] = &heartbeatJob{
+		stopWatch: context.AfterFunc(ctx, func() { h.Stop(jobGroupID) }),
+	}
+	if h.loopStop == nil {
+		h.loopStop = make(chan struct{})
+		h.wg.Add(1)
+		go h.loop(h.loopStop)
+	}
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		sendCtx, cancel := context.WithTimeout(ctx, h.interval)
+		defer cancel()
+		err := h.send(sendCtx, func(ctx context.Context) error {
+			return h.sender.SendHeartbeat(ctx, jobGroupID)
+		})
+		h.record(map[string]error{jobGroupID: err})
+	}()
+	return true
+}
+
+// Stop stops keeping jobGroupID alive. It does nothing if it is not running.
+func (h *HeartbeatManager) Stop(jobGroupID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	job, ok := h.jobs[jobGroupID]
+	if !ok {
+		return
+	}
+	job.stopWatch()
+	delete(h.jobs, jobGroupID)
+	if len(h.jobs) == 0 {
+		h.stopLoop()
+	}
+}
+
+// StopAll stops every VM and waits for in-flight heartbeats to finish
+func (h *HeartbeatManager) StopAll() {
+	h.mu.Lock()
+	for id, job := range h.jobs {
+		job.stopWatch()
+		delete(h.jobs, id)
+	}
+	h.stopLoop()
+	h.mu.Unlock()
+	h.wg.Wait()
+}
+
+// Running reports whether jobGroupID is being kept alive
+func (h *HeartbeatManager) Running(jobGroupID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.jobs[jobGroupID]
+	return ok
+}
+
+// stopLoop ends the heartbeat loop; h.mu must be held
+func (h *HeartbeatManager) stopLoop() {
+	if h.loopStop != nil {
+		close(h.loopStop)
+		h.loopStop = nil
+	}
+}
+
+// loop sends a batched heartbeat for the running VMs every interval until stop
+// is closed
+func (h *HeartbeatManager) loop(stop chan struct{}) {
+	defer h.wg.Done()
+	ticks, stopTicker := h.newTicker(h.interval)
+	defer stopTicker()
+
+	// Stopping the loop also cuts short a heartbeat waiting to be retried
+	stopCtx, cancelStop := context.WithCancel(context.Background())
+	defer cancelStop()
+	go func() {
+		select {
+		case <-stop:
+			cancelStop()
+		case <-stopCtx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ticks:
+			h.mu.Lock()
+			ids := make([]string, 0, len(h.jobs))
+			for id := range h.jobs {
+				ids = append(ids, id)
+			}
+			h.mu.Unlock()
+			if len(ids) == 0 {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(stopCtx, h.interval)
+			results := h.sendBatch(ctx, ids)
+			cancel()
+			h.record(results)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// send calls sendFn until it succeeds, retrying with backoff up to h.retries
+// times while ctx allows, and returns the last error
+func (h *HeartbeatManager) send(ctx context.Context, sendFn func(context.Context) error) error {
+	delay := h.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := sendFn(ctx)
+		if err == nil || attempt >= h.retries {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// sendBatch sends a batched heartbeat for ids as send does, and returns the
+// outcome for each VM. When the sender reports failures per VM with a
+// *HeartbeatBatchError, only the VMs that failed are retried and charged.
+func (h *HeartbeatManager) sendBatch(ctx context.Context, ids []string) map[string]error {
+	results := make(map[string]error, len(ids))
+	pending := ids
+	h.send(ctx, func(ctx context.Context) error {
+		err := h.sender.SendHeartbeatBatch(ctx, pending)
+		var batchErr *HeartbeatBatchError
+		perVM := errors.As(err, &batchErr)
+		var failed []string
+		for _, id := range pending {
+			idErr := err
+			if perVM {
+				idErr = batchErr.Failed[id]
+			}
+			results[id] = idErr
+			if idErr != nil {
+				failed = append(failed, id)
+			}
+		}
+		pending = failed
+		return err
+	})
+	return results
+}
+
+// record updates the failure counts of VMs from the outcome of a heartbeat
+// and reports the VMs that reached the threshold or recovered from it
+func (h *HeartbeatManager) record(results map[string]error) {
+	type report struct {
+		id       string
+		failures int
+		err      error
+	}
+	var reports []report
+
+	h.mu.Lock()
+	for id, err := range results {
+		job, ok := h.jobs[id]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			job.failures++
+			if job.failures >= h.threshold {
+				job.reported = true
+				reports = append(reports, report{id, job.failures, err})
+			}
+			continue
+		}
+		job.failures = 0
+		if job.reported {
+			job.reported = false
+			reports = append(reports, report{id, 0, nil})
+		}
+	}
+	h.mu.Unlock()
+
+	if h.onFailure == nil {
+		return
+	}
+	for _, r := range reports {
+		h.onFailure(r.id, r.failures, r.err)
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHeartbeatSender records heartbeats and fails while err is set, or for
+// the first failFirst heartbeats
+type fakeHeartbeatSender struct {
+	mu        sync.Mutex
+	err       error
+	failFirst int
+	batches   [][]string
+}
+
+func (f *fakeHeartbeatSender) SendHeartbeat(ctx context.Context, jobGroupID string) error {
+	return f.SendHeartbeatBatch(ctx, []string{jobGroupID})
+}
+
+func (f *fakeHeartbeatSender) SendHeartbeatBatch(ctx context.Context, jobGroupIDs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, jobGroupIDs)
+	if len(f.batches) <= f.failFirst {
+		return errors.New("connection reset")
+	}
+	return f.err
+}
+
+func (f *fakeHeartbeatSender) sent() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.batches)
+}
+
+func (f *fakeHeartbeatSender) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// heartbeatReport is one call of a HeartbeatFailureFunc
+type heartbeatReport struct {
+	jobGroupID string
+	failures   int
+	err        error
+}
+
+// newTestHeartbeatManager returns a manager whose ticks are sent on the
+// returned channel and whose failure reports arrive on the other
+func newTestHeartbeatManager(sender HeartbeatSender, threshold int) (*HeartbeatManager, chan time.Time, chan heartbeatReport) {
+	ticks := make(chan time.Time)
+	reports := make(chan heartbeatReport, 10)
+	h := NewHeartbeatManager(sender, WithHeartbeatFailures(threshold, func(id string, failures int, err error) {
+		reports <- heartbeatReport{id, failures, err}
+	}))
+	h.newTicker = func(time.Duration) (<-chan time.Time, func()) { return ticks, func() {} }
+	h.retryDelay = time.Millisecond
+	return h, ticks, reports
+}
+
+func TestHeartbeatManager_ReportsConsecutiveFailures(t *testing.T) {
+	sender := &fakeHeartbeatSender{err: errors.New("503")}
+	h, ticks, reports := newTestHeartbeatManager(sender, 3)
+	defer h.StopAll()
+
+	h.Start(context.Background(), "jg-1")
+	ticks <- time.Now()
+	select {
+	case r := <-reports:
+		t.Fatalf("reported after fewer failures than the threshold: %+v", r)
+	case ticks <- time.Now():
+	}
+
+	r := <-reports
+	if r.jobGroupID != "jg-1" || r.failures != 3 || r.err == nil {
+		t.Fatalf("expected jg-1 reported after 3 failures, got %+v", r)
+	}
+
+	sender.setErr(nil)
+	ticks <- time.Now()
+	if r := <-reports; r.failures != 0 || r.err != nil {
+		t.Fatalf("expected a recovery report, got %+v", r)
+	}
+}
+
+func TestHeartbeatManager_StartCoalescesAndStops(t *testing.T) {
+	sender := &fakeHeartbeatSender{}
+	h, _, _ := newTestHeartbeatManager(sender, 1)
+	defer h.StopAll()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if !h.Start(ctx, "jg-1") {
+		t.Fatal("expected the first Start to start jg-1")
+	}
+	if h.Start(ctx, "jg-1") {
+		t.Fatal("expected a second Start of jg-1 to be coalesced")
+	}
+	h.Start(context.Background(), "jg-2")
+
+	h.Stop("jg-2")
+	if h.Running("jg-2") {
+		t.Error("expected jg-2 to stop")
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for h.Running("jg-1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if h.Running("jg-1") {
+		t.Error("expected jg-1 to stop when its context ended")
+	}
+}
+
+func TestHeartbeatManager_RetriesWithinATick(t *testing.T) {
+	// The first heartbeat fails twice and succeeds on its last retry
+	sender := &fakeHeartbeatSender{failFirst: 2}
+	h, _, reports := newTestHeartbeatManager(sender, 1)
+
+	h.Start(context.Background(), "jg-1")
+	deadline := time.Now().Add(time.Second)
+	for sender.sent() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	h.StopAll()
+
+	if got := sender.sent(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	select {
+	case r := <-reports:
+		t.Fatalf("a heartbeat that succeeded on retry was reported: %+v", r)
+	default:
+	}
+}
+
+func TestHeartbeatManager_ChargesOnlyFailedVMs(t *testing.T) {
+	sender := &fakeHeartbeatSender{}
+	h, ticks, reports := newTestHeartbeatManager(sender, 1)
+	defer h.StopAll()
+
+	h.Start(context.Background(), "jg-ok")
+	h.Start(context.Background(), "jg-gone")
+	deadline := time.Now().Add(time.Second)
+	for sender.sent() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The batch fell back to individual heartbeats and only jg-gone's failed
+	goneErr := errors.New("410 gone")
+	sender.setErr(&HeartbeatBatchError{Failed: map[string]error{"jg-gone": goneErr}})
+	ticks <- time.Now()
+	ticks <- time.Now() // the loop takes the next tick once the first is recorded
+
+	r := <-reports
+	if r.jobGroupID != "jg-gone" || r.failures != 1 || !errors.Is(r.err, goneErr) {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	select {
+	case r := <-reports:
+		if r.jobGroupID != "jg-gone" {
+			t.Fatalf("a VM whose heartbeat succeeded was reported: %+v", r)
+		}
+	default:
+	}
+
+	// Retries resend only the failed VM
+	sender.mu.Lock()
+	retries := sender.batches[3:5]
+	sender.mu.Unlock()
+	for _, batch := range retries {
+		if len(batch) != 1 || batch[0] != "jg-gone" {
+			t.Errorf("retry sent to %v, want only jg-gone", batch)
+		}
+	}
+}
@@ -41,7 +41,7 @@ func (s *OrganizationService) GetRunningSessions(ctx context.Context, lastNHours
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("got unexpected status code from %s: %d", path, resp.StatusCode)
+		return nil, fmt.Errorf("%s failed: %w", path, ParseAPIError(resp))
 	}
 
 	var summary models.SessionSummary
@@ -66,7 +66,7 @@ func (s *OrganizationService) GetRunningJobs(ctx context.Context) (*models.Runni
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, ParseAPIError(resp)
 	}
 
 	var jobsResp models.RunningJobsResponse
@@ -93,7 +93,7 @@ func (s *OrganizationService) GetJobMetrics(ctx context.Context, hours float64)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, ParseAPIError(resp)
 	}
 
 	var metrics models.JobMetrics
@@ -1,12 +1,14 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os/exec"
 	"plato-sdk/utils"
 	"strings"
 	"sync"
+	"time"
 )
 
 // ProxyTunnelService manages proxytunnel connections
@@ -23,9 +25,24 @@ type ProxyTunnel struct {
 	LocalPort  int
 	RemotePort int
 	PublicID   string
-	cmd        *exec.Cmd
+	// Status is TunnelStatusActive, or TunnelStatusReconnecting from when a
+	// health check finds the tunnel dead until its restart is seen working
+	Status string
+	cmd    *exec.Cmd
+	// path and args start the proxytunnel process again after it dies
+	path string
+	args []string
 }
 
+// Tunnel states reported in ProxyTunnel.Status
+const (
+	TunnelStatusActive       = "active"
+	TunnelStatusReconnecting = "reconnecting"
+)
+
+// tunnelProbeTimeout bounds the TCP dial a health check makes
+const tunnelProbeTimeout = 2 * time.Second
+
 // ProxyConfig holds proxy server configuration
 type ProxyConfig struct {
 	Server string
@@ -137,12 +154,84 @@ func (s *ProxyTunnelService) Start(publicID string, remotePort int, localPort in
 		LocalPort:  localPort,
 		RemotePort: remotePort,
 		PublicID:   publicID,
+		Status:     TunnelStatusActive,
 		cmd:        cmd,
+		path:       proxytunnelPath,
+		args:       args,
 	}
 
 	return tunnelID, localPort, nil
 }
 
+// HealthCheck reports whether a tunnel still accepts connections on its
+// local port. A tunnel that does not is restarted on the same port and marked
+// TunnelStatusReconnecting until a later check succeeds; the error is set
+// only if it could not be restarted.
+func (s *ProxyTunnelService) HealthCheck(tunnelID string) (bool, error) {
+	s.tunnelsMu.Lock()
+	tunnel, exists := s.tunnels[tunnelID]
+	if !exists {
+		s.tunnelsMu.Unlock()
+		return false, fmt.Errorf("tunnel %s not found", tunnelID)
+	}
+	localPort := tunnel.LocalPort
+	s.tunnelsMu.Unlock()
+
+	// Dial without holding the lock so a slow probe does not block Stop
+	healthy := probeTunnel(localPort)
+
+	s.tunnelsMu.Lock()
+	defer s.tunnelsMu.Unlock()
+	// The tunnel may have been stopped while it was probed
+	if s.tunnels[tunnelID] != tunnel {
+		return false, fmt.Errorf("tunnel %s not found", tunnelID)
+	}
+	if healthy {
+		tunnel.Status = TunnelStatusActive
+		return true, nil
+	}
+
+	tunnel.Status = TunnelStatusReconnecting
+	if tunnel.cmd != nil && tunnel.cmd.Process != nil {
+		_ = tunnel.cmd.Process.Kill()
+		_ = tunnel.cmd.Wait()
+	}
+	cmd := exec.Command(tunnel.path, tunnel.args...)
+	if err := cmd.Start(); err != nil {
+		tunnel.cmd = nil
+		return false, fmt.Errorf("failed to restart proxytunnel: %w", err)
+	}
+	tunnel.cmd = cmd
+	return false, nil
+}
+
+// Monitor health-checks every tunnel once per interval until ctx is done,
+// restarting the ones that died
+func (s *ProxyTunnelService) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, tunnel := range s.List() {
+			_, _ = s.HealthCheck(tunnel.ID)
+		}
+	}
+}
+
+// probeTunnel reports whether something accepts connections on localPort
+func probeTunnel(localPort int) bool {
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", localPort), tunnelProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
 // Stop stops a proxytunnel connection
 func (s *ProxyTunnelService) Stop(tunnelID string) error {
 	s.tunnelsMu.Lock()
@@ -183,14 +272,16 @@ func (s *ProxyTunnelService) StopAll() {
 	s.tunnels = make(map[string]*ProxyTunnel)
 }
 
-// List returns all active tunnels
+// List returns all active tunnels. The tunnels are copies, so their Status
+// does not change while they are read.
 func (s *ProxyTunnelService) List() []*ProxyTunnel {
 	s.tunnelsMu.Lock()
 	defer s.tunnelsMu.Unlock()
 
 	result := make([]*ProxyTunnel, 0, len(s.tunnels))
 	for _, tunnel := range s.tunnels {
-		result = append(result, tunnel)
+		t := *tunnel
+		result = append(result, &t)
 	}
 	return result
 }
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"net"
+	"os/exec"
+	"testing"
+)
+
+func TestProxyTunnelHealthCheck(t *testing.T) {
+	truePath, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("true not found")
+	}
+	svc := NewProxyTunnelService(&testClient{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	livePort := listener.Addr().(*net.TCPAddr).Port
+
+	deadPort, err := findFreePort()
+	if err != nil {
+		t.Fatalf("findFreePort: %v", err)
+	}
+
+	svc.tunnels["live"] = &ProxyTunnel{ID: "live", LocalPort: livePort, Status: TunnelStatusReconnecting, path: truePath}
+	svc.tunnels["dead"] = &ProxyTunnel{ID: "dead", LocalPort: deadPort, Status: TunnelStatusActive, path: truePath}
+
+	healthy, err := svc.HealthCheck("live")
+	if !healthy || err != nil {
+		t.Fatalf("live tunnel: healthy=%t err=%v", healthy, err)
+	}
+	if status := svc.tunnels["live"].Status; status != TunnelStatusActive {
+		t.Errorf("live tunnel status = %q, want %q", status, TunnelStatusActive)
+	}
+
+	healthy, err = svc.HealthCheck("dead")
+	if healthy || err != nil {
+		t.Fatalf("dead tunnel: healthy=%t err=%v", healthy, err)
+	}
+	dead := svc.tunnels["dead"]
+	if dead.Status != TunnelStatusReconnecting {
+		t.Errorf("dead tunnel status = %q, want %q", dead.Status, TunnelStatusReconnecting)
+	}
+	if dead.cmd == nil || dead.cmd.Process == nil {
+		t.Fatal("expected the dead tunnel to be restarted")
+	}
+	dead.cmd.Wait()
+
+	if _, err := svc.HealthCheck("missing"); err == nil {
+		t.Error("expected an error for an unknown tunnel")
+	}
+}
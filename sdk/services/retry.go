@@ -0,0 +1,20 @@
+package services
+
+import "context"
+
+// retrySafeKey marks a request context as safe to retry
+type retrySafeKey struct{}
+
+// WithRetrySafe marks requests made with ctx as safe to send more than once,
+// so the client retries them on transient failures even when the method is
+// not idempotent (such as a POST). Operations that create something, like
+// CreateSnapshot, are only retried when the caller opts in this way.
+func WithRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// IsRetrySafe reports whether ctx was marked by WithRetrySafe
+func IsRetrySafe(ctx context.Context) bool {
+	safe, _ := ctx.Value(retrySafeKey{}).(bool)
+	return safe
+}
@@ -8,14 +8,18 @@
 package services
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"plato-sdk/models"
@@ -32,18 +36,75 @@ type ClientInterface interface {
 
 type SandboxService struct {
 	client ClientInterface
+	// batchHeartbeatUnsupported is set once the API rejects POST
+	// /env/heartbeat, after which SendHeartbeatBatch goes straight to the
+	// per-VM endpoint
+	batchHeartbeatUnsupported atomic.Bool
+	// sseIdleTimeout is how long the monitors wait for any line before
+	// treating an event stream as stalled
+	sseIdleTimeout time.Duration
+	// maxReconnects and reconnectDelay bound how the monitors re-open an
+	// event stream that ends before the operation finishes
+	maxReconnects  int
+	reconnectDelay time.Duration
 }
 
 func NewSandboxService(client ClientInterface) *SandboxService {
+	return NewSandboxServiceWithIdleTimeout(client, DefaultSSEIdleTimeout)
+}
+
+// NewSandboxServiceWithIdleTimeout returns a SandboxService whose event
+// monitors give up on a silent stream after idle. Zero or negative uses
+// DefaultSSEIdleTimeout.
+func NewSandboxServiceWithIdleTimeout(client ClientInterface, idle time.Duration) *SandboxService {
+	if idle <= 0 {
+		idle = DefaultSSEIdleTimeout
+	}
 	return &SandboxService{
-		client: client,
+		client:         client,
+		sseIdleTimeout: idle,
+		maxReconnects:  DefaultStreamReconnects,
+		reconnectDelay: DefaultStreamReconnectDelay,
+	}
+}
+
+// SetStreamReconnects sets how many times the monitors re-open an event
+// stream that ends before the operation finishes, and how long they wait
+// before each attempt. A max of 0 disables reconnecting.
+func (s *SandboxService) SetStreamReconnects(max int, delay time.Duration) {
+	if max < 0 {
+		max = 0
 	}
+	s.maxReconnects = max
+	s.reconnectDelay = delay
 }
 
-// Create creates a new sandbox from a full SimConfigDataset configuration
+// Create creates a new sandbox from a full SimConfigDataset configuration.
+// It is kept for existing callers; new code should use CreateWithOptions.
+// A retried create can start a second VM, so transient failures are only
+// retried when ctx is marked with WithRetrySafe.
 func (s *SandboxService) Create(ctx context.Context, config *models.SimConfigDataset, dataset, alias string, artifactID *string, service string, timeout *int) (*models.Sandbox, error) {
+	return s.CreateWithOptions(ctx, &models.CreateSandboxOptions{
+		Config:     config,
+		Dataset:    dataset,
+		Alias:      alias,
+		Service:    service,
+		ArtifactID: artifactID,
+		Timeout:    timeout,
+	})
+}
+
+// CreateWithOptions creates a new sandbox described by opts
+func (s *SandboxService) CreateWithOptions(ctx context.Context, opts *models.CreateSandboxOptions) (*models.Sandbox, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("sandbox options are required")
+	}
+	if err := opts.Config.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Marshal config to JSON
-	configJSON, err := json.Marshal(config)
+	configJSON, err := json.Marshal(opts.Config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -55,23 +116,23 @@ func (s *SandboxService) Create(ctx context.Context, config *models.SimConfigDat
 	}
 
 	payload := map[string]interface{}{
-		"dataset":              dataset,
+		"dataset":              opts.Dataset,
 		"plato_dataset_config": configMap,
 		"wait_time":            600,
-		"alias":                alias,
+		"alias":                opts.Alias,
 	}
 
 	// Only include timeout if provided, otherwise server will use default
-	if timeout != nil {
-		payload["sandbox_timeout"] = *timeout
+	if opts.Timeout != nil {
+		payload["sandbox_timeout"] = *opts.Timeout
 	}
 
-	if artifactID != nil {
-		payload["artifact_id"] = *artifactID
+	if opts.ArtifactID != nil {
+		payload["artifact_id"] = *opts.ArtifactID
 	}
 
-	if service != "" {
-		payload["service"] = service
+	if opts.Service != "" {
+		payload["service"] = opts.Service
 	}
 
 	body, err := json.Marshal(payload)
@@ -102,29 +163,7 @@ func (s *SandboxService) Create(ctx context.Context, config *models.SimConfigDat
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-
-		// Try to parse as JSON error response
-		var errResp struct {
-			Error   string `json:"error"`
-			Message string `json:"message"`
-			Detail  string `json:"detail"`
-		}
-		if err := json.Unmarshal(bodyBytes, &errResp); err == nil {
-			// Use structured error message if available
-			if errResp.Error != "" {
-				return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Error)
-			}
-			if errResp.Message != "" {
-				return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Message)
-			}
-			if errResp.Detail != "" {
-				return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, errResp.Detail)
-			}
-		}
-
-		// Fallback to raw body
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, ParseAPIError(resp)
 	}
 
 	var createResp struct {
@@ -133,11 +172,18 @@ func (s *SandboxService) Create(ctx context.Context, config *models.SimConfigDat
 		JobGroupID    string `json:"job_group_id"`
 		Status        string `json:"status"`
 		CorrelationID string `json:"correlation_id"`
+		Alias         string `json:"alias"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&createResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// Prefer the alias the server settled on, otherwise keep the one we asked for
+	alias := opts.Alias
+	if createResp.Alias != "" {
+		alias = createResp.Alias
+	}
+
 	// Map to Sandbox model
 	sandbox := &models.Sandbox{
 		PublicId:      createResp.PublicID,
@@ -145,186 +191,199 @@ func (s *SandboxService) Create(ctx context.Context, config *models.SimConfigDat
 		Url:           createResp.URL,
 		Status:        createResp.Status,
 		CorrelationId: createResp.CorrelationID,
+		Alias:         alias,
 	}
 
 	return sandbox, nil
 }
 
-// MonitorOperationWithEvents monitors an SSE stream and sends event details to a channel
+// MonitorOperationWithEvents monitors an SSE stream and sends event details to a channel.
+// If the stream drops before the operation finishes it is re-opened, and a
+// notice is sent on eventChan for each reconnect.
 func (s *SandboxService) MonitorOperationWithEvents(ctx context.Context, correlationID string, timeout time.Duration, eventChan chan<- string) error {
-	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/public-build/events/%s", correlationID), nil)
-	if err != nil {
-		return fmt.Errorf("failed to create SSE request: %w", err)
-	}
-
 	// Set timeout on context
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	req = req.WithContext(ctx)
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("SSE request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("SSE connection failed (%d): %s", resp.StatusCode, string(bodyBytes))
+	onReconnect := func(attempt int, err error) {
+		eventChan <- fmt.Sprintf("⚠️  Lost connection to operation events (%v), reconnecting (%d/%d)...", err, attempt, s.maxReconnects)
 	}
 
 	// Read SSE stream
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
+	err := s.followEvents(ctx, correlationID, timeout, onReconnect, func(line string) (bool, error) {
+		// Keepalive comments only show the operation is still running
+		if isSSEComment(line) {
+			eventChan <- KeepaliveEvent
+			return false, nil
+		}
 
 		// SSE format: "data: <json>"
-		if strings.HasPrefix(line, "data: ") {
-			jsonData := strings.TrimPrefix(line, "data: ")
-
-			// Parse JSON
-			var event struct {
-				Type    string `json:"type"`
-				Success bool   `json:"success"`
-				Error   string `json:"error"`
-				Message string `json:"message"`
-			}
-			if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
-				eventChan <- fmt.Sprintf("[DEBUG] Failed to parse JSON: %v, data: %s", err, jsonData)
-				continue // Skip malformed JSON
-			}
+		if !strings.HasPrefix(line, "data: ") {
+			return false, nil
+		}
+		jsonData := strings.TrimPrefix(line, "data: ")
 
-			eventChan <- fmt.Sprintf("[DEBUG] Received event - Type: %s, Success: %v, Message: %s", event.Type, event.Success, event.Message)
+		// Parse JSON
+		var event sseEvent
+		if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
+			eventChan <- fmt.Sprintf("[DEBUG] Failed to parse JSON: %v, data: %s", err, jsonData)
+			return false, nil // Skip malformed JSON
+		}
 
-			// Send event message to channel if available
-			// Send both message and type information
-			if event.Message != "" {
-				eventChan <- event.Message
-			} else if event.Type != "" && event.Type != "connected" {
-				// If no message but we have a type, send that
-				eventChan <- fmt.Sprintf("[%s]", event.Type)
-			}
+		eventChan <- fmt.Sprintf("[DEBUG] Received event - Type: %s, Success: %v, Message: %s", event.Type, event.Success, event.Message)
 
-			// Handle different event types
-			switch event.Type {
-			case "connected":
-				// Initial connection, continue listening
-				eventChan <- "[DEBUG] SSE connected"
-				continue
-			case "error":
-				// Error event
-				eventChan <- fmt.Sprintf("[DEBUG] Error event: %s", event.Error)
-				errorMsg := event.Error
-				if errorMsg == "" {
-					errorMsg = event.Message
-				}
-				return fmt.Errorf("operation error: %s", errorMsg)
-			default:
-				// Handle all other event types by checking success field
-				eventChan <- fmt.Sprintf("[DEBUG] Event type=%s, success=%v", event.Type, event.Success)
-				if event.Success {
-					return nil // Success!
-				}
-				// Operation failed
-				errorMsg := event.Error
-				if errorMsg == "" {
-					errorMsg = event.Message
-				}
-				if errorMsg == "" {
-					errorMsg = "Operation failed"
-				}
-				return fmt.Errorf("operation failed: %s", errorMsg)
+		// Send event message to channel if available
+		// Send both message and type information
+		if event.Message != "" {
+			eventChan <- event.Message
+		} else if event.Type != "" && event.Type != "connected" {
+			// If no message but we have a type, send that
+			eventChan <- fmt.Sprintf("[%s]", event.Type)
+		}
+
+		// Handle different event types
+		switch event.Type {
+		case "connected":
+			// Initial connection, continue listening
+			eventChan <- "[DEBUG] SSE connected"
+			return false, nil
+		case "error":
+			// Error event
+			eventChan <- fmt.Sprintf("[DEBUG] Error event: %s", event.Error)
+			return true, fmt.Errorf("operation error: %s", event.failureMessage(""))
+		default:
+			// Handle all other event types by checking success field
+			eventChan <- fmt.Sprintf("[DEBUG] Event type=%s, success=%v", event.Type, event.Success)
+			if event.Success {
+				return true, nil // Success!
 			}
+			// Operation failed
+			return true, fmt.Errorf("operation failed: %s", event.failureMessage("Operation failed"))
 		}
-	}
+	})
 
-	if err := scanner.Err(); err != nil {
-		eventChan <- fmt.Sprintf("[DEBUG] Scanner error: %v", err)
-		return fmt.Errorf("error reading SSE stream: %w", err)
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		eventChan <- fmt.Sprintf("[DEBUG] %v", err)
 	}
+	return err
+}
+
+// StreamRawEvents follows an operation's SSE stream and passes every line to
+// onLine exactly as received. It returns when the operation finishes, with the
+// operation's error if it failed, or when the stream breaks for good or
+// timeout elapses. Lines replayed after a reconnect are not passed again.
+func (s *SandboxService) StreamRawEvents(ctx context.Context, correlationID string, timeout time.Duration, onLine func(line string)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	eventChan <- "[DEBUG] SSE stream ended without receiving completion event"
-	return fmt.Errorf("SSE stream ended without completion")
+	return s.followEvents(ctx, correlationID, timeout, nil, func(line string) (bool, error) {
+		onLine(line)
+
+		if !strings.HasPrefix(line, "data: ") {
+			return false, nil
+		}
+		var event sseEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			return false, nil
+		}
+		return event.outcome()
+	})
 }
 
-// MonitorOperation monitors an SSE stream for operation completion
+// MonitorOperation monitors an SSE stream for operation completion,
+// re-opening the stream if it drops before the operation finishes
 func (s *SandboxService) MonitorOperation(ctx context.Context, correlationID string, timeout time.Duration) error {
-	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/public-build/events/%s", correlationID), nil)
-	if err != nil {
-		return fmt.Errorf("failed to create SSE request: %w", err)
-	}
-
 	// Set timeout on context
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	req = req.WithContext(ctx)
+
+	// Read SSE stream; keepalive comments only reset the idle timer
+	return s.followEvents(ctx, correlationID, timeout, nil, func(line string) (bool, error) {
+		// SSE format: "data: <json>"
+		if !strings.HasPrefix(line, "data: ") {
+			return false, nil
+		}
+		var event sseEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			return false, nil // Skip malformed JSON
+		}
+		return event.outcome()
+	})
+}
+
+// followEvents reads an operation's event stream until handle reports that
+// the operation finished. When the stream ends or breaks first and ctx is
+// still alive, it is re-opened up to s.maxReconnects times, s.reconnectDelay
+// apart, and events the server replays are not passed to handle again. A
+// stalled stream is re-opened too; ErrStreamStalled is only returned once
+// the reconnects are used up.
+func (s *SandboxService) followEvents(ctx context.Context, correlationID string, timeout time.Duration, onReconnect func(attempt int, err error), handle func(line string) (bool, error)) error {
+	dedup := &eventDedup{seenIDs: make(map[string]bool)}
+	for reconnects := 0; ; reconnects++ {
+		err := s.readEvents(ctx, correlationID, timeout, dedup, handle)
+		var streamErr *StreamError
+		if err == nil || !errors.As(err, &streamErr) || reconnects >= s.maxReconnects || ctx.Err() != nil {
+			return err
+		}
+
+		if onReconnect != nil {
+			onReconnect(reconnects+1, err)
+		}
+		select {
+		case <-time.After(s.reconnectDelay):
+		case <-ctx.Done():
+			return streamFailure(ctx, timeout, err)
+		}
+		dedup.reconnected = true
+		dedup.connData = 0
+	}
+}
+
+// readEvents opens an operation's event stream once and reads it until handle
+// reports the operation finished, returning handle's error, or until the
+// stream breaks, returning a *StreamError or *TimeoutError
+func (s *SandboxService) readEvents(ctx context.Context, correlationID string, timeout time.Duration, dedup *eventDedup, handle func(line string) (bool, error)) error {
+	// abort unblocks the read if the stream stalls
+	streamCtx, abort := context.WithCancel(WithStreaming(ctx))
+	defer abort()
+
+	req, err := s.client.NewRequest(streamCtx, "GET", fmt.Sprintf("/public-build/events/%s", correlationID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create SSE request: %w", err)
+	}
+	if dedup.lastID != "" {
+		req.Header.Set("Last-Event-ID", dedup.lastID)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("SSE request failed: %w", err)
+		return streamFailure(ctx, timeout, fmt.Errorf("SSE request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("SSE connection failed (%d): %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("SSE connection failed: %w", ParseAPIError(resp))
 	}
 
-	// Read SSE stream
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// SSE format: "data: <json>"
-		if strings.HasPrefix(line, "data: ") {
-			jsonData := strings.TrimPrefix(line, "data: ")
-
-			// Parse JSON
-			var event struct {
-				Type    string `json:"type"`
-				Success bool   `json:"success"`
-				Error   string `json:"error"`
-				Message string `json:"message"`
-			}
-			if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
-				continue // Skip malformed JSON
-			}
+	body, err := sseBody(resp)
+	if err != nil {
+		return err
+	}
 
-			// Handle different event types
-			switch event.Type {
-			case "connected":
-				// Initial connection, continue listening
-				continue
-			case "error":
-				// Error event
-				errorMsg := event.Error
-				if errorMsg == "" {
-					errorMsg = event.Message
-				}
-				return fmt.Errorf("operation error: %s", errorMsg)
-			default:
-				// Handle all other event types by checking success field
-				if event.Success {
-					return nil // Success!
-				}
-				// Operation failed
-				errorMsg := event.Error
-				if errorMsg == "" {
-					errorMsg = event.Message
-				}
-				if errorMsg == "" {
-					errorMsg = "Operation failed"
-				}
-				return fmt.Errorf("operation failed: %s", errorMsg)
-			}
+	done, err := readSSE(body, s.sseIdleTimeout, abort, func(line string) (bool, error) {
+		if dedup.replayed(line) {
+			return false, nil
 		}
+		return handle(line)
+	})
+	if done || errors.Is(err, ErrStreamStalled) {
+		return err
 	}
-
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading SSE stream: %w", err)
+	if err != nil {
+		return streamFailure(ctx, timeout, fmt.Errorf("error reading SSE stream: %w", err))
 	}
-
-	return fmt.Errorf("SSE stream ended without completion")
+	return &StreamError{Err: fmt.Errorf("SSE stream ended without completion")}
 }
 
 // SetupSandbox sets up a sandbox with optional SSH public key for plato user
@@ -368,41 +427,7 @@ func (s *SandboxService) SetupSandbox(ctx context.Context, jobID string, config
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-
-		// Try to parse as JSON error response
-		var errResp struct {
-			Error   string `json:"error"`
-			Message string `json:"message"`
-			Detail  []struct {
-				Msg string        `json:"msg"`
-				Loc []interface{} `json:"loc"`
-			} `json:"detail"`
-		}
-		if err := json.Unmarshal(bodyBytes, &errResp); err == nil {
-			// Use structured error message if available
-			if errResp.Error != "" {
-				return "", fmt.Errorf("%s", errResp.Error)
-			}
-			if errResp.Message != "" {
-				return "", fmt.Errorf("%s", errResp.Message)
-			}
-			if len(errResp.Detail) > 0 {
-				// Format validation errors nicely
-				msg := errResp.Detail[0].Msg
-				if len(errResp.Detail[0].Loc) > 0 {
-					field := fmt.Sprintf("%v", errResp.Detail[0].Loc[len(errResp.Detail[0].Loc)-1])
-					return "", fmt.Errorf("%s: %s", field, msg)
-				}
-				return "", fmt.Errorf("%s", msg)
-			}
-		}
-
-		// Fallback to status code only if body is too long
-		if len(bodyBytes) > 100 {
-			return "", fmt.Errorf("API error %d", resp.StatusCode)
-		}
-		return "", fmt.Errorf("%s", string(bodyBytes))
+		return "", parseSandboxError(resp)
 	}
 
 	// Parse the response to get correlation_id
@@ -419,7 +444,7 @@ func (s *SandboxService) SetupSandbox(ctx context.Context, jobID string, config
 
 // SendHeartbeat sends a heartbeat to keep the VM alive
 func (s *SandboxService) SendHeartbeat(ctx context.Context, jobGroupID string) error {
-	req, err := s.client.NewRequest(ctx, "POST", fmt.Sprintf("/env/%s/heartbeat", jobGroupID), nil)
+	req, err := s.client.NewRequest(WithRetrySafe(ctx), "POST", fmt.Sprintf("/env/%s/heartbeat", jobGroupID), nil)
 	if err != nil {
 		return err
 	}
@@ -431,13 +456,98 @@ func (s *SandboxService) SendHeartbeat(ctx context.Context, jobGroupID string) e
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("heartbeat failed (%d): %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("heartbeat failed: %w", parseSandboxError(resp))
 	}
 
 	return nil
 }
 
+// HeartbeatBatchError is returned by SendHeartbeatBatch when it sent the
+// heartbeats individually and some of them failed. Failed maps each VM whose
+// heartbeat failed to its error; the other VMs were kept alive.
+type HeartbeatBatchError struct {
+	Failed map[string]error
+}
+
+func (e *HeartbeatBatchError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	lines := make([]string, len(ids))
+	for i, id := range ids {
+		lines[i] = fmt.Sprintf("%s: %v", id, e.Failed[id])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *HeartbeatBatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// SendHeartbeatBatch keeps several VMs alive with one request instead of one
+// per VM. The batch endpoint is not in sdk/openapi/openapi.json yet: if the
+// API does not offer it (404 or 405), that is remembered and heartbeats are
+// sent individually; failures are then reported per VM in a
+// *HeartbeatBatchError.
+func (s *SandboxService) SendHeartbeatBatch(ctx context.Context, jobGroupIDs []string) error {
+	if len(jobGroupIDs) == 0 {
+		return nil
+	}
+
+	if !s.batchHeartbeatUnsupported.Load() {
+		supported, err := s.sendHeartbeatBatch(ctx, jobGroupIDs)
+		if supported {
+			return err
+		}
+		s.batchHeartbeatUnsupported.Store(true)
+	}
+
+	failed := make(map[string]error)
+	for _, jobGroupID := range jobGroupIDs {
+		if err := s.SendHeartbeat(ctx, jobGroupID); err != nil {
+			failed[jobGroupID] = err
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &HeartbeatBatchError{Failed: failed}
+}
+
+// sendHeartbeatBatch posts to the batch heartbeat endpoint. supported is
+// false when the API does not know the endpoint.
+func (s *SandboxService) sendHeartbeatBatch(ctx context.Context, jobGroupIDs []string) (supported bool, err error) {
+	body, err := json.Marshal(models.HeartbeatBatchRequest{JobGroupIDs: jobGroupIDs})
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := s.client.NewRequest(WithRetrySafe(ctx), "POST", "/env/heartbeat", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("heartbeat request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return true, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return false, nil
+	}
+	return true, fmt.Errorf("heartbeat failed: %w", ParseAPIError(resp))
+}
+
 // Get retrieves a sandbox by job ID
 func (s *SandboxService) Get(ctx context.Context, jobID string) (*models.Sandbox, error) {
 	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/sandboxes/%s", jobID), nil)
@@ -452,7 +562,7 @@ func (s *SandboxService) Get(ctx context.Context, jobID string) (*models.Sandbox
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, parseSandboxError(resp)
 	}
 
 	var sandbox models.Sandbox
@@ -477,7 +587,7 @@ func (s *SandboxService) Delete(ctx context.Context, jobID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return parseSandboxError(resp)
 	}
 
 	return nil
@@ -497,8 +607,7 @@ func (s *SandboxService) DeleteVM(ctx context.Context, publicID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete VM (%d): %s", resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("failed to delete VM: %w", parseSandboxError(resp))
 	}
 
 	return nil
@@ -518,7 +627,7 @@ func (s *SandboxService) List(ctx context.Context) ([]*models.Sandbox, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, ParseAPIError(resp)
 	}
 
 	var sandboxes []*models.Sandbox
@@ -529,8 +638,45 @@ func (s *SandboxService) List(ctx context.Context) ([]*models.Sandbox, error) {
 	return sandboxes, nil
 }
 
+// ResolvePublicID normalizes a user-supplied public ID with
+// utils.NormalizePublicID. A pasted sandbox URL names the sandbox by its
+// alias, job group ID or public ID, so it is looked up among the caller's
+// sandboxes and resolved to the public ID. A URL that matches none of them
+// returns an error matching ErrSandboxNotFound.
+func (s *SandboxService) ResolvePublicID(ctx context.Context, input string) (string, error) {
+	id, err := utils.NormalizePublicID(input)
+	if err != nil || !utils.IsSandboxURL(input) {
+		return id, err
+	}
+
+	sandboxes, err := s.List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve sandbox URL %q, pass the public ID instead: %w", input, err)
+	}
+	for _, sandbox := range sandboxes {
+		if sandbox.PublicId == id {
+			return id, nil
+		}
+	}
+	for _, sandbox := range sandboxes {
+		if sandbox.Alias == id || sandbox.JobGroupId == id {
+			return sandbox.PublicId, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q in sandbox URL %q is not the public ID or alias of one of your sandboxes; pass the public ID instead", ErrSandboxNotFound, id, input)
+}
+
 // SetupRootPassword sets up root SSH access using a public key
 func (s *SandboxService) SetupRootPassword(ctx context.Context, publicID, sshPublicKey string) error {
+	_, err := s.SetupRootAccess(ctx, publicID, sshPublicKey)
+	return err
+}
+
+// SetupRootAccess requests root SSH access for the given public key.
+// The server may finish the setup asynchronously; when it does it returns a
+// correlation ID that can be passed to MonitorOperation. An empty ID means the
+// server did not report one and callers should confirm access themselves.
+func (s *SandboxService) SetupRootAccess(ctx context.Context, publicID, sshPublicKey string) (string, error) {
 	payload := map[string]interface{}{
 		"ssh_public_key": sshPublicKey,
 		"timeout":        60,
@@ -538,26 +684,33 @@ func (s *SandboxService) SetupRootPassword(ctx context.Context, publicID, sshPub
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := s.client.NewRequest(ctx, "POST", fmt.Sprintf("/public-build/vm/%s/setup-root-access", publicID), bytes.NewReader(body))
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return "", parseSandboxError(resp)
 	}
 
-	return nil
+	var setupResp struct {
+		CorrelationID string `json:"correlation_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&setupResp); err != nil {
+		// Older servers return an empty or non-JSON body
+		return "", nil
+	}
+
+	return setupResp.CorrelationID, nil
 }
 
 // CreateSnapshot creates a snapshot of a VM
@@ -579,8 +732,7 @@ func (s *SandboxService) CreateSnapshot(ctx context.Context, publicID string, re
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, parseSandboxError(resp)
 	}
 
 	var snapshotResp models.CreateSnapshotResponse
@@ -591,6 +743,72 @@ func (s *SandboxService) CreateSnapshot(ctx context.Context, publicID string, re
 	return &snapshotResp, nil
 }
 
+// GetSnapshot returns the processing status of a snapshot artifact. The
+// endpoint is not in sdk/openapi/openapi.json yet; an API without it returns
+// an error matching ErrEndpointUnsupported.
+func (s *SandboxService) GetSnapshot(ctx context.Context, artifactID string) (*models.SnapshotStatus, error) {
+	httpReq, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/public-build/snapshots/%s", artifactID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, markUnsupported(ParseAPIError(resp))
+	}
+
+	var status models.SnapshotStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &status, nil
+}
+
+// WaitForSnapshot polls GetSnapshot until the artifact is available, fails, or ctx is done.
+// The last observed status is returned alongside any error. pollInterval must
+// be positive.
+func (s *SandboxService) WaitForSnapshot(ctx context.Context, artifactID string, pollInterval time.Duration) (*models.SnapshotStatus, error) {
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %s", pollInterval)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last *models.SnapshotStatus
+	for {
+		status, err := s.GetSnapshot(ctx, artifactID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, fmt.Errorf("timed out waiting for snapshot %s: %w", artifactID, ctx.Err())
+			}
+			return last, err
+		}
+		last = status
+
+		switch status.Status {
+		case models.SnapshotStatusAvailable:
+			return status, nil
+		case models.SnapshotStatusFailed:
+			if status.Error != "" {
+				return status, fmt.Errorf("snapshot %s failed: %s", artifactID, status.Error)
+			}
+			return status, fmt.Errorf("snapshot %s failed", artifactID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, fmt.Errorf("timed out waiting for snapshot %s (last status: %s): %w", artifactID, last.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
 // CreateCheckpoint creates a checkpoint of a VM
 func (s *SandboxService) CreateCheckpoint(ctx context.Context, publicID string, req *models.CreateSnapshotRequest) (*models.CreateSnapshotResponse, error) {
 	// Prefix dataset with "ckpt-" for checkpoints
@@ -615,8 +833,7 @@ func (s *SandboxService) CreateCheckpoint(ctx context.Context, publicID string,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, parseSandboxError(resp)
 	}
 
 	var checkpointResp models.CreateSnapshotResponse
@@ -627,6 +844,42 @@ func (s *SandboxService) CreateCheckpoint(ctx context.Context, publicID string,
 	return &checkpointResp, nil
 }
 
+// ExtendTimeout adds additionalSeconds to the lifetime of a running VM and
+// returns its new expiry. The endpoint is not in sdk/openapi/openapi.json
+// yet; an API without it returns an error matching ErrEndpointUnsupported.
+func (s *SandboxService) ExtendTimeout(ctx context.Context, publicID string, additionalSeconds int) (*models.ExtendTimeoutResponse, error) {
+	if additionalSeconds <= 0 {
+		return nil, fmt.Errorf("additional seconds must be positive, got %d", additionalSeconds)
+	}
+
+	body, err := json.Marshal(models.ExtendTimeoutRequest{AdditionalSeconds: additionalSeconds})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := s.client.NewRequest(ctx, "POST", fmt.Sprintf("/public-build/vm/%s/extend-timeout", publicID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, markUnsupported(parseSandboxError(resp))
+	}
+
+	var extendResp models.ExtendTimeoutResponse
+	if err := json.NewDecoder(resp.Body).Decode(&extendResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &extendResp, nil
+}
+
 // StartWorker starts the Plato worker and listeners on a VM
 func (s *SandboxService) StartWorker(ctx context.Context, publicID string, req *models.StartWorkerRequest) (*models.StartWorkerResponse, error) {
 	body, err := json.Marshal(req)
@@ -634,7 +887,8 @@ func (s *SandboxService) StartWorker(ctx context.Context, publicID string, req *
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := s.client.NewRequest(ctx, "POST", fmt.Sprintf("/public-build/vm/%s/start-worker", publicID), bytes.NewReader(body))
+	// Starting the worker again only restarts it, so transient failures are retried
+	httpReq, err := s.client.NewRequest(WithRetrySafe(ctx), "POST", fmt.Sprintf("/public-build/vm/%s/start-worker", publicID), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -646,8 +900,7 @@ func (s *SandboxService) StartWorker(ctx context.Context, publicID string, req *
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, parseSandboxError(resp)
 	}
 
 	var workerResp models.StartWorkerResponse
@@ -658,6 +911,143 @@ func (s *SandboxService) StartWorker(ctx context.Context, publicID string, req *
 	return &workerResp, nil
 }
 
+// GetWorkerLogs fetches the recent output of the Plato worker on a VM, which
+// explains most worker startup failures. The endpoint is not in
+// sdk/openapi/openapi.json yet; an API without it returns an error matching
+// ErrEndpointUnsupported.
+func (s *SandboxService) GetWorkerLogs(ctx context.Context, publicID string) (*models.WorkerLogs, error) {
+	req, err := s.client.NewRequest(ctx, "GET", fmt.Sprintf("/public-build/vm/%s/worker-logs", publicID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, markUnsupported(parseSandboxError(resp))
+	}
+
+	var logs models.WorkerLogs
+	if err := json.NewDecoder(resp.Body).Decode(&logs); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &logs, nil
+}
+
+// ErrLogStreamUnsupported is returned by StreamLogs when the API has no log
+// streaming endpoint, which is not in sdk/openapi/openapi.json yet; the logs
+// can still be tailed over SSH
+var ErrLogStreamUnsupported = errors.New("the API does not stream VM logs")
+
+// StreamLogs tails the container logs of a VM, sending each line on the
+// returned channel until ctx is cancelled or the stream ends or breaks, when
+// the channel is closed. service limits the logs to one docker compose
+// service; an empty service tails every container. The server may answer with
+// SSE or with a plain chunked stream of lines.
+func (s *SandboxService) StreamLogs(ctx context.Context, publicID, service string) (<-chan string, error) {
+	path := fmt.Sprintf("/public-build/vm/%s/logs?follow=true", publicID)
+	if service != "" {
+		path += "&service=" + url.QueryEscape(service)
+	}
+	req, err := s.client.NewRequest(WithStreaming(ctx), "GET", path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log stream request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream, text/plain")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("log stream request failed: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w (HTTP %d)", ErrLogStreamUnsupported, resp.StatusCode)
+	default:
+		apiErr := parseSandboxError(resp)
+		resp.Body.Close()
+		return nil, fmt.Errorf("log stream failed: %w", apiErr)
+	}
+
+	body, err := sseBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	sse := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer resp.Body.Close()
+
+		scanner := newSSEScanner(body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if sse {
+				var ok bool
+				if line, ok = parseLogEvent(line); !ok {
+					continue
+				}
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
+
+// parseLogEvent returns the log line carried by one SSE line. The payload is
+// either the raw line or JSON with the line in "line", "message" or "log".
+func parseLogEvent(line string) (string, bool) {
+	data, ok := strings.CutPrefix(line, "data:")
+	if !ok || isSSEComment(line) {
+		return "", false
+	}
+	data = strings.TrimPrefix(data, " ")
+	var payload struct {
+		Type    string  `json:"type"`
+		Line    *string `json:"line"`
+		Message *string `json:"message"`
+		Log     *string `json:"log"`
+	}
+	if !strings.HasPrefix(data, "{") || json.Unmarshal([]byte(data), &payload) != nil {
+		return data, true
+	}
+	for _, text := range []*string{payload.Line, payload.Log, payload.Message} {
+		if text != nil {
+			return strings.TrimRight(*text, "\n"), true
+		}
+	}
+	if payload.Type == "connected" {
+		return "", false
+	}
+	return data, true
+}
+
+// StartWorkerAndWait starts the Plato worker and blocks until the worker
+// setup operation completes or timeout elapses
+func (s *SandboxService) StartWorkerAndWait(ctx context.Context, publicID string, req *models.StartWorkerRequest, timeout time.Duration) (*models.StartWorkerResponse, error) {
+	resp, err := s.StartWorker(ctx, publicID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.MonitorOperation(ctx, resp.CorrelationId, timeout); err != nil {
+		return resp, fmt.Errorf("worker setup failed: %w", err)
+	}
+	return resp, nil
+}
+
 // CreateSnapshotWithGit creates a snapshot with automatic git push and merge workflow
 // If sourceDir is provided, it will:
 // 1. Push code to Gitea on a timestamped branch
@@ -713,7 +1103,7 @@ func (s *SandboxService) clearEnvState(ctx context.Context, jobGroupID string) e
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("/env/state returned status %d", resp.StatusCode)
+		return fmt.Errorf("/env/state failed: %w", parseSandboxError(resp))
 	}
 
 	return nil
@@ -722,38 +1112,123 @@ func (s *SandboxService) clearEnvState(ctx context.Context, jobGroupID string) e
 // CreateSnapshotWithCleanup creates a snapshot with pre-snapshot database cleanup
 // This performs database cleanup (clears audit_log and env state) before creating the snapshot
 func (s *SandboxService) CreateSnapshotWithCleanup(ctx context.Context, publicID, jobGroupID string, req *models.CreateSnapshotRequest, dbConfig *models.DBConfig) (*models.CreateSnapshotResponse, error) {
+	resp, _, err := s.CreateSnapshotWithCleanupResults(ctx, publicID, jobGroupID, req, dbConfig)
+	return resp, err
+}
+
+// CreateSnapshotWithCleanupResults is CreateSnapshotWithCleanup, also
+// reporting for each database whether its audit_log was cleared and how each
+// cleanup SQL statement went. Both are best-effort: a database that could not
+// be cleaned up is reported in the results but does not stop the snapshot.
+func (s *SandboxService) CreateSnapshotWithCleanupResults(ctx context.Context, publicID, jobGroupID string, req *models.CreateSnapshotRequest, dbConfig *models.DBConfig) (*models.CreateSnapshotResponse, []utils.AuditLogResult, error) {
 	// Step 1: Perform pre-snapshot cleanup if dbConfig is provided
-	if dbConfig != nil {
-		// Convert models.DBConfig to utils.DBConfig
-		utilsDBConfig := utils.DBConfig{
-			DBType:    dbConfig.DBType,
-			User:      dbConfig.User,
-			Password:  dbConfig.Password,
-			DestPort:  dbConfig.DestPort,
-			Databases: dbConfig.Databases,
+	results, err := s.preSnapshotCleanup(ctx, publicID, jobGroupID, dbConfig)
+	if err != nil {
+		return nil, results, err
+	}
+
+	// Step 2: Create the snapshot
+	snapshotCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := s.CreateSnapshot(snapshotCtx, publicID, req)
+	return resp, results, err
+}
+
+// SnapshotResult is the outcome of one request passed to CreateSnapshots
+type SnapshotResult struct {
+	Request  models.CreateSnapshotRequest
+	Response *models.CreateSnapshotResponse
+	Err      error
+}
+
+// CreateSnapshots snapshots the same VM state once per request, e.g. as
+// several datasets. The pre-snapshot cleanup runs once, when dbConfig is
+// given, and the snapshots are then created concurrently. There is one
+// result per request, in order; a snapshot that fails does not affect the
+// others, and the returned error then says how many failed. A cleanup
+// failure returns before any snapshot is created.
+func (s *SandboxService) CreateSnapshots(ctx context.Context, publicID, jobGroupID string, reqs []models.CreateSnapshotRequest, dbConfig *models.DBConfig) ([]SnapshotResult, []utils.AuditLogResult, error) {
+	cleanup, err := s.preSnapshotCleanup(ctx, publicID, jobGroupID, dbConfig)
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	results := make([]SnapshotResult, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		results[i].Request = req
+		wg.Add(1)
+		go func(result *SnapshotResult) {
+			defer wg.Done()
+			snapshotCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			result.Response, result.Err = s.CreateSnapshot(snapshotCtx, publicID, &result.Request)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("dataset %s: %w", result.Request.Dataset, result.Err))
 		}
+	}
+	if len(errs) > 0 {
+		return results, cleanup, fmt.Errorf("%d of %d snapshots failed: %w", len(errs), len(reqs), errors.Join(errs...))
+	}
+	return results, cleanup, nil
+}
 
-		// Open a temporary proxy tunnel using SDK utils
-		tunnelCmd, localPort, err := utils.OpenTemporaryProxytunnel(s.client.GetBaseURL(), publicID, utilsDBConfig.DestPort)
+// preSnapshotCleanup clears the audit log, runs the custom cleanup SQL and
+// clears the env state of a VM before it is snapshotted. It does nothing
+// when dbConfig is nil.
+func (s *SandboxService) preSnapshotCleanup(ctx context.Context, publicID, jobGroupID string, dbConfig *models.DBConfig) ([]utils.AuditLogResult, error) {
+	if dbConfig == nil {
+		return nil, nil
+	}
+
+	// Convert models.DBConfig to utils.DBConfig
+	utilsDBConfig := utils.DBConfig{
+		DBType:        dbConfig.DBType,
+		User:          dbConfig.User,
+		Password:      dbConfig.Password,
+		DestPort:      dbConfig.DestPort,
+		Databases:     dbConfig.Databases,
+		CleanupTables: dbConfig.CleanupTables,
+		CleanupSQL:    dbConfig.CleanupSQL,
+		PasswordEnv:   dbConfig.PasswordEnv,
+	}
+
+	// Open a temporary proxy tunnel using SDK utils; SQLite files are
+	// opened directly
+	localPort := 0
+	if utils.NeedsProxytunnel(utilsDBConfig.DBType) {
+		tunnelCmd, port, err := utils.OpenTemporaryProxytunnel(s.client.GetBaseURL(), publicID, utilsDBConfig.DestPort)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open proxytunnel: %w", err)
 		}
 		defer utils.CloseTemporaryProxytunnel(tunnelCmd)
+		localPort = port
+	}
 
-		// Clear audit log using SDK utils
-		if err := utils.ClearAuditLog(utilsDBConfig, localPort); err != nil {
-			return nil, fmt.Errorf("failed to clear audit log: %w", err)
-		}
+	// Clear audit log using SDK utils; failures are in the results
+	results, _ := utils.ClearAuditLogResults(utilsDBConfig, localPort)
 
-		// Clear env state
-		if err := s.clearEnvState(ctx, jobGroupID); err != nil {
-			return nil, fmt.Errorf("failed to clear env state: %w", err)
+	// Custom cleanup statements are best-effort and must not block the
+	// snapshot; how each went is reported with its database's result
+	statements, _ := utils.RunCleanupSQLResults(utilsDBConfig, localPort)
+	for _, statement := range statements {
+		for i := range results {
+			if results[i].Database == statement.Database {
+				results[i].CleanupSQL = append(results[i].CleanupSQL, statement)
+			}
 		}
 	}
 
-	// Step 2: Create the snapshot
-	snapshotCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	return s.CreateSnapshot(snapshotCtx, publicID, req)
+	// Clear env state
+	if err := s.clearEnvState(ctx, jobGroupID); err != nil {
+		return results, fmt.Errorf("failed to clear env state: %w", err)
+	}
+	return results, nil
 }
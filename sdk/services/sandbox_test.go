@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"plato-sdk/models"
+)
+
+func TestCreateWithOptions_RejectsIncompleteConfig(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	tests := []struct {
+		name   string
+		config *models.SimConfigDataset
+		want   []string
+	}{
+		{"nil config", nil, []string{"sandbox config is required"}},
+		{"empty config", &models.SimConfigDataset{}, []string{"compute.cpus", "compute.memory", "compute.disk", "metadata.name"}},
+		{"missing name", &models.SimConfigDataset{
+			Compute: models.SimConfigCompute{Cpus: 1, Memory: 512, Disk: 10240},
+		}, []string{"metadata.name is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateWithOptions(context.Background(), &models.CreateSandboxOptions{Config: tt.config, Dataset: "base"})
+			if err == nil {
+				t.Fatal("expected a validation error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to mention %q, got %q", want, err)
+				}
+			}
+		})
+	}
+
+	if requests != 0 {
+		t.Errorf("expected no requests for invalid configs, got %d", requests)
+	}
+}
+
+func TestResolvePublicID(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode([]models.Sandbox{
+			{PublicId: "pub123", JobGroupId: "jg123", Alias: "my-app"},
+		})
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	ctx := context.Background()
+
+	for input, want := range map[string]string{
+		" pub123 ":                      "pub123",
+		"https://my-app.sims.plato.so":  "pub123",
+		"https://pub123.sims.plato.so/": "pub123",
+		"https://jg123.sims.plato.so":   "pub123",
+	} {
+		got, err := svc.ResolvePublicID(ctx, input)
+		if err != nil || got != want {
+			t.Errorf("ResolvePublicID(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("expected only URLs to be looked up, got %d requests", requests)
+	}
+
+	if _, err := svc.ResolvePublicID(ctx, "https://other.sims.plato.so"); !errors.Is(err, ErrSandboxNotFound) {
+		t.Errorf("expected an unknown alias to match ErrSandboxNotFound, got %v", err)
+	}
+}
+
+func TestSendHeartbeatBatch(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path != "/env/heartbeat" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		var body models.HeartbeatBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if strings.Join(body.JobGroupIDs, ",") != "a,b,c" {
+			t.Errorf("unexpected job_group_ids %v", body.JobGroupIDs)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	if err := svc.SendHeartbeatBatch(context.Background(), nil); err != nil {
+		t.Fatalf("empty batch: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Fatalf("expected no request for an empty batch, got %v", paths)
+	}
+	if err := svc.SendHeartbeatBatch(context.Background(), []string{"a", "b", "c"}); err != nil {
+		t.Fatalf("SendHeartbeatBatch: %v", err)
+	}
+	if len(paths) != 1 {
+		t.Errorf("expected one batch request, got %v", paths)
+	}
+}
+
+func TestSendHeartbeatBatch_FallsBackToIndividualHeartbeats(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/env/heartbeat":
+			w.WriteHeader(http.StatusNotFound)
+		case "/env/gone/heartbeat":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	err := svc.SendHeartbeatBatch(context.Background(), []string{"a", "gone"})
+	if err == nil || !strings.Contains(err.Error(), "gone: heartbeat failed: API error (410)") {
+		t.Fatalf("expected the failed individual heartbeat in the error, got %v", err)
+	}
+	var batchErr *HeartbeatBatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failed) != 1 || batchErr.Failed["gone"] == nil {
+		t.Fatalf("expected only gone to fail in a *HeartbeatBatchError, got %v", err)
+	}
+	want := "/env/heartbeat,/env/a/heartbeat,/env/gone/heartbeat"
+	if got := strings.Join(paths, ","); got != want {
+		t.Fatalf("requests = %s, want %s", got, want)
+	}
+
+	// The unsupported batch endpoint is not tried again
+	paths = nil
+	if err := svc.SendHeartbeatBatch(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("SendHeartbeatBatch: %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "/env/a/heartbeat" {
+		t.Errorf("requests = %s, want /env/a/heartbeat", got)
+	}
+}
+
+func TestExtendTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/public-build/vm/abc123/extend-timeout" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body models.ExtendTimeoutRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if body.AdditionalSeconds != 1800 {
+			t.Errorf("additional_seconds = %d, want 1800", body.AdditionalSeconds)
+		}
+		w.Write([]byte(`{"public_id": "abc123", "expires_at": "2030-01-01T12:30:00Z", "timeout": 5400}`))
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	resp, err := svc.ExtendTimeout(context.Background(), "abc123", 1800)
+	if err != nil {
+		t.Fatalf("ExtendTimeout: %v", err)
+	}
+	if resp.ExpiresAt != "2030-01-01T12:30:00Z" || resp.Timeout != 5400 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	if _, err := svc.ExtendTimeout(context.Background(), "abc123", 0); err == nil {
+		t.Error("expected an error for a non-positive extension")
+	}
+}
+
+func TestExtendTimeout_Unsupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		unsupported bool
+		expired     bool
+	}{
+		{"unknown route", http.StatusNotFound, `{"detail": "Not Found"}`, true, true},
+		{"method not allowed", http.StatusMethodNotAllowed, `{"detail": "Method Not Allowed"}`, true, false},
+		{"expired VM", http.StatusNotFound, `{"detail": "VM abc123 not found"}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+			_, err := svc.ExtendTimeout(context.Background(), "abc123", 60)
+			if got := errors.Is(err, ErrEndpointUnsupported); got != tt.unsupported {
+				t.Errorf("errors.Is(%v, ErrEndpointUnsupported) = %v, want %v", err, got, tt.unsupported)
+			}
+			if got := errors.Is(err, ErrSandboxNotFound); got != tt.expired {
+				t.Errorf("errors.Is(%v, ErrSandboxNotFound) = %v, want %v", err, got, tt.expired)
+			}
+		})
+	}
+}
+
+func TestWaitForSnapshot_RejectsNonPositiveInterval(t *testing.T) {
+	svc := NewSandboxService(&testClient{baseURL: "http://127.0.0.1:0", httpClient: http.DefaultClient})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := svc.WaitForSnapshot(context.Background(), "art-1", interval); err == nil {
+			t.Errorf("WaitForSnapshot(%s): expected an error", interval)
+		}
+	}
+}
+
+func TestCreateSnapshots_KeepsSuccessfulDatasets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/public-build/vm/vm1/snapshot" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		var req models.CreateSnapshotRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode body: %v", err)
+		}
+		if req.Dataset == "seeded" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"disk full"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(models.CreateSnapshotResponse{ArtifactId: "art-" + req.Dataset, Status: "pending"})
+	}))
+	defer server.Close()
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+
+	reqs := []models.CreateSnapshotRequest{{Dataset: "base"}, {Dataset: "seeded"}, {Dataset: "demo"}}
+	results, _, err := svc.CreateSnapshots(context.Background(), "vm1", "jg1", reqs, nil)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 snapshots failed") || !strings.Contains(err.Error(), "dataset seeded") {
+		t.Fatalf("expected one failed dataset, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, want := range []string{"art-base", "", "art-demo"} {
+		result := results[i]
+		if result.Request.Dataset != reqs[i].Dataset {
+			t.Errorf("result %d is for %q, want %q", i, result.Request.Dataset, reqs[i].Dataset)
+		}
+		if want == "" {
+			if result.Err == nil || result.Response != nil {
+				t.Errorf("expected %s to fail, got %+v", result.Request.Dataset, result)
+			}
+			continue
+		}
+		if result.Err != nil || result.Response == nil || result.Response.ArtifactId != want {
+			t.Errorf("expected %s to create %s, got %+v", result.Request.Dataset, want, result)
+		}
+	}
+}
+
+func TestStreamLogs_SSEAndPlainStreams(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+	}{
+		{
+			name:        "sse",
+			contentType: "text/event-stream",
+			body:        ": keepalive\n\ndata: {\"type\":\"connected\"}\n\ndata: {\"line\":\"web-1  | listening on :8080\\n\"}\n\nevent: log\ndata: db-1  | ready\n\n",
+			want:        []string{"web-1  | listening on :8080", "db-1  | ready"},
+		},
+		{
+			name:        "chunked",
+			contentType: "text/plain",
+			body:        "web-1  | listening on :8080\n\ndb-1  | ready\n",
+			want:        []string{"web-1  | listening on :8080", "", "db-1  | ready"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/public-build/vm/vm-1/logs" || r.URL.Query().Get("follow") != "true" || r.URL.Query().Get("service") != "web" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+			lines, err := svc.StreamLogs(context.Background(), "vm-1", "web")
+			if err != nil {
+				t.Fatalf("StreamLogs: %v", err)
+			}
+			var got []string
+			for line := range lines {
+				got = append(got, line)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamLogs_Unsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	if _, err := svc.StreamLogs(context.Background(), "vm-1", ""); !errors.Is(err, ErrLogStreamUnsupported) {
+		t.Fatalf("expected ErrLogStreamUnsupported, got %v", err)
+	}
+}
+
+func TestStreamLogs_ClosesWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "first\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	lines, err := svc.StreamLogs(ctx, "vm-1", "")
+	if err != nil {
+		t.Fatalf("StreamLogs: %v", err)
+	}
+	if line := <-lines; line != "first" {
+		t.Fatalf("first line = %q", line)
+	}
+	cancel()
+	select {
+	case line, ok := <-lines:
+		if ok {
+			t.Fatalf("expected channel to close after cancel, got %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
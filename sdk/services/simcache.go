@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"plato-sdk/models"
+)
+
+// SimulatorCache keeps the simulator lists for the lifetime of a client, so
+// that looking simulators up by name on the selection, clone and launch paths
+// lists them at most once. plato.NewClient shares one between its Simulator
+// and Gitea services. It is safe for concurrent use.
+type SimulatorCache struct {
+	mu sync.Mutex
+	// simulators is the /simulator/list result, hubSimulators the
+	// /gitea/simulators one; each is nil until first fetched
+	simulators    []*models.SimulatorListItem
+	hubSimulators []models.GiteaSimulator
+}
+
+// NewSimulatorCache returns an empty SimulatorCache
+func NewSimulatorCache() *SimulatorCache {
+	return &SimulatorCache{}
+}
+
+// Invalidate drops the cached lists, so the next lookup fetches them again.
+// Call it after creating or renaming simulators or their hub repositories.
+func (c *SimulatorCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.simulators = nil
+	c.hubSimulators = nil
+}
+
+// invalidateHub drops only the cached hub simulator list
+func (c *SimulatorCache) invalidateHub() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hubSimulators = nil
+}
+
+// simulatorList returns the cached simulator list, calling fetch to fill it
+// if needed. The lock is held while fetching so concurrent lookups share a
+// single request.
+func (c *SimulatorCache) simulatorList(ctx context.Context, fetch func(context.Context) ([]*models.SimulatorListItem, error)) ([]*models.SimulatorListItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.simulators != nil {
+		return c.simulators, nil
+	}
+	simulators, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.simulators = nonNil(simulators)
+	return c.simulators, nil
+}
+
+// hubSimulatorList is simulatorList for the hub's simulator list
+func (c *SimulatorCache) hubSimulatorList(ctx context.Context, fetch func(context.Context) ([]models.GiteaSimulator, error)) ([]models.GiteaSimulator, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hubSimulators != nil {
+		return c.hubSimulators, nil
+	}
+	simulators, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.hubSimulators = nonNil(simulators)
+	return c.hubSimulators, nil
+}
+
+// storeSimulators replaces the cached simulator list with a fresh one
+func (c *SimulatorCache) storeSimulators(simulators []*models.SimulatorListItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.simulators = nonNil(simulators)
+}
+
+// storeHubSimulators replaces the cached hub simulator list with a fresh one
+func (c *SimulatorCache) storeHubSimulators(simulators []models.GiteaSimulator) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hubSimulators = nonNil(simulators)
+}
+
+// nonNil turns an empty list into a non-nil one, so it is cached as fetched
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+// SimulatorNotFoundError reports a simulator name missing from the list
+type SimulatorNotFoundError struct {
+	Name string
+	// Hub is set when the name was looked up in the hub's simulator list
+	Hub bool
+}
+
+func (e *SimulatorNotFoundError) Error() string {
+	if e.Hub {
+		return fmt.Sprintf("simulator '%s' not found in hub", e.Name)
+	}
+	return fmt.Sprintf("simulator '%s' not found", e.Name)
+}
@@ -12,22 +12,68 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
 
 	"plato-sdk/models"
+	"plato-sdk/utils"
 )
 
 type SimulatorService struct {
 	client ClientInterface
+	cache  *SimulatorCache
 }
 
 func NewSimulatorService(client ClientInterface) *SimulatorService {
+	return NewSimulatorServiceWithCache(client, NewSimulatorCache())
+}
+
+// NewSimulatorServiceWithCache returns a SimulatorService that looks
+// simulators up by name in cache, which may be shared with a GiteaService
+func NewSimulatorServiceWithCache(client ClientInterface, cache *SimulatorCache) *SimulatorService {
+	if cache == nil {
+		cache = NewSimulatorCache()
+	}
 	return &SimulatorService{
 		client: client,
+		cache:  cache,
 	}
 }
 
-// List retrieves all available simulators
+// List retrieves all available simulators, refreshing the cache GetByName uses
 func (s *SimulatorService) List(ctx context.Context) ([]*models.SimulatorListItem, error) {
+	simulators, err := s.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.storeSimulators(simulators)
+	return simulators, nil
+}
+
+// GetByName returns the simulator called name (case-insensitively), listing
+// simulators only if they are not cached yet. It returns a
+// *SimulatorNotFoundError if there is no such simulator.
+func (s *SimulatorService) GetByName(ctx context.Context, name string) (*models.SimulatorListItem, error) {
+	simulators, err := s.cache.simulatorList(ctx, s.list)
+	if err != nil {
+		return nil, err
+	}
+	for _, sim := range simulators {
+		if strings.EqualFold(sim.Name, name) {
+			return sim, nil
+		}
+	}
+	return nil, &SimulatorNotFoundError{Name: name}
+}
+
+// Invalidate drops the cached simulator lists, so the next GetByName lists
+// them again
+func (s *SimulatorService) Invalidate() {
+	s.cache.Invalidate()
+}
+
+// list fetches the simulator list without touching the cache
+func (s *SimulatorService) list(ctx context.Context) ([]*models.SimulatorListItem, error) {
 	req, err := s.client.NewRequest(ctx, "GET", "/simulator/list", nil)
 	if err != nil {
 		return nil, err
@@ -40,7 +86,7 @@ func (s *SimulatorService) List(ctx context.Context) ([]*models.SimulatorListIte
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, ParseAPIError(resp)
 	}
 
 	var simulators []*models.SimulatorListItem
@@ -65,7 +111,7 @@ func (s *SimulatorService) GetVersions(ctx context.Context, simulatorName string
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, ParseAPIError(resp)
 	}
 
 	// Read the response body for logging
@@ -84,3 +130,64 @@ func (s *SimulatorService) GetVersions(ctx context.Context, simulatorName string
 
 	return response.Versions, nil
 }
+
+// ListDatasets returns the names of the datasets that have at least one
+// published version of the simulator, sorted
+func (s *SimulatorService) ListDatasets(ctx context.Context, simulatorName string) ([]string, error) {
+	versions, err := s.GetVersions(ctx, simulatorName)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var datasets []string
+	for _, v := range versions {
+		if v.Dataset != "" && !seen[v.Dataset] {
+			seen[v.Dataset] = true
+			datasets = append(datasets, v.Dataset)
+		}
+	}
+	sort.Strings(datasets)
+	return datasets, nil
+}
+
+// UnknownDatasetError reports a dataset the simulator has no versions for
+type UnknownDatasetError struct {
+	Simulator string
+	Dataset   string
+	// Known lists the datasets the simulator does have
+	Known []string
+	// Suggestions are the known datasets the name looks like a typo of
+	Suggestions []string
+}
+
+func (e *UnknownDatasetError) Error() string {
+	msg := fmt.Sprintf("dataset '%s' has no published versions of %s", e.Dataset, e.Simulator)
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf("%s; did you mean '%s'?", msg, strings.Join(e.Suggestions, "' or '"))
+	}
+	if len(e.Known) > 0 {
+		return fmt.Sprintf("%s (known datasets: %s)", msg, strings.Join(e.Known, ", "))
+	}
+	return msg
+}
+
+// ValidateDataset checks that dataset has a published version of the
+// simulator, returning an *UnknownDatasetError with close matches if not
+func (s *SimulatorService) ValidateDataset(ctx context.Context, simulatorName, dataset string) error {
+	datasets, err := s.ListDatasets(ctx, simulatorName)
+	if err != nil {
+		return err
+	}
+	for _, known := range datasets {
+		if known == dataset {
+			return nil
+		}
+	}
+	return &UnknownDatasetError{
+		Simulator:   simulatorName,
+		Dataset:     dataset,
+		Known:       datasets,
+		Suggestions: utils.SuggestNames(dataset, datasets),
+	}
+}
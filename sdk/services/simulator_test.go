@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func newVersionsServer(t *testing.T) *SimulatorService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simulator/espocrm/versions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"versions": [
+			{"artifact_id": "a1", "version": "1", "dataset": "base"},
+			{"artifact_id": "a2", "version": "2", "dataset": "base"},
+			{"artifact_id": "a3", "version": "1", "dataset": "large"}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+	return NewSimulatorService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+}
+
+func TestListDatasets(t *testing.T) {
+	svc := newVersionsServer(t)
+
+	datasets, err := svc.ListDatasets(context.Background(), "espocrm")
+	if err != nil {
+		t.Fatalf("ListDatasets: %v", err)
+	}
+	if want := []string{"base", "large"}; !reflect.DeepEqual(datasets, want) {
+		t.Errorf("datasets = %v, want %v", datasets, want)
+	}
+}
+
+func TestValidateDataset(t *testing.T) {
+	svc := newVersionsServer(t)
+
+	if err := svc.ValidateDataset(context.Background(), "espocrm", "large"); err != nil {
+		t.Errorf("expected large to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		dataset     string
+		suggestions []string
+	}{
+		{"bsae", []string{"base"}},
+		{"Large", []string{"large"}},
+		{"medium", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataset, func(t *testing.T) {
+			err := svc.ValidateDataset(context.Background(), "espocrm", tt.dataset)
+			var unknown *UnknownDatasetError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("expected *UnknownDatasetError, got %v", err)
+			}
+			if !reflect.DeepEqual(unknown.Suggestions, tt.suggestions) {
+				t.Errorf("suggestions = %v, want %v", unknown.Suggestions, tt.suggestions)
+			}
+		})
+	}
+}
+
+func TestGetByName_SharesOneListCall(t *testing.T) {
+	var simLists, hubLists atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/simulator/list":
+			simLists.Add(1)
+			w.Write([]byte(`[{"id": 1, "name": "EspoCRM"}, {"id": 2, "name": "kanboard"}]`))
+		case "/gitea/simulators":
+			hubLists.Add(1)
+			w.Write([]byte(`[{"id": 1, "name": "espocrm", "has_repo": true}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &testClient{baseURL: server.URL, httpClient: server.Client()}
+	cache := NewSimulatorCache()
+	sims := NewSimulatorServiceWithCache(client, cache)
+	gitea := NewGiteaService(client)
+	gitea.SetSimulatorCache(cache)
+	ctx := context.Background()
+
+	for _, name := range []string{"espocrm", "KANBOARD", "espocrm"} {
+		if _, err := sims.GetByName(ctx, name); err != nil {
+			t.Fatalf("GetByName(%q): %v", name, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if sim, err := gitea.GetSimulatorByName(ctx, "EspoCRM"); err != nil || !sim.HasRepo {
+			t.Fatalf("GetSimulatorByName = %+v, %v", sim, err)
+		}
+	}
+	if simLists.Load() != 1 || hubLists.Load() != 1 {
+		t.Errorf("expected one call per list, got %d simulator and %d hub lists", simLists.Load(), hubLists.Load())
+	}
+
+	var notFound *SimulatorNotFoundError
+	if _, err := sims.GetByName(ctx, "missing"); !errors.As(err, &notFound) {
+		t.Errorf("expected *SimulatorNotFoundError, got %v", err)
+	}
+
+	sims.Invalidate()
+	sims.GetByName(ctx, "espocrm")
+	gitea.GetSimulatorByName(ctx, "espocrm")
+	if simLists.Load() != 2 || hubLists.Load() != 2 {
+		t.Errorf("expected Invalidate to drop both lists, got %d simulator and %d hub lists", simLists.Load(), hubLists.Load())
+	}
+}
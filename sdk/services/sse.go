@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamingKey marks a request context as a long-lived stream
+type streamingKey struct{}
+
+// WithStreaming marks requests made with ctx as event streams, which stay
+// open for as long as the operation runs. The client does not apply its
+// default request timeout to them; the monitors bound them with their own
+// idle timeout instead.
+func WithStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// IsStreaming reports whether ctx was marked by WithStreaming
+func IsStreaming(ctx context.Context) bool {
+	streaming, _ := ctx.Value(streamingKey{}).(bool)
+	return streaming
+}
+
+// StreamError reports that the event stream broke or ended before the
+// operation finished. The operation itself may still be running, so callers
+// can re-attach to the same correlation ID instead of starting over.
+type StreamError struct {
+	Err error
+}
+
+func (e *StreamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError reports that a monitored operation produced no result before
+// its timeout. It unwraps to context.DeadlineExceeded.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("no result within %s: %v", e.Timeout, context.DeadlineExceeded)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// streamFailure classifies an error from an SSE request or read. Running out
+// of time is a *TimeoutError; anything else is a *StreamError worth retrying.
+func streamFailure(ctx context.Context, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Timeout: timeout}
+	}
+	return &StreamError{Err: err}
+}
+
+// DefaultSSEIdleTimeout is how long the monitors wait without receiving any
+// line, event or keepalive comment, before treating the stream as stalled
+const DefaultSSEIdleTimeout = 90 * time.Second
+
+// ErrStreamStalled is wrapped by the *StreamError returned when an event
+// stream sends nothing, not even a keepalive, for longer than the idle timeout
+var ErrStreamStalled = errors.New("event stream stalled")
+
+// DefaultStreamReconnects is how many times the monitors re-open an event
+// stream that a proxy closed before the operation finished
+const DefaultStreamReconnects = 5
+
+// DefaultStreamReconnectDelay is how long the monitors wait before
+// re-opening a dropped event stream
+const DefaultStreamReconnectDelay = 2 * time.Second
+
+// KeepaliveEvent is sent on the event channel of MonitorOperationWithEvents
+// for each keepalive comment, so callers can show the operation is still alive
+const KeepaliveEvent = "[KEEPALIVE] still working..."
+
+// isSSEComment reports whether line is an SSE comment such as ": keepalive"
+func isSSEComment(line string) bool {
+	return strings.HasPrefix(line, ":")
+}
+
+// readSSE passes each line of body to handle until handle reports that the
+// operation finished, returning done=true and handle's error. If the stream
+// ends first it returns done=false with the read error, if any. If no line
+// arrives within idle, abort is called to unblock the read and a *StreamError
+// wrapping ErrStreamStalled is returned so callers can re-attach.
+func readSSE(body io.Reader, idle time.Duration, abort func(), handle func(line string) (done bool, err error)) (bool, error) {
+	lines := make(chan string)
+	stop := make(chan struct{})
+	defer close(stop)
+	var scanErr error
+	go func() {
+		defer close(lines)
+		scanner := newSSEScanner(body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-stop:
+				return
+			}
+		}
+		scanErr = scanner.Err()
+	}()
+
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return false, scanErr
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(idle)
+			if done, err := handle(line); done {
+				return true, err
+			}
+		case <-timer.C:
+			abort()
+			return false, &StreamError{Err: fmt.Errorf("%w: nothing received for %s", ErrStreamStalled, idle)}
+		}
+	}
+}
+
+// MaxSSELineSize is the longest single SSE line the monitors accept. Verbose
+// events such as errors carrying a full stack can exceed bufio's 64KB default.
+var MaxSSELineSize = 1024 * 1024
+
+// newSSEScanner returns a line scanner over an SSE stream sized for MaxSSELineSize
+func newSSEScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxSSELineSize)
+	return scanner
+}
+
+// sseBody returns a reader over the decoded SSE stream.
+//
+// The default transport only decompresses responses when it added the
+// Accept-Encoding header itself, so a gateway that gzips the event stream can
+// still hand us compressed bytes. Those are unwrapped here before scanning.
+func sseBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed || !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return resp.Body, nil
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress SSE stream: %w", err)
+	}
+	return gz, nil
+}
+
+// sseEvent is the JSON payload of an operation event
+type sseEvent struct {
+	Type    string `json:"type"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	// Reason and Details carry the underlying cause on failures, e.g. the
+	// worker's own error output
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// failureMessage describes a failed event, preferring the most specific field
+// and appending Details when the server sent them
+func (e sseEvent) failureMessage(fallback string) string {
+	msg := e.Error
+	if msg == "" {
+		msg = e.Message
+	}
+	if msg == "" {
+		msg = e.Reason
+	}
+	if msg == "" {
+		msg = fallback
+	}
+	if e.Details != "" && e.Details != msg {
+		msg += ": " + e.Details
+	}
+	return msg
+}
+
+// outcome reports whether e ends the operation and, if so, with which error.
+// It mirrors how the monitors treat events: "connected" is informational,
+// "error" always fails and any other type finishes according to Success.
+func (e sseEvent) outcome() (done bool, err error) {
+	switch e.Type {
+	case "connected":
+		return false, nil
+	case "error":
+		return true, fmt.Errorf("operation error: %s", e.failureMessage(""))
+	}
+	if e.Success {
+		return true, nil
+	}
+	return true, fmt.Errorf("operation failed: %s", e.failureMessage("Operation failed"))
+}
+
+// eventDedup tracks the events seen on an operation's stream so those the
+// server replays after a reconnect are not handled twice. Events with an SSE
+// id are matched by id. Others are matched by position: a server without ids
+// replays the stream from the start, so the first data lines of a new
+// connection repeat those already handled, while identical lines past that
+// point are new.
+type eventDedup struct {
+	// reconnected is set once the stream has been re-opened; until then
+	// nothing is treated as a replay
+	reconnected bool
+	seenIDs     map[string]bool
+	// handledData counts the data lines without an id handled so far, and
+	// connData those read on the current connection
+	handledData int
+	connData    int
+	// lastID is the id of the latest event, sent as Last-Event-ID on reconnect
+	lastID string
+	// hasID and skipping describe the event being read: whether it had an
+	// id, and whether that id was already seen
+	hasID    bool
+	skipping bool
+}
+
+// replayed records line and reports whether it belongs to an event that was
+// already handled before a reconnect
+func (d *eventDedup) replayed(line string) bool {
+	switch {
+	case line == "":
+		// A blank line ends the event
+		d.hasID, d.skipping = false, false
+		return false
+	case strings.HasPrefix(line, "id:"):
+		id := strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		d.hasID = true
+		d.skipping = d.reconnected && d.seenIDs[id]
+		d.seenIDs[id] = true
+		d.lastID = id
+		return d.skipping
+	case strings.HasPrefix(line, "data:"):
+		if d.hasID {
+			return d.skipping
+		}
+		d.connData++
+		if d.connData <= d.handledData {
+			return true
+		}
+		d.handledData = d.connData
+		return false
+	}
+	return d.skipping
+}
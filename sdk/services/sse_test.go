@@ -0,0 +1,352 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient is a minimal ClientInterface backed by an httptest server
+type testClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func (c *testClient) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+}
+
+func (c *testClient) NewHubRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return c.NewRequest(ctx, method, path, body)
+}
+
+func (c *testClient) Do(req *http.Request) (*http.Response, error) {
+	return c.httpClient.Do(req)
+}
+
+func (c *testClient) GetBaseURL() string {
+	return c.baseURL
+}
+
+func newGzipSSEServer(t *testing.T, events ...string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		for _, e := range events {
+			io.WriteString(gz, "data: "+e+"\n\n")
+			gz.Flush()
+		}
+	}))
+}
+
+func TestMonitorOperation_GzipStream(t *testing.T) {
+	server := newGzipSSEServer(t,
+		`{"type":"connected"}`,
+		`{"type":"setup","success":true,"message":"done"}`,
+	)
+	defer server.Close()
+
+	// Disable transparent decompression so the compressed body reaches the scanner,
+	// as it does behind gateways that compress streaming responses
+	client := &testClient{
+		baseURL:    server.URL,
+		httpClient: &http.Client{Transport: &http.Transport{DisableCompression: true}},
+	}
+	svc := NewSandboxService(client)
+
+	if err := svc.MonitorOperation(context.Background(), "corr-1", 5*time.Second); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+}
+
+func TestMonitorOperationWithEvents_GzipStream(t *testing.T) {
+	server := newGzipSSEServer(t,
+		`{"type":"connected"}`,
+		`{"type":"error","error":"disk full"}`,
+	)
+	defer server.Close()
+
+	client := &testClient{
+		baseURL:    server.URL,
+		httpClient: &http.Client{Transport: &http.Transport{DisableCompression: true}},
+	}
+	svc := NewSandboxService(client)
+
+	events := make(chan string, 32)
+	err := svc.MonitorOperationWithEvents(context.Background(), "corr-2", 5*time.Second, events)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected operation error mentioning disk full, got %v", err)
+	}
+}
+
+func TestSSEBody_PlainStream(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{},
+		Body:   io.NopCloser(strings.NewReader("data: {}\n")),
+	}
+	body, err := sseBody(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	if string(data) != "data: {}\n" {
+		t.Errorf("expected body to pass through unchanged, got %q", data)
+	}
+}
+
+func TestMonitorOperation_OversizedEventLine(t *testing.T) {
+	// An error event well beyond bufio's default 64KB token limit
+	stack := strings.Repeat("at frame ", 20000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"type":"connected"}`+"\n\n")
+		io.WriteString(w, `data: {"type":"error","error":"boom `+stack+`"}`+"\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: http.DefaultClient})
+
+	err := svc.MonitorOperation(context.Background(), "corr-3", 5*time.Second)
+	if err == nil {
+		t.Fatal("expected the error event to be reported")
+	}
+	if !strings.Contains(err.Error(), "operation error: boom") {
+		t.Fatalf("expected the oversized error event to be parsed, got %.200s", err.Error())
+	}
+}
+
+func TestMonitorOperation_FailureDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"type":"worker","success":false,"reason":"worker exited","details":"missing env DB_URL"}`+"\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: http.DefaultClient})
+
+	err := svc.MonitorOperation(context.Background(), "corr-4", 5*time.Second)
+	if err == nil || err.Error() != "operation failed: worker exited: missing env DB_URL" {
+		t.Fatalf("expected reason and details in error, got %v", err)
+	}
+}
+
+func TestMonitorOperation_TimeoutError(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"type\":\"connected\"}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	err := svc.MonitorOperation(context.Background(), "corr-1", 100*time.Millisecond)
+
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected *TimeoutError, got %T: %v", err, err)
+	}
+	if timeoutErr.Timeout != 100*time.Millisecond {
+		t.Errorf("expected timeout 100ms, got %s", timeoutErr.Timeout)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded")
+	}
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		t.Errorf("a timeout should not be reported as a re-attachable stream error")
+	}
+}
+
+func TestMonitorOperationWithEvents_KeepaliveKeepsStreamAlive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"type":"connected"}`+"\n\n")
+		w.(http.Flusher).Flush()
+		// Keepalives alone, each well within the idle timeout, for longer than it
+		for i := 0; i < 4; i++ {
+			time.Sleep(50 * time.Millisecond)
+			io.WriteString(w, ": keepalive\n\n")
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, `data: {"type":"setup","success":true}`+"\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxServiceWithIdleTimeout(&testClient{baseURL: server.URL, httpClient: server.Client()}, 150*time.Millisecond)
+	events := make(chan string, 100)
+	if err := svc.MonitorOperationWithEvents(context.Background(), "corr-5", 5*time.Second, events); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	close(events)
+
+	keepalives := 0
+	for event := range events {
+		if event == KeepaliveEvent {
+			keepalives++
+		}
+	}
+	if keepalives != 4 {
+		t.Errorf("expected 4 keepalive events, got %d", keepalives)
+	}
+}
+
+func TestMonitorOperation_StalledStream(t *testing.T) {
+	release := make(chan struct{})
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"type":"connected"}`+"\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	svc := NewSandboxServiceWithIdleTimeout(&testClient{baseURL: server.URL, httpClient: server.Client()}, 100*time.Millisecond)
+	svc.SetStreamReconnects(2, 10*time.Millisecond)
+	start := time.Now()
+	err := svc.MonitorOperation(context.Background(), "corr-6", 5*time.Second)
+
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) || !errors.Is(err, ErrStreamStalled) {
+		t.Fatalf("expected a stalled *StreamError, got %T: %v", err, err)
+	}
+	if got := connections.Load(); got != 3 {
+		t.Errorf("expected a stalled stream to be re-opened twice, got %d connections", got)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected each stall to be detected after the idle timeout, took %s", elapsed)
+	}
+}
+
+func TestMonitorOperation_RecoversFromStall(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"type":"connected"}`+"\n\n")
+		w.(http.Flusher).Flush()
+		if connections.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		io.WriteString(w, `data: {"type":"setup","success":true}`+"\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxServiceWithIdleTimeout(&testClient{baseURL: server.URL, httpClient: server.Client()}, 100*time.Millisecond)
+	svc.SetStreamReconnects(2, 10*time.Millisecond)
+	if err := svc.MonitorOperation(context.Background(), "corr-6b", 5*time.Second); err != nil {
+		t.Fatalf("expected success after re-opening the stalled stream, got %v", err)
+	}
+}
+
+func TestStreamRawEvents_KeepsRepeatedLinesAfterReplay(t *testing.T) {
+	var connections atomic.Int32
+	tick := `data: {"type":"connected","message":"waiting for db"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Without ids the server replays the stream from the start
+		io.WriteString(w, tick+"\n\n"+tick+"\n\n")
+		if connections.Add(1) == 1 {
+			return
+		}
+		io.WriteString(w, tick+"\n\n")
+		io.WriteString(w, `data: {"type":"setup","success":true}`+"\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	svc.SetStreamReconnects(2, 10*time.Millisecond)
+	ticks := 0
+	err := svc.StreamRawEvents(context.Background(), "corr-6c", 5*time.Second, func(line string) {
+		if line == tick {
+			ticks++
+		}
+	})
+	if err != nil {
+		t.Fatalf("StreamRawEvents: %v", err)
+	}
+	if ticks != 3 {
+		t.Errorf("expected the two replayed lines to be skipped and the new repeat kept, got %d", ticks)
+	}
+}
+
+func TestMonitorOperationWithEvents_ReconnectsAfterStreamDrops(t *testing.T) {
+	var connections atomic.Int32
+	var lastEventID atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "id: 1\ndata: {\"type\":\"connected\",\"message\":\"booting\"}\n\n")
+		if connections.Add(1) == 1 {
+			// The proxy closes the connection before the operation finishes
+			return
+		}
+		lastEventID.Store(r.Header.Get("Last-Event-ID"))
+		io.WriteString(w, "id: 2\ndata: {\"type\":\"setup\",\"success\":true,\"message\":\"ready\"}\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	svc.SetStreamReconnects(2, 10*time.Millisecond)
+	events := make(chan string, 100)
+	if err := svc.MonitorOperationWithEvents(context.Background(), "corr-7", 5*time.Second, events); err != nil {
+		t.Fatalf("expected success after reconnecting, got %v", err)
+	}
+	close(events)
+
+	if got := connections.Load(); got != 2 {
+		t.Errorf("expected 2 connections, got %d", got)
+	}
+	if got, _ := lastEventID.Load().(string); got != "1" {
+		t.Errorf("expected reconnect to send Last-Event-ID 1, got %q", got)
+	}
+	counts := make(map[string]int)
+	for event := range events {
+		counts[event]++
+	}
+	if counts["booting"] != 1 || counts["ready"] != 1 {
+		t.Errorf("expected each stage reported once, got booting=%d ready=%d", counts["booting"], counts["ready"])
+	}
+}
+
+func TestMonitorOperation_GivesUpAfterMaxReconnects(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"type\":\"connected\"}\n\n")
+	}))
+	defer server.Close()
+
+	svc := NewSandboxService(&testClient{baseURL: server.URL, httpClient: server.Client()})
+	svc.SetStreamReconnects(2, time.Millisecond)
+	err := svc.MonitorOperation(context.Background(), "corr-8", 5*time.Second)
+
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) {
+		t.Fatalf("expected a *StreamError, got %T: %v", err, err)
+	}
+	if got := connections.Load(); got != 3 {
+		t.Errorf("expected 1 connection and 2 reconnects, got %d connections", got)
+	}
+}
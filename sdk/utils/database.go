@@ -7,25 +7,64 @@ package utils
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
+	"strings"
 	"time"
 
+	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
-// DBConfig represents database configuration for a simulator
+// DBConfig represents database configuration for a simulator.
+// DBType is "postgresql", "mysql", "mssql" or "sqlite". For sqlite, Databases
+// holds paths to the database files, which must be reachable from this
+// machine (for example on a volume mounted from the VM); no tunnel is used.
 type DBConfig struct {
 	DBType    string   `json:"db_type"`
 	User      string   `json:"user"`
 	Password  string   `json:"password"`
 	DestPort  int      `json:"dest_port"`
 	Databases []string `json:"databases"`
+	// CleanupTables lists tables emptied along with audit_log, such as
+	// sessions or job queues; names may be schema-qualified
+	CleanupTables []string `json:"cleanup_tables,omitempty"`
+	// CleanupSQL holds optional statements run in order against each database after truncation
+	CleanupSQL []string `json:"cleanup_sql,omitempty"`
+	// PasswordEnv names an environment variable holding the password, read
+	// when the database is opened. It wins over Password when both are set,
+	// which keeps the secret out of presets and saved configs.
+	PasswordEnv string `json:"password_env,omitempty"`
+}
+
+// ResolvePassword returns the password to connect with: the value of
+// PasswordEnv when it is set in the environment, otherwise Password
+func (c DBConfig) ResolvePassword() (string, error) {
+	if c.PasswordEnv == "" {
+		return c.Password, nil
+	}
+	if password, ok := os.LookupEnv(c.PasswordEnv); ok {
+		return password, nil
+	}
+	if c.Password != "" {
+		debugf("%s is not set, using the configured password", c.PasswordEnv)
+		return c.Password, nil
+	}
+	return "", fmt.Errorf("database password environment variable %s (password_env) is not set", c.PasswordEnv)
 }
 
 // OpenTemporaryProxytunnel opens a proxytunnel for the duration of a cleanup operation
 func OpenTemporaryProxytunnel(baseURL, publicID string, remotePort int) (*exec.Cmd, int, error) {
+	publicID, err := NormalizePublicID(publicID)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	localPort, err := FindFreePortPreferred(remotePort)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to find free port: %w", err)
@@ -59,9 +98,7 @@ func OpenTemporaryProxytunnel(baseURL, publicID string, remotePort int) (*exec.C
 		return nil, 0, fmt.Errorf("failed to start proxytunnel: %w", err)
 	}
 
-	// Give the tunnel time to establish
-	time.Sleep(500 * time.Millisecond)
-
+	// Callers ping with pingWithRetry, which waits for the tunnel to come up
 	return cmd, localPort, nil
 }
 
@@ -73,80 +110,336 @@ func CloseTemporaryProxytunnel(cmd *exec.Cmd) {
 	}
 }
 
-// ClearAuditLog connects to the database and clears the audit_log table
+// DBUnreachableError reports that cleanup could not connect to any of the
+// configured databases, as opposed to connecting and finding no audit_log
+// table. It usually means the database listens on a port other than DestPort.
+type DBUnreachableError struct {
+	DBType string
+	Port   int // the port in the VM that was tried
+	Err    error
+}
+
+func (e *DBUnreachableError) Error() string {
+	return fmt.Sprintf("could not connect to any %s database on port %d: %v", e.DBType, e.Port, e.Err)
+}
+
+func (e *DBUnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// AuditLogResult reports whether audit_log was cleared in one database
+type AuditLogResult struct {
+	Database string
+	Cleared  bool
+	Err      error // why it was not cleared
+	// Tables lists the CleanupTables that were emptied; tables missing from
+	// this database are left out
+	Tables []string
+	// CleanupSQL reports the CleanupSQL statements run in this database, when
+	// the caller ran them too
+	CleanupSQL []CleanupSQLResult
+}
+
+// ClearAuditLog connects to the database and clears the audit_log table,
+// along with any CleanupTables. It returns a *DBUnreachableError if none of
+// the databases answered.
 func ClearAuditLog(dbConfig DBConfig, localPort int) error {
-	var db *sql.DB
-	var err error
+	_, err := ClearAuditLogResults(dbConfig, localPort)
+	return err
+}
+
+// ClearAuditLogResults is ClearAuditLog, also reporting for each database
+// whether its audit_log was cleared. Clearing is best-effort: a database that
+// fails does not stop the others.
+func ClearAuditLogResults(dbConfig DBConfig, localPort int) ([]AuditLogResult, error) {
+	results := make([]AuditLogResult, 0, len(dbConfig.Databases))
+	reachedCount := 0
 	clearedCount := 0
+	var pingErr error
 
-	if dbConfig.DBType == "postgresql" {
-		for _, dbName := range dbConfig.Databases {
-			connStr := fmt.Sprintf("host=127.0.0.1 port=%d user=%s password=%s dbname=%s sslmode=disable",
-				localPort, dbConfig.User, dbConfig.Password, dbName)
+	for _, dbName := range dbConfig.Databases {
+		result := AuditLogResult{Database: dbName}
+		db, err := openDatabase(dbConfig, dbName, localPort)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
 
-			db, err = sql.Open("postgres", connStr)
-			if err != nil {
-				continue
-			}
+		if err := pingWithRetry(db, DBPingTimeout); err != nil {
+			pingErr = err
+			result.Err = err
+			results = append(results, result)
+			db.Close()
+			continue
+		}
+		reachedCount++
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		result.Tables, result.Err = clearTables(ctx, db, dbConfig.DBType, dbConfig.CleanupTables)
+		if result.Err == nil {
+			result.Cleared = true
+			clearedCount++
+		}
+		results = append(results, result)
+		cancel()
+		db.Close()
+	}
 
-			if err = db.PingContext(ctx); err != nil {
-				cancel()
-				db.Close()
-				continue
-			}
+	if reachedCount == 0 && pingErr != nil {
+		return results, &DBUnreachableError{DBType: dbConfig.DBType, Port: dbConfig.DestPort, Err: pingErr}
+	}
+	if clearedCount == 0 {
+		return results, fmt.Errorf("could not find or clear audit_log table in any database")
+	}
 
-			_, err = db.ExecContext(ctx, "TRUNCATE TABLE public.audit_log RESTART IDENTITY CASCADE")
-			if err == nil {
-				clearedCount++
-			}
-			cancel()
-			db.Close()
+	return results, nil
+}
+
+// clearTables empties audit_log and then extra in one connected database. It
+// returns the extra tables that were emptied and why audit_log was not. An
+// extra table that cannot be emptied, usually because this database does not
+// have it, is skipped.
+func clearTables(ctx context.Context, db *sql.DB, dbType string, extra []string) ([]string, error) {
+	switch dbType {
+	case "postgresql", "mysql", "mssql", "sqlite":
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	// Session settings such as FOREIGN_KEY_CHECKS only apply to the
+	// connection they were set on, so run everything on one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if dbType == "mysql" {
+		if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return nil, err
 		}
-	} else if dbConfig.DBType == "mysql" {
-		for _, dbName := range dbConfig.Databases {
-			dsn := fmt.Sprintf("%s:%s@tcp(127.0.0.1:%d)/%s",
-				dbConfig.User, dbConfig.Password, localPort, dbName)
+		defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+	}
 
-			db, err = sql.Open("mysql", dsn)
-			if err != nil {
-				continue
-			}
+	auditErr := clearTable(ctx, conn, dbType, "audit_log")
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var cleared []string
+	seen := map[string]bool{"audit_log": true}
+	for _, table := range extra {
+		if seen[table] {
+			continue
+		}
+		seen[table] = true
+		if err := clearTable(ctx, conn, dbType, table); err != nil {
+			debugf("Skipping cleanup table %s: %v", table, err)
+			continue
+		}
+		cleared = append(cleared, table)
+	}
+	return cleared, auditErr
+}
 
-			if err = db.PingContext(ctx); err != nil {
-				cancel()
-				db.Close()
-				continue
-			}
+// clearTable empties one table and restarts its ids
+func clearTable(ctx context.Context, conn *sql.Conn, dbType, table string) error {
+	switch dbType {
+	case "postgresql":
+		// Unqualified names are looked up in public, like audit_log always was
+		if !strings.Contains(table, ".") {
+			table = "public." + table
+		}
+		_, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+quoteTable(dbType, table)+" RESTART IDENTITY CASCADE")
+		return err
+	case "mysql":
+		_, err := conn.ExecContext(ctx, "DELETE FROM "+quoteTable(dbType, table))
+		return err
+	case "mssql":
+		// TRUNCATE fails on tables referenced by a foreign key, so fall back to DELETE
+		if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+quoteTable(dbType, table)); err == nil {
+			return nil
+		}
+		_, err := conn.ExecContext(ctx, "DELETE FROM "+quoteTable(dbType, table))
+		return err
+	case "sqlite":
+		if _, err := conn.ExecContext(ctx, "DELETE FROM "+quoteTable(dbType, table)); err != nil {
+			return err
+		}
+		// Restart AUTOINCREMENT ids like the other databases; sqlite_sequence
+		// only exists if some table uses AUTOINCREMENT
+		conn.ExecContext(ctx, "DELETE FROM sqlite_sequence WHERE name = ?", table)
+		return nil
+	default:
+		return fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
 
-			_, err = db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0")
-			if err != nil {
-				cancel()
-				db.Close()
-				continue
-			}
+// quoteTable quotes each part of a possibly schema-qualified table name for
+// dbType, so configured names cannot inject SQL
+func quoteTable(dbType, table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		switch dbType {
+		case "mysql":
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		case "mssql":
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		default:
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
 
-			_, err = db.ExecContext(ctx, "DELETE FROM `audit_log`")
-			if err != nil {
-				db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
-				cancel()
-				db.Close()
-				continue
-			}
+// NeedsProxytunnel reports whether databases of dbType are reached through a
+// proxytunnel to the VM. SQLite files are opened directly.
+func NeedsProxytunnel(dbType string) bool {
+	return dbType != "sqlite"
+}
 
-			db.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
-			clearedCount++
-			cancel()
-			db.Close()
+// ProbeDatabase reports whether the first configured database answers through
+// the tunnel on localPort within timeout
+func ProbeDatabase(dbConfig DBConfig, localPort int, timeout time.Duration) error {
+	if len(dbConfig.Databases) == 0 {
+		return fmt.Errorf("no databases configured")
+	}
+	db, err := openDatabase(dbConfig, dbConfig.Databases[0], localPort)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return pingWithRetry(db, timeout)
+}
+
+// DBPingTimeout bounds how long cleanup waits for a database to answer through a new tunnel
+const DBPingTimeout = 10 * time.Second
+
+// DebugLogf, when set, receives diagnostic messages from this package
+var DebugLogf func(format string, args ...interface{})
+
+func debugf(format string, args ...interface{}) {
+	if DebugLogf != nil {
+		DebugLogf(format, args...)
+	}
+}
+
+// pingWithRetry pings db until it answers or timeout elapses, backing off between
+// attempts so that a proxytunnel that is still connecting doesn't cause a skip
+func pingWithRetry(db *sql.DB, timeout time.Duration) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	backoff := 100 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			debugf("Database answered after %s (%d attempts)", time.Since(start).Round(time.Millisecond), attempt)
+			return nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			debugf("Database did not answer after %s (%d attempts): %v", time.Since(start).Round(time.Millisecond), attempt, err)
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+
+		time.Sleep(backoff)
+		if backoff < time.Second {
+			backoff *= 2
 		}
 	}
+}
 
-	if clearedCount == 0 {
-		return fmt.Errorf("could not find or clear audit_log table in any database")
+// openDatabase opens a connection to dbName through the tunnel on localPort
+func openDatabase(dbConfig DBConfig, dbName string, localPort int) (*sql.DB, error) {
+	if dbConfig.DBType != "sqlite" {
+		password, err := dbConfig.ResolvePassword()
+		if err != nil {
+			return nil, err
+		}
+		dbConfig.Password = password
+	}
+	switch dbConfig.DBType {
+	case "postgresql":
+		connStr := fmt.Sprintf("host=127.0.0.1 port=%d user=%s password=%s dbname=%s sslmode=disable",
+			localPort, dbConfig.User, dbConfig.Password, dbName)
+		return sql.Open("postgres", connStr)
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(127.0.0.1:%d)/%s",
+			dbConfig.User, dbConfig.Password, localPort, dbName)
+		return sql.Open("mysql", dsn)
+	case "mssql":
+		dsn := fmt.Sprintf("sqlserver://%s:%s@127.0.0.1:%d?database=%s",
+			url.QueryEscape(dbConfig.User), url.QueryEscape(dbConfig.Password), localPort, url.QueryEscape(dbName))
+		return sql.Open("sqlserver", dsn)
+	case "sqlite":
+		// Opening a missing file would silently create an empty database
+		if _, err := os.Stat(dbName); err != nil {
+			return nil, fmt.Errorf("sqlite database not found: %w", err)
+		}
+		return sql.Open("sqlite", dbName)
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbConfig.DBType)
+	}
+}
+
+// CleanupSQLResult reports how one CleanupSQL statement went in one database
+type CleanupSQLResult struct {
+	Database  string
+	Statement string
+	Err       error // why the statement failed or could not be run
+}
+
+// RunCleanupSQL executes dbConfig.CleanupSQL in order against each database.
+// It is best-effort: a failing statement does not stop the remaining ones, and
+// all failures are returned joined together. It is a no-op when CleanupSQL is empty.
+func RunCleanupSQL(dbConfig DBConfig, localPort int) error {
+	_, err := RunCleanupSQLResults(dbConfig, localPort)
+	return err
+}
+
+// RunCleanupSQLResults is RunCleanupSQL, also reporting each statement in
+// each database. When a database cannot be reached, each of its statements
+// is reported with the connection error.
+func RunCleanupSQLResults(dbConfig DBConfig, localPort int) ([]CleanupSQLResult, error) {
+	if len(dbConfig.CleanupSQL) == 0 {
+		return nil, nil
+	}
+
+	var results []CleanupSQLResult
+	var errs []error
+	skip := func(dbName string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %w", dbName, err))
+		for _, stmt := range dbConfig.CleanupSQL {
+			results = append(results, CleanupSQLResult{Database: dbName, Statement: stmt, Err: err})
+		}
+	}
+	for _, dbName := range dbConfig.Databases {
+		db, err := openDatabase(dbConfig, dbName, localPort)
+		if err != nil {
+			skip(dbName, err)
+			continue
+		}
+
+		if err := pingWithRetry(db, DBPingTimeout); err != nil {
+			skip(dbName, err)
+			db.Close()
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+		for _, stmt := range dbConfig.CleanupSQL {
+			result := CleanupSQLResult{Database: dbName, Statement: stmt}
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				result.Err = err
+				errs = append(errs, fmt.Errorf("%s: %q: %w", dbName, stmt, err))
+			}
+			results = append(results, result)
+		}
+		cancel()
+		db.Close()
 	}
 
-	return nil
+	return results, errors.Join(errs...)
 }
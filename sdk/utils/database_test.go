@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestClearAuditLogResults_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	withLog := filepath.Join(dir, "app.db")
+	withoutLog := filepath.Join(dir, "other.db")
+
+	for path, stmts := range map[string][]string{
+		withLog: {
+			"CREATE TABLE audit_log (id INTEGER PRIMARY KEY AUTOINCREMENT, action TEXT)",
+			"INSERT INTO audit_log (action) VALUES ('insert'), ('update')",
+			"CREATE TABLE sessions (id INTEGER PRIMARY KEY, token TEXT)",
+			"INSERT INTO sessions (token) VALUES ('abc')",
+		},
+		withoutLog: {"CREATE TABLE users (id INTEGER PRIMARY KEY)"},
+	} {
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("%s: %v", stmt, err)
+			}
+		}
+		db.Close()
+	}
+
+	missing := filepath.Join(dir, "missing.db")
+	config := DBConfig{
+		DBType:        "sqlite",
+		Databases:     []string{withLog, withoutLog, missing},
+		CleanupTables: []string{"sessions", "job_queue", "audit_log"},
+	}
+	results, err := ClearAuditLogResults(config, 0)
+	if err != nil {
+		t.Fatalf("ClearAuditLogResults: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected a result per database, got %+v", results)
+	}
+	if !results[0].Cleared || results[0].Err != nil {
+		t.Errorf("expected %s cleared, got %+v", withLog, results[0])
+	}
+	// job_queue is missing everywhere and audit_log is not repeated
+	if fmt.Sprint(results[0].Tables) != "[sessions]" {
+		t.Errorf("expected sessions cleared in %s, got %v", withLog, results[0].Tables)
+	}
+	if len(results[1].Tables) != 0 {
+		t.Errorf("expected no tables cleared in %s, got %v", withoutLog, results[1].Tables)
+	}
+	if results[1].Cleared || results[1].Err == nil {
+		t.Errorf("expected %s without audit_log to be reported, got %+v", withoutLog, results[1])
+	}
+	if results[2].Cleared || results[2].Err == nil {
+		t.Errorf("expected missing file to be reported, got %+v", results[2])
+	}
+
+	db, err := sql.Open("sqlite", withLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, table := range []string{"audit_log", "sessions"} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil || count != 0 {
+			t.Errorf("expected empty %s, got %d rows (%v)", table, count, err)
+		}
+	}
+
+	if NeedsProxytunnel("sqlite") || !NeedsProxytunnel("mssql") {
+		t.Error("only sqlite should skip the proxytunnel")
+	}
+}
+
+func TestRunCleanupSQLResults_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE TABLE jobs (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	config := DBConfig{
+		DBType:     "sqlite",
+		Databases:  []string{path, filepath.Join(dir, "missing.db")},
+		CleanupSQL: []string{"DELETE FROM jobs", "DELETE FROM no_such_table"},
+	}
+	results, err := RunCleanupSQLResults(config, 0)
+	if err == nil {
+		t.Fatal("expected the failed statements to be returned")
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected a result per statement and database, got %+v", results)
+	}
+	if results[0].Err != nil || results[0].Statement != "DELETE FROM jobs" {
+		t.Errorf("expected the first statement to succeed, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("expected the missing table to be reported, got %+v", results[1])
+	}
+	for _, result := range results[2:] {
+		if result.Err == nil {
+			t.Errorf("expected statements of the missing database to be reported, got %+v", result)
+		}
+	}
+}
+
+func TestDBConfigResolvePassword(t *testing.T) {
+	t.Setenv("PLATO_TEST_DB_PASSWORD", "from-env")
+
+	password, err := DBConfig{Password: "literal", PasswordEnv: "PLATO_TEST_DB_PASSWORD"}.ResolvePassword()
+	if err != nil || password != "from-env" {
+		t.Fatalf("expected the environment variable to win, got %q, %v", password, err)
+	}
+
+	password, err = DBConfig{Password: "literal", PasswordEnv: "PLATO_TEST_DB_PASSWORD_UNSET"}.ResolvePassword()
+	if err != nil || password != "literal" {
+		t.Fatalf("expected a fallback to the literal password, got %q, %v", password, err)
+	}
+
+	if _, err := (DBConfig{PasswordEnv: "PLATO_TEST_DB_PASSWORD_UNSET"}).ResolvePassword(); err == nil {
+		t.Fatal("expected an error when neither the variable nor a password is set")
+	}
+}
@@ -1,14 +1,206 @@
 package utils
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// CopyFilesRespectingGitignore copies files from src to dst while respecting .gitignore rules
-func CopyFilesRespectingGitignore(src, dst string) error {
+// DefaultGitTimeout bounds each hub clone, push or ls-remote whose caller set
+// no deadline, so a hung network fails the operation instead of blocking forever
+const DefaultGitTimeout = 2 * time.Minute
+
+// gitWaitDelay is how long a killed git may keep its output pipes open. A
+// helper such as git-remote-https can outlive git and hold them otherwise.
+const gitWaitDelay = 5 * time.Second
+
+// WithGitTimeout returns ctx with DefaultGitTimeout applied, unless ctx
+// already has a deadline of its own
+func WithGitTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultGitTimeout)
+}
+
+// GitCommand returns a command running git with args that is killed when ctx
+// is done
+func GitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.WaitDelay = gitWaitDelay
+	return cmd
+}
+
+// RunRemoteGit runs `git args...` in dir with env for a command that talks to
+// a remote, such as clone, push or ls-remote, and returns its combined output.
+// Each call gets DefaultGitTimeout of its own unless ctx has a deadline, so the
+// time earlier steps took doesn't count against it.
+func RunRemoteGit(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	ctx, cancel := WithGitTimeout(ctx)
+	defer cancel()
+	cmd := GitCommand(ctx, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	return output, GitInterrupted(ctx, err)
+}
+
+// GitInterrupted adds ctx's error to err when ctx ended while git ran. A git
+// killed by its context only reports "signal: killed", which would hide that
+// the operation timed out or was cancelled.
+func GitInterrupted(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w (%w)", err, ctx.Err())
+}
+
+// KeepTempEnv names the environment variable that keeps temporary git
+// checkouts around after a failed operation so the git state can be inspected
+const KeepTempEnv = "PLATO_KEEP_TEMP"
+
+// KeepTempOnFailure reports whether PLATO_KEEP_TEMP is set to a true value
+func KeepTempOnFailure() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(KeepTempEnv))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// CleanupTempDir removes dir once an operation has finished. If err is non-nil
+// and PLATO_KEEP_TEMP is set, dir is left in place and err is annotated with
+// its path so it shows up wherever the failure is reported.
+func CleanupTempDir(dir string, err error) error {
+	if err != nil && KeepTempOnFailure() {
+		return fmt.Errorf("%w (temp dir kept at %s)", err, dir)
+	}
+	os.RemoveAll(dir)
+	return err
+}
+
+// GitSupportsPartialClone reports whether the local git understands
+// `clone --filter=blob:none` (git 2.22 or later). The version is checked once.
+var GitSupportsPartialClone = sync.OnceValue(func() bool {
+	output, err := exec.Command("git", "version").Output()
+	if err != nil {
+		return false
+	}
+	return gitVersionAtLeast(string(output), 2, 22)
+})
+
+// gitVersionAtLeast parses `git version` output such as "git version 2.39.5"
+// or "git version 2.37.1 (Apple Git-137.1)" and compares it with major.minor
+func gitVersionAtLeast(output string, major, minor int) bool {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return false
+	}
+	parts := strings.SplitN(fields[2], ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	gotMajor, err1 := strconv.Atoi(parts[0])
+	gotMinor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
+// RemoteDefaultBranch asks remote which branch its HEAD points at, using
+// `git ls-remote --symref`. It returns "" without an error when HEAD is
+// unborn, as in a repository that has no commits on its default branch yet.
+func RemoteDefaultBranch(ctx context.Context, remote string, env []string) (string, error) {
+	ctx, cancel := WithGitTimeout(ctx)
+	defer cancel()
+	cmd := GitCommand(ctx, "ls-remote", "--symref", remote, "HEAD")
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote failed: %w", err)
+	}
+	return parseSymrefHEAD(string(output)), nil
+}
+
+// ErrBranchMoved reports that a push was refused because the target branch
+// no longer points where RemoteBranchTip found it, so someone else updated it
+var ErrBranchMoved = errors.New("the branch was updated by someone else")
+
+// RemoteBranchTip returns the commit branch points to on remote, or "" when
+// remote has no such branch
+func RemoteBranchTip(ctx context.Context, remote string, env []string, branch string) (string, error) {
+	ctx, cancel := WithGitTimeout(ctx)
+	defer cancel()
+	cmd := GitCommand(ctx, "ls-remote", remote, "refs/heads/"+branch)
+	cmd.Env = env
+	output, err := cmd.Output()
+	if err != nil {
+		return "", GitInterrupted(ctx, fmt.Errorf("git ls-remote failed: %w", err))
+	}
+	tip, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\t")
+	return tip, nil
+}
+
+// LeasePushArgs returns the git arguments that reset branch on origin to HEAD
+// only if it still points to tip, as recorded by RemoteBranchTip. An empty tip
+// means the branch must not exist yet.
+func LeasePushArgs(branch, tip string) []string {
+	ref := "refs/heads/" + branch
+	return []string{"push", "--force-with-lease=" + ref + ":" + tip, "origin", "HEAD:" + ref}
+}
+
+// LeaseError wraps err from a LeasePushArgs push with ErrBranchMoved when
+// output shows the lease refused it
+func LeaseError(err error, output string) error {
+	if err != nil && strings.Contains(output, "stale info") {
+		return fmt.Errorf("%w: %w", ErrBranchMoved, err)
+	}
+	return err
+}
+
+// HasCommits reports whether the repository in dir has a commit checked out.
+// A clone of a repository nothing was pushed to has none.
+func HasCommits(dir string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
+
+// parseSymrefHEAD extracts the branch from a "ref: refs/heads/<branch>\tHEAD"
+// line of `git ls-remote --symref` output
+func parseSymrefHEAD(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		ref, target, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || target != "HEAD" || !strings.HasPrefix(ref, "ref: ") {
+			continue
+		}
+		return strings.TrimPrefix(strings.TrimPrefix(ref, "ref: "), "refs/heads/")
+	}
+	return ""
+}
+
+// CopyProgress reports how far CopyFilesRespectingGitignore has got. The
+// files to copy are listed before any is copied, so the totals are final.
+type CopyProgress struct {
+	FilesCopied int
+	TotalFiles  int
+	BytesCopied int64
+	TotalBytes  int64
+}
+
+// CopyFilesRespectingGitignore copies files from src to dst while respecting
+// .gitignore rules. progress, if not nil, is called after each file.
+func CopyFilesRespectingGitignore(src, dst string, progress func(CopyProgress)) error {
 	// First copy .gitignore if it exists
 	gitignoreSrc := filepath.Join(src, ".gitignore")
 	if _, err := os.Stat(gitignoreSrc); err == nil {
@@ -40,8 +232,16 @@ func CopyFilesRespectingGitignore(src, dst string) error {
 		return err != nil // Return true if NOT ignored
 	}
 
-	// Walk through source directory
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	// Walk through source directory, creating directories and listing the
+	// files to copy
+	type fileToCopy struct {
+		path    string
+		dstPath string
+		info    os.FileInfo
+	}
+	var files []fileToCopy
+	var totalBytes int64
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -70,12 +270,29 @@ func CopyFilesRespectingGitignore(src, dst string) error {
 		if info.IsDir() {
 			return os.MkdirAll(dstPath, info.Mode())
 		}
+		files = append(files, fileToCopy{path: path, dstPath: dstPath, info: info})
+		totalBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		// Copy file
-		input, err := os.ReadFile(path)
+	// Copy files
+	copied := CopyProgress{TotalFiles: len(files), TotalBytes: totalBytes}
+	for _, file := range files {
+		input, err := os.ReadFile(file.path)
 		if err != nil {
 			return err
 		}
-		return os.WriteFile(dstPath, input, info.Mode())
-	})
+		if err := os.WriteFile(file.dstPath, input, file.info.Mode()); err != nil {
+			return err
+		}
+		copied.FilesCopied++
+		copied.BytesCopied += file.info.Size()
+		if progress != nil {
+			progress(copied)
+		}
+	}
+	return nil
 }
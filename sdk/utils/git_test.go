@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFilesRespectingGitignoreReportsProgress(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"a.txt":           "alpha",
+		"sub/b.txt":       "bravo!",
+		"sub/deep/c":      "c",
+		".plato-hub.json": "{}",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	var reports []CopyProgress
+	if err := CopyFilesRespectingGitignore(src, dst, func(p CopyProgress) { reports = append(reports, p) }); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("expected a report per copied file, got %+v", reports)
+	}
+	last := reports[len(reports)-1]
+	if last.FilesCopied != 3 || last.TotalFiles != 3 || last.BytesCopied != 12 || last.TotalBytes != 12 {
+		t.Errorf("unexpected final progress: %+v", last)
+	}
+	for i, p := range reports {
+		if p.FilesCopied != i+1 || p.TotalFiles != 3 {
+			t.Errorf("report %d: unexpected progress %+v", i, p)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "sub", "deep", "c")); err != nil || string(data) != "c" {
+		t.Errorf("expected sub/deep/c to be copied, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".plato-hub.json")); !os.IsNotExist(err) {
+		t.Errorf("expected .plato-hub.json to be skipped, got %v", err)
+	}
+}
+
+func TestRunRemoteGitReportsCancellation(t *testing.T) {
+	if _, err := RunRemoteGit(context.Background(), t.TempDir(), nil, "version"); err != nil {
+		t.Fatalf("git version failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunRemoteGit(ctx, t.TempDir(), nil, "version"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled git to report context.Canceled, got %v", err)
+	}
+}
+
+func TestLeasePushRefusesMovedBranch(t *testing.T) {
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return string(output)
+	}
+	commit := func(dir, name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git(dir, "add", name)
+		git(dir, "commit", "-m", name)
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	git(filepath.Dir(remote), "init", "--bare", remote)
+	ours, theirs := t.TempDir(), t.TempDir()
+	git(ours, "clone", remote, ".")
+	commit(ours, "base")
+	git(ours, "push", "origin", "HEAD:refs/heads/snapshots")
+	git(theirs, "clone", remote, ".")
+
+	ctx := context.Background()
+	tip, err := RemoteBranchTip(ctx, remote, nil, "snapshots")
+	if err != nil || tip == "" {
+		t.Fatalf("RemoteBranchTip = %q, %v", tip, err)
+	}
+	if missing, err := RemoteBranchTip(ctx, remote, nil, "nope"); err != nil || missing != "" {
+		t.Fatalf("RemoteBranchTip of a missing branch = %q, %v", missing, err)
+	}
+
+	// Someone else moves the branch after its tip was recorded
+	git(theirs, "checkout", "snapshots")
+	commit(theirs, "theirs")
+	git(theirs, "push", "origin", "snapshots")
+
+	commit(ours, "ours")
+	output, err := RunRemoteGit(ctx, ours, nil, LeasePushArgs("snapshots", tip)...)
+	if err := LeaseError(err, string(output)); !errors.Is(err, ErrBranchMoved) {
+		t.Fatalf("expected the diverged push to fail with ErrBranchMoved, got %v\n%s", err, output)
+	}
+
+	// With the current tip recorded the push goes through
+	current, err := RemoteBranchTip(ctx, remote, nil, "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, err := RunRemoteGit(ctx, ours, nil, LeasePushArgs("snapshots", current)...); err != nil {
+		t.Fatalf("push with the current tip failed: %v\n%s", err, output)
+	}
+	// An empty tip creates a branch that does not exist yet
+	if output, err := RunRemoteGit(ctx, ours, nil, LeasePushArgs("fresh", "")...); err != nil {
+		t.Fatalf("push creating a branch failed: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// publicIDPattern matches bare sandbox public IDs and job group IDs
+var publicIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,127}$`)
+
+// NormalizePublicID trims a user-supplied public ID and validates it.
+// A pasted sandbox URL such as https://<id>.sims.plato.so is reduced to the
+// identifier in its host, which for an aliased sandbox is the alias; callers
+// with a client resolve that with SandboxService.ResolvePublicID.
+func NormalizePublicID(input string) (string, error) {
+	id := strings.TrimSpace(input)
+	if id == "" {
+		return "", fmt.Errorf("invalid public ID: value is empty")
+	}
+
+	if strings.Contains(id, "://") {
+		u, err := url.Parse(id)
+		if err != nil || u.Hostname() == "" {
+			return "", fmt.Errorf("invalid public ID %q: could not parse URL", input)
+		}
+		host := u.Hostname()
+		if !strings.Contains(host, ".sims.") {
+			return "", fmt.Errorf("invalid public ID %q: not a sandbox URL", input)
+		}
+		id = strings.SplitN(host, ".", 2)[0]
+	}
+
+	if !publicIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid public ID %q: expected letters, digits, '-' or '_'", input)
+	}
+	return id, nil
+}
+
+// IsSandboxURL reports whether input is a URL rather than a bare ID, so
+// NormalizePublicID may have returned an alias
+func IsSandboxURL(input string) bool {
+	return strings.Contains(strings.TrimSpace(input), "://")
+}
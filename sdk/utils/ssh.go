@@ -5,8 +5,11 @@
 package utils
 
 import (
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"encoding/pem"
 	"fmt"
 	"os"
@@ -44,9 +47,38 @@ func ReadSSHPublicKey() (string, error) {
 	return "", fmt.Errorf("no SSH public key found in %s (tried: %s)", sshDir, strings.Join(keyFiles, ", "))
 }
 
+// SSH key types accepted by GenerateSSHKeyPairOfType
+const (
+	SSHKeyTypeEd25519 = "ed25519"
+	SSHKeyTypeRSA     = "rsa"
+	SSHKeyTypeECDSA   = "ecdsa"
+)
+
+// DefaultSSHKeyType is the key type generated for sandboxes unless another is asked for
+const DefaultSSHKeyType = SSHKeyTypeEd25519
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 3072
+
+// Connection settings written into generated SSH configs, unless the ssh
+// section of plato-config.yml overrides them
+const (
+	DefaultSSHConnectTimeout      = 10
+	DefaultSSHServerAliveInterval = 30
+	DefaultSSHServerAliveCountMax = 3
+)
+
 // GenerateSSHKeyPair generates a new ed25519 SSH key pair for a specific sandbox
 // Returns (publicKey, privateKeyPath, error)
 func GenerateSSHKeyPair(sandboxNum int) (string, string, error) {
+	return GenerateSSHKeyPairOfType(sandboxNum, DefaultSSHKeyType)
+}
+
+// GenerateSSHKeyPairOfType generates a new SSH key pair of keyType ("ed25519",
+// "rsa" for RSA-3072 or "ecdsa" for ECDSA P-256) for a specific sandbox, for
+// VM images and SSH policies that reject ed25519.
+// Returns (publicKey, privateKeyPath, error)
+func GenerateSSHKeyPairOfType(sandboxNum int, keyType string) (string, string, error) {
 	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
 	if err := os.MkdirAll(platoDir, 0700); err != nil {
 		return "", "", fmt.Errorf("failed to create .plato directory: %w", err)
@@ -54,53 +86,83 @@ func GenerateSSHKeyPair(sandboxNum int) (string, string, error) {
 
 	// Generate key pair in ~/.plato/ssh_{num}_key (private) and ssh_{num}_key.pub (public)
 	privateKeyPath := filepath.Join(platoDir, fmt.Sprintf("ssh_%d_key", sandboxNum))
+	publicKey, err := GenerateSSHKeyPairOfTypeAt(privateKeyPath, fmt.Sprintf("plato-sandbox-%d", sandboxNum), keyType)
+	if err != nil {
+		return "", "", err
+	}
+
+	return publicKey, privateKeyPath, nil
+}
+
+// GenerateSSHKeyPairOfTypeAt generates a key pair of keyType at privateKeyPath
+// (and privateKeyPath.pub), both in OpenSSH format and tagged with comment.
+// Returns the public key in authorized_keys format
+func GenerateSSHKeyPairOfTypeAt(privateKeyPath, comment, keyType string) (string, error) {
 	publicKeyPath := privateKeyPath + ".pub"
 
+	// Generate the key pair using native Go crypto
+	var publicKey, privateKey any
+	switch keyType {
+	case SSHKeyTypeEd25519, "":
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate key pair: %w", err)
+		}
+		publicKey, privateKey = pub, priv
+	case SSHKeyTypeRSA:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate key pair: %w", err)
+		}
+		publicKey, privateKey = &priv.PublicKey, priv
+	case SSHKeyTypeECDSA:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate key pair: %w", err)
+		}
+		publicKey, privateKey = &priv.PublicKey, priv
+	default:
+		return "", fmt.Errorf("unsupported SSH key type %q (expected %s, %s or %s)", keyType, SSHKeyTypeEd25519, SSHKeyTypeRSA, SSHKeyTypeECDSA)
+	}
+
 	// Remove existing keys if they exist
 	os.Remove(privateKeyPath)
 	os.Remove(publicKeyPath)
 
-	// Generate ed25519 key pair using native Go crypto
-	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
-	}
-
 	// Convert to SSH format
 	sshPublicKey, err := ssh.NewPublicKey(publicKey)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to convert public key: %w", err)
+		return "", fmt.Errorf("failed to convert public key: %w", err)
 	}
 
 	// Format public key in OpenSSH authorized_keys format
-	comment := fmt.Sprintf("plato-sandbox-%d", sandboxNum)
 	pubKeyBytes := ssh.MarshalAuthorizedKey(sshPublicKey)
 	// Add comment to public key (MarshalAuthorizedKey includes a newline)
 	pubKeyStr := strings.TrimSpace(string(pubKeyBytes)) + " " + comment + "\n"
 
 	// Write public key with 0644 permissions (standard for .pub files)
 	if err := os.WriteFile(publicKeyPath, []byte(pubKeyStr), 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write public key: %w", err)
+		return "", fmt.Errorf("failed to write public key: %w", err)
 	}
 
 	// Marshal private key in OpenSSH format
 	privKeyPEM, err := ssh.MarshalPrivateKey(privateKey, comment)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to marshal private key: %w", err)
+		return "", fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
 	// Encode PEM block to bytes
 	privKeyBytes := pem.EncodeToMemory(privKeyPEM)
 	if privKeyBytes == nil {
-		return "", "", fmt.Errorf("failed to encode private key to PEM")
+		return "", fmt.Errorf("failed to encode private key to PEM")
 	}
 
 	// Write private key with 0600 permissions (required for SSH to accept it)
 	if err := os.WriteFile(privateKeyPath, privKeyBytes, 0600); err != nil {
-		return "", "", fmt.Errorf("failed to write private key: %w", err)
+		return "", fmt.Errorf("failed to write private key: %w", err)
 	}
 
-	return strings.TrimSpace(pubKeyStr), privateKeyPath, nil
+	return strings.TrimSpace(pubKeyStr), nil
 }
 
 // GetSSHPrivateKeyPath returns the path to the SSH private key
@@ -221,12 +283,12 @@ func CreateTempSSHConfig(baseURL, hostname string, port int, jobGroupID string,
     IdentitiesOnly yes
     StrictHostKeyChecking no
     UserKnownHostsFile /dev/null
-    ConnectTimeout 10
+    ConnectTimeout %d
     ProxyCommand %s
-    ServerAliveInterval 30
-    ServerAliveCountMax 3
+    ServerAliveInterval %d
+    ServerAliveCountMax %d
     TCPKeepAlive yes
-`, hostname, port, username, privateKeyPath, proxyCmd)
+`, hostname, port, username, privateKeyPath, DefaultSSHConnectTimeout, proxyCmd, DefaultSSHServerAliveInterval, DefaultSSHServerAliveCountMax)
 
 	// Create temp file in ~/.plato directory
 	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
@@ -282,12 +344,12 @@ func AppendSSHHostEntry(baseURL, hostname string, port int, jobGroupID string, u
     IdentitiesOnly yes
     StrictHostKeyChecking no
     UserKnownHostsFile /dev/null
-    ConnectTimeout 10
+    ConnectTimeout %d
     ProxyCommand %s
-    ServerAliveInterval 30
-    ServerAliveCountMax 3
+    ServerAliveInterval %d
+    ServerAliveCountMax %d
     TCPKeepAlive yes
-    `, hostname, port, username, privateKeyPath, proxyCmd)
+    `, hostname, port, username, privateKeyPath, DefaultSSHConnectTimeout, proxyCmd, DefaultSSHServerAliveInterval, DefaultSSHServerAliveCountMax)
 
 	if configContent != "" {
 		configContent = strings.TrimRight(configContent, "\n") + "\n\n" + configWithProxy
@@ -298,6 +360,11 @@ func AppendSSHHostEntry(baseURL, hostname string, port int, jobGroupID string, u
 	return WriteSSHConfig(configContent)
 }
 
+// getSandboxLockPath returns the lockfile guarding sandbox number allocation
+func getSandboxLockPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".plato", "sandbox.lock")
+}
+
 // getNextSandboxNumber finds the next available sandbox number by checking existing config files
 func getNextSandboxNumber() int {
 	platoDir := filepath.Join(os.Getenv("HOME"), ".plato")
@@ -308,12 +375,18 @@ func getNextSandboxNumber() int {
 
 	maxNum := 0
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), "ssh_") && strings.HasSuffix(file.Name(), ".conf") {
-			// Extract number from ssh_N.conf
-			name := strings.TrimPrefix(file.Name(), "ssh_")
-			name = strings.TrimSuffix(name, ".conf")
-			if num, err := strconv.Atoi(name); err == nil && num > maxNum {
-				maxNum = num
+		if !strings.HasPrefix(file.Name(), "ssh_") {
+			continue
+		}
+		// Extract number from ssh_N.conf, ssh_N_key or ssh_N_key.pub so that a
+		// half-finished allocation still reserves its number
+		name := strings.TrimPrefix(file.Name(), "ssh_")
+		for _, suffix := range []string{".conf", "_key.pub", "_key"} {
+			if strings.HasSuffix(name, suffix) {
+				if num, err := strconv.Atoi(strings.TrimSuffix(name, suffix)); err == nil && num > maxNum {
+					maxNum = num
+				}
+				break
 			}
 		}
 	}
@@ -323,6 +396,14 @@ func getNextSandboxNumber() int {
 // SetupSSHConfig creates a temporary SSH config file and generates a new SSH key pair
 // Returns (hostname, configPath, publicKey, privateKeyPath, error)
 func SetupSSHConfig(baseURL string, localPort int, jobPublicID string, username string) (string, string, string, string, error) {
+	// Hold the ~/.plato lock until the key and config files exist so that
+	// concurrent launches never compute the same sandbox number
+	unlock, err := LockFile(getSandboxLockPath())
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("failed to lock sandbox allocation: %w", err)
+	}
+	defer unlock()
+
 	// Get next available sandbox number for a simple hostname
 	sandboxNum := getNextSandboxNumber()
 	sshHost := fmt.Sprintf("sandbox-%d", sandboxNum)
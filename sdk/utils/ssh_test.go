@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateSSHKeyPairOfTypeAt(t *testing.T) {
+	tests := []struct {
+		keyType string
+		sshType string
+	}{
+		{SSHKeyTypeEd25519, ssh.KeyAlgoED25519},
+		{SSHKeyTypeRSA, ssh.KeyAlgoRSA},
+		{SSHKeyTypeECDSA, ssh.KeyAlgoECDSA256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyType, func(t *testing.T) {
+			privateKeyPath := filepath.Join(t.TempDir(), "ssh_1_key")
+			publicKey, err := GenerateSSHKeyPairOfTypeAt(privateKeyPath, "plato-sandbox-1", tt.keyType)
+			if err != nil {
+				t.Fatalf("GenerateSSHKeyPairOfTypeAt: %v", err)
+			}
+
+			privateKeyPEM, err := os.ReadFile(privateKeyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer, err := ssh.ParsePrivateKey(privateKeyPEM)
+			if err != nil {
+				t.Fatalf("ssh.ParsePrivateKey: %v", err)
+			}
+			if got := signer.PublicKey().Type(); got != tt.sshType {
+				t.Errorf("key type = %s, want %s", got, tt.sshType)
+			}
+
+			// The returned key and the .pub file match the private key and carry the comment
+			wantPublic := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))) + " plato-sandbox-1"
+			if publicKey != wantPublic {
+				t.Errorf("public key = %q, want %q", publicKey, wantPublic)
+			}
+			pubFile, err := os.ReadFile(privateKeyPath + ".pub")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(pubFile) != wantPublic+"\n" {
+				t.Errorf(".pub file = %q, want %q", pubFile, wantPublic+"\n")
+			}
+
+			for path, want := range map[string]os.FileMode{privateKeyPath: 0600, privateKeyPath + ".pub": 0644} {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != want {
+					t.Errorf("%s mode = %v, want %v", filepath.Base(path), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSSHKeyPairOfTypeAt_UnknownType(t *testing.T) {
+	privateKeyPath := filepath.Join(t.TempDir(), "ssh_1_key")
+	if _, err := GenerateSSHKeyPairOfTypeAt(privateKeyPath, "plato-sandbox-1", "dsa"); err == nil {
+		t.Fatal("expected an error for an unsupported key type")
+	}
+	if _, err := os.Stat(privateKeyPath); !os.IsNotExist(err) {
+		t.Errorf("expected no key to be written, stat returned %v", err)
+	}
+}